@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // File upload constants
@@ -12,15 +14,51 @@ const (
 	UploadDir     = ""        // Empty string uses system temp directory
 )
 
+// MinJWTSecretLength is the shortest JWT_SECRET accepted, the size of an
+// HMAC-SHA256 key
+const MinJWTSecretLength = 32
+
 // Environment variables used:
 // - LIBREOFFICE_PATH: Custom path to LibreOffice/soffice executable (optional)
 //   Example for Windows: C:\Program Files\LibreOffice\program\soffice.exe
 // - uploadDir: Custom directory for temporary file uploads (optional)
+// - JWT_SECRET: Key used to sign and verify bearer tokens (required, at least
+//   MinJWTSecretLength characters)
+// - JWT_TTL_MINUTES: Lifetime of issued tokens in minutes (default 480)
+// - IMPORT_WORKERS: Number of background import job workers (default 2)
+// - IMPORT_CHUNK_SIZE: Rows imported per transaction by import jobs (default 200)
+// - IMPORT_POLL_SECONDS: How often idle workers look for queued jobs (default 5)
+// - IMPORT_LEASE_SECONDS: How long a worker holds a job without progress before
+//   another worker may resume it (default 120)
+// - AUDIT_READER_PROFILES: Comma-separated IDs of the profiles allowed to read
+//   the audit trail (default none)
+// - APPROVAL_ADMIN_PROFILES: Comma-separated IDs of the profiles allowed to
+//   change the approvers of object types (default none)
+// - WEBHOOK_ADMIN_PROFILES: Comma-separated IDs of the profiles allowed to
+//   manage webhook subscriptions (default none)
+// - WEBHOOK_WORKERS: Number of background webhook delivery workers (default 2)
+// - WEBHOOK_POLL_SECONDS: How often idle workers look for due deliveries (default 5)
+// - WEBHOOK_TIMEOUT_SECONDS: How long a receiver has to answer a delivery (default 10)
+// - WEBHOOK_MAX_ATTEMPTS: Attempts before a delivery is dead-lettered (default 8)
+// - WEBHOOK_BACKOFF_SECONDS: Delay before the first retry, doubled after every
+//   further failure (default 30)
+// - WEBHOOK_MAX_BACKOFF_SECONDS: Longest delay between retries (default 3600)
+// - EVENT_BUFFER_SIZE: Recent events kept for event stream clients resuming
+//   with Last-Event-ID (default 1000)
+// - EVENT_HEARTBEAT_SECONDS: How often an idle event stream is sent a
+//   keep-alive comment (default 25)
 
 // Config holds all configuration for the application
 type Config struct {
 	Server   ServerConfig
 	Database DatabaseConfig
+	Auth     AuthConfig
+	Import   ImportConfig
+	Audit    AuditConfig
+	Approval ApprovalConfig
+	Library  LibraryConfig
+	Webhook  WebhookConfig
+	Events   EventStreamConfig
 }
 
 // ServerConfig holds server configuration
@@ -39,6 +77,52 @@ type DatabaseConfig struct {
 	Trusted  bool
 }
 
+// AuthConfig holds authentication configuration
+type AuthConfig struct {
+	JWTSecret string
+	TokenTTL  time.Duration
+}
+
+// ImportConfig holds background import job configuration
+type ImportConfig struct {
+	Workers      int
+	ChunkSize    int
+	PollInterval time.Duration
+	Lease        time.Duration
+}
+
+// AuditConfig holds audit trail configuration
+type AuditConfig struct {
+	ReaderProfiles []int
+}
+
+// ApprovalConfig holds approval workflow configuration
+type ApprovalConfig struct {
+	AdminProfiles []int
+}
+
+// LibraryConfig holds library configuration
+type LibraryConfig struct {
+	AdminProfiles []int
+}
+
+// WebhookConfig holds webhook delivery configuration
+type WebhookConfig struct {
+	AdminProfiles []int
+	Workers       int
+	PollInterval  time.Duration
+	Timeout       time.Duration
+	MaxAttempts   int
+	Backoff       time.Duration
+	MaxBackoff    time.Duration
+}
+
+// EventStreamConfig holds event stream configuration
+type EventStreamConfig struct {
+	BufferSize int
+	Heartbeat  time.Duration
+}
+
 // Load loads configuration from environment variables
 func Load() (*Config, error) {
 	dbPort, err := strconv.Atoi(getEnv("DB_PORT", "1433"))
@@ -46,6 +130,85 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid DB_PORT: %w", err)
 	}
 
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if len(jwtSecret) < MinJWTSecretLength {
+		return nil, fmt.Errorf("JWT_SECRET must be set to at least %d characters", MinJWTSecretLength)
+	}
+	tokenTTL, err := strconv.Atoi(getEnv("JWT_TTL_MINUTES", "480"))
+	if err != nil || tokenTTL <= 0 {
+		return nil, fmt.Errorf("invalid JWT_TTL_MINUTES: %q", getEnv("JWT_TTL_MINUTES", "480"))
+	}
+
+	importWorkers, err := strconv.Atoi(getEnv("IMPORT_WORKERS", "2"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid IMPORT_WORKERS: %w", err)
+	}
+	importChunkSize, err := strconv.Atoi(getEnv("IMPORT_CHUNK_SIZE", "200"))
+	if err != nil || importChunkSize <= 0 {
+		return nil, fmt.Errorf("invalid IMPORT_CHUNK_SIZE: %q", getEnv("IMPORT_CHUNK_SIZE", "200"))
+	}
+	importPoll, err := strconv.Atoi(getEnv("IMPORT_POLL_SECONDS", "5"))
+	if err != nil || importPoll <= 0 {
+		return nil, fmt.Errorf("invalid IMPORT_POLL_SECONDS: %q", getEnv("IMPORT_POLL_SECONDS", "5"))
+	}
+	importLease, err := strconv.Atoi(getEnv("IMPORT_LEASE_SECONDS", "120"))
+	if err != nil || importLease <= 0 {
+		return nil, fmt.Errorf("invalid IMPORT_LEASE_SECONDS: %q", getEnv("IMPORT_LEASE_SECONDS", "120"))
+	}
+
+	auditReaders, err := getEnvProfiles("AUDIT_READER_PROFILES")
+	if err != nil {
+		return nil, err
+	}
+
+	approvalAdmins, err := getEnvProfiles("APPROVAL_ADMIN_PROFILES")
+	if err != nil {
+		return nil, err
+	}
+
+	libraryAdmins, err := getEnvProfiles("LIBRARY_ADMIN_PROFILES")
+	if err != nil {
+		return nil, err
+	}
+
+	webhookAdmins, err := getEnvProfiles("WEBHOOK_ADMIN_PROFILES")
+	if err != nil {
+		return nil, err
+	}
+	webhookWorkers, err := strconv.Atoi(getEnv("WEBHOOK_WORKERS", "2"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid WEBHOOK_WORKERS: %w", err)
+	}
+	webhookPoll, err := strconv.Atoi(getEnv("WEBHOOK_POLL_SECONDS", "5"))
+	if err != nil || webhookPoll <= 0 {
+		return nil, fmt.Errorf("invalid WEBHOOK_POLL_SECONDS: %q", getEnv("WEBHOOK_POLL_SECONDS", "5"))
+	}
+	webhookTimeout, err := strconv.Atoi(getEnv("WEBHOOK_TIMEOUT_SECONDS", "10"))
+	if err != nil || webhookTimeout <= 0 {
+		return nil, fmt.Errorf("invalid WEBHOOK_TIMEOUT_SECONDS: %q", getEnv("WEBHOOK_TIMEOUT_SECONDS", "10"))
+	}
+	webhookAttempts, err := strconv.Atoi(getEnv("WEBHOOK_MAX_ATTEMPTS", "8"))
+	if err != nil || webhookAttempts <= 0 {
+		return nil, fmt.Errorf("invalid WEBHOOK_MAX_ATTEMPTS: %q", getEnv("WEBHOOK_MAX_ATTEMPTS", "8"))
+	}
+	webhookBackoff, err := strconv.Atoi(getEnv("WEBHOOK_BACKOFF_SECONDS", "30"))
+	if err != nil || webhookBackoff <= 0 {
+		return nil, fmt.Errorf("invalid WEBHOOK_BACKOFF_SECONDS: %q", getEnv("WEBHOOK_BACKOFF_SECONDS", "30"))
+	}
+	webhookMaxBackoff, err := strconv.Atoi(getEnv("WEBHOOK_MAX_BACKOFF_SECONDS", "3600"))
+	if err != nil || webhookMaxBackoff < webhookBackoff {
+		return nil, fmt.Errorf("invalid WEBHOOK_MAX_BACKOFF_SECONDS: %q", getEnv("WEBHOOK_MAX_BACKOFF_SECONDS", "3600"))
+	}
+
+	eventBuffer, err := strconv.Atoi(getEnv("EVENT_BUFFER_SIZE", "1000"))
+	if err != nil || eventBuffer < 0 {
+		return nil, fmt.Errorf("invalid EVENT_BUFFER_SIZE: %q", getEnv("EVENT_BUFFER_SIZE", "1000"))
+	}
+	eventHeartbeat, err := strconv.Atoi(getEnv("EVENT_HEARTBEAT_SECONDS", "25"))
+	if err != nil || eventHeartbeat <= 0 {
+		return nil, fmt.Errorf("invalid EVENT_HEARTBEAT_SECONDS: %q", getEnv("EVENT_HEARTBEAT_SECONDS", "25"))
+	}
+
 	config := &Config{
 		Server: ServerConfig{
 			Port: getEnv("SERVER_PORT", "8080"),
@@ -59,6 +222,38 @@ func Load() (*Config, error) {
 			Password: getEnv("DB_PASSWORD", "123"),
 			Trusted:  getEnv("DB_TRUSTED", "true") == "true",
 		},
+		Auth: AuthConfig{
+			JWTSecret: jwtSecret,
+			TokenTTL:  time.Duration(tokenTTL) * time.Minute,
+		},
+		Import: ImportConfig{
+			Workers:      importWorkers,
+			ChunkSize:    importChunkSize,
+			PollInterval: time.Duration(importPoll) * time.Second,
+			Lease:        time.Duration(importLease) * time.Second,
+		},
+		Audit: AuditConfig{
+			ReaderProfiles: auditReaders,
+		},
+		Approval: ApprovalConfig{
+			AdminProfiles: approvalAdmins,
+		},
+		Library: LibraryConfig{
+			AdminProfiles: libraryAdmins,
+		},
+		Webhook: WebhookConfig{
+			AdminProfiles: webhookAdmins,
+			Workers:       webhookWorkers,
+			PollInterval:  time.Duration(webhookPoll) * time.Second,
+			Timeout:       time.Duration(webhookTimeout) * time.Second,
+			MaxAttempts:   webhookAttempts,
+			Backoff:       time.Duration(webhookBackoff) * time.Second,
+			MaxBackoff:    time.Duration(webhookMaxBackoff) * time.Second,
+		},
+		Events: EventStreamConfig{
+			BufferSize: eventBuffer,
+			Heartbeat:  time.Duration(eventHeartbeat) * time.Second,
+		},
 	}
 
 	return config, nil
@@ -72,3 +267,19 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+// getEnvProfiles parses a comma-separated list of profile IDs
+func getEnvProfiles(key string) ([]int, error) {
+	var profiles []int
+	for _, field := range strings.Split(getEnv(key, ""), ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		profileID, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %q", key, field)
+		}
+		profiles = append(profiles, profileID)
+	}
+	return profiles, nil
+}
@@ -6,6 +6,8 @@ require (
 	github.com/denisenkom/go-mssqldb v0.12.3
 	github.com/google/uuid v1.5.0
 	github.com/gorilla/mux v1.8.1
+	github.com/rs/cors v1.11.1
+	golang.org/x/crypto v0.17.0
 )
 
 require (
@@ -13,6 +15,4 @@ require (
 	github.com/golang-sql/civil v0.0.0-20220223132316-b832511892a9 // indirect
 	github.com/golang-sql/sqlexp v0.1.0 // indirect
 	github.com/gorilla/handlers v1.5.2 // indirect
-	github.com/rs/cors v1.11.1 // indirect
-	golang.org/x/crypto v0.17.0 // indirect
 )
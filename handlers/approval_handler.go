@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/repositories"
+	"enterprise-architect-api/services"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// ApprovalHandler handles HTTP requests for the version approval workflow
+type ApprovalHandler struct {
+	service     *services.ApprovalService
+	objects     *services.ObjectService
+	permissions *services.PermissionService
+	events      *services.EventStreamService
+}
+
+// NewApprovalHandler creates a new ApprovalHandler
+func NewApprovalHandler(service *services.ApprovalService, objects *services.ObjectService, permissions *services.PermissionService, events *services.EventStreamService) *ApprovalHandler {
+	return &ApprovalHandler{service: service, objects: objects, permissions: permissions, events: events}
+}
+
+// SubmitForApproval handles POST /api/objects/{id}/submit-approval
+func (h *ApprovalHandler) SubmitForApproval(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, models.PermissionModify, "Failed to submit for approval", false, h.service.Submit)
+}
+
+// Approve handles POST /api/objects/{id}/approve
+func (h *ApprovalHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, models.PermissionRead, "Failed to approve version", true, h.service.Approve)
+}
+
+// Reject handles POST /api/objects/{id}/reject
+func (h *ApprovalHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, models.PermissionRead, "Failed to reject version", true, h.service.Reject)
+}
+
+// decide runs one of the workflow actions for the object in the URL. Actions
+// that change which version is current set changesObject, so that event
+// stream clients are sent the object as it now is.
+func (h *ApprovalHandler) decide(w http.ResponseWriter, r *http.Request, permission, failure string, changesObject bool,
+	action func(uuid.UUID, int, int, models.ApprovalRequest, *repositories.ChangeLog) (*models.ApprovalState, error)) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid object ID", err.Error())
+		return
+	}
+
+	var req models.ApprovalRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+			return
+		}
+	}
+	if !authorizeObject(w, r, h.permissions, id, permission) {
+		return
+	}
+
+	state, err := action(id, currentUser(r).UserID, currentUser(r).ProfileID, req, changeLog(r))
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), failure, err.Error())
+		return
+	}
+	if changesObject {
+		if object, err := h.objects.GetObjectByID(id); err == nil {
+			streamObjectEvent(r, h.events, models.StreamEventUpdate, object, nil)
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, state)
+}
+
+// GetApprovalHistory handles GET /api/objects/{id}/approvals
+func (h *ApprovalHandler) GetApprovalHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid object ID", err.Error())
+		return
+	}
+	if !authorizeObject(w, r, h.permissions, id, models.PermissionRead) {
+		return
+	}
+
+	history, err := h.service.GetHistory(id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve approval history", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, history)
+}
+
+// GetMyPendingApprovals handles GET /api/approvals/pending
+func (h *ApprovalHandler) GetMyPendingApprovals(w http.ResponseWriter, r *http.Request) {
+	pending, err := h.service.GetPendingApprovals(currentUser(r).ProfileID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve pending approvals", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, pending)
+}
+
+// GetApprovers handles GET /api/object-types/{id}/approvers
+func (h *ApprovalHandler) GetApprovers(w http.ResponseWriter, r *http.Request) {
+	objectTypeID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid object type ID", err.Error())
+		return
+	}
+
+	profileIDs, err := h.service.GetApprovers(objectTypeID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve approvers", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.ObjectTypeApproversRequest{ProfileIDs: profileIDs})
+}
+
+// SetApprovers handles PUT /api/object-types/{id}/approvers
+func (h *ApprovalHandler) SetApprovers(w http.ResponseWriter, r *http.Request) {
+	objectTypeID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid object type ID", err.Error())
+		return
+	}
+
+	var req models.ObjectTypeApproversRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	profileIDs, err := h.service.SetApprovers(objectTypeID, req, currentUser(r).UserID, currentUser(r).ProfileID, changeLog(r))
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to update approvers", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.ObjectTypeApproversRequest{ProfileIDs: profileIDs})
+}
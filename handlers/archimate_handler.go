@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"enterprise-architect-api/config"
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/services"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ArchiMateHandler handles HTTP requests for ArchiMate Open Exchange Format
+// exports and imports
+type ArchiMateHandler struct {
+	service     *services.ArchiMateService
+	permissions *services.PermissionService
+}
+
+// NewArchiMateHandler creates a new ArchiMateHandler
+func NewArchiMateHandler(service *services.ArchiMateService, permissions *services.PermissionService) *ArchiMateHandler {
+	return &ArchiMateHandler{service: service, permissions: permissions}
+}
+
+// ExportArchiMate handles GET /api/export/archimate
+func (h *ArchiMateHandler) ExportArchiMate(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := uuid.Parse(r.URL.Query().Get("libraryId"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid library ID", err.Error())
+		return
+	}
+
+	if !authorizeObject(w, r, h.permissions, libraryID, models.PermissionRead) {
+		return
+	}
+
+	libraryName, mappings, err := h.service.CheckExport(libraryID)
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to export ArchiMate model", err.Error())
+		return
+	}
+
+	fileName := fmt.Sprintf("archimate-%s-%s.xml", libraryID, time.Now().Format("20060102"))
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	w.WriteHeader(http.StatusOK)
+
+	// The status has been sent, so a failure part way can only be logged and
+	// the download left incomplete
+	if err := h.service.ExportLibrary(w, libraryID, libraryName, mappings, currentUser(r).ProfileID); err != nil {
+		log.Printf("ArchiMate export of library %s failed: %v", libraryID, err)
+	}
+}
+
+// ImportArchiMate handles POST /api/import/archimate
+func (h *ArchiMateHandler) ImportArchiMate(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, config.MaxUploadSize)
+	if err := r.ParseMultipartForm(config.MaxUploadSize); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to parse form", err.Error())
+		return
+	}
+
+	libraryID, err := uuid.Parse(r.FormValue("libraryId"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid library ID", err.Error())
+		return
+	}
+	folderID, err := uuid.Parse(r.FormValue("folderId"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid folder ID", err.Error())
+		return
+	}
+	req := models.ArchiMateImportRequest{
+		LibraryId: libraryID,
+		FolderId:  folderID,
+		MatchBy:   r.FormValue("matchBy"),
+		Strategy:  r.FormValue("strategy"),
+		DryRun:    isDryRun(r.FormValue("dryRun")),
+		Locale:    r.FormValue("locale"),
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to get uploaded file", err.Error())
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to read uploaded file", err.Error())
+		return
+	}
+
+	if !authorizeObject(w, r, h.permissions, req.FolderId, models.PermissionModifyContents) {
+		return
+	}
+
+	user := currentUser(r)
+	response, err := h.service.ImportFile(req, data, user.UserID, user.ProfileID, changeLog(r))
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to import ArchiMate model", err.Error())
+		return
+	}
+
+	respondWithImportResult(w, response.DryRun, response)
+}
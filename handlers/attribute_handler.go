@@ -12,11 +12,12 @@ import (
 )
 
 type AttributeHandler struct {
-	service *services.AttributeService
+	service     *services.AttributeService
+	permissions *services.PermissionService
 }
 
-func NewAttributeHandler(service *services.AttributeService) *AttributeHandler {
-	return &AttributeHandler{service: service}
+func NewAttributeHandler(service *services.AttributeService, permissions *services.PermissionService) *AttributeHandler {
+	return &AttributeHandler{service: service, permissions: permissions}
 }
 
 func (ah *AttributeHandler) GetAttributeForObject(w http.ResponseWriter, r *http.Request) {
@@ -36,7 +37,16 @@ func (ah *AttributeHandler) GetAttributeForObject(w http.ResponseWriter, r *http
 		objectTypeIdPtr = &objectTypeId
 	}
 
-	attributes, err := ah.service.GetAttributeForObject(uuid.Must(uuid.Parse(objectID)), objectTypeIdPtr)
+	id, err := uuid.Parse(objectID)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid object ID", err.Error())
+		return
+	}
+	if !authorizeObject(w, r, ah.permissions, id, models.PermissionRead) {
+		return
+	}
+
+	attributes, err := ah.service.GetAttributeForObject(id, objectTypeIdPtr, currentUser(r).ProfileID)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "error retrieving attributes", err.Error())
 		return
@@ -53,7 +63,7 @@ func (ah *AttributeHandler) CreateAttribute(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if err := ah.service.CreateAttribute(&attribute); err != nil {
+	if err := ah.service.CreateAttribute(&attribute, changeLog(r)); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to create attribute", err.Error())
 		return
 	}
@@ -80,12 +90,9 @@ func (ah *AttributeHandler) GetAttributeByID(w http.ResponseWriter, r *http.Requ
 
 // GetAllAttributes handles GET /api/attributes
 func (ah *AttributeHandler) GetAllAttributes(w http.ResponseWriter, r *http.Request) {
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	pageSize, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
-
-	response, err := ah.service.GetAllAttributes(page, pageSize)
+	response, err := ah.service.GetAllAttributes(pageRequest(r))
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve attributes", err.Error())
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to retrieve attributes", err.Error())
 		return
 	}
 
@@ -106,7 +113,7 @@ func (ah *AttributeHandler) UpdateAttribute(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	updatedAttribute, err := ah.service.UpdateAttribute(id, &attribute)
+	updatedAttribute, err := ah.service.UpdateAttribute(id, &attribute, changeLog(r))
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to update attribute", err.Error())
 		return
@@ -123,7 +130,7 @@ func (ah *AttributeHandler) DeleteAttribute(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if err := ah.service.DeleteAttribute(id); err != nil {
+	if err := ah.service.DeleteAttribute(id, changeLog(r)); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to delete attribute", err.Error())
 		return
 	}
@@ -141,7 +148,7 @@ func (ah *AttributeHandler) AssignAttributeToObjectType(w http.ResponseWriter, r
 		return
 	}
 
-	if err := ah.service.AssignAttributeToObjectType(&req); err != nil {
+	if err := ah.service.AssignAttributeToObjectType(&req, changeLog(r)); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to assign attribute to object type", err.Error())
 		return
 	}
@@ -184,7 +191,7 @@ func (ah *AttributeHandler) UnassignAttributeFromObjectType(w http.ResponseWrite
 		return
 	}
 
-	if err := ah.service.UnassignAttributeFromObjectType(&req); err != nil {
+	if err := ah.service.UnassignAttributeFromObjectType(&req, changeLog(r)); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to unassign attribute from object type", err.Error())
 		return
 	}
@@ -202,8 +209,19 @@ func (ah *AttributeHandler) UpdateAttributeValue(w http.ResponseWriter, r *http.
 		return
 	}
 
-	if err := ah.service.UpdateAttributeValue(attrs); err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to update attribute values", err.Error())
+	checked := make(map[uuid.UUID]bool)
+	for _, attr := range attrs {
+		if checked[attr.ObjectId] {
+			continue
+		}
+		if !authorizeObject(w, r, ah.permissions, attr.ObjectId, models.PermissionModify) {
+			return
+		}
+		checked[attr.ObjectId] = true
+	}
+
+	if err := ah.service.UpdateAttributeValue(attrs, currentUser(r).UserID, changeLog(r)); err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to update attribute values", err.Error())
 		return
 	}
 
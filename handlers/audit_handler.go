@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"enterprise-architect-api/middleware"
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/repositories"
+	"enterprise-architect-api/services"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// AuditHandler handles HTTP requests for the audit trail
+type AuditHandler struct {
+	service *services.AuditService
+}
+
+// NewAuditHandler creates a new AuditHandler
+func NewAuditHandler(service *services.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// List handles GET /api/audit
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := models.AuditQuery{
+		PageRequest: pageRequest(r),
+		EntityType:  query.Get("entityType"),
+		EntityId:    query.Get("entityId"),
+	}
+	if v := query.Get("userId"); v != "" {
+		userID, err := strconv.Atoi(v)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid user ID", err.Error())
+			return
+		}
+		q.UserId = &userID
+	}
+	var err error
+	if q.From, err = auditTime(query.Get("from")); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid from", err.Error())
+		return
+	}
+	if q.To, err = auditTime(query.Get("to")); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid to", err.Error())
+		return
+	}
+
+	response, err := h.service.List(q, currentUser(r).ProfileID)
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to retrieve audit events", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// auditTime parses an RFC 3339 time or a date, which means midnight UTC
+func auditTime(v string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("expected an RFC 3339 time or a YYYY-MM-DD date, got %q", v)
+}
+
+// changeLog returns the log that records the changes the request makes in
+// the audit trail, in the transaction of each change
+func changeLog(r *http.Request) *repositories.ChangeLog {
+	user := currentUser(r)
+	return repositories.NewChangeLog(user.UserID, user.ProfileID, middleware.GetRequestID(r.Context()))
+}
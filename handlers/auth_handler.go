@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"encoding/json"
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/services"
+	"net/http"
+)
+
+// AuthHandler handles HTTP requests for authentication
+type AuthHandler struct {
+	service *services.AuthService
+}
+
+// NewAuthHandler creates a new AuthHandler
+func NewAuthHandler(service *services.AuthService) *AuthHandler {
+	return &AuthHandler{service: service}
+}
+
+// Login handles POST /api/auth/login
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req models.LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	response, err := h.service.Login(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Login failed", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// Me handles GET /api/auth/me
+func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, currentUser(r))
+}
@@ -2,8 +2,14 @@ package handlers
 
 import (
 	"encoding/json"
+	"enterprise-architect-api/middleware"
 	"enterprise-architect-api/models"
+	"enterprise-architect-api/repositories"
+	"enterprise-architect-api/services"
+	"errors"
 	"net/http"
+
+	"github.com/google/uuid"
 )
 
 // respondWithJSON writes a JSON response
@@ -22,3 +28,113 @@ func respondWithError(w http.ResponseWriter, statusCode int, message string, det
 	respondWithJSON(w, statusCode, errorResponse)
 }
 
+
+// currentUser returns the authenticated caller attached by the auth middleware
+func currentUser(r *http.Request) *models.AuthUser {
+	if user := middleware.GetAuthUser(r.Context()); user != nil {
+		return user
+	}
+	return &models.AuthUser{}
+}
+
+// authorizeObject checks the caller's permission on an object and writes a
+// 404, 403 or 500 response when the operation may not proceed
+func authorizeObject(w http.ResponseWriter, r *http.Request, permissions *services.PermissionService, objectID uuid.UUID, action string) bool {
+	err := permissions.Authorize(objectID, currentUser(r).ProfileID, action)
+	if err == nil {
+		return true
+	}
+
+	var denied *services.PermissionDeniedError
+	switch {
+	case errors.As(err, &denied):
+		respondWithError(w, http.StatusForbidden, "Forbidden", err.Error())
+	case errors.Is(err, repositories.ErrObjectNotFound):
+		respondWithError(w, http.StatusNotFound, "Object not found", err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "Failed to check permissions", err.Error())
+	}
+	return false
+}
+
+// authorizePlacement checks that the caller may add objects to the folder an
+// object is moved, copied or created in: the target folder, or the target
+// library when no folder is given. Without either there is nothing to check
+// and the service rejects the request.
+func authorizePlacement(w http.ResponseWriter, r *http.Request, permissions *services.PermissionService, folderID, libraryID *uuid.UUID) bool {
+	switch {
+	case folderID != nil:
+		return authorizeObject(w, r, permissions, *folderID, models.PermissionModifyContents)
+	case libraryID != nil:
+		return authorizeObject(w, r, permissions, *libraryID, models.PermissionModifyContents)
+	}
+	return true
+}
+
+// errorStatus maps well-known service errors to an HTTP status code, falling
+// back to the given status for anything else
+func errorStatus(err error, fallback int) int {
+	var denied *services.PermissionDeniedError
+	switch {
+	case errors.As(err, &denied):
+		return http.StatusForbidden
+	case errors.Is(err, repositories.ErrObjectNotFound),
+		errors.Is(err, repositories.ErrVersionNotFound),
+		errors.Is(err, repositories.ErrImportJobNotFound),
+		errors.Is(err, repositories.ErrRelationTypeNotFound),
+		errors.Is(err, repositories.ErrRelationshipNotFound),
+		errors.Is(err, repositories.ErrDeleteTransactionNotFound),
+		errors.Is(err, repositories.ErrWebhookNotFound),
+		errors.Is(err, repositories.ErrWebhookDeliveryNotFound),
+		errors.Is(err, repositories.ErrFolderNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrAlreadyCheckedOut),
+		errors.Is(err, services.ErrNotCheckedOut),
+		errors.Is(err, services.ErrCheckedOutByAnotherUser),
+		errors.Is(err, services.ErrObjectCheckedOut),
+		errors.Is(err, services.ErrPendingApproval),
+		errors.Is(err, services.ErrNotPendingApproval),
+		errors.Is(err, services.ErrAlreadyApproved),
+		errors.Is(err, repositories.ErrRelationTypeExists),
+		errors.Is(err, repositories.ErrRelationTypeInUse),
+		errors.Is(err, repositories.ErrRelationshipExists),
+		errors.Is(err, repositories.ErrObjectDeleted),
+		errors.Is(err, repositories.ErrCheckedOut),
+		errors.Is(err, repositories.ErrCheckoutRequired),
+		errors.Is(err, repositories.ErrNotCurrentVersion),
+		errors.Is(err, repositories.ErrDeleteCheckedOut),
+		errors.Is(err, repositories.ErrRestoreParentDeleted),
+		errors.Is(err, repositories.ErrTargetFolderDeleted),
+		errors.Is(err, repositories.ErrWebhookDeliveryPending),
+		errors.Is(err, repositories.ErrFolderAutoSorted):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrNotApprover),
+		errors.Is(err, services.ErrSelfApproval),
+		errors.Is(err, services.ErrApproversNotAllowed),
+		errors.Is(err, services.ErrAuditNotAllowed),
+		errors.Is(err, services.ErrLibraryNotAllowed),
+		errors.Is(err, services.ErrWebhookNotAllowed),
+		errors.Is(err, repositories.ErrMoveNotPermitted):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrNotGoverned),
+		errors.Is(err, services.ErrCommentRequired),
+		errors.Is(err, services.ErrInvalidApprovers),
+		errors.Is(err, services.ErrInvalidImportFile),
+		errors.Is(err, services.ErrInvalidImportRequest),
+		errors.Is(err, repositories.ErrInvalidImportKey),
+		errors.Is(err, services.ErrInvalidExportRequest),
+		errors.Is(err, services.ErrInvalidRelationship),
+		errors.Is(err, services.ErrInvalidGraphRequest),
+		errors.Is(err, services.ErrInvalidSearch),
+		errors.Is(err, repositories.ErrInvalidFilter),
+		errors.Is(err, repositories.ErrInvalidCursor),
+		errors.Is(err, services.ErrInvalidAuditQuery),
+		errors.Is(err, services.ErrInvalidWebhook),
+		errors.Is(err, repositories.ErrRelationshipNotAllowed),
+		errors.Is(err, repositories.ErrInvalidPlacement),
+		errors.Is(err, repositories.ErrTypeNotAllowedInFolder),
+		errors.Is(err, repositories.ErrInvalidFolder):
+		return http.StatusBadRequest
+	}
+	return fallback
+}
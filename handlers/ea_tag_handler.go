@@ -28,7 +28,7 @@ func (h *EATagHandler) CreateEATag(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tag, err := h.service.CreateEATag(req)
+	tag, err := h.service.CreateEATag(req, changeLog(r))
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to create EA tag", err.Error())
 		return
@@ -57,12 +57,9 @@ func (h *EATagHandler) GetEATagByID(w http.ResponseWriter, r *http.Request) {
 
 // GetAllEATags handles GET /api/ea-tags
 func (h *EATagHandler) GetAllEATags(w http.ResponseWriter, r *http.Request) {
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	pageSize, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
-
-	response, err := h.service.GetAllEATags(page, pageSize)
+	response, err := h.service.GetAllEATags(pageRequest(r))
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve EA tags", err.Error())
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to retrieve EA tags", err.Error())
 		return
 	}
 
@@ -84,7 +81,7 @@ func (h *EATagHandler) UpdateEATag(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tag, err := h.service.UpdateEATag(id, req)
+	tag, err := h.service.UpdateEATag(id, req, changeLog(r))
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to update EA tag", err.Error())
 		return
@@ -102,7 +99,7 @@ func (h *EATagHandler) DeleteEATag(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.DeleteEATag(id); err != nil {
+	if err := h.service.DeleteEATag(id, changeLog(r)); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to delete EA tag", err.Error())
 		return
 	}
@@ -120,7 +117,7 @@ func (h *EATagHandler) AssignObjectTypeToDimention(w http.ResponseWriter, r *htt
 		return
 	}
 
-	dimention, err := h.service.AssignObjectTypeToDimention(req)
+	dimention, err := h.service.AssignObjectTypeToDimention(req, changeLog(r))
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to assign object type to dimension", err.Error())
 		return
@@ -144,3 +141,57 @@ func (h *EATagHandler) GetEAObjectTypesAssignedToDimension(w http.ResponseWriter
 
 	respondWithJSON(w, http.StatusOK, objectTypes)
 }
+
+// GetArchiMateMappings handles GET /api/ea-tags/archimate-mappings
+func (h *EATagHandler) GetArchiMateMappings(w http.ResponseWriter, r *http.Request) {
+	mappings, err := h.service.GetArchiMateMappings()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve ArchiMate mappings", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, mappings)
+}
+
+// SetArchiMateMapping handles PUT /api/ea-tags/archimate-mappings/{objectTypeID}
+func (h *EATagHandler) SetArchiMateMapping(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	objectTypeID, err := strconv.Atoi(vars["objectTypeID"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid object type ID", err.Error())
+		return
+	}
+
+	var req models.SetArchiMateMappingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	mapping, err := h.service.SetArchiMateMapping(objectTypeID, req, changeLog(r))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to save ArchiMate mapping", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, mapping)
+}
+
+// DeleteArchiMateMapping handles DELETE /api/ea-tags/archimate-mappings/{objectTypeID}
+func (h *EATagHandler) DeleteArchiMateMapping(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	objectTypeID, err := strconv.Atoi(vars["objectTypeID"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid object type ID", err.Error())
+		return
+	}
+
+	if err := h.service.DeleteArchiMateMapping(objectTypeID, changeLog(r)); err != nil {
+		respondWithError(w, http.StatusNotFound, "Failed to delete ArchiMate mapping", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: "ArchiMate mapping deleted successfully",
+	})
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/services"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventStreamHandler handles the server-sent event stream of repository tree
+// changes
+type EventStreamHandler struct {
+	service     *services.EventStreamService
+	permissions *services.PermissionService
+	heartbeat   time.Duration
+}
+
+// NewEventStreamHandler creates a new EventStreamHandler
+func NewEventStreamHandler(service *services.EventStreamService, permissions *services.PermissionService, heartbeat time.Duration) *EventStreamHandler {
+	return &EventStreamHandler{service: service, permissions: permissions, heartbeat: heartbeat}
+}
+
+// Stream handles GET /api/events/stream
+func (h *EventStreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondWithError(w, http.StatusInternalServerError, "Streaming unsupported", "response writer cannot be flushed")
+		return
+	}
+
+	var libraryID *uuid.UUID
+	if v := r.URL.Query().Get("libraryId"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid library ID", err.Error())
+			return
+		}
+		if !authorizeObject(w, r, h.permissions, id, models.PermissionRead) {
+			return
+		}
+		libraryID = &id
+	}
+
+	// Browsers send Last-Event-ID when they reconnect; the query parameter
+	// lets a client resume on its first connection
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+
+	sub, missed, reset, latestID := h.service.Subscribe(currentUser(r).ProfileID, libraryID, lastEventID)
+	defer h.service.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if reset {
+		writeStreamEvent(w, models.StreamEvent{
+			Id:         latestID,
+			Type:       models.StreamEventReset,
+			OccurredAt: time.Now().UTC(),
+		})
+	}
+	for _, event := range missed {
+		if h.service.CanRead(sub, event) {
+			writeStreamEvent(w, event)
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				// Dropped for falling behind; the client reconnects and
+				// resumes from the last event it received
+				return
+			}
+			if !h.service.CanRead(sub, event) {
+				continue
+			}
+			writeStreamEvent(w, event)
+			flusher.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// writeStreamEvent writes one event in the text/event-stream format
+func writeStreamEvent(w http.ResponseWriter, event models.StreamEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to encode stream event %s: %v", event.Id, err)
+		return
+	}
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.Id, event.Type, data)
+}
+
+// streamObjectEvent sends a change to an object to event stream clients.
+// parentID is the folder the object is in, where the caller knows it.
+func streamObjectEvent(r *http.Request, events *services.EventStreamService, eventType string, object *models.Object, parentID *uuid.UUID) {
+	if object == nil {
+		return
+	}
+	libraryID := object.LibraryId
+	if object.IsLibrary {
+		libraryID = &object.ObjectID
+	}
+	events.Publish(models.StreamEvent{
+		Type:      eventType,
+		ObjectId:  object.ObjectID,
+		ParentId:  parentID,
+		LibraryId: libraryID,
+		UserId:    currentUser(r).UserID,
+		Data:      object,
+	})
+}
+
+// streamMoveEvent sends an object's move to another folder to event stream
+// clients
+func streamMoveEvent(r *http.Request, events *services.EventStreamService, result *models.ObjectPlacementResult) {
+	events.Publish(models.StreamEvent{
+		Type:             models.StreamEventMove,
+		ObjectId:         result.Object.ObjectID,
+		ParentId:         &result.ParentId,
+		PreviousParentId: result.PreviousParentId,
+		LibraryId:        &result.LibraryId,
+		UserId:           currentUser(r).UserID,
+		Data:             result.Object,
+	})
+}
+
+// streamRecycleBinEvent sends the deletion or restore of a delete transaction's
+// root object to event stream clients. A deleted object no longer inherits
+// its folder's permissions, so the event is authorized on the folder.
+func streamRecycleBinEvent(r *http.Request, events *services.EventStreamService, eventType string, entry *models.RecycleBinEntry) {
+	if entry == nil {
+		return
+	}
+	event := models.StreamEvent{
+		Type:      eventType,
+		ObjectId:  entry.RootObjectId,
+		ParentId:  entry.ParentObjectId,
+		LibraryId: entry.LibraryId,
+		UserId:    currentUser(r).UserID,
+		Data:      entry,
+	}
+	if entry.ParentObjectId != nil {
+		event.AuthorizeOn = *entry.ParentObjectId
+	}
+	events.Publish(event)
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/services"
+	"enterprise-architect-api/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExportHandler handles HTTP requests for spreadsheet exports
+type ExportHandler struct {
+	service     *services.ExportService
+	permissions *services.PermissionService
+}
+
+// NewExportHandler creates a new ExportHandler
+func NewExportHandler(service *services.ExportService, permissions *services.PermissionService) *ExportHandler {
+	return &ExportHandler{service: service, permissions: permissions}
+}
+
+// ExportObjects handles GET /api/export/objects
+func (h *ExportHandler) ExportObjects(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	objectTypeID, err := strconv.Atoi(query.Get("objectTypeId"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid object type ID", err.Error())
+		return
+	}
+	libraryID, err := uuid.Parse(query.Get("libraryId"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid library ID", err.Error())
+		return
+	}
+	format := strings.ToLower(query.Get("format"))
+	if format == "" {
+		format = models.ExportFormatCSV
+	}
+
+	if !authorizeObject(w, r, h.permissions, libraryID, models.PermissionRead) {
+		return
+	}
+
+	profileID := currentUser(r).ProfileID
+	columns, err := h.service.ExportColumns(objectTypeID, format, profileID)
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to export objects", err.Error())
+		return
+	}
+
+	fileName := fmt.Sprintf("objects-%d-%s.%s", objectTypeID, time.Now().Format("20060102"), format)
+	w.Header().Set("Content-Type", utils.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	w.WriteHeader(http.StatusOK)
+
+	// The status has been sent, so a failure part way can only be logged and
+	// the download left incomplete
+	if err := h.service.ExportObjects(w, format, columns, objectTypeID, libraryID, profileID); err != nil {
+		log.Printf("export of object type %d in library %s failed: %v", objectTypeID, libraryID, err)
+	}
+}
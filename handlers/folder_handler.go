@@ -1,9 +1,10 @@
 package handlers
 
 import (
+	"encoding/json"
+	"enterprise-architect-api/models"
 	"enterprise-architect-api/services"
 	"net/http"
-	"strconv"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -11,12 +12,14 @@ import (
 
 // FolderHandler handles HTTP requests for folders
 type FolderHandler struct {
-	service *services.FolderService
+	service     *services.FolderService
+	permissions *services.PermissionService
+	events      *services.EventStreamService
 }
 
 // NewFolderHandler creates a new FolderHandler
-func NewFolderHandler(service *services.FolderService) *FolderHandler {
-	return &FolderHandler{service: service}
+func NewFolderHandler(service *services.FolderService, permissions *services.PermissionService, events *services.EventStreamService) *FolderHandler {
+	return &FolderHandler{service: service, permissions: permissions, events: events}
 }
 
 // GetObjectTypeFolders handles GET /api/folders/object-type/{libraryId}
@@ -46,25 +49,116 @@ func (h *FolderHandler) GetFoldersByLibrary(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	// Get profileId from query parameter
-	profileIDStr := r.URL.Query().Get("profileId")
-	if profileIDStr == "" {
-		respondWithError(w, http.StatusBadRequest, "Profile ID is required", "profileId query parameter is missing")
+	contents, err := h.service.GetFoldersByLibrary(folderID, currentUser(r).ProfileID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve folder contents", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, contents)
+}
+
+// CreateFolder handles POST /api/folders
+func (h *FolderHandler) CreateFolder(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateFolderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+	if !authorizePlacement(w, r, h.permissions, req.ParentId, req.LibraryId) {
 		return
 	}
 
-	profileID, err := strconv.Atoi(profileIDStr)
+	folder, err := h.service.CreateFolder(req, currentUser(r).UserID, changeLog(r))
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid profile ID", err.Error())
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to create folder", err.Error())
 		return
 	}
+	parentID := req.ParentId
+	if parentID == nil {
+		parentID = req.LibraryId
+	}
+	streamObjectEvent(r, h.events, models.StreamEventCreate, folder, parentID)
 
-	contents, err := h.service.GetFoldersByLibrary(folderID, profileID)
+	respondWithJSON(w, http.StatusCreated, folder)
+}
+
+// UpdateFolder handles PUT /api/folders/{folderId}
+func (h *FolderHandler) UpdateFolder(w http.ResponseWriter, r *http.Request) {
+	folderID, err := uuid.Parse(mux.Vars(r)["folderId"])
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve folder contents", err.Error())
+		respondWithError(w, http.StatusBadRequest, "Invalid folder ID", err.Error())
 		return
 	}
 
+	var req models.UpdateFolderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+	if !authorizeObject(w, r, h.permissions, folderID, models.PermissionModify) {
+		return
+	}
+
+	folder, err := h.service.UpdateFolder(folderID, req, currentUser(r).UserID, changeLog(r))
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to update folder", err.Error())
+		return
+	}
+	streamObjectEvent(r, h.events, models.StreamEventUpdate, folder, nil)
+
+	respondWithJSON(w, http.StatusOK, folder)
+}
+
+// ReorderFolder handles PUT /api/folders/{folderId}/order
+func (h *FolderHandler) ReorderFolder(w http.ResponseWriter, r *http.Request) {
+	folderID, err := uuid.Parse(mux.Vars(r)["folderId"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid folder ID", err.Error())
+		return
+	}
+
+	var req models.ReorderFolderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+	if !authorizeObject(w, r, h.permissions, folderID, models.PermissionModifyContents) {
+		return
+	}
+
+	contents, err := h.service.ReorderFolder(folderID, req, currentUser(r).ProfileID, changeLog(r))
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to reorder folder", err.Error())
+		return
+	}
+	if folder, err := h.service.GetFolder(folderID); err == nil {
+		streamObjectEvent(r, h.events, models.StreamEventReorder, folder, nil)
+	}
+
 	respondWithJSON(w, http.StatusOK, contents)
 }
 
+// DeleteFolder handles DELETE /api/folders/{folderId}
+func (h *FolderHandler) DeleteFolder(w http.ResponseWriter, r *http.Request) {
+	folderID, err := uuid.Parse(mux.Vars(r)["folderId"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid folder ID", err.Error())
+		return
+	}
+	if !authorizeObject(w, r, h.permissions, folderID, models.PermissionDelete) {
+		return
+	}
+
+	entry, err := h.service.DeleteFolder(folderID, currentUser(r).UserID, changeLog(r))
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to delete folder", err.Error())
+		return
+	}
+	streamRecycleBinEvent(r, h.events, models.StreamEventDelete, entry)
+
+	respondWithJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: "Folder moved to the recycle bin",
+		Data:    entry,
+	})
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/services"
+	"enterprise-architect-api/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GraphHandler handles HTTP requests for GraphML and DOT graph exports
+type GraphHandler struct {
+	service     *services.GraphService
+	permissions *services.PermissionService
+}
+
+// NewGraphHandler creates a new GraphHandler
+func NewGraphHandler(service *services.GraphService, permissions *services.PermissionService) *GraphHandler {
+	return &GraphHandler{service: service, permissions: permissions}
+}
+
+// ExportLibraryGraph handles GET /api/export/graph
+func (h *GraphHandler) ExportLibraryGraph(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	libraryID, err := uuid.Parse(query.Get("libraryId"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid library ID", err.Error())
+		return
+	}
+	format, err := h.service.CheckFormat(query.Get("format"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid format", err.Error())
+		return
+	}
+
+	if !authorizeObject(w, r, h.permissions, libraryID, models.PermissionRead) {
+		return
+	}
+
+	libraryName, edges, err := h.service.CheckLibraryExport(libraryID, query.Get("edges"))
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to export graph", err.Error())
+		return
+	}
+
+	writeGraphHeaders(w, fmt.Sprintf("graph-%s-%s.%s", libraryID, time.Now().Format("20060102"), format), format)
+
+	// The status has been sent, so a failure part way can only be logged and
+	// the download left incomplete
+	if err := h.service.ExportLibrary(w, format, libraryID, libraryName, edges, currentUser(r).ProfileID); err != nil {
+		log.Printf("graph export of library %s failed: %v", libraryID, err)
+	}
+}
+
+// ExportImpactGraph handles GET /api/objects/{id}/impact/graph
+func (h *GraphHandler) ExportImpactGraph(w http.ResponseWriter, r *http.Request) {
+	id, depth, relationTypeIDs, ok := parseImpactRequest(w, r)
+	if !ok {
+		return
+	}
+	format, err := h.service.CheckFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid format", err.Error())
+		return
+	}
+
+	if !authorizeObject(w, r, h.permissions, id, models.PermissionRead) {
+		return
+	}
+
+	graph, err := h.service.AnalyzeImpact(id, r.URL.Query().Get("direction"), depth, relationTypeIDs, currentUser(r).ProfileID)
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to analyze impact", err.Error())
+		return
+	}
+
+	writeGraphHeaders(w, fmt.Sprintf("impact-%s-%s.%s", id, time.Now().Format("20060102"), format), format)
+	if err := h.service.ExportImpact(w, format, graph); err != nil {
+		log.Printf("graph export of impact of %s failed: %v", id, err)
+	}
+}
+
+// writeGraphHeaders starts a graph download
+func writeGraphHeaders(w http.ResponseWriter, fileName, format string) {
+	w.Header().Set("Content-Type", utils.GraphContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	w.WriteHeader(http.StatusOK)
+}
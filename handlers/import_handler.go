@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"encoding/json"
+	"enterprise-architect-api/config"
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/services"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// ImportHandler handles HTTP requests for spreadsheet imports and background
+// import jobs
+type ImportHandler struct {
+	service     *services.ImportService
+	jobs        *services.ImportJobService
+	permissions *services.PermissionService
+}
+
+// NewImportHandler creates a new ImportHandler
+func NewImportHandler(service *services.ImportService, jobs *services.ImportJobService, permissions *services.PermissionService) *ImportHandler {
+	return &ImportHandler{service: service, jobs: jobs, permissions: permissions}
+}
+
+// UploadSpreadsheet handles POST /api/objects/import/upload
+func (h *ImportHandler) UploadSpreadsheet(w http.ResponseWriter, r *http.Request) {
+	req, fileName, data, ok := h.readSpreadsheetUpload(w, r)
+	if !ok {
+		return
+	}
+
+	user := currentUser(r)
+	response, err := h.service.ImportSpreadsheet(req, fileName, data, user.UserID, user.ProfileID, changeLog(r))
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to import objects", err.Error())
+		return
+	}
+
+	respondWithImportResult(w, response.DryRun, response)
+}
+
+// CreateImportJob handles POST /api/import-jobs
+func (h *ImportHandler) CreateImportJob(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	var job *models.ImportJob
+	var err error
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		req, fileName, data, ok := h.readSpreadsheetUpload(w, r)
+		if !ok {
+			return
+		}
+		job, err = h.jobs.CreateFromSpreadsheet(req, fileName, data, user.UserID, user.ProfileID)
+	} else {
+		var req models.ObjectImportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+			return
+		}
+		if !authorizeObject(w, r, h.permissions, req.FolderId, models.PermissionModifyContents) {
+			return
+		}
+		job, err = h.jobs.Create(req, user.UserID, user.ProfileID)
+	}
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to create import job", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusAccepted, models.SuccessResponse{
+		Message: "Import job queued",
+		Data:    job,
+	})
+}
+
+// GetImportJob handles GET /api/import-jobs/{id}
+func (h *ImportHandler) GetImportJob(w http.ResponseWriter, r *http.Request) {
+	jobID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid job ID", err.Error())
+		return
+	}
+
+	job, err := h.jobs.GetJob(jobID, currentUser(r).UserID)
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to retrieve import job", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, job)
+}
+
+// CancelImportJob handles POST /api/import-jobs/{id}/cancel
+func (h *ImportHandler) CancelImportJob(w http.ResponseWriter, r *http.Request) {
+	jobID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid job ID", err.Error())
+		return
+	}
+
+	job, err := h.jobs.Cancel(jobID, currentUser(r).UserID)
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to cancel import job", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, job)
+}
+
+// readSpreadsheetUpload parses a multipart spreadsheet upload and checks that
+// the caller may add objects to the target folder
+func (h *ImportHandler) readSpreadsheetUpload(w http.ResponseWriter, r *http.Request) (models.SpreadsheetImportRequest, string, []byte, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, config.MaxUploadSize)
+	if err := r.ParseMultipartForm(config.MaxUploadSize); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to parse form", err.Error())
+		return models.SpreadsheetImportRequest{}, "", nil, false
+	}
+
+	req, ok := parseSpreadsheetImportForm(w, r)
+	if !ok {
+		return req, "", nil, false
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to get uploaded file", err.Error())
+		return req, "", nil, false
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to read uploaded file", err.Error())
+		return req, "", nil, false
+	}
+
+	if !authorizeObject(w, r, h.permissions, req.FolderId, models.PermissionModifyContents) {
+		return req, "", nil, false
+	}
+
+	return req, header.Filename, data, true
+}
+
+// parseSpreadsheetImportForm reads the import target and column mapping from
+// the multipart form fields
+func parseSpreadsheetImportForm(w http.ResponseWriter, r *http.Request) (models.SpreadsheetImportRequest, bool) {
+	var req models.SpreadsheetImportRequest
+
+	libraryID, err := uuid.Parse(r.FormValue("libraryId"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid library ID", err.Error())
+		return req, false
+	}
+	folderID, err := uuid.Parse(r.FormValue("folderId"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid folder ID", err.Error())
+		return req, false
+	}
+	objectTypeID, err := strconv.Atoi(r.FormValue("objectTypeId"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid object type ID", err.Error())
+		return req, false
+	}
+
+	req.LibraryId = libraryID
+	req.FolderId = folderID
+	req.ObjectTypeId = objectTypeID
+	req.DryRun = isDryRun(r.FormValue("dryRun"))
+	req.Locale = r.FormValue("locale")
+	req.MatchBy = r.FormValue("matchBy")
+	req.Strategy = r.FormValue("strategy")
+	if matchAttributeID := r.FormValue("matchAttributeId"); matchAttributeID != "" {
+		req.MatchAttributeId = &matchAttributeID
+	}
+
+	if mapping := r.FormValue("mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &req.Mapping); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid column mapping", err.Error())
+			return req, false
+		}
+	}
+
+	return req, true
+}
+
+// isDryRun reports whether the dryRun parameter asks for a preview
+func isDryRun(value string) bool {
+	dryRun, _ := strconv.ParseBool(value)
+	return dryRun
+}
+
+// respondWithImportResult writes an import result: 201 for a committed import
+// and 200 for a dry run, which changes nothing
+func respondWithImportResult(w http.ResponseWriter, dryRun bool, result interface{}) {
+	if dryRun {
+		respondWithJSON(w, http.StatusOK, models.SuccessResponse{
+			Message: "Import preview generated, no changes were saved",
+			Data:    result,
+		})
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, models.SuccessResponse{
+		Message: "Objects imported successfully",
+		Data:    result,
+	})
+}
@@ -29,6 +29,7 @@ func (h *ObjectContentHandler) CreateObjectContent(w http.ResponseWriter, r *htt
 		return
 	}
 
+	req.CreatedBy = currentUser(r).UserID
 	objectContent, err := h.service.CreateObjectContent(req)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to create object content", err.Error())
@@ -58,12 +59,9 @@ func (h *ObjectContentHandler) GetObjectContentByID(w http.ResponseWriter, r *ht
 
 // GetAllObjectContents handles GET /api/object-contents
 func (h *ObjectContentHandler) GetAllObjectContents(w http.ResponseWriter, r *http.Request) {
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	pageSize, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
-
-	response, err := h.service.GetAllObjectContents(page, pageSize)
+	response, err := h.service.GetAllObjectContents(pageRequest(r))
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve object contents", err.Error())
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to retrieve object contents", err.Error())
 		return
 	}
 
@@ -85,6 +83,7 @@ func (h *ObjectContentHandler) UpdateObjectContent(w http.ResponseWriter, r *htt
 		return
 	}
 
+	req.ModifiedBy = currentUser(r).UserID
 	objectContent, err := h.service.UpdateObjectContent(id, req)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to update object content", err.Error())
@@ -16,11 +16,13 @@ import (
 type ObjectHandler struct {
 	service              *services.ObjectService
 	objectContentService *services.ObjectContentService
+	permissions          *services.PermissionService
+	events               *services.EventStreamService
 }
 
 // NewObjectHandler creates a new ObjectHandler
-func NewObjectHandler(service *services.ObjectService, objectContent *services.ObjectContentService) *ObjectHandler {
-	return &ObjectHandler{service: service, objectContentService: objectContent}
+func NewObjectHandler(service *services.ObjectService, objectContent *services.ObjectContentService, permissions *services.PermissionService, events *services.EventStreamService) *ObjectHandler {
+	return &ObjectHandler{service: service, objectContentService: objectContent, permissions: permissions, events: events}
 }
 
 // ImportObjects handles POST /api/objects/import
@@ -30,17 +32,20 @@ func (h *ObjectHandler) ImportObjects(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err.Error())
 		return
 	}
+	if isDryRun(r.URL.Query().Get("dryRun")) {
+		req.DryRun = true
+	}
+	if !authorizeObject(w, r, h.permissions, req.FolderId, models.PermissionModifyContents) {
+		return
+	}
 	var response *models.ObjectImportResponse
 	var err error
-	if response, err = h.service.ImportObjects(req); err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to import objects", err.Error())
+	if response, err = h.service.ImportObjects(req, currentUser(r).UserID, currentUser(r).ProfileID, changeLog(r)); err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to import objects", err.Error())
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, models.SuccessResponse{
-		Message: "Objects imported successfully",
-		Data:    &response,
-	})
+	respondWithImportResult(w, req.DryRun, &response)
 }
 
 // CreateObject handles POST /api/objects
@@ -50,17 +55,28 @@ func (h *ObjectHandler) CreateObject(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err.Error())
 		return
 	}
-	req.CreatedBy = 62
-	object, err := h.service.CreateObject(req)
+	req.CreatedBy = currentUser(r).UserID
+	// Libraries are created at the root of the tree; everything else is
+	// created in a folder the caller may add to
+	if !req.IsLibrary {
+		if req.DirectParentId == nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid request payload", "directParentId is required unless isLibrary is true")
+			return
+		}
+		if !authorizeObject(w, r, h.permissions, *req.DirectParentId, models.PermissionModifyContents) {
+			return
+		}
+	}
+	object, err := h.service.CreateObject(req, currentUser(r).ProfileID, changeLog(r))
 
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to create object", err.Error())
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to create object", err.Error())
 		return
 	}
 	objectContent := &models.CreateObjectContentRequest{
 		ContainerVersionID: *object.CurrentVersionId,
 		ObjectID:           object.ObjectID,
-		CreatedBy:          62,
+		CreatedBy:          req.CreatedBy,
 	}
 
 	objectContent.ContainmentType = 1
@@ -76,6 +92,7 @@ func (h *ObjectHandler) CreateObject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	fmt.Println("object content created", objectContentItem)
+	streamObjectEvent(r, h.events, models.StreamEventCreate, object, req.DirectParentId)
 	respondWithJSON(w, http.StatusCreated, object)
 }
 
@@ -87,6 +104,9 @@ func (h *ObjectHandler) GetObjectByID(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusBadRequest, "Invalid object ID", err.Error())
 		return
 	}
+	if !authorizeObject(w, r, h.permissions, id, models.PermissionRead) {
+		return
+	}
 
 	object, err := h.service.GetObjectByID(id)
 	if err != nil {
@@ -99,12 +119,9 @@ func (h *ObjectHandler) GetObjectByID(w http.ResponseWriter, r *http.Request) {
 
 // GetAllObjects handles GET /api/objects
 func (h *ObjectHandler) GetAllObjects(w http.ResponseWriter, r *http.Request) {
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	pageSize, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
-
-	response, err := h.service.GetAllObjects(page, pageSize)
+	response, err := h.service.GetAllObjects(objectListQuery(r), currentUser(r).ProfileID)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve objects", err.Error())
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to retrieve objects", err.Error())
 		return
 	}
 
@@ -125,12 +142,16 @@ func (h *ObjectHandler) UpdateObject(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err.Error())
 		return
 	}
-	req.ModifiedBy = 62 // unitl make
-	object, err := h.service.UpdateObject(id, req)
+	if !authorizeObject(w, r, h.permissions, id, models.PermissionModify) {
+		return
+	}
+	req.ModifiedBy = currentUser(r).UserID
+	object, err := h.service.UpdateObject(id, req, changeLog(r))
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to update object", err.Error())
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to update object", err.Error())
 		return
 	}
+	streamObjectEvent(r, h.events, models.StreamEventUpdate, object, nil)
 
 	respondWithJSON(w, http.StatusOK, object)
 }
@@ -143,23 +164,87 @@ func (h *ObjectHandler) DeleteObject(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusBadRequest, "Invalid object ID", err.Error())
 		return
 	}
+	if !authorizeObject(w, r, h.permissions, id, models.PermissionDelete) {
+		return
+	}
 
-	if err := h.service.DeleteObject(id); err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to delete object", err.Error())
+	entry, err := h.service.DeleteObject(id, currentUser(r).UserID, changeLog(r))
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to delete object", err.Error())
 		return
 	}
+	streamRecycleBinEvent(r, h.events, models.StreamEventDelete, entry)
 
 	respondWithJSON(w, http.StatusOK, models.SuccessResponse{
-		Message: "Object deleted successfully",
+		Message: "Object moved to the recycle bin",
+		Data:    entry,
 	})
 }
 
+// MoveObject handles POST /api/objects/{id}/move
+func (h *ObjectHandler) MoveObject(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid object ID", err.Error())
+		return
+	}
+
+	var req models.MoveObjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+	if !authorizeObject(w, r, h.permissions, id, models.PermissionModify) ||
+		!authorizePlacement(w, r, h.permissions, req.TargetFolderId, req.TargetLibraryId) {
+		return
+	}
+
+	user := currentUser(r)
+	result, err := h.service.MoveObject(id, req, user.UserID, user.ProfileID, changeLog(r))
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to move object", err.Error())
+		return
+	}
+	streamMoveEvent(r, h.events, result)
+
+	respondWithJSON(w, http.StatusOK, result)
+}
+
+// CopyObject handles POST /api/objects/{id}/copy
+func (h *ObjectHandler) CopyObject(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid object ID", err.Error())
+		return
+	}
+
+	var req models.CopyObjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+	if !authorizeObject(w, r, h.permissions, id, models.PermissionRead) ||
+		!authorizePlacement(w, r, h.permissions, req.TargetFolderId, req.TargetLibraryId) {
+		return
+	}
+
+	user := currentUser(r)
+	result, err := h.service.CopyObject(id, req, user.UserID, user.ProfileID, changeLog(r))
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to copy object", err.Error())
+		return
+	}
+	streamObjectEvent(r, h.events, models.StreamEventCreate, result.Object, &result.ParentId)
+
+	respondWithJSON(w, http.StatusCreated, result)
+}
+
 // GetLibraries handles GET /api/objects/libraries
 func (h *ObjectHandler) GetLibraries(w http.ResponseWriter, r *http.Request) {
 	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
 	pageSize, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
 
-	response, err := h.service.GetLibraries(page, pageSize)
+	response, err := h.service.GetLibraries(page, pageSize, currentUser(r).ProfileID)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve libraries", err.Error())
 		return
@@ -177,12 +262,9 @@ func (h *ObjectHandler) GetObjectsByTypeID(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	pageSize, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
-
-	response, err := h.service.GetObjectsByTypeID(typeID, page, pageSize)
+	response, err := h.service.GetObjectsByTypeID(typeID, objectListQuery(r), currentUser(r).ProfileID)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve objects by type", err.Error())
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to retrieve objects by type", err.Error())
 		return
 	}
 
@@ -195,9 +277,8 @@ func (h *ObjectHandler) GetHierarchyFolder(w http.ResponseWriter, r *http.Reques
 		respondWithError(w, http.StatusBadRequest, "Invalid object ID", err.Error())
 		return
 	}
-	profileID, _ := strconv.Atoi(r.URL.Query().Get("profileID"))
 	isFolder, _ := strconv.Atoi(r.URL.Query().Get("isFolder"))
-	response, err := h.service.GetHierarchyFolder(objectID, profileID, isFolder == 1)
+	response, err := h.service.GetHierarchyFolder(objectID, currentUser(r).ProfileID, isFolder == 1)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve hierarchy folder", err.Error())
 		return
@@ -218,26 +299,27 @@ func (h *ObjectHandler) GetObjectsByObjectTypeIDAndLibraryID(w http.ResponseWrit
 		respondWithError(w, http.StatusBadRequest, "Invalid library ID", err.Error())
 		return
 	}
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	pageSize, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
-	if pageSize == 0 {
-		pageSize = 20
+	q := objectListQuery(r)
+	if q.PageSize == 0 {
+		q.PageSize = 20
 	}
-	// Fix: Capture all 3 return values from the service method
-	objects, totalCount, err := h.service.GetObjectsByObjectTypeIDAndLibraryID(objectTypeID, libraryID, page, pageSize)
+	response, err := h.service.GetObjectsByObjectTypeIDAndLibraryID(objectTypeID, libraryID, q, currentUser(r).ProfileID)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve objects by type and library", err.Error())
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to retrieve objects by type and library", err.Error())
 		return
 	}
 
-	// Build paginated response
-	response := models.PaginatedResponse{
-		Data:       objects,
-		Page:       page,
-		PageSize:   pageSize,
-		TotalCount: totalCount,
-		TotalPages: (totalCount + pageSize - 1) / pageSize,
-	}
-
 	respondWithJSON(w, http.StatusOK, response)
 }
+
+// objectListQuery reads the paging, filter, sort and facets parameters of an
+// object list request
+func objectListQuery(r *http.Request) models.ObjectListQuery {
+	query := r.URL.Query()
+	return models.ObjectListQuery{
+		PageRequest: pageRequest(r),
+		Filter:      query.Get("filter"),
+		Sort:        query.Get("sort"),
+		Facets:      query.Get("facets"),
+	}
+}
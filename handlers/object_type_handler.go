@@ -28,7 +28,9 @@ func (h *ObjectTypeHandler) CreateObjectType(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	objectType, err := h.service.CreateObjectType(req)
+	req.CreatedBy = currentUser(r).UserID
+	req.ModifiedBy = req.CreatedBy
+	objectType, err := h.service.CreateObjectType(req, changeLog(r))
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to create object type", err.Error())
 		return
@@ -99,7 +101,8 @@ func (h *ObjectTypeHandler) UpdateObjectType(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	objectType, err := h.service.UpdateObjectType(id, req)
+	req.ModifiedBy = currentUser(r).UserID
+	objectType, err := h.service.UpdateObjectType(id, req, changeLog(r))
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to update object type", err.Error())
 		return
@@ -117,7 +120,7 @@ func (h *ObjectTypeHandler) DeleteObjectType(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	if err := h.service.DeleteObjectType(id); err != nil {
+	if err := h.service.DeleteObjectType(id, changeLog(r)); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to delete object type", err.Error())
 		return
 	}
@@ -146,7 +149,7 @@ func (h *ObjectTypeHandler) AddFolderToTree(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	folderTypeHierarchyId, err := h.service.AddFolderToTree(req)
+	folderTypeHierarchyId, err := h.service.AddFolderToTree(req, currentUser(r).UserID, changeLog(r))
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to add folder to tree", err.Error())
 		return
@@ -166,7 +169,7 @@ func (h *ObjectTypeHandler) AssignObjectTypeToFolder(w http.ResponseWriter, r *h
 		return
 	}
 
-	if err := h.service.AssignObjectTypeToFolder(req); err != nil {
+	if err := h.service.AssignObjectTypeToFolder(req, changeLog(r)); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to assign object type to folder", err.Error())
 		return
 	}
@@ -226,7 +229,7 @@ func (h *ObjectTypeHandler) DeleteObjectTypeFromFolder(w http.ResponseWriter, r
 		return
 	}
 
-	if err := h.service.DeleteObjectTypeFromFolder(folderObjectTypeId, objectTypeId); err != nil {
+	if err := h.service.DeleteObjectTypeFromFolder(folderObjectTypeId, objectTypeId, changeLog(r)); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to delete object type from folder", err.Error())
 		return
 	}
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"enterprise-architect-api/models"
+	"net/http"
+	"strconv"
+)
+
+// pageRequest reads the page, pageSize, cursor and count parameters of a list
+// request. count=false skips the total count.
+func pageRequest(r *http.Request) models.PageRequest {
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	pageSize, _ := strconv.Atoi(query.Get("pageSize"))
+	return models.PageRequest{
+		Page:      page,
+		PageSize:  pageSize,
+		Cursor:    query.Get("cursor"),
+		SkipCount: query.Get("count") == "false",
+	}
+}
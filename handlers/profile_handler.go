@@ -28,7 +28,8 @@ func (h *ProfileHandler) CreateProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	profile, err := h.service.CreateProfile(req)
+	req.CreatedBy = currentUser(r).UserID
+	profile, err := h.service.CreateProfile(req, changeLog(r))
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to create profile", err.Error())
 		return
@@ -84,7 +85,8 @@ func (h *ProfileHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	profile, err := h.service.UpdateProfile(id, req)
+	req.ModifiedBy = currentUser(r).UserID
+	profile, err := h.service.UpdateProfile(id, req, changeLog(r))
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to update profile", err.Error())
 		return
@@ -102,7 +104,7 @@ func (h *ProfileHandler) DeleteProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.DeleteProfile(id); err != nil {
+	if err := h.service.DeleteProfile(id, changeLog(r)); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to delete profile", err.Error())
 		return
 	}
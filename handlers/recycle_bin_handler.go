@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/services"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// RecycleBinHandler handles HTTP requests for the recycle bin
+type RecycleBinHandler struct {
+	service *services.RecycleBinService
+	events  *services.EventStreamService
+}
+
+// NewRecycleBinHandler creates a new RecycleBinHandler
+func NewRecycleBinHandler(service *services.RecycleBinService, events *services.EventStreamService) *RecycleBinHandler {
+	return &RecycleBinHandler{service: service, events: events}
+}
+
+// List handles GET /api/recycle-bin
+func (h *RecycleBinHandler) List(w http.ResponseWriter, r *http.Request) {
+	response, err := h.service.List(pageRequest(r), currentUser(r).ProfileID)
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to retrieve recycle bin", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// Get handles GET /api/recycle-bin/{id}
+func (h *RecycleBinHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid delete transaction ID", err.Error())
+		return
+	}
+
+	entry, err := h.service.Get(id, currentUser(r).ProfileID)
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to retrieve delete transaction", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, entry)
+}
+
+// Restore handles POST /api/recycle-bin/{id}/restore
+func (h *RecycleBinHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid delete transaction ID", err.Error())
+		return
+	}
+
+	user := currentUser(r)
+	entry, count, err := h.service.Restore(id, user.UserID, user.ProfileID, changeLog(r))
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to restore objects", err.Error())
+		return
+	}
+	result := models.RecycleBinActionResult{DeleteTransactionId: id, ObjectCount: count}
+	streamRecycleBinEvent(r, h.events, models.StreamEventRestore, entry)
+
+	respondWithJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: "Objects restored successfully",
+		Data:    result,
+	})
+}
+
+// Purge handles DELETE /api/recycle-bin/{id}
+func (h *RecycleBinHandler) Purge(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid delete transaction ID", err.Error())
+		return
+	}
+
+	_, count, err := h.service.Purge(id, currentUser(r).ProfileID, changeLog(r))
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to purge objects", err.Error())
+		return
+	}
+	result := models.RecycleBinActionResult{DeleteTransactionId: id, ObjectCount: count}
+
+	respondWithJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: "Objects purged permanently",
+		Data:    result,
+	})
+}
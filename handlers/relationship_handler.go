@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"encoding/json"
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/services"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// RelationshipHandler handles HTTP requests for relation types and relationships
+type RelationshipHandler struct {
+	service     *services.RelationshipService
+	permissions *services.PermissionService
+}
+
+// NewRelationshipHandler creates a new RelationshipHandler
+func NewRelationshipHandler(service *services.RelationshipService, permissions *services.PermissionService) *RelationshipHandler {
+	return &RelationshipHandler{service: service, permissions: permissions}
+}
+
+// GetRelationTypes handles GET /api/relation-types
+func (h *RelationshipHandler) GetRelationTypes(w http.ResponseWriter, r *http.Request) {
+	types, err := h.service.GetRelationTypes()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve relation types", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, types)
+}
+
+// GetRelationType handles GET /api/relation-types/{id}
+func (h *RelationshipHandler) GetRelationType(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid relation type ID", err.Error())
+		return
+	}
+
+	relationType, err := h.service.GetRelationType(id)
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to retrieve relation type", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, relationType)
+}
+
+// CreateRelationType handles POST /api/relation-types
+func (h *RelationshipHandler) CreateRelationType(w http.ResponseWriter, r *http.Request) {
+	var req models.RelationTypeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	relationType, err := h.service.CreateRelationType(req, currentUser(r).UserID)
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to create relation type", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, relationType)
+}
+
+// UpdateRelationType handles PUT /api/relation-types/{id}
+func (h *RelationshipHandler) UpdateRelationType(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid relation type ID", err.Error())
+		return
+	}
+
+	var req models.RelationTypeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	relationType, err := h.service.UpdateRelationType(id, req, currentUser(r).UserID)
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to update relation type", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, relationType)
+}
+
+// DeleteRelationType handles DELETE /api/relation-types/{id}
+func (h *RelationshipHandler) DeleteRelationType(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid relation type ID", err.Error())
+		return
+	}
+
+	if err := h.service.DeleteRelationType(id); err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to delete relation type", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: "Relation type deleted successfully",
+	})
+}
+
+// GetRelationTypeAttributes handles GET /api/relation-types/{id}/attributes
+func (h *RelationshipHandler) GetRelationTypeAttributes(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid relation type ID", err.Error())
+		return
+	}
+
+	assignments, err := h.service.GetRelationTypeAttributes(id)
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to retrieve relation type attributes", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, assignments)
+}
+
+// CreateRelationship handles POST /api/relationships
+func (h *RelationshipHandler) CreateRelationship(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateRelationshipRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	if !authorizeObject(w, r, h.permissions, req.SourceObjectId, models.PermissionModifyRelationships) ||
+		!authorizeObject(w, r, h.permissions, req.TargetObjectId, models.PermissionRead) {
+		return
+	}
+
+	user := currentUser(r)
+	relationship, err := h.service.CreateRelationship(req, user.UserID, user.ProfileID, changeLog(r))
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to create relationship", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, relationship)
+}
+
+// GetRelationship handles GET /api/relationships/{id}
+func (h *RelationshipHandler) GetRelationship(w http.ResponseWriter, r *http.Request) {
+	relationship, ok := h.loadRelationship(w, r, models.PermissionRead)
+	if !ok {
+		return
+	}
+	if !authorizeObject(w, r, h.permissions, relationship.TargetObjectId, models.PermissionRead) {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, relationship)
+}
+
+// UpdateRelationship handles PUT /api/relationships/{id}
+func (h *RelationshipHandler) UpdateRelationship(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateRelationshipRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	relationship, ok := h.loadRelationship(w, r, models.PermissionModifyRelationships)
+	if !ok {
+		return
+	}
+
+	user := currentUser(r)
+	relationship, err := h.service.UpdateRelationship(relationship.RelationshipId, req, user.UserID, user.ProfileID, changeLog(r))
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to update relationship", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, relationship)
+}
+
+// DeleteRelationship handles DELETE /api/relationships/{id}
+func (h *RelationshipHandler) DeleteRelationship(w http.ResponseWriter, r *http.Request) {
+	relationship, ok := h.loadRelationship(w, r, models.PermissionModifyRelationships)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteRelationship(relationship.RelationshipId, changeLog(r)); err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to delete relationship", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: "Relationship deleted successfully",
+	})
+}
+
+// GetObjectRelationships handles GET /api/objects/{id}/relationships
+func (h *RelationshipHandler) GetObjectRelationships(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid object ID", err.Error())
+		return
+	}
+
+	var relationTypeID *uuid.UUID
+	if value := r.URL.Query().Get("relationTypeId"); value != "" {
+		parsed, err := uuid.Parse(value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid relationTypeId", err.Error())
+			return
+		}
+		relationTypeID = &parsed
+	}
+
+	if !authorizeObject(w, r, h.permissions, id, models.PermissionRead) {
+		return
+	}
+
+	relationships, err := h.service.GetObjectRelationships(id, r.URL.Query().Get("direction"), relationTypeID, currentUser(r).ProfileID)
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to retrieve relationships", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, relationships)
+}
+
+// GetObjectImpact handles GET /api/objects/{id}/impact
+func (h *RelationshipHandler) GetObjectImpact(w http.ResponseWriter, r *http.Request) {
+	id, depth, relationTypeIDs, ok := parseImpactRequest(w, r)
+	if !ok || !authorizeObject(w, r, h.permissions, id, models.PermissionRead) {
+		return
+	}
+
+	graph, err := h.service.GetImpact(id, r.URL.Query().Get("direction"), depth, relationTypeIDs, currentUser(r).ProfileID)
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to analyze impact", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, graph)
+}
+
+// parseImpactRequest reads the object ID, depth and relationTypes of an
+// impact analysis request, responding with an error if one is invalid
+func parseImpactRequest(w http.ResponseWriter, r *http.Request) (uuid.UUID, int, []uuid.UUID, bool) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid object ID", err.Error())
+		return uuid.Nil, 0, nil, false
+	}
+
+	query := r.URL.Query()
+	depth := 0
+	if value := query.Get("depth"); value != "" {
+		depth, err = strconv.Atoi(value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid depth", err.Error())
+			return uuid.Nil, 0, nil, false
+		}
+	}
+	var relationTypeIDs []uuid.UUID
+	if value := query.Get("relationTypes"); value != "" {
+		for _, part := range strings.Split(value, ",") {
+			relationTypeID, err := uuid.Parse(strings.TrimSpace(part))
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, "Invalid relationTypes", err.Error())
+				return uuid.Nil, 0, nil, false
+			}
+			relationTypeIDs = append(relationTypeIDs, relationTypeID)
+		}
+	}
+	return id, depth, relationTypeIDs, true
+}
+
+// loadRelationship reads the relationship in the URL and checks the caller's
+// permission on its source object, which owns the relationship
+func (h *RelationshipHandler) loadRelationship(w http.ResponseWriter, r *http.Request, permission string) (*models.Relationship, bool) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid relationship ID", err.Error())
+		return nil, false
+	}
+
+	relationship, err := h.service.GetRelationship(id, currentUser(r).ProfileID)
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to retrieve relationship", err.Error())
+		return nil, false
+	}
+
+	if !authorizeObject(w, r, h.permissions, relationship.SourceObjectId, permission) {
+		return nil, false
+	}
+	return relationship, true
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/services"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// SearchHandler handles HTTP requests for object search
+type SearchHandler struct {
+	service *services.SearchService
+}
+
+// NewSearchHandler creates a new SearchHandler
+func NewSearchHandler(service *services.SearchService) *SearchHandler {
+	return &SearchHandler{service: service}
+}
+
+// Search handles GET /api/search
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var filter models.SearchFilter
+	if v := query.Get("libraryId"); v != "" {
+		libraryID, err := uuid.Parse(v)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid library ID", err.Error())
+			return
+		}
+		filter.LibraryID = &libraryID
+	}
+	if v := query.Get("objectTypeId"); v != "" {
+		objectTypeID, err := strconv.Atoi(v)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid object type ID", err.Error())
+			return
+		}
+		filter.ObjectTypeID = &objectTypeID
+	}
+	if v := query.Get("eaTagId"); v != "" {
+		eaTagID, err := strconv.Atoi(v)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid EA tag ID", err.Error())
+			return
+		}
+		filter.EATagID = &eaTagID
+	}
+	page, _ := strconv.Atoi(query.Get("page"))
+	pageSize, _ := strconv.Atoi(query.Get("pageSize"))
+
+	response, err := h.service.Search(query.Get("q"), filter, page, pageSize, currentUser(r).ProfileID)
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to search objects", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"encoding/json"
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/services"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// VersionHandler handles HTTP requests for object versions
+type VersionHandler struct {
+	service     *services.VersionService
+	permissions *services.PermissionService
+	events      *services.EventStreamService
+}
+
+// NewVersionHandler creates a new VersionHandler
+func NewVersionHandler(service *services.VersionService, permissions *services.PermissionService, events *services.EventStreamService) *VersionHandler {
+	return &VersionHandler{service: service, permissions: permissions, events: events}
+}
+
+// CheckOut handles POST /api/objects/{id}/checkout
+func (h *VersionHandler) CheckOut(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid object ID", err.Error())
+		return
+	}
+	if !authorizeObject(w, r, h.permissions, id, models.PermissionModify) {
+		return
+	}
+
+	object, err := h.service.CheckOut(id, currentUser(r).UserID, changeLog(r))
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to check out object", err.Error())
+		return
+	}
+	streamObjectEvent(r, h.events, models.StreamEventCheckOut, object, nil)
+
+	respondWithJSON(w, http.StatusOK, object)
+}
+
+// CheckIn handles POST /api/objects/{id}/checkin
+func (h *VersionHandler) CheckIn(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid object ID", err.Error())
+		return
+	}
+
+	var req models.CheckInRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+	if req.Reason == "" {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", "check-in reason is required")
+		return
+	}
+	if !authorizeObject(w, r, h.permissions, id, models.PermissionModify) {
+		return
+	}
+
+	object, err := h.service.CheckIn(id, currentUser(r).UserID, currentUser(r).ProfileID, req, changeLog(r))
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to check in object", err.Error())
+		return
+	}
+	streamObjectEvent(r, h.events, models.StreamEventCheckIn, object, nil)
+
+	respondWithJSON(w, http.StatusOK, object)
+}
+
+// UndoCheckOut handles POST /api/objects/{id}/undo-checkout
+func (h *VersionHandler) UndoCheckOut(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid object ID", err.Error())
+		return
+	}
+	if !authorizeObject(w, r, h.permissions, id, models.PermissionModify) {
+		return
+	}
+
+	object, err := h.service.UndoCheckOut(id, currentUser(r).UserID, changeLog(r))
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to undo checkout", err.Error())
+		return
+	}
+	streamObjectEvent(r, h.events, models.StreamEventUndoCheckOut, object, nil)
+
+	respondWithJSON(w, http.StatusOK, object)
+}
+
+// ListVersions handles GET /api/objects/{id}/versions
+func (h *VersionHandler) ListVersions(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid object ID", err.Error())
+		return
+	}
+	if !authorizeObject(w, r, h.permissions, id, models.PermissionRead) {
+		return
+	}
+
+	versions, err := h.service.ListVersions(id)
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to retrieve versions", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, versions)
+}
+
+// DiffVersions handles GET /api/objects/{id}/versions/{a}/diff/{b}
+func (h *VersionHandler) DiffVersions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid object ID", err.Error())
+		return
+	}
+	fromID, err := uuid.Parse(vars["a"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid version ID", err.Error())
+		return
+	}
+	toID, err := uuid.Parse(vars["b"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid version ID", err.Error())
+		return
+	}
+	if !authorizeObject(w, r, h.permissions, id, models.PermissionRead) {
+		return
+	}
+
+	diff, err := h.service.DiffVersions(id, fromID, toID, currentUser(r).ProfileID)
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to compare versions", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, diff)
+}
+
+// RestoreVersion handles POST /api/objects/{id}/versions/{versionId}/restore
+func (h *VersionHandler) RestoreVersion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid object ID", err.Error())
+		return
+	}
+	versionID, err := uuid.Parse(vars["versionId"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid version ID", err.Error())
+		return
+	}
+	if !authorizeObject(w, r, h.permissions, id, models.PermissionModify) {
+		return
+	}
+
+	object, err := h.service.RestoreVersion(id, versionID, currentUser(r).UserID, currentUser(r).ProfileID, changeLog(r))
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to restore version", err.Error())
+		return
+	}
+	streamObjectEvent(r, h.events, models.StreamEventUpdate, object, nil)
+
+	respondWithJSON(w, http.StatusOK, object)
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/services"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// WebhookHandler handles HTTP requests for webhook subscriptions and their
+// deliveries
+type WebhookHandler struct {
+	service *services.WebhookService
+}
+
+// NewWebhookHandler creates a new WebhookHandler
+func NewWebhookHandler(service *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// CreateSubscription handles POST /api/webhooks
+func (h *WebhookHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	var req models.WebhookSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	user := currentUser(r)
+	sub, err := h.service.CreateSubscription(req, user.UserID, user.ProfileID)
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to create webhook", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, sub)
+}
+
+// ListSubscriptions handles GET /api/webhooks
+func (h *WebhookHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.service.ListSubscriptions(currentUser(r).ProfileID)
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to retrieve webhooks", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, subs)
+}
+
+// GetSubscription handles GET /api/webhooks/{id}
+func (h *WebhookHandler) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid webhook ID", err.Error())
+		return
+	}
+
+	sub, err := h.service.GetSubscription(id, currentUser(r).ProfileID)
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to retrieve webhook", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, sub)
+}
+
+// UpdateSubscription handles PUT /api/webhooks/{id}
+func (h *WebhookHandler) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid webhook ID", err.Error())
+		return
+	}
+
+	var req models.WebhookSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	sub, err := h.service.UpdateSubscription(id, req, currentUser(r).ProfileID)
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to update webhook", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, sub)
+}
+
+// DeleteSubscription handles DELETE /api/webhooks/{id}
+func (h *WebhookHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid webhook ID", err.Error())
+		return
+	}
+
+	if err := h.service.DeleteSubscription(id, currentUser(r).ProfileID); err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to delete webhook", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.SuccessResponse{
+		Message: "Webhook deleted successfully",
+	})
+}
+
+// ListDeliveries handles GET /api/webhooks/{id}/deliveries
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid webhook ID", err.Error())
+		return
+	}
+
+	h.listDeliveries(w, r, models.WebhookDeliveryQuery{
+		PageRequest:    pageRequest(r),
+		SubscriptionId: &id,
+		Status:         r.URL.Query().Get("status"),
+	})
+}
+
+// ListDeadLetters handles GET /api/webhooks/dead-letters
+func (h *WebhookHandler) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	h.listDeliveries(w, r, models.WebhookDeliveryQuery{
+		PageRequest: pageRequest(r),
+		Status:      models.WebhookDeliveryDead,
+	})
+}
+
+func (h *WebhookHandler) listDeliveries(w http.ResponseWriter, r *http.Request, q models.WebhookDeliveryQuery) {
+	response, err := h.service.ListDeliveries(q, currentUser(r).ProfileID)
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to retrieve webhook deliveries", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// Redeliver handles POST /api/webhooks/deliveries/{id}/redeliver
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid delivery ID", err.Error())
+		return
+	}
+
+	delivery, err := h.service.Redeliver(id, currentUser(r).ProfileID)
+	if err != nil {
+		respondWithError(w, errorStatus(err, http.StatusInternalServerError), "Failed to redeliver webhook", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, delivery)
+}
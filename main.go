@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"enterprise-architect-api/config"
 	"enterprise-architect-api/handlers"
 	"enterprise-architect-api/middleware"
@@ -45,34 +46,82 @@ func main() {
 	attributeRepo := repositories.NewAttributeRepository(db)
 	reportConfigRepo := repositories.NewReportConfigRepository(db)
 	objectRepo := repositories.NewObjectRepository(db, attributeRepo)
+	userRepo := repositories.NewUserRepository(db)
+	permissionRepo := repositories.NewPermissionRepository(db)
+	versionRepo := repositories.NewVersionRepository(db)
+	approvalRepo := repositories.NewApprovalRepository(db)
+	importJobRepo := repositories.NewImportJobRepository(db, objectRepo)
+	relationshipRepo := repositories.NewRelationshipRepository(db, objectRepo)
+	searchRepo := repositories.NewSearchRepository(db)
+	recycleBinRepo := repositories.NewRecycleBinRepository(db)
+	auditRepo := repositories.NewAuditRepository(db)
+	webhookRepo := repositories.NewWebhookRepository(db)
 	// Initialize services
-	objectService := services.NewObjectService(objectRepo)
+	objectService := services.NewObjectService(objectRepo, cfg.Library.AdminProfiles)
 	objectTypeService := services.NewObjectTypeService(objectTypeRepo)
 	profileService := services.NewProfileService(profileRepo)
 	objectContentService := services.NewObjectContentService(objectContentRepo)
-	folderService := services.NewFolderService(folderRepo)
-	attributeService := services.NewAttributeService(attributeRepo)
+	folderService := services.NewFolderService(folderRepo, objectRepo)
+	attributeService := services.NewAttributeService(attributeRepo, objectRepo)
 	fileObjectsService := services.NewFileObjectsService()
 	eaTagService := services.NewEATagService(reportConfigRepo)
+	authService := services.NewAuthService(userRepo, cfg.Auth)
+	permissionService := services.NewPermissionService(permissionRepo)
+	versionService := services.NewVersionService(versionRepo, objectRepo)
+	approvalService := services.NewApprovalService(approvalRepo, cfg.Approval.AdminProfiles)
+	importService := services.NewImportService(objectRepo, attributeRepo)
+	exportService := services.NewExportService(objectRepo, attributeRepo)
+	importJobService := services.NewImportJobService(importJobRepo, importService, cfg.Import)
+	relationshipService := services.NewRelationshipService(relationshipRepo, attributeRepo, objectRepo)
+	archiMateService := services.NewArchiMateService(objectRepo, attributeRepo, reportConfigRepo, relationshipRepo, exportService, importService)
+	graphService := services.NewGraphService(objectRepo, objectContentRepo, relationshipRepo, relationshipService)
+	searchService := services.NewSearchService(searchRepo)
+	recycleBinService := services.NewRecycleBinService(recycleBinRepo)
+	auditService := services.NewAuditService(auditRepo, cfg.Audit.ReaderProfiles)
+	webhookService := services.NewWebhookService(webhookRepo, cfg.Webhook)
+	eventStreamService := services.NewEventStreamService(permissionService, cfg.Events.BufferSize)
 
 	// Initialize handlers
-	objectHandler := handlers.NewObjectHandler(objectService, objectContentService)
+	objectHandler := handlers.NewObjectHandler(objectService, objectContentService, permissionService, eventStreamService)
 	objectTypeHandler := handlers.NewObjectTypeHandler(objectTypeService)
 	profileHandler := handlers.NewProfileHandler(profileService)
 	objectContentHandler := handlers.NewObjectContentHandler(objectContentService)
-	folderHandler := handlers.NewFolderHandler(folderService)
-	attributeHandler := handlers.NewAttributeHandler(attributeService)
+	folderHandler := handlers.NewFolderHandler(folderService, permissionService, eventStreamService)
+	attributeHandler := handlers.NewAttributeHandler(attributeService, permissionService)
 	fileObjectsHandler := handlers.NewFileObjectsHandler(fileObjectsService)
 	eaTagHandler := handlers.NewEATagHandler(eaTagService)
+	authHandler := handlers.NewAuthHandler(authService)
+	versionHandler := handlers.NewVersionHandler(versionService, permissionService, eventStreamService)
+	approvalHandler := handlers.NewApprovalHandler(approvalService, objectService, permissionService, eventStreamService)
+	importHandler := handlers.NewImportHandler(importService, importJobService, permissionService)
+	exportHandler := handlers.NewExportHandler(exportService, permissionService)
+	relationshipHandler := handlers.NewRelationshipHandler(relationshipService, permissionService)
+	archiMateHandler := handlers.NewArchiMateHandler(archiMateService, permissionService)
+	graphHandler := handlers.NewGraphHandler(graphService, permissionService)
+	searchHandler := handlers.NewSearchHandler(searchService)
+	recycleBinHandler := handlers.NewRecycleBinHandler(recycleBinService, eventStreamService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	eventStreamHandler := handlers.NewEventStreamHandler(eventStreamService, permissionService, cfg.Events.Heartbeat)
 
 	// Setup router
 	router := mux.NewRouter()
 
 	// API routes
-	api := router.PathPrefix("/api").Subrouter()
-	api.Use(loggingMiddleware)
+	apiRoot := router.PathPrefix("/api").Subrouter()
+	apiRoot.Use(middleware.RequestIDMiddleware, loggingMiddleware)
+
+	// Auth routes (public)
+	apiRoot.HandleFunc("/auth/login", authHandler.Login).Methods("POST")
+
+	// Everything else requires a bearer token
+	api := apiRoot.NewRoute().Subrouter()
+	api.Use(middleware.AuthMiddleware(authService))
+	api.HandleFunc("/auth/me", authHandler.Me).Methods("GET")
+
 	// Object routes
 	api.HandleFunc("/objects/import", objectHandler.ImportObjects).Methods("POST")
+	api.HandleFunc("/objects/import/upload", importHandler.UploadSpreadsheet).Methods("POST")
 	api.HandleFunc("/objects", objectHandler.GetAllObjects).Methods("GET")
 	api.HandleFunc("/objects", objectHandler.CreateObject).Methods("POST")
 	api.HandleFunc("/objects/libraries", objectHandler.GetLibraries).Methods("GET")
@@ -81,8 +130,73 @@ func main() {
 	api.HandleFunc("/objects/{id}", objectHandler.GetObjectByID).Methods("GET")
 	api.HandleFunc("/objects/{id}", objectHandler.UpdateObject).Methods("PUT")
 	api.HandleFunc("/objects/{id}", objectHandler.DeleteObject).Methods("DELETE")
+	api.HandleFunc("/objects/{id}/move", objectHandler.MoveObject).Methods("POST")
+	api.HandleFunc("/objects/{id}/copy", objectHandler.CopyObject).Methods("POST")
+	api.HandleFunc("/objects/{id}/checkout", versionHandler.CheckOut).Methods("POST")
+	api.HandleFunc("/objects/{id}/checkin", versionHandler.CheckIn).Methods("POST")
+	api.HandleFunc("/objects/{id}/undo-checkout", versionHandler.UndoCheckOut).Methods("POST")
+	api.HandleFunc("/objects/{id}/versions", versionHandler.ListVersions).Methods("GET")
+	api.HandleFunc("/objects/{id}/versions/{a}/diff/{b}", versionHandler.DiffVersions).Methods("GET")
+	api.HandleFunc("/objects/{id}/versions/{versionId}/restore", versionHandler.RestoreVersion).Methods("POST")
+	api.HandleFunc("/objects/{id}/submit-approval", approvalHandler.SubmitForApproval).Methods("POST")
+	api.HandleFunc("/objects/{id}/approve", approvalHandler.Approve).Methods("POST")
+	api.HandleFunc("/objects/{id}/reject", approvalHandler.Reject).Methods("POST")
+	api.HandleFunc("/objects/{id}/approvals", approvalHandler.GetApprovalHistory).Methods("GET")
+	api.HandleFunc("/objects/{id}/relationships", relationshipHandler.GetObjectRelationships).Methods("GET")
+	api.HandleFunc("/objects/{id}/impact", relationshipHandler.GetObjectImpact).Methods("GET")
+	api.HandleFunc("/objects/{id}/impact/graph", graphHandler.ExportImpactGraph).Methods("GET")
 	api.HandleFunc("/objects/{objectTypeID}/{libraryID}", objectHandler.GetObjectsByObjectTypeIDAndLibraryID).Methods("GET")
 
+	// Import job routes
+	api.HandleFunc("/import-jobs", importHandler.CreateImportJob).Methods("POST")
+	api.HandleFunc("/import-jobs/{id}", importHandler.GetImportJob).Methods("GET")
+	api.HandleFunc("/import-jobs/{id}/cancel", importHandler.CancelImportJob).Methods("POST")
+
+	// Export routes
+	api.HandleFunc("/export/objects", exportHandler.ExportObjects).Methods("GET")
+	api.HandleFunc("/export/graph", graphHandler.ExportLibraryGraph).Methods("GET")
+
+	// Relationship routes
+	api.HandleFunc("/relation-types", relationshipHandler.GetRelationTypes).Methods("GET")
+	api.HandleFunc("/relation-types", relationshipHandler.CreateRelationType).Methods("POST")
+	api.HandleFunc("/relation-types/{id}", relationshipHandler.GetRelationType).Methods("GET")
+	api.HandleFunc("/relation-types/{id}", relationshipHandler.UpdateRelationType).Methods("PUT")
+	api.HandleFunc("/relation-types/{id}", relationshipHandler.DeleteRelationType).Methods("DELETE")
+	api.HandleFunc("/relation-types/{id}/attributes", relationshipHandler.GetRelationTypeAttributes).Methods("GET")
+	api.HandleFunc("/relationships", relationshipHandler.CreateRelationship).Methods("POST")
+	api.HandleFunc("/relationships/{id}", relationshipHandler.GetRelationship).Methods("GET")
+	api.HandleFunc("/relationships/{id}", relationshipHandler.UpdateRelationship).Methods("PUT")
+	api.HandleFunc("/relationships/{id}", relationshipHandler.DeleteRelationship).Methods("DELETE")
+
+	// Search routes
+	api.HandleFunc("/search", searchHandler.Search).Methods("GET")
+
+	// Recycle bin routes
+	api.HandleFunc("/recycle-bin", recycleBinHandler.List).Methods("GET")
+	api.HandleFunc("/recycle-bin/{id}", recycleBinHandler.Get).Methods("GET")
+	api.HandleFunc("/recycle-bin/{id}/restore", recycleBinHandler.Restore).Methods("POST")
+	api.HandleFunc("/recycle-bin/{id}", recycleBinHandler.Purge).Methods("DELETE")
+
+	// Audit routes
+	api.HandleFunc("/audit", auditHandler.List).Methods("GET")
+
+	// Webhook routes
+	api.HandleFunc("/webhooks", webhookHandler.CreateSubscription).Methods("POST")
+	api.HandleFunc("/webhooks", webhookHandler.ListSubscriptions).Methods("GET")
+	api.HandleFunc("/webhooks/dead-letters", webhookHandler.ListDeadLetters).Methods("GET")
+	api.HandleFunc("/webhooks/deliveries/{id}/redeliver", webhookHandler.Redeliver).Methods("POST")
+	api.HandleFunc("/webhooks/{id}", webhookHandler.GetSubscription).Methods("GET")
+	api.HandleFunc("/webhooks/{id}", webhookHandler.UpdateSubscription).Methods("PUT")
+	api.HandleFunc("/webhooks/{id}", webhookHandler.DeleteSubscription).Methods("DELETE")
+	api.HandleFunc("/webhooks/{id}/deliveries", webhookHandler.ListDeliveries).Methods("GET")
+
+	// Event stream routes
+	api.HandleFunc("/events/stream", eventStreamHandler.Stream).Methods("GET")
+
+	// ArchiMate exchange routes
+	api.HandleFunc("/export/archimate", archiMateHandler.ExportArchiMate).Methods("GET")
+	api.HandleFunc("/import/archimate", archiMateHandler.ImportArchiMate).Methods("POST")
+
 	// ObjectType routes
 	api.HandleFunc("/object-types/folder-tree", objectTypeHandler.GetFolderRepositoryTree).Methods("GET")
 	api.HandleFunc("/object-types/baseLibrary", objectTypeHandler.GetBaseLibrary).Methods("GET")
@@ -98,6 +212,11 @@ func main() {
 	api.HandleFunc("/object-types/{id}", objectTypeHandler.GetObjectTypeByID).Methods("GET")
 	api.HandleFunc("/object-types/{id}", objectTypeHandler.UpdateObjectType).Methods("PUT")
 	api.HandleFunc("/object-types/{id}", objectTypeHandler.DeleteObjectType).Methods("DELETE")
+	api.HandleFunc("/object-types/{id}/approvers", approvalHandler.GetApprovers).Methods("GET")
+	api.HandleFunc("/object-types/{id}/approvers", approvalHandler.SetApprovers).Methods("PUT")
+
+	// Approval routes
+	api.HandleFunc("/approvals/pending", approvalHandler.GetMyPendingApprovals).Methods("GET")
 
 	// Profile routes
 	api.HandleFunc("/profiles", profileHandler.GetAllProfiles).Methods("GET")
@@ -116,6 +235,10 @@ func main() {
 	// Folder routes
 	api.HandleFunc("/folders/object-type/{libraryId}", folderHandler.GetObjectTypeFolders).Methods("GET")
 	api.HandleFunc("/folders/{folderId}/contents", folderHandler.GetFoldersByLibrary).Methods("GET")
+	api.HandleFunc("/folders", folderHandler.CreateFolder).Methods("POST")
+	api.HandleFunc("/folders/{folderId}", folderHandler.UpdateFolder).Methods("PUT")
+	api.HandleFunc("/folders/{folderId}/order", folderHandler.ReorderFolder).Methods("PUT")
+	api.HandleFunc("/folders/{folderId}", folderHandler.DeleteFolder).Methods("DELETE")
 
 	// Dashboard routes
 	api.HandleFunc("/dashboard/object-counts/{libraryId}", objectContentHandler.GetDashboardStatistics).Methods("GET")
@@ -139,6 +262,9 @@ func main() {
 	// EA Tags routes
 	api.HandleFunc("/ea-tags", eaTagHandler.GetAllEATags).Methods("GET")
 	api.HandleFunc("/ea-tags", eaTagHandler.CreateEATag).Methods("POST")
+	api.HandleFunc("/ea-tags/archimate-mappings", eaTagHandler.GetArchiMateMappings).Methods("GET")
+	api.HandleFunc("/ea-tags/archimate-mappings/{objectTypeID}", eaTagHandler.SetArchiMateMapping).Methods("PUT")
+	api.HandleFunc("/ea-tags/archimate-mappings/{objectTypeID}", eaTagHandler.DeleteArchiMateMapping).Methods("DELETE")
 	api.HandleFunc("/ea-tags/{id}", eaTagHandler.GetEATagByID).Methods("GET")
 	api.HandleFunc("/ea-tags/{id}", eaTagHandler.UpdateEATag).Methods("PUT")
 	api.HandleFunc("/ea-tags/{id}", eaTagHandler.DeleteEATag).Methods("DELETE")
@@ -151,6 +277,12 @@ func main() {
 		w.Write([]byte("OK"))
 	}).Methods("GET")
 
+	// Process queued and interrupted import jobs in the background
+	importJobService.Start(context.Background())
+
+	// Deliver queued webhook events in the background
+	webhookService.Start(context.Background())
+
 	// Start server
 	serverAddr := fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Server.Port)
 	log.Printf("Starting server on %s", serverAddr)
@@ -165,7 +297,7 @@ func main() {
 
 func loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("API Request: %s %s", r.Method, r.RequestURI)
+		log.Printf("API Request: %s %s [%s]", r.Method, r.RequestURI, middleware.GetRequestID(r.Context()))
 		next.ServeHTTP(w, r)
 	})
 }
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"enterprise-architect-api/models"
+	"net/http"
+	"strings"
+)
+
+type contextKey string
+
+const authUserKey contextKey = "authUser"
+
+// TokenValidator validates a bearer token and returns the caller it identifies
+type TokenValidator interface {
+	ValidateToken(token string) (*models.AuthUser, error)
+}
+
+// AuthMiddleware rejects requests without a valid bearer token and stores the
+// authenticated user in the request context.
+func AuthMiddleware(validator TokenValidator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if !strings.HasPrefix(header, "Bearer ") {
+				unauthorized(w, "missing bearer token")
+				return
+			}
+
+			user, err := validator.ValidateToken(strings.TrimSpace(strings.TrimPrefix(header, "Bearer ")))
+			if err != nil {
+				unauthorized(w, err.Error())
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithAuthUser(r.Context(), user)))
+		})
+	}
+}
+
+// GetAuthUser returns the authenticated user stored by AuthMiddleware
+func GetAuthUser(ctx context.Context) *models.AuthUser {
+	user, _ := ctx.Value(authUserKey).(*models.AuthUser)
+	return user
+}
+
+// WithAuthUser returns a copy of ctx carrying the given user
+func WithAuthUser(ctx context.Context, user *models.AuthUser) context.Context {
+	return context.WithValue(ctx, authUserKey, user)
+}
+
+func unauthorized(w http.ResponseWriter, details string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(models.ErrorResponse{
+		Error:   "Unauthorized",
+		Message: details,
+	})
+}
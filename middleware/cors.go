@@ -11,7 +11,8 @@ func CorsMiddleware() func(http.Handler) http.Handler {
 	c := cors.New(cors.Options{
 		AllowedOrigins: []string{"http://localhost:5173"},
 		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders: []string{"Content-Type", "Authorization", "X-Requested-With", "Origin", "Accept", "Application/json", "User-Agent"},
+		AllowedHeaders: []string{"Content-Type", "Authorization", "X-Requested-With", "Origin", "Accept", "Application/json", "User-Agent", "Last-Event-ID", RequestIDHeader},
+		ExposedHeaders: []string{RequestIDHeader},
 	})
 	return c.Handler
 }
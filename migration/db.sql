@@ -0,0 +1,325 @@
+USE [iserver-light]
+GO
+
+SET ANSI_NULLS ON
+GO
+
+SET QUOTED_IDENTIFIER ON
+GO
+
+/****** Users: API login accounts (passwords are bcrypt hashes) ******/
+IF OBJECT_ID(N'[dbo].[Users]', N'U') IS NULL
+BEGIN
+    CREATE TABLE [dbo].[Users] (
+        [UserID]        INT IDENTITY(1,1) NOT NULL CONSTRAINT [PK_Users] PRIMARY KEY,
+        [UserName]      NVARCHAR(256)     NOT NULL CONSTRAINT [UQ_Users_UserName] UNIQUE,
+        [DisplayName]   NVARCHAR(256)     NULL,
+        [Email]         NVARCHAR(256)     NULL,
+        [PasswordHash]  NVARCHAR(255)     NOT NULL,
+        [ProfileID]     INT               NOT NULL,
+        [IsActive]      BIT               NOT NULL CONSTRAINT [DF_Users_IsActive] DEFAULT (1),
+        [DateCreated]   DATETIME          NOT NULL CONSTRAINT [DF_Users_DateCreated] DEFAULT (GETDATE()),
+        [DateModified]  DATETIME          NOT NULL CONSTRAINT [DF_Users_DateModified] DEFAULT (GETDATE())
+    )
+END
+GO
+
+/****** fn_EffectiveObjectPermissions: a profile's permissions per object, inherited from the nearest folder with an explicit row ******/
+CREATE OR ALTER FUNCTION [dbo].[fn_EffectiveObjectPermissions] (@ProfileID INT)
+RETURNS TABLE
+AS
+RETURN
+    WITH inherited (ObjectID, HasRead, HasModify, HasDelete, HasModifyContents, HasModifyRelationships) AS (
+        SELECT op.ObjectID, op.HasRead, op.HasModify, op.HasDelete, op.HasModifyContents, op.HasModifyRelationships
+        FROM [dbo].[ObjectPermissions] AS op
+        WHERE op.ProfileID = @ProfileID
+
+        UNION ALL
+
+        SELECT fc.ObjectId, inherited.HasRead, inherited.HasModify, inherited.HasDelete,
+            inherited.HasModifyContents, inherited.HasModifyRelationships
+        FROM inherited
+        INNER JOIN [dbo].[vwFolderContents] AS fc ON fc.FolderId = inherited.ObjectID AND fc.IsDeleted = 0
+        WHERE NOT EXISTS (
+            SELECT 1 FROM [dbo].[ObjectPermissions] AS own
+            WHERE own.ObjectID = fc.ObjectId AND own.ProfileID = @ProfileID
+        )
+    )
+    SELECT
+        ObjectID,
+        CAST(MAX(CAST(HasRead AS INT)) AS BIT) AS HasRead,
+        CAST(MAX(CAST(HasModify AS INT)) AS BIT) AS HasModify,
+        CAST(MAX(CAST(HasDelete AS INT)) AS BIT) AS HasDelete,
+        CAST(MAX(CAST(HasModifyContents AS INT)) AS BIT) AS HasModifyContents,
+        CAST(MAX(CAST(HasModifyRelationships AS INT)) AS BIT) AS HasModifyRelationships
+    FROM inherited
+    GROUP BY ObjectID
+GO
+
+/****** Approval workflow ******/
+-- The workflow needs the database's own rejected status. Its value is owned by
+-- the desktop client, so it is not made up here: define the function with that
+-- value and run the migration again. Run with sqlcmd -b so the error stops it.
+IF OBJECT_ID(N'[dbo].[const_ApprovalStatus_Rejected]') IS NULL
+    THROW 50000, N'dbo.const_ApprovalStatus_Rejected() is missing. Create it to return the rejected ApprovalStatus value used by the client, then re-run this migration.', 1;
+GO
+
+-- Profiles allowed to approve versions of an object type; types with approvers are governed
+IF OBJECT_ID(N'[dbo].[ObjectTypeApprovers]', N'U') IS NULL
+BEGIN
+    CREATE TABLE [dbo].[ObjectTypeApprovers] (
+        [ObjectTypeID]  INT       NOT NULL,
+        [ProfileID]     INT       NOT NULL,
+        [DateCreated]   DATETIME  NOT NULL CONSTRAINT [DF_ObjectTypeApprovers_DateCreated] DEFAULT (GETDATE()),
+        [CreatedBy]     INT       NOT NULL,
+        CONSTRAINT [PK_ObjectTypeApprovers] PRIMARY KEY ([ObjectTypeID], [ProfileID])
+    )
+END
+GO
+
+-- Submit / approve / reject history per version
+IF OBJECT_ID(N'[dbo].[VersionApprovals]', N'U') IS NULL
+BEGIN
+    CREATE TABLE [dbo].[VersionApprovals] (
+        [ApprovalID]    INT IDENTITY(1,1)  NOT NULL CONSTRAINT [PK_VersionApprovals] PRIMARY KEY,
+        [ObjectID]      UNIQUEIDENTIFIER   NOT NULL,
+        [VersionID]     UNIQUEIDENTIFIER   NOT NULL,
+        [Action]        NVARCHAR(20)       NOT NULL,
+        [Comment]       NVARCHAR(MAX)      NULL,
+        [ProfileID]     INT                NOT NULL,
+        [UserID]        INT                NOT NULL,
+        [DateCreated]   DATETIME           NOT NULL CONSTRAINT [DF_VersionApprovals_DateCreated] DEFAULT (GETDATE())
+    )
+    CREATE INDEX [IX_VersionApprovals_VersionID] ON [dbo].[VersionApprovals] ([VersionID])
+END
+GO
+
+/****** Import jobs ******/
+-- Background imports; Payload holds the JSON import request and NextRow the resume point
+IF OBJECT_ID(N'[dbo].[ImportJobs]', N'U') IS NULL
+BEGIN
+    CREATE TABLE [dbo].[ImportJobs] (
+        [JobID]            UNIQUEIDENTIFIER  NOT NULL CONSTRAINT [PK_ImportJobs] PRIMARY KEY,
+        [Status]           NVARCHAR(20)      NOT NULL,
+        [FileName]         NVARCHAR(260)     NULL,
+        [LibraryID]        UNIQUEIDENTIFIER  NOT NULL,
+        [FolderID]         UNIQUEIDENTIFIER  NOT NULL,
+        [ObjectTypeID]     INT               NOT NULL,
+        [Payload]          NVARCHAR(MAX)     NOT NULL,
+        [TotalRows]        INT               NOT NULL,
+        [NextRow]          INT               NOT NULL CONSTRAINT [DF_ImportJobs_NextRow] DEFAULT (0),
+        [SuccessCount]     INT               NOT NULL CONSTRAINT [DF_ImportJobs_SuccessCount] DEFAULT (0),
+        [FailedCount]      INT               NOT NULL CONSTRAINT [DF_ImportJobs_FailedCount] DEFAULT (0),
+        [SkippedCount]     INT               NOT NULL CONSTRAINT [DF_ImportJobs_SkippedCount] DEFAULT (0),
+        [CancelRequested]  BIT               NOT NULL CONSTRAINT [DF_ImportJobs_CancelRequested] DEFAULT (0),
+        [ErrorMessage]     NVARCHAR(MAX)     NULL,
+        [LeaseOwner]       NVARCHAR(100)     NULL,
+        [LeaseExpiresAt]   DATETIME          NULL,
+        [UserID]           INT               NOT NULL,
+        [ProfileID]        INT               NOT NULL,
+        [DateCreated]      DATETIME          NOT NULL CONSTRAINT [DF_ImportJobs_DateCreated] DEFAULT (GETDATE()),
+        [DateStarted]      DATETIME          NULL,
+        [DateCompleted]    DATETIME          NULL,
+        [DateModified]     DATETIME          NOT NULL CONSTRAINT [DF_ImportJobs_DateModified] DEFAULT (GETDATE())
+    )
+    CREATE INDEX [IX_ImportJobs_Status] ON [dbo].[ImportJobs] ([Status], [DateCreated])
+END
+GO
+
+-- Rows left unchanged by the import strategy
+IF COL_LENGTH(N'[dbo].[ImportJobs]', N'SkippedCount') IS NULL
+BEGIN
+    ALTER TABLE [dbo].[ImportJobs] ADD [SkippedCount] INT NOT NULL CONSTRAINT [DF_ImportJobs_SkippedCount] DEFAULT (0)
+END
+GO
+
+-- Outcome of every processed import job row; Errors holds a JSON array
+IF OBJECT_ID(N'[dbo].[ImportJobRows]', N'U') IS NULL
+BEGIN
+    CREATE TABLE [dbo].[ImportJobRows] (
+        [JobID]       UNIQUEIDENTIFIER  NOT NULL,
+        [RowNo]       INT               NOT NULL,
+        [Action]      NVARCHAR(20)      NOT NULL,
+        [ObjectID]    UNIQUEIDENTIFIER  NULL,
+        [ObjectName]  NVARCHAR(MAX)     NULL,
+        [Errors]      NVARCHAR(MAX)     NULL,
+        CONSTRAINT [PK_ImportJobRows] PRIMARY KEY ([JobID], [RowNo])
+    )
+END
+GO
+
+-- ArchiMate element type of each object type, used by the Open Exchange export and import
+IF OBJECT_ID(N'[dbo].[EA_ArchiMate_Mappings]', N'U') IS NULL
+BEGIN
+    CREATE TABLE [dbo].[EA_ArchiMate_Mappings] (
+        [object_type_id]  INT           NOT NULL CONSTRAINT [PK_EA_ArchiMate_Mappings] PRIMARY KEY,
+        [element_type]    NVARCHAR(50)  NOT NULL
+    )
+END
+GO
+
+-- Relation types: typed links between objects, with the object type pairs they may connect.
+-- Attributes are assigned to a relation type through AttributeAssigned.RelationTypeId.
+IF OBJECT_ID(N'[dbo].[RelationTypes]', N'U') IS NULL
+BEGIN
+    CREATE TABLE [dbo].[RelationTypes] (
+        [RelationTypeId]    UNIQUEIDENTIFIER  NOT NULL CONSTRAINT [PK_RelationTypes] PRIMARY KEY,
+        [RelationTypeName]  NVARCHAR(256)     NOT NULL CONSTRAINT [UQ_RelationTypes_Name] UNIQUE,
+        [ReverseName]       NVARCHAR(256)     NULL,
+        [Description]       NVARCHAR(MAX)     NULL,
+        [ArchiMateType]     NVARCHAR(50)      NULL,
+        [DateCreated]       DATETIME          NOT NULL CONSTRAINT [DF_RelationTypes_DateCreated] DEFAULT (GETDATE()),
+        [CreatedBy]         INT               NOT NULL,
+        [DateModified]      DATETIME          NOT NULL CONSTRAINT [DF_RelationTypes_DateModified] DEFAULT (GETDATE()),
+        [ModifiedBy]        INT               NOT NULL
+    )
+END
+GO
+
+IF OBJECT_ID(N'[dbo].[RelationTypeRules]', N'U') IS NULL
+BEGIN
+    CREATE TABLE [dbo].[RelationTypeRules] (
+        [RelationTypeId]      UNIQUEIDENTIFIER  NOT NULL,
+        [SourceObjectTypeID]  INT               NOT NULL,
+        [TargetObjectTypeID]  INT               NOT NULL,
+        CONSTRAINT [PK_RelationTypeRules] PRIMARY KEY ([RelationTypeId], [SourceObjectTypeID], [TargetObjectTypeID]),
+        CONSTRAINT [FK_RelationTypeRules_RelationTypes] FOREIGN KEY ([RelationTypeId]) REFERENCES [dbo].[RelationTypes] ([RelationTypeId]) ON DELETE CASCADE
+    )
+END
+GO
+
+-- Relationship instances between two objects
+IF OBJECT_ID(N'[dbo].[Relationships]', N'U') IS NULL
+BEGIN
+    CREATE TABLE [dbo].[Relationships] (
+        [RelationshipId]  UNIQUEIDENTIFIER  NOT NULL CONSTRAINT [PK_Relationships] PRIMARY KEY,
+        [RelationTypeId]  UNIQUEIDENTIFIER  NOT NULL,
+        [SourceObjectID]  UNIQUEIDENTIFIER  NOT NULL,
+        [TargetObjectID]  UNIQUEIDENTIFIER  NOT NULL,
+        [Description]     NVARCHAR(MAX)     NULL,
+        [DateCreated]     DATETIME          NOT NULL CONSTRAINT [DF_Relationships_DateCreated] DEFAULT (GETDATE()),
+        [CreatedBy]       INT               NOT NULL,
+        [DateModified]    DATETIME          NOT NULL CONSTRAINT [DF_Relationships_DateModified] DEFAULT (GETDATE()),
+        [ModifiedBy]      INT               NOT NULL,
+        CONSTRAINT [UQ_Relationships] UNIQUE ([RelationTypeId], [SourceObjectID], [TargetObjectID]),
+        CONSTRAINT [FK_Relationships_RelationTypes] FOREIGN KEY ([RelationTypeId]) REFERENCES [dbo].[RelationTypes] ([RelationTypeId])
+    )
+    CREATE INDEX [IX_Relationships_SourceObjectID] ON [dbo].[Relationships] ([SourceObjectID])
+    CREATE INDEX [IX_Relationships_TargetObjectID] ON [dbo].[Relationships] ([TargetObjectID])
+END
+GO
+
+-- Attribute values of relationships, stored like AttributeValue
+IF OBJECT_ID(N'[dbo].[RelationshipAttributeValues]', N'U') IS NULL
+BEGIN
+    CREATE TABLE [dbo].[RelationshipAttributeValues] (
+        [RelationshipId]  UNIQUEIDENTIFIER  NOT NULL,
+        [AttributeId]     UNIQUEIDENTIFIER  NOT NULL,
+        [DataType]        INT               NOT NULL,
+        [ValueBigInt]     BIGINT            NULL,
+        [ValueFloat]      FLOAT             NULL,
+        [ValueDate]       DATETIME          NULL,
+        [ValueText]       NVARCHAR(MAX)     NULL,
+        [ValueRichText]   NVARCHAR(MAX)     NULL,
+        [DateModified]    DATETIME          NOT NULL CONSTRAINT [DF_RelationshipAttributeValues_DateModified] DEFAULT (GETDATE()),
+        [ModifiedBy]      INT               NOT NULL,
+        CONSTRAINT [PK_RelationshipAttributeValues] PRIMARY KEY ([RelationshipId], [AttributeId]),
+        CONSTRAINT [FK_RelationshipAttributeValues_Relationships] FOREIGN KEY ([RelationshipId]) REFERENCES [dbo].[Relationships] ([RelationshipId]) ON DELETE CASCADE
+    )
+END
+GO
+
+/****** Recycle bin ******/
+-- One row per delete: the object deleted and the folder it was deleted from. Every object
+-- soft-deleted with it carries the transaction in [Object].DeleteTransactionId.
+IF OBJECT_ID(N'[dbo].[DeleteTransactions]', N'U') IS NULL
+BEGIN
+    CREATE TABLE [dbo].[DeleteTransactions] (
+        [DeleteTransactionId]  UNIQUEIDENTIFIER  NOT NULL CONSTRAINT [PK_DeleteTransactions] PRIMARY KEY,
+        [RootObjectID]         UNIQUEIDENTIFIER  NOT NULL,
+        [ParentObjectID]       UNIQUEIDENTIFIER  NULL,
+        [ObjectCount]          INT               NOT NULL,
+        [DeletedBy]            INT               NOT NULL,
+        [DateDeleted]          DATETIME          NOT NULL CONSTRAINT [DF_DeleteTransactions_DateDeleted] DEFAULT (GETDATE())
+    )
+    CREATE INDEX [IX_DeleteTransactions_DateDeleted] ON [dbo].[DeleteTransactions] ([DateDeleted])
+END
+GO
+
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = N'IX_Object_DeleteTransactionId' AND object_id = OBJECT_ID(N'[dbo].[Object]'))
+    CREATE INDEX [IX_Object_DeleteTransactionId] ON [dbo].[Object] ([DeleteTransactionId]) WHERE [DeleteTransactionId] IS NOT NULL
+GO
+
+/****** Audit trail ******/
+-- One row per create, update or delete; Before and After hold the entity as JSON
+IF OBJECT_ID(N'[dbo].[AuditEvents]', N'U') IS NULL
+BEGIN
+    CREATE TABLE [dbo].[AuditEvents] (
+        [EventId]     BIGINT IDENTITY(1,1)  NOT NULL CONSTRAINT [PK_AuditEvents] PRIMARY KEY,
+        [EntityType]  NVARCHAR(50)          NOT NULL,
+        [EntityId]    NVARCHAR(100)         NOT NULL,
+        [Action]      NVARCHAR(20)          NOT NULL,
+        [Before]      NVARCHAR(MAX)         NULL,
+        [After]       NVARCHAR(MAX)         NULL,
+        [UserID]      INT                   NOT NULL,
+        [ProfileID]   INT                   NOT NULL,
+        [RequestID]   NVARCHAR(100)         NULL,
+        [Timestamp]   DATETIME2             NOT NULL CONSTRAINT [DF_AuditEvents_Timestamp] DEFAULT (SYSUTCDATETIME())
+    )
+    CREATE INDEX [IX_AuditEvents_Entity] ON [dbo].[AuditEvents] ([EntityType], [EntityId], [EventId])
+    CREATE INDEX [IX_AuditEvents_UserID] ON [dbo].[AuditEvents] ([UserID], [EventId])
+    CREATE INDEX [IX_AuditEvents_Timestamp] ON [dbo].[AuditEvents] ([Timestamp])
+END
+GO
+
+-- The audit trail is append-only
+CREATE OR ALTER TRIGGER [dbo].[TR_AuditEvents_AppendOnly] ON [dbo].[AuditEvents]
+INSTEAD OF UPDATE, DELETE
+AS
+    THROW 51000, N'AuditEvents is append-only', 1;
+GO
+
+/****** Webhooks ******/
+-- EntityTypes is a comma-separated list of the entity types delivered, or NULL for all
+IF OBJECT_ID(N'[dbo].[WebhookSubscriptions]', N'U') IS NULL
+BEGIN
+    CREATE TABLE [dbo].[WebhookSubscriptions] (
+        [SubscriptionId]  UNIQUEIDENTIFIER  NOT NULL CONSTRAINT [PK_WebhookSubscriptions] PRIMARY KEY,
+        [Url]             NVARCHAR(2000)    NOT NULL,
+        [Secret]          NVARCHAR(200)     NOT NULL,
+        [EntityTypes]     NVARCHAR(200)     NULL,
+        [ObjectTypeID]    INT               NULL,
+        [LibraryID]       UNIQUEIDENTIFIER  NULL,
+        [IsActive]        BIT               NOT NULL CONSTRAINT [DF_WebhookSubscriptions_IsActive] DEFAULT (1),
+        [CreatedBy]       INT               NOT NULL,
+        [DateCreated]     DATETIME2         NOT NULL CONSTRAINT [DF_WebhookSubscriptions_DateCreated] DEFAULT (SYSUTCDATETIME()),
+        [DateModified]    DATETIME2         NOT NULL CONSTRAINT [DF_WebhookSubscriptions_DateModified] DEFAULT (SYSUTCDATETIME())
+    )
+END
+GO
+
+-- The outbox: one row per event and subscription, delivered by background workers.
+-- Status is pending until delivered, or dead once every attempt has failed.
+IF OBJECT_ID(N'[dbo].[WebhookDeliveries]', N'U') IS NULL
+BEGIN
+    CREATE TABLE [dbo].[WebhookDeliveries] (
+        [DeliveryId]      UNIQUEIDENTIFIER  NOT NULL CONSTRAINT [PK_WebhookDeliveries] PRIMARY KEY,
+        [SubscriptionId]  UNIQUEIDENTIFIER  NOT NULL,
+        [EventId]         UNIQUEIDENTIFIER  NOT NULL,
+        [EventType]       NVARCHAR(100)     NOT NULL,
+        [Payload]         NVARCHAR(MAX)     NOT NULL,
+        [Status]          NVARCHAR(20)      NOT NULL,
+        [Attempts]        INT               NOT NULL CONSTRAINT [DF_WebhookDeliveries_Attempts] DEFAULT (0),
+        [NextAttemptAt]   DATETIME2         NOT NULL CONSTRAINT [DF_WebhookDeliveries_NextAttemptAt] DEFAULT (SYSUTCDATETIME()),
+        [LeaseOwner]      NVARCHAR(200)     NULL,
+        [LeaseExpiresAt]  DATETIME2         NULL,
+        [LastStatusCode]  INT               NULL,
+        [LastError]       NVARCHAR(2000)    NULL,
+        [DateCreated]     DATETIME2         NOT NULL CONSTRAINT [DF_WebhookDeliveries_DateCreated] DEFAULT (SYSUTCDATETIME()),
+        [DateDelivered]   DATETIME2         NULL,
+        CONSTRAINT [FK_WebhookDeliveries_WebhookSubscriptions] FOREIGN KEY ([SubscriptionId]) REFERENCES [dbo].[WebhookSubscriptions] ([SubscriptionId]) ON DELETE CASCADE
+    )
+    CREATE INDEX [IX_WebhookDeliveries_Due] ON [dbo].[WebhookDeliveries] ([Status], [NextAttemptAt])
+    CREATE INDEX [IX_WebhookDeliveries_Subscription] ON [dbo].[WebhookDeliveries] ([SubscriptionId], [DateCreated])
+END
+GO
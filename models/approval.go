@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Approval actions recorded in VersionApprovals
+const (
+	ApprovalActionSubmitted = "submitted"
+	ApprovalActionApproved  = "approved"
+	ApprovalActionRejected  = "rejected"
+)
+
+// ApprovalRequest represents the request body for submitting, approving or rejecting a version
+type ApprovalRequest struct {
+	Comment string `json:"comment"`
+}
+
+// ApprovalHistoryEntry represents a row of the VersionApprovals table
+type ApprovalHistoryEntry struct {
+	ApprovalID  int       `json:"approvalId" db:"ApprovalID"`
+	ObjectID    uuid.UUID `json:"objectId" db:"ObjectID"`
+	VersionID   uuid.UUID `json:"versionId" db:"VersionID"`
+	Action      string    `json:"action" db:"Action"`
+	Comment     *string   `json:"comment,omitempty" db:"Comment"`
+	ProfileID   int       `json:"profileId" db:"ProfileID"`
+	UserID      int       `json:"userId" db:"UserID"`
+	DateCreated time.Time `json:"dateCreated" db:"DateCreated"`
+}
+
+// ApprovalState describes where an object's current version is in the approval workflow
+type ApprovalState struct {
+	ObjectID          uuid.UUID `json:"objectId"`
+	ExactObjectTypeID int       `json:"exactObjectTypeId"`
+	CurrentVersionID  uuid.UUID `json:"currentVersionId"`
+	IsCheckedOut      bool      `json:"isCheckedOut"`
+	IsPending         bool      `json:"isPending"`
+	IsApproved        bool      `json:"isApproved"`
+	IsRejected        bool      `json:"isRejected"`
+	IsGoverned        bool      `json:"isGoverned"`
+	// SubmittedBy is the user who submitted a pending version
+	SubmittedBy *int `json:"submittedBy,omitempty"`
+}
+
+// PendingApproval represents a version waiting for the caller's sign-off
+type PendingApproval struct {
+	ObjectID          uuid.UUID `json:"objectId" db:"ObjectID"`
+	ObjectName        string    `json:"objectName" db:"ObjectName"`
+	ExactObjectTypeID int       `json:"exactObjectTypeId" db:"ExactObjectTypeID"`
+	VersionID         uuid.UUID `json:"versionId" db:"VersionID"`
+	SystemVersionNo   int       `json:"systemVersionNo" db:"SystemVersionNo"`
+	CheckInReason     *string   `json:"checkInReason,omitempty" db:"CheckInReason"`
+	SubmittedBy       int       `json:"submittedBy" db:"SubmittedBy"`
+	DateSubmitted     time.Time `json:"dateSubmitted" db:"DateSubmitted"`
+}
+
+// ObjectTypeApproversRequest represents the request body for configuring an object type's approvers
+type ObjectTypeApproversRequest struct {
+	ProfileIDs []int `json:"profileIds"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "github.com/google/uuid"
+
+// ArchiMateImportRequest holds the form fields of an ArchiMate exchange file
+// upload. MatchBy is "name" or "objectId"; the latter matches elements whose
+// identifier is "id-" followed by an object ID, as written by the export.
+type ArchiMateImportRequest struct {
+	LibraryId uuid.UUID `json:"libraryId"`
+	FolderId  uuid.UUID `json:"folderId"`
+	MatchBy   string    `json:"matchBy"`
+	Strategy  string    `json:"strategy"`
+	DryRun    bool      `json:"dryRun"`
+	Locale    string    `json:"locale"`
+}
+
+// ArchiMateSkippedElement is an element of an exchange file that was not
+// imported because its type is not mapped to an object type
+type ArchiMateSkippedElement struct {
+	Identifier string `json:"identifier"`
+	Type       string `json:"type"`
+	Name       string `json:"name"`
+	Message    string `json:"message"`
+}
+
+// ArchiMateImportResponse reports the outcome of an ArchiMate import. Row in
+// Rows is the 1-based position of the element in the file.
+type ArchiMateImportResponse struct {
+	ObjectImportResponse
+	Relationships     []RelationshipImportResult `json:"relationships"`
+	SkippedElements   []ArchiMateSkippedElement  `json:"skippedElements"`
+	IgnoredProperties []string                   `json:"ignoredProperties"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audited entity types
+const (
+	AuditEntityObject         = "object"
+	AuditEntityObjectType     = "objectType"
+	AuditEntityAttribute      = "attribute"
+	AuditEntityAttributeValue = "attributeValue"
+	AuditEntityProfile        = "profile"
+	AuditEntityEATag          = "eaTag"
+	// Assignments, identified by the object type they belong to, or by
+	// {objectTypeId}/{attributeId} and {folderObjectTypeId}/{objectTypeId}
+	AuditEntityDimension           = "eaTagDimension"
+	AuditEntityArchiMateMapping    = "archiMateMapping"
+	AuditEntityApprovers           = "approvers"
+	AuditEntityAttributeAssignment = "attributeAssignment"
+	AuditEntityFolderObjectType    = "folderObjectType"
+)
+
+// Audited actions. Objects are also moved between folders, restored from and
+// purged from the recycle bin, checked out and in, restored to a version and
+// approved; folders are reordered.
+const (
+	AuditActionCreate         = "create"
+	AuditActionUpdate         = "update"
+	AuditActionDelete         = "delete"
+	AuditActionMove           = "move"
+	AuditActionRestore        = "restore"
+	AuditActionPurge          = "purge"
+	AuditActionReorder        = "reorder"
+	AuditActionCheckOut       = "checkOut"
+	AuditActionCheckIn        = "checkIn"
+	AuditActionUndoCheckOut   = "undoCheckOut"
+	AuditActionRestoreVersion = "restoreVersion"
+	AuditActionSubmit         = "submit"
+	AuditActionApprove        = "approve"
+	AuditActionReject         = "reject"
+)
+
+// AuditEvent represents the AuditEvents table: one change to an entity, with
+// the entity as JSON before and after it. Before is null for creates, After
+// for deletes.
+type AuditEvent struct {
+	EventId    int64           `json:"eventId" db:"EventId"`
+	EntityType string          `json:"entityType" db:"EntityType"`
+	EntityId   string          `json:"entityId" db:"EntityId"`
+	Action     string          `json:"action" db:"Action"`
+	Before     json.RawMessage `json:"before" db:"Before"`
+	After      json.RawMessage `json:"after" db:"After"`
+	UserId     int             `json:"userId" db:"UserID"`
+	ProfileId  int             `json:"profileId" db:"ProfileID"`
+	RequestId  string          `json:"requestId,omitempty" db:"RequestID"`
+	Timestamp  time.Time       `json:"timestamp" db:"Timestamp"`
+}
+
+// AuditQuery selects audit events. Every filter is optional; From is
+// inclusive and To exclusive.
+type AuditQuery struct {
+	PageRequest
+	EntityType string
+	EntityId   string
+	UserId     *int
+	From       *time.Time
+	To         *time.Time
+}
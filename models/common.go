@@ -13,6 +13,8 @@ type PaginatedResponse struct {
 	PageSize   int         `json:"pageSize"`
 	TotalCount int         `json:"totalCount"`
 	TotalPages int         `json:"totalPages"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+	PrevCursor string      `json:"prevCursor,omitempty"`
 }
 
 // ErrorResponse represents an error response
@@ -36,3 +36,16 @@ type AssignObjectTypeToDimentionResponse struct {
 	ObjectTypeID int `json:"object_type_id" validate:"required"`
 	EAID         int `json:"ea_tag_id" validate:"required"`
 }
+
+// ArchiMateMapping represents the EA_ArchiMate_Mappings table, which maps an
+// object type to the ArchiMate element type it is exchanged as
+type ArchiMateMapping struct {
+	ObjectTypeID   int     `json:"object_type_id" db:"object_type_id"`
+	ObjectTypeName *string `json:"object_type_name,omitempty" db:"ObjectTypeName"`
+	ElementType    string  `json:"element_type" db:"element_type"`
+}
+
+// SetArchiMateMappingRequest represents the request for mapping an object type to an ArchiMate element type
+type SetArchiMateMappingRequest struct {
+	ElementType string `json:"element_type" validate:"required"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Repository tree event types sent on the event stream
+const (
+	StreamEventCreate       = "object.create"
+	StreamEventUpdate       = "object.update"
+	StreamEventMove         = "object.move"
+	StreamEventDelete       = "object.delete"
+	StreamEventRestore      = "object.restore"
+	StreamEventCheckOut     = "object.checkout"
+	StreamEventCheckIn      = "object.checkin"
+	StreamEventUndoCheckOut = "object.undo-checkout"
+	// StreamEventReorder is sent for the folder or library whose contents
+	// were put in a new order
+	StreamEventReorder = "folder.reorder"
+	// StreamEventReset tells a resuming client that events were missed and
+	// its tree must be re-fetched
+	StreamEventReset = "reset"
+)
+
+// StreamEvent is a change to the repository tree sent to event stream
+// clients. ParentId is the folder the object is in, where known;
+// PreviousParentId is the folder a moved object left.
+type StreamEvent struct {
+	Id               string      `json:"id"`
+	Type             string      `json:"type"`
+	ObjectId         uuid.UUID   `json:"objectId"`
+	ParentId         *uuid.UUID  `json:"parentId,omitempty"`
+	PreviousParentId *uuid.UUID  `json:"previousParentId,omitempty"`
+	LibraryId        *uuid.UUID  `json:"libraryId,omitempty"`
+	UserId           int         `json:"userId"`
+	OccurredAt       time.Time   `json:"occurredAt"`
+	Data             interface{} `json:"data,omitempty"`
+	// AuthorizeOn is the object a client must be able to read to receive the
+	// event: the object itself, or the folder a deleted object was in
+	AuthorizeOn uuid.UUID `json:"-"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Export file formats
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatXLSX = "xlsx"
+)
+
+// ExportColumn is a column of an object export. AttributeId is uuid.Nil for
+// the system columns.
+type ExportColumn struct {
+	Header        string
+	AttributeId   uuid.UUID
+	AttributeType string
+}
+
+// ExportedObject is an object with the attribute values of its current
+// version, keyed by attribute ID
+type ExportedObject struct {
+	ObjectID     uuid.UUID
+	ObjectName   string
+	Description  *string
+	DateCreated  *time.Time
+	DateModified *time.Time
+	Values       map[uuid.UUID]AssignedAttribute
+}
@@ -73,3 +73,32 @@ type FolderContent struct {
 	CheckedOutBy                     *int    `json:"checkedOutBy,omitempty" db:"CheckedOutBy"`
 	IsFirstVersionCheckedOut         bool    `json:"isFirstVersionCheckedOut" db:"IsFirstVersionCheckedOut"`
 }
+
+// CreateFolderRequest creates a folder of folder type ObjectTypeId in
+// ParentId, or at the top of LibraryId when no parent is given. SortOrder is
+// the folder's 1-based position among the parent's contents; it goes last
+// when omitted, and the position is ignored when the parent sorts
+// automatically.
+type CreateFolderRequest struct {
+	ParentId          *uuid.UUID `json:"parentId,omitempty"`
+	LibraryId         *uuid.UUID `json:"libraryId,omitempty"`
+	ObjectTypeId      int        `json:"objectTypeId"`
+	ObjectName        string     `json:"objectName"`
+	ObjectDescription string     `json:"objectDescription"`
+	SortOrder         *int       `json:"sortOrder,omitempty"`
+	AutoSort          bool       `json:"autoSort"`
+}
+
+// UpdateFolderRequest renames a folder or changes its description or whether
+// its contents are sorted by name
+type UpdateFolderRequest struct {
+	ObjectName        *string `json:"objectName,omitempty"`
+	ObjectDescription *string `json:"objectDescription,omitempty"`
+	AutoSort          *bool   `json:"autoSort,omitempty"`
+}
+
+// ReorderFolderRequest lists contents of a folder in the order to show them.
+// Contents not listed follow in their current order.
+type ReorderFolderRequest struct {
+	ObjectIds []uuid.UUID `json:"objectIds"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "github.com/google/uuid"
+
+// Graph export formats
+const (
+	GraphFormatGraphML = "graphml"
+	GraphFormatDOT     = "dot"
+)
+
+// Edge kinds of a library graph export
+const (
+	GraphEdgesContainment   = "containment"
+	GraphEdgesRelationships = "relationships"
+	GraphEdgesAll           = "all"
+)
+
+// GraphNode is an object as a node of a graph, with its object type
+type GraphNode struct {
+	ObjectId       uuid.UUID `json:"objectId"`
+	ObjectName     string    `json:"objectName"`
+	ObjectTypeId   int       `json:"objectTypeId"`
+	ObjectTypeName *string   `json:"objectTypeName,omitempty"`
+	Color          *int      `json:"color,omitempty"`
+}
+
+// ContainmentEdge is an ObjectContents row of a container's current version:
+// the container holds the object
+type ContainmentEdge struct {
+	ContainerObjectId uuid.UUID
+	ObjectId          uuid.UUID
+	ContainmentType   int
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Import job statuses
+const (
+	ImportJobQueued    = "queued"
+	ImportJobRunning   = "running"
+	ImportJobCompleted = "completed"
+	ImportJobFailed    = "failed"
+	ImportJobCancelled = "cancelled"
+)
+
+// ImportJob reports the progress of a background import
+type ImportJob struct {
+	JobID           uuid.UUID           `json:"jobId"`
+	Status          string              `json:"status"`
+	FileName        *string             `json:"fileName,omitempty"`
+	LibraryId       uuid.UUID           `json:"libraryId"`
+	FolderId        uuid.UUID           `json:"folderId"`
+	ObjectTypeId    int                 `json:"objectTypeId"`
+	TotalRows       int                 `json:"totalRows"`
+	ProcessedRows   int                 `json:"processedRows"`
+	SuccessCount    int                 `json:"successCount"`
+	FailedCount     int                 `json:"failedCount"`
+	SkippedCount    int                 `json:"skippedCount"`
+	Progress        float64             `json:"progress"`
+	CancelRequested bool                `json:"cancelRequested"`
+	ErrorMessage    *string             `json:"errorMessage,omitempty"`
+	CreatedBy       int                 `json:"createdBy"`
+	DateCreated     time.Time           `json:"dateCreated"`
+	DateStarted     *time.Time          `json:"dateStarted,omitempty"`
+	DateCompleted   *time.Time          `json:"dateCompleted,omitempty"`
+	Columns         []ImportColumnMatch `json:"columns,omitempty"`
+	Errors          []ImportRowResult   `json:"errors"`
+}
+
+// ImportJobPayload is the work stored with a job: the import request and, for
+// spreadsheet uploads, the line number of each data row
+type ImportJobPayload struct {
+	Request ObjectImportRequest `json:"request"`
+	Lines   []int               `json:"lines,omitempty"`
+}
@@ -76,18 +76,109 @@ type UpdateObjectRequest struct {
 	ModifiedBy          int     `json:"modifiedBy" validate:"required"`
 }
 
+// MoveObjectRequest moves an object into TargetFolderId, or to the top of
+// TargetLibraryId when no folder is given. When both are given the folder
+// must be in the library.
+type MoveObjectRequest struct {
+	TargetFolderId  *uuid.UUID `json:"targetFolderId,omitempty"`
+	TargetLibraryId *uuid.UUID `json:"targetLibraryId,omitempty"`
+}
+
+// CopyObjectRequest copies an object into a target folder or library as
+// MoveObjectRequest does. A deep copy also copies the object's attribute
+// values and, recursively, the contents of its folder. ObjectName renames the
+// copy.
+type CopyObjectRequest struct {
+	TargetFolderId  *uuid.UUID `json:"targetFolderId,omitempty"`
+	TargetLibraryId *uuid.UUID `json:"targetLibraryId,omitempty"`
+	Deep            bool       `json:"deep"`
+	ObjectName      *string    `json:"objectName,omitempty"`
+}
+
+// ObjectPlacementResult reports where a moved or copied object now is.
+// PreviousParentId is the folder a moved object left, and ObjectCount the
+// objects moved or created: the object and everything below it.
+type ObjectPlacementResult struct {
+	Object           *Object    `json:"object"`
+	ParentId         uuid.UUID  `json:"parentId"`
+	PreviousParentId *uuid.UUID `json:"previousParentId,omitempty"`
+	LibraryId        uuid.UUID  `json:"libraryId"`
+	ObjectCount      int        `json:"objectCount"`
+}
+
+// ObjectImportRequest imports rows of attribute values as objects. MatchBy
+// selects how rows are matched to existing objects, MatchAttributeId names
+// the attribute holding the key for the externalId and autoId keys, and
+// Strategy decides what happens to matched and unmatched rows. ExcelDates
+// reads date values written as bare numbers as Excel serial day numbers, as
+// XLSX workbooks store them.
 type ObjectImportRequest struct {
-	LibraryId    uuid.UUID                    `json:"libraryId"`
-	FolderId     uuid.UUID                    `json:"folderId"`
-	ObjectTypeId int                          `json:"objectTypeId"`
-	Data         []map[string]ObjectImportRow `json:"data"`
-	Mappings     []interface{}                `json:"mappings"`
+	LibraryId        uuid.UUID                    `json:"libraryId"`
+	FolderId         uuid.UUID                    `json:"folderId"`
+	ObjectTypeId     int                          `json:"objectTypeId"`
+	Data             []map[string]ObjectImportRow `json:"data"`
+	Mappings         []interface{}                `json:"mappings"`
+	DryRun           bool                         `json:"dryRun"`
+	Locale           string                       `json:"locale"`
+	MatchBy          string                       `json:"matchBy"`
+	MatchAttributeId *string                      `json:"matchAttributeId"`
+	Strategy         string                       `json:"strategy"`
+	ExcelDates       bool                         `json:"excelDates,omitempty"`
 }
+
+// Import matching keys
+const (
+	ImportMatchByName       = "name"
+	ImportMatchByObjectID   = "objectId"
+	ImportMatchByExternalID = "externalId"
+	ImportMatchByAutoID     = "autoId"
+)
+
+// Import strategies
+const (
+	ImportStrategyUpsert          = "upsert"
+	ImportStrategyInsertOnly      = "insertOnly"
+	ImportStrategyUpdateOnly      = "updateOnly"
+	ImportStrategySkipIfUnchanged = "skipIfUnchanged"
+)
+
 type ObjectImportResponse struct {
 	General
-	SuccessImportedObjectCount int `json:"successImportedObjectCount"`
-	FailedImportObjectCount    int `json:"failedImportObjectCount"`
-	TotalImportedObjectCount   int `json:"totalImportedObjectCount"`
+	SuccessImportedObjectCount int               `json:"successImportedObjectCount"`
+	FailedImportObjectCount    int               `json:"failedImportObjectCount"`
+	SkippedImportObjectCount   int               `json:"skippedImportObjectCount"`
+	TotalImportedObjectCount   int               `json:"totalImportedObjectCount"`
+	DryRun                     bool              `json:"dryRun"`
+	Rows                       []ImportRowResult `json:"rows"`
+}
+
+// Import row actions
+const (
+	ImportActionInsert = "insert"
+	ImportActionUpdate = "update"
+	ImportActionSkip   = "skip"
+	// ImportActionUnchanged marks a row left alone by the import strategy
+	ImportActionUnchanged = "unchanged"
+)
+
+// ImportRowResult reports what the import did, or would do in a dry run,
+// with one row. Row is the 1-based position in the request data, or the
+// line number for spreadsheet uploads.
+type ImportRowResult struct {
+	Row        int              `json:"row"`
+	Action     string           `json:"action"`
+	ObjectID   *uuid.UUID       `json:"objectId,omitempty"`
+	ObjectName string           `json:"objectName"`
+	Message    string           `json:"message,omitempty"`
+	Errors     []ImportRowError `json:"errors,omitempty"`
+}
+
+// ImportRowError describes a value or validation problem that prevented a row
+// from being imported. Field is empty for errors that concern the whole row.
+type ImportRowError struct {
+	Field   string  `json:"field,omitempty"`
+	Value   *string `json:"value,omitempty"`
+	Message string  `json:"message"`
 }
 type ObjectImportRow struct {
 	AttributeId    *string `json:"attributeId"`
@@ -95,3 +186,33 @@ type ObjectImportRow struct {
 	AttributeType  *string `json:"attributeType"`
 	AttributeName  *string `json:"attributeName"`
 }
+
+// SpreadsheetImportRequest holds the form fields of a spreadsheet upload.
+// Mapping maps a column header to an attribute ID, an attribute name,
+// "Object Name", "Object ID" or "Description"; an empty target ignores the
+// column. Columns not in Mapping are matched to attributes by name.
+type SpreadsheetImportRequest struct {
+	LibraryId        uuid.UUID         `json:"libraryId"`
+	FolderId         uuid.UUID         `json:"folderId"`
+	ObjectTypeId     int               `json:"objectTypeId"`
+	Mapping          map[string]string `json:"mapping"`
+	DryRun           bool              `json:"dryRun"`
+	Locale           string            `json:"locale"`
+	MatchBy          string            `json:"matchBy"`
+	MatchAttributeId *string           `json:"matchAttributeId"`
+	Strategy         string            `json:"strategy"`
+}
+
+// ImportColumnMatch describes how a spreadsheet column was mapped
+type ImportColumnMatch struct {
+	Column        string  `json:"column"`
+	Target        string  `json:"target,omitempty"`
+	AttributeId   *string `json:"attributeId,omitempty"`
+	AttributeType *string `json:"attributeType,omitempty"`
+	MatchedBy     string  `json:"matchedBy"`
+}
+
+type SpreadsheetImportResponse struct {
+	ObjectImportResponse
+	Columns []ImportColumnMatch `json:"columns"`
+}
@@ -0,0 +1,30 @@
+package models
+
+// ObjectListQuery holds the page, filter expression, sort keys and facet
+// fields of an object list request
+type ObjectListQuery struct {
+	PageRequest
+	Filter string
+	Sort   string
+	Facets string
+}
+
+// FacetValue is a distinct value of a facet field and the number of objects
+// matching the filter that have it
+type FacetValue struct {
+	Value interface{} `json:"value"`
+	Count int         `json:"count"`
+}
+
+// Facet holds the most common values of a field among the objects matching
+// the filter
+type Facet struct {
+	Field  string       `json:"field"`
+	Values []FacetValue `json:"values"`
+}
+
+// ObjectListResponse is a page of objects with the requested facets
+type ObjectListResponse struct {
+	PaginatedResponse
+	Facets []Facet `json:"facets,omitempty"`
+}
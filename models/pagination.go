@@ -0,0 +1,19 @@
+package models
+
+// PageRequest selects a page of a list, either by page number or, for large
+// lists, by a cursor from a previous response. SkipCount leaves out the
+// total count, which is costly on large tables.
+type PageRequest struct {
+	Page      int
+	PageSize  int
+	Cursor    string
+	SkipCount bool
+}
+
+// PageInfo describes a page read by a repository. TotalCount is -1 when the
+// count was skipped; the cursors are empty at either end of the list.
+type PageInfo struct {
+	TotalCount int
+	NextCursor string
+	PrevCursor string
+}
@@ -0,0 +1,24 @@
+package models
+
+import "github.com/google/uuid"
+
+// Object permission actions
+const (
+	PermissionRead                = "read"
+	PermissionModify              = "modify"
+	PermissionDelete              = "delete"
+	PermissionModifyContents      = "modify contents"
+	PermissionModifyRelationships = "modify relationships"
+)
+
+// ObjectPermission represents a profile's effective permissions on an object,
+// either granted directly or inherited from a containing folder
+type ObjectPermission struct {
+	ObjectID               uuid.UUID `json:"objectId" db:"ObjectID"`
+	ProfileID              int       `json:"profileId" db:"ProfileID"`
+	HasRead                bool      `json:"hasRead" db:"HasRead"`
+	HasModify              bool      `json:"hasModify" db:"HasModify"`
+	HasDelete              bool      `json:"hasDelete" db:"HasDelete"`
+	HasModifyContents      bool      `json:"hasModifyContents" db:"HasModifyContents"`
+	HasModifyRelationships bool      `json:"hasModifyRelationships" db:"HasModifyRelationships"`
+}
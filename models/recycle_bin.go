@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecycleBinEntry represents a delete transaction: an object deleted together
+// with the contents of its folder tree, which are restored or purged as one
+type RecycleBinEntry struct {
+	DeleteTransactionId uuid.UUID  `json:"deleteTransactionId" db:"DeleteTransactionId"`
+	RootObjectId        uuid.UUID  `json:"rootObjectId" db:"RootObjectID"`
+	RootObjectName      string     `json:"rootObjectName" db:"ObjectName"`
+	RootObjectTypeId    int        `json:"rootObjectTypeId" db:"ExactObjectTypeID"`
+	LibraryId           *uuid.UUID `json:"libraryId,omitempty" db:"LibraryId"`
+	ParentObjectId      *uuid.UUID `json:"parentObjectId,omitempty" db:"ParentObjectID"`
+	ObjectCount         int        `json:"objectCount" db:"ObjectCount"`
+	DeletedBy           int        `json:"deletedBy" db:"DeletedBy"`
+	DateDeleted         time.Time  `json:"dateDeleted" db:"DateDeleted"`
+}
+
+// RecycleBinObject is an object deleted in a delete transaction
+type RecycleBinObject struct {
+	ObjectId     uuid.UUID `json:"objectId" db:"ObjectID"`
+	ObjectName   string    `json:"objectName" db:"ObjectName"`
+	ObjectTypeId int       `json:"objectTypeId" db:"ExactObjectTypeID"`
+}
+
+// RecycleBinEntryDetail is a delete transaction with the objects it deleted
+type RecycleBinEntryDetail struct {
+	RecycleBinEntry
+	Objects []RecycleBinObject `json:"objects"`
+}
+
+// RecycleBinActionResult reports the objects restored or purged with a delete
+// transaction
+type RecycleBinActionResult struct {
+	DeleteTransactionId uuid.UUID `json:"deleteTransactionId"`
+	ObjectCount         int       `json:"objectCount"`
+}
@@ -0,0 +1,145 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RelationType represents the RelationTypes table. Rules lists the object
+// type pairs a relationship of this type may connect; ArchiMateType is the
+// ArchiMate relationship type it is exchanged as.
+type RelationType struct {
+	RelationTypeId   uuid.UUID          `json:"relationTypeId" db:"RelationTypeId"`
+	RelationTypeName string             `json:"relationTypeName" db:"RelationTypeName"`
+	ReverseName      *string            `json:"reverseName,omitempty" db:"ReverseName"`
+	Description      *string            `json:"description,omitempty" db:"Description"`
+	ArchiMateType    *string            `json:"archiMateType,omitempty" db:"ArchiMateType"`
+	Rules            []RelationTypeRule `json:"rules"`
+	DateCreated      time.Time          `json:"dateCreated" db:"DateCreated"`
+	CreatedBy        int                `json:"createdBy" db:"CreatedBy"`
+	DateModified     time.Time          `json:"dateModified" db:"DateModified"`
+	ModifiedBy       int                `json:"modifiedBy" db:"ModifiedBy"`
+}
+
+// RelationTypeRule allows relationships from objects of one type to objects
+// of another
+type RelationTypeRule struct {
+	SourceObjectTypeId int `json:"sourceObjectTypeId" db:"SourceObjectTypeID"`
+	TargetObjectTypeId int `json:"targetObjectTypeId" db:"TargetObjectTypeID"`
+}
+
+// RelationTypeRequest represents the request body for creating or replacing a relation type
+type RelationTypeRequest struct {
+	RelationTypeName string             `json:"relationTypeName" validate:"required"`
+	ReverseName      *string            `json:"reverseName,omitempty"`
+	Description      *string            `json:"description,omitempty"`
+	ArchiMateType    *string            `json:"archiMateType,omitempty"`
+	Rules            []RelationTypeRule `json:"rules" validate:"required"`
+}
+
+// Relationship represents the Relationships table: a typed link from a source
+// object to a target object, with the values of the attributes assigned to
+// its relation type
+type Relationship struct {
+	RelationshipId   uuid.UUID           `json:"relationshipId" db:"RelationshipId"`
+	RelationTypeId   uuid.UUID           `json:"relationTypeId" db:"RelationTypeId"`
+	RelationTypeName string              `json:"relationTypeName" db:"RelationTypeName"`
+	SourceObjectId   uuid.UUID           `json:"sourceObjectId" db:"SourceObjectID"`
+	SourceObjectName string              `json:"sourceObjectName" db:"SourceObjectName"`
+	TargetObjectId   uuid.UUID           `json:"targetObjectId" db:"TargetObjectID"`
+	TargetObjectName string              `json:"targetObjectName" db:"TargetObjectName"`
+	Description      *string             `json:"description,omitempty" db:"Description"`
+	Attributes       []AssignedAttribute `json:"attributes"`
+	DateCreated      time.Time           `json:"dateCreated" db:"DateCreated"`
+	CreatedBy        int                 `json:"createdBy" db:"CreatedBy"`
+	DateModified     time.Time           `json:"dateModified" db:"DateModified"`
+	ModifiedBy       int                 `json:"modifiedBy" db:"ModifiedBy"`
+}
+
+// CreateRelationshipRequest represents the request body for creating a relationship
+type CreateRelationshipRequest struct {
+	RelationTypeId uuid.UUID           `json:"relationTypeId" validate:"required"`
+	SourceObjectId uuid.UUID           `json:"sourceObjectId" validate:"required"`
+	TargetObjectId uuid.UUID           `json:"targetObjectId" validate:"required"`
+	Description    *string             `json:"description,omitempty"`
+	Attributes     []AssignedAttribute `json:"attributes,omitempty"`
+}
+
+// UpdateRelationshipRequest represents the request body for updating a
+// relationship. Listed attributes are set; an attribute without a value is
+// cleared.
+type UpdateRelationshipRequest struct {
+	Description *string             `json:"description,omitempty"`
+	Attributes  []AssignedAttribute `json:"attributes,omitempty"`
+}
+
+// Relationship directions, as seen from an object
+const (
+	RelationshipDirectionOutgoing = "outgoing"
+	RelationshipDirectionIncoming = "incoming"
+	RelationshipDirectionBoth     = "both"
+)
+
+// RelationshipImport is a relationship created by an import between two rows
+// of the imported data. Values are parsed like import cells.
+type RelationshipImport struct {
+	Identifier     string
+	RelationTypeId uuid.UUID
+	Source         ImportRowRef
+	Target         ImportRowRef
+	Description    *string
+	Values         []ObjectImportRow
+}
+
+// ImportRowRef refers to a row of one of the requests of a multi-request
+// import, both zero-based
+type ImportRowRef struct {
+	Request int
+	Row     int
+}
+
+// RelationshipImportResult reports what an import did with a relationship.
+// Action is one of the import row actions.
+type RelationshipImportResult struct {
+	Identifier     string     `json:"identifier"`
+	Type           string     `json:"type,omitempty"`
+	Action         string     `json:"action"`
+	RelationshipId *uuid.UUID `json:"relationshipId,omitempty"`
+	Message        string     `json:"message,omitempty"`
+}
+
+// Impact analysis directions. Down follows relationships from source to
+// target, up from target to source.
+const (
+	ImpactDirectionUp   = "up"
+	ImpactDirectionDown = "down"
+	ImpactDirectionBoth = "both"
+)
+
+// ImpactNode is an object reached by an impact analysis, at the number of
+// relationships between it and the analysed object
+type ImpactNode struct {
+	GraphNode
+	Depth int `json:"depth"`
+}
+
+// ImpactEdge is a relationship followed by an impact analysis
+type ImpactEdge struct {
+	RelationshipId   uuid.UUID `json:"relationshipId"`
+	RelationTypeId   uuid.UUID `json:"relationTypeId"`
+	RelationTypeName string    `json:"relationTypeName"`
+	SourceObjectId   uuid.UUID `json:"sourceObjectId"`
+	TargetObjectId   uuid.UUID `json:"targetObjectId"`
+}
+
+// ImpactGraph is the result of an impact analysis. HasCycles reports that the
+// returned relationships can be followed from an object back to itself.
+type ImpactGraph struct {
+	RootObjectId uuid.UUID    `json:"rootObjectId"`
+	Direction    string       `json:"direction"`
+	Depth        int          `json:"depth"`
+	HasCycles    bool         `json:"hasCycles"`
+	Nodes        []ImpactNode `json:"nodes"`
+	Edges        []ImpactEdge `json:"edges"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import "github.com/google/uuid"
+
+// Fields a search match can be found in
+const (
+	SearchFieldName        = "name"
+	SearchFieldDescription = "description"
+	SearchFieldRichText    = "richText"
+	SearchFieldAttribute   = "attribute"
+)
+
+// SearchFilter narrows a search to a library, an object type or the object
+// types of an EA tag
+type SearchFilter struct {
+	LibraryID    *uuid.UUID
+	ObjectTypeID *int
+	EATagID      *int
+}
+
+// SearchCandidate is an object that may match a search, with the text of its
+// readable text and rich text attribute values
+type SearchCandidate struct {
+	ObjectId            uuid.UUID
+	ObjectName          string
+	ObjectDescription   *string
+	RichTextDescription *string
+	ObjectTypeId        *int
+	ObjectTypeName      *string
+	LibraryId           *uuid.UUID
+	Attributes          []SearchAttributeValue
+}
+
+// SearchAttributeValue is a text or rich text attribute value of a search
+// candidate
+type SearchAttributeValue struct {
+	AttributeId   uuid.UUID
+	AttributeName string
+	Value         string
+	RichText      bool
+}
+
+// SearchHighlight is a matched range of a snippet, in characters from Start
+// up to but not including End
+type SearchHighlight struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// SearchMatch is a field of an object that matched the search
+type SearchMatch struct {
+	Field         string            `json:"field"`
+	AttributeId   *uuid.UUID        `json:"attributeId,omitempty"`
+	AttributeName *string           `json:"attributeName,omitempty"`
+	Snippet       string            `json:"snippet"`
+	Highlights    []SearchHighlight `json:"highlights"`
+}
+
+// SearchResult is an object that matched the search, with its score and
+// where it matched
+type SearchResult struct {
+	ObjectId       uuid.UUID     `json:"objectId"`
+	ObjectName     string        `json:"objectName"`
+	ObjectTypeId   *int          `json:"objectTypeId,omitempty"`
+	ObjectTypeName *string       `json:"objectTypeName,omitempty"`
+	LibraryId      *uuid.UUID    `json:"libraryId,omitempty"`
+	Score          int           `json:"score"`
+	Matches        []SearchMatch `json:"matches"`
+}
+
+// SearchResponse is a page of search results. Truncated is set when more
+// objects matched the prefilter than are ranked, so results may be missing.
+type SearchResponse struct {
+	Query      string         `json:"query"`
+	Data       []SearchResult `json:"data"`
+	Page       int            `json:"page"`
+	PageSize   int            `json:"pageSize"`
+	TotalCount int            `json:"totalCount"`
+	TotalPages int            `json:"totalPages"`
+	Truncated  bool           `json:"truncated"`
+}
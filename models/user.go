@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// User represents the Users table in the database
+type User struct {
+	UserID       int       `json:"userId" db:"UserID"`
+	UserName     string    `json:"userName" db:"UserName"`
+	DisplayName  *string   `json:"displayName,omitempty" db:"DisplayName"`
+	Email        *string   `json:"email,omitempty" db:"Email"`
+	PasswordHash string    `json:"-" db:"PasswordHash"`
+	ProfileID    int       `json:"profileId" db:"ProfileID"`
+	IsActive     bool      `json:"isActive" db:"IsActive"`
+	DateCreated  time.Time `json:"dateCreated" db:"DateCreated"`
+	DateModified time.Time `json:"dateModified" db:"DateModified"`
+}
+
+// AuthUser is the authenticated caller attached to the request context
+type AuthUser struct {
+	UserID    int    `json:"userId"`
+	ProfileID int    `json:"profileId"`
+	UserName  string `json:"userName"`
+}
+
+// LoginRequest represents the request body for a local login
+type LoginRequest struct {
+	UserName string `json:"userName" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// LoginResponse represents the token issued after a successful login
+type LoginResponse struct {
+	Token     string    `json:"token"`
+	TokenType string    `json:"tokenType"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	User      AuthUser  `json:"user"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ObjectVersion represents a row of the Version table
+type ObjectVersion struct {
+	VersionID           uuid.UUID `json:"versionId" db:"ID"`
+	ObjectID            uuid.UUID `json:"objectId" db:"ObjectId"`
+	ObjectName          string    `json:"objectName" db:"ObjectName"`
+	ObjectDescription   *string   `json:"objectDescription,omitempty" db:"ObjectDescription"`
+	RichTextDescription *string   `json:"richTextDescription,omitempty" db:"RichTextDescription"`
+	SystemVersionNo     int       `json:"systemVersionNo" db:"SystemVersionNo"`
+	UserVersionNo       *string   `json:"userVersionNo,omitempty" db:"UserVersionNo"`
+	IsCheckedOut        bool      `json:"isCheckedOut" db:"IsCheckedOut"`
+	CheckedOutBy        *int      `json:"checkedOutBy,omitempty" db:"CheckedOutBy"`
+	CheckInReason       *string   `json:"checkInReason,omitempty" db:"CheckInReason"`
+	ApprovalStatus      *int      `json:"approvalStatus,omitempty" db:"ApprovalStatus"`
+	IsCurrent           bool      `json:"isCurrent"`
+	IsCheckedIn         bool      `json:"isCheckedIn"`
+	DateCreated         time.Time `json:"dateCreated" db:"DateCreated"`
+	CreatedBy           int       `json:"createdBy" db:"CreatedBy"`
+	DateModified        time.Time `json:"dateModified" db:"DateModified"`
+	ModifiedBy          int       `json:"modifiedBy" db:"ModifiedBy"`
+}
+
+// CheckInRequest represents the request body for checking in an object
+type CheckInRequest struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+// VersionFieldChange describes a changed version column between two versions
+type VersionFieldChange struct {
+	Field string  `json:"field"`
+	From  *string `json:"from"`
+	To    *string `json:"to"`
+}
+
+// VersionAttributeChange describes a changed attribute value between two versions
+type VersionAttributeChange struct {
+	AttributeID   uuid.UUID   `json:"attributeId"`
+	AttributeName string      `json:"attributeName"`
+	AttributeType string      `json:"attributeType"`
+	ChangeType    string      `json:"changeType"` // added, removed or modified
+	From          interface{} `json:"from"`
+	To            interface{} `json:"to"`
+}
+
+// VersionDiff represents the differences between two versions of an object
+type VersionDiff struct {
+	ObjectID   uuid.UUID                `json:"objectId"`
+	From       ObjectVersion            `json:"from"`
+	To         ObjectVersion            `json:"to"`
+	Fields     []VersionFieldChange     `json:"fields"`
+	Attributes []VersionAttributeChange `json:"attributes"`
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Entity types delivered to webhooks
+const (
+	WebhookEntityObject         = AuditEntityObject
+	WebhookEntityAttributeValue = AuditEntityAttributeValue
+	WebhookEntityRelationship   = "relationship"
+)
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryDead      = "dead"
+)
+
+// WebhookSubscription represents the WebhookSubscriptions table: a URL that
+// receives the events matching its filters. Empty EntityTypes and nil
+// ObjectTypeId and LibraryId match every event.
+type WebhookSubscription struct {
+	SubscriptionId uuid.UUID  `json:"subscriptionId" db:"SubscriptionId"`
+	Url            string     `json:"url" db:"Url"`
+	Secret         string     `json:"secret,omitempty" db:"Secret"`
+	EntityTypes    []string   `json:"entityTypes" db:"EntityTypes"`
+	ObjectTypeId   *int       `json:"objectTypeId,omitempty" db:"ObjectTypeID"`
+	LibraryId      *uuid.UUID `json:"libraryId,omitempty" db:"LibraryID"`
+	IsActive       bool       `json:"isActive" db:"IsActive"`
+	CreatedBy      int        `json:"createdBy" db:"CreatedBy"`
+	DateCreated    time.Time  `json:"dateCreated" db:"DateCreated"`
+	DateModified   time.Time  `json:"dateModified" db:"DateModified"`
+}
+
+// WebhookSubscriptionRequest represents the request body for creating or
+// updating a webhook subscription. A missing secret is generated on create
+// and kept on update.
+type WebhookSubscriptionRequest struct {
+	Url          string     `json:"url" validate:"required"`
+	Secret       string     `json:"secret,omitempty"`
+	EntityTypes  []string   `json:"entityTypes,omitempty"`
+	ObjectTypeId *int       `json:"objectTypeId,omitempty"`
+	LibraryId    *uuid.UUID `json:"libraryId,omitempty"`
+	IsActive     *bool      `json:"isActive,omitempty"`
+}
+
+// WebhookEvent is the JSON payload delivered to webhooks. ObjectId is the
+// object the event concerns: the object itself, the object whose attribute
+// value changed, or the source object of a relationship.
+type WebhookEvent struct {
+	EventId      uuid.UUID   `json:"eventId"`
+	EventType    string      `json:"eventType"`
+	EntityType   string      `json:"entityType"`
+	EntityId     string      `json:"entityId"`
+	Action       string      `json:"action"`
+	ObjectId     uuid.UUID   `json:"objectId"`
+	ObjectTypeId *int        `json:"objectTypeId,omitempty"`
+	LibraryId    *uuid.UUID  `json:"libraryId,omitempty"`
+	UserId       int         `json:"userId"`
+	RequestId    string      `json:"requestId,omitempty"`
+	OccurredAt   time.Time   `json:"occurredAt"`
+	Data         interface{} `json:"data,omitempty"`
+}
+
+// WebhookDelivery represents the WebhookDeliveries table: an event queued for
+// one subscription, with the outcome of its latest attempt
+type WebhookDelivery struct {
+	DeliveryId     uuid.UUID       `json:"deliveryId" db:"DeliveryId"`
+	SubscriptionId uuid.UUID       `json:"subscriptionId" db:"SubscriptionId"`
+	EventId        uuid.UUID       `json:"eventId" db:"EventId"`
+	EventType      string          `json:"eventType" db:"EventType"`
+	Status         string          `json:"status" db:"Status"`
+	Attempts       int             `json:"attempts" db:"Attempts"`
+	NextAttemptAt  *time.Time      `json:"nextAttemptAt,omitempty" db:"NextAttemptAt"`
+	LastStatusCode *int            `json:"lastStatusCode,omitempty" db:"LastStatusCode"`
+	LastError      *string         `json:"lastError,omitempty" db:"LastError"`
+	Payload        json.RawMessage `json:"payload" db:"Payload"`
+	DateCreated    time.Time       `json:"dateCreated" db:"DateCreated"`
+	DateDelivered  *time.Time      `json:"dateDelivered,omitempty" db:"DateDelivered"`
+}
+
+// WebhookDeliveryQuery selects webhook deliveries. Both filters are optional.
+type WebhookDeliveryQuery struct {
+	PageRequest
+	SubscriptionId *uuid.UUID
+	Status         string
+}
@@ -0,0 +1,373 @@
+package repositories
+
+import (
+	"database/sql"
+	"enterprise-architect-api/models"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotPendingApproval is returned when approving or rejecting an object
+// whose current version is not pending approval
+var ErrNotPendingApproval = errors.New("object has no version pending approval")
+
+// ApprovalRepository handles database operations for the version approval workflow
+type ApprovalRepository struct {
+	db *sql.DB
+}
+
+// NewApprovalRepository creates a new ApprovalRepository
+func NewApprovalRepository(db *sql.DB) *ApprovalRepository {
+	return &ApprovalRepository{db: db}
+}
+
+// GetApproverProfiles retrieves the profiles allowed to approve versions of an object type
+func (r *ApprovalRepository) GetApproverProfiles(objectTypeID int) ([]int, error) {
+	return getApproverProfiles(r.db, objectTypeID)
+}
+
+// getApproverProfiles retrieves the approver profiles of an object type with
+// a database or transaction
+func getApproverProfiles(q dbQuerier, objectTypeID int) ([]int, error) {
+	rows, err := q.Query(`SELECT ProfileID FROM ObjectTypeApprovers WHERE ObjectTypeID = @p1 ORDER BY ProfileID`, objectTypeID)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving approvers: %w", err)
+	}
+	defer rows.Close()
+
+	profileIDs := []int{}
+	for rows.Next() {
+		var profileID int
+		if err := rows.Scan(&profileID); err != nil {
+			return nil, fmt.Errorf("error scanning approver: %w", err)
+		}
+		profileIDs = append(profileIDs, profileID)
+	}
+
+	return profileIDs, rows.Err()
+}
+
+// SetApproverProfiles replaces the approver profiles of an object type
+func (r *ApprovalRepository) SetApproverProfiles(objectTypeID int, profileIDs []int, userID int, changes *ChangeLog) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := getApproverProfiles(tx, objectTypeID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM ObjectTypeApprovers WHERE ObjectTypeID = @p1`, objectTypeID); err != nil {
+		return fmt.Errorf("error clearing approvers: %w", err)
+	}
+
+	for _, profileID := range profileIDs {
+		_, err := tx.Exec(`
+			INSERT INTO ObjectTypeApprovers (ObjectTypeID, ProfileID, DateCreated, CreatedBy)
+			VALUES (@p1, @p2, GETDATE(), @p3)
+		`, objectTypeID, profileID, userID)
+		if err != nil {
+			return fmt.Errorf("error adding approver profile %d: %w", profileID, err)
+		}
+	}
+	after, err := getApproverProfiles(tx, objectTypeID)
+	if err != nil {
+		return err
+	}
+	if err := changes.Record(tx, models.AuditEntityApprovers, strconv.Itoa(objectTypeID), models.AuditActionUpdate, before, after); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// IsApprover reports whether the profile may approve versions of the object type
+func (r *ApprovalRepository) IsApprover(objectTypeID, profileID int) (bool, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM ObjectTypeApprovers WHERE ObjectTypeID = @p1 AND ProfileID = @p2`,
+		objectTypeID, profileID).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("error checking approver: %w", err)
+	}
+	return count > 0, nil
+}
+
+// GetApprovalState retrieves the approval state of an object's current version
+func (r *ApprovalRepository) GetApprovalState(objectID uuid.UUID) (*models.ApprovalState, error) {
+	return getApprovalState(r.db, objectID, "")
+}
+
+// getApprovalState retrieves the approval state of an object's current
+// version with a database or transaction, reading the object row with the
+// given table hint. Objects in the recycle bin are not found.
+func getApprovalState(q dbQuerier, objectID uuid.UUID, hint string) (*models.ApprovalState, error) {
+	query := `
+		SELECT o.ExactObjectTypeID, o.CurrentVersionId, o.IsCheckedOut,
+			CAST(CASE WHEN v.ApprovalStatus = dbo.const_ApprovalStatus_PendingApproval() THEN 1 ELSE 0 END AS BIT),
+			CAST(CASE WHEN v.ApprovalStatus = dbo.const_ApprovalStatus_Approved() THEN 1 ELSE 0 END AS BIT),
+			CAST(CASE WHEN v.ApprovalStatus = dbo.const_ApprovalStatus_Rejected() THEN 1 ELSE 0 END AS BIT),
+			CAST(CASE WHEN EXISTS (SELECT 1 FROM ObjectTypeApprovers AS ota WHERE ota.ObjectTypeID = o.ExactObjectTypeID) THEN 1 ELSE 0 END AS BIT),
+			CASE WHEN v.ApprovalStatus = dbo.const_ApprovalStatus_PendingApproval() THEN ISNULL(sub.UserID, v.ModifiedBy) END
+		FROM [Object] AS o ` + hint + `
+		INNER JOIN [Version] AS v ON v.ID = o.CurrentVersionId
+		OUTER APPLY (
+			SELECT TOP 1 va.UserID
+			FROM VersionApprovals AS va
+			WHERE va.VersionID = v.ID AND va.Action = @p2
+			ORDER BY va.DateCreated DESC
+		) AS sub
+		WHERE o.ObjectID = @p1 AND ISNULL(o.DeleteFlag, 0) = 0
+	`
+
+	state := &models.ApprovalState{ObjectID: objectID}
+	dbID, _ := TransformUUID(objectID)
+	var versionIDBytes []byte
+	err := q.QueryRow(query, dbID, models.ApprovalActionSubmitted).Scan(
+		&state.ExactObjectTypeID, &versionIDBytes, &state.IsCheckedOut,
+		&state.IsPending, &state.IsApproved, &state.IsRejected, &state.IsGoverned,
+		&state.SubmittedBy,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving approval state: %w", err)
+	}
+	if state.CurrentVersionID, err = parseSQLServerUUID(versionIDBytes); err != nil {
+		return nil, fmt.Errorf("error parsing CurrentVersionId: %w", err)
+	}
+
+	return state, nil
+}
+
+// promoteApprovedSql makes the current version of object @p1 its checked-in
+// version, as user @p2
+const promoteApprovedSql = `
+	UPDATE [Object] SET CheckedInVersionId = CurrentVersionId, DateModified = GETDATE(), ModifiedBy = @p2
+	WHERE ObjectID = @p1
+`
+
+// revertRejectedSql returns object @p1 to its checked-in version, as user
+// @p2. The rejected version stays in the version history.
+const revertRejectedSql = `
+	UPDATE o SET
+		CurrentVersionId = ci.ID,
+		ObjectName = ci.ObjectName,
+		ObjectDescription = ci.ObjectDescription,
+		RichTextDescription = ci.RichTextDescription,
+		DateModified = GETDATE(),
+		ModifiedBy = @p2
+	FROM [Object] AS o
+	INNER JOIN [Version] AS ci ON ci.ID = o.CheckedInVersionId
+	WHERE o.ObjectID = @p1 AND o.CurrentVersionId <> o.CheckedInVersionId
+`
+
+// approvalAuditActions maps each approval action to the action it is
+// audited as
+var approvalAuditActions = map[string]string{
+	models.ApprovalActionSubmitted: models.AuditActionSubmit,
+	models.ApprovalActionApproved:  models.AuditActionApprove,
+	models.ApprovalActionRejected:  models.AuditActionReject,
+}
+
+// Submit marks the object's current version as pending approval. check is
+// called with the object's approval state while the object is locked and
+// stops the submission if it returns an error.
+func (r *ApprovalRepository) Submit(objectID uuid.UUID, userID, profileID int, comment string, check func(*models.ApprovalState) error, changes *ChangeLog) error {
+	return r.setStatus(objectID, "dbo.const_ApprovalStatus_PendingApproval()", models.ApprovalActionSubmitted, "", userID, profileID, comment, check, changes)
+}
+
+// Approve approves the object's pending version and makes it the checked-in
+// version. check is called as for Submit.
+func (r *ApprovalRepository) Approve(objectID uuid.UUID, userID, profileID int, comment string, check func(*models.ApprovalState) error, changes *ChangeLog) error {
+	return r.setStatus(objectID, "dbo.const_ApprovalStatus_Approved()", models.ApprovalActionApproved, promoteApprovedSql, userID, profileID, comment, check, changes)
+}
+
+// Reject rejects the object's pending version and returns the object to its
+// checked-in version, if it has one. check is called as for Submit.
+func (r *ApprovalRepository) Reject(objectID uuid.UUID, userID, profileID int, comment string, check func(*models.ApprovalState) error, changes *ChangeLog) error {
+	return r.setStatus(objectID, "dbo.const_ApprovalStatus_Rejected()", models.ApprovalActionRejected, revertRejectedSql, userID, profileID, comment, check, changes)
+}
+
+// setStatus locks the object, checks its approval state, updates the current
+// version's ApprovalStatus, records the action and then runs then, if given,
+// with the object and user IDs. Approving and rejecting require the version
+// to still be pending approval.
+func (r *ApprovalRepository) setStatus(objectID uuid.UUID, status, action, then string, userID, profileID int, comment string, check func(*models.ApprovalState) error, changes *ChangeLog) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	state, err := getApprovalState(tx, objectID, "WITH (UPDLOCK)")
+	if err != nil {
+		return err
+	}
+	if err := check(state); err != nil {
+		return err
+	}
+
+	objectID, _ = TransformUUID(objectID)
+	before, err := getObject(tx, objectID)
+	if err != nil {
+		return err
+	}
+
+	requirePending := action != models.ApprovalActionSubmitted
+	if err := recordApproval(tx, objectID, status, requirePending, action, userID, profileID, comment); err != nil {
+		return err
+	}
+
+	if then != "" {
+		if _, err = tx.Exec(then, objectID, userID); err != nil {
+			return fmt.Errorf("error applying %s version: %w", action, err)
+		}
+	}
+	if err := recordObject(tx, changes, objectID, approvalAuditActions[action], before); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// recordApproval sets the current version's ApprovalStatus to the given status
+// expression and writes a VersionApprovals row. With requirePending it returns
+// ErrNotPendingApproval unless the version is pending approval. objectID must
+// be in SQL Server byte order.
+func recordApproval(tx *sql.Tx, objectID uuid.UUID, status string, requirePending bool, action string, userID, profileID int, comment string) error {
+	query := `
+		UPDATE v SET ApprovalStatus = ` + status + `, DateModified = GETDATE(), ModifiedBy = @p2
+		FROM [Version] AS v
+		INNER JOIN [Object] AS o ON o.CurrentVersionId = v.ID
+		WHERE o.ObjectID = @p1
+	`
+	if requirePending {
+		query += ` AND v.ApprovalStatus = dbo.const_ApprovalStatus_PendingApproval()`
+	}
+	result, err := tx.Exec(query, objectID, userID)
+	if err != nil {
+		return fmt.Errorf("error updating approval status: %w", err)
+	}
+	if requirePending {
+		n, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error updating approval status: %w", err)
+		}
+		if n == 0 {
+			return ErrNotPendingApproval
+		}
+	}
+
+	var commentValue interface{}
+	if comment != "" {
+		commentValue = comment
+	}
+	_, err = tx.Exec(`
+		INSERT INTO VersionApprovals (ObjectID, VersionID, Action, Comment, ProfileID, UserID, DateCreated)
+		SELECT ObjectID, CurrentVersionId, @p2, @p3, @p4, @p5, GETDATE()
+		FROM [Object]
+		WHERE ObjectID = @p1
+	`, objectID, action, commentValue, profileID, userID)
+	if err != nil {
+		return fmt.Errorf("error recording approval history: %w", err)
+	}
+
+	return nil
+}
+
+// GetHistory retrieves the approval history of an object, newest first
+func (r *ApprovalRepository) GetHistory(objectID uuid.UUID) ([]models.ApprovalHistoryEntry, error) {
+	query := `
+		SELECT ApprovalID, ObjectID, VersionID, Action, Comment, ProfileID, UserID, DateCreated
+		FROM VersionApprovals
+		WHERE ObjectID = @p1
+		ORDER BY DateCreated DESC, ApprovalID DESC
+	`
+
+	objectID, _ = TransformUUID(objectID)
+	rows, err := r.db.Query(query, objectID)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving approval history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []models.ApprovalHistoryEntry{}
+	for rows.Next() {
+		var entry models.ApprovalHistoryEntry
+		var objectIDBytes, versionIDBytes []byte
+		err := rows.Scan(&entry.ApprovalID, &objectIDBytes, &versionIDBytes, &entry.Action, &entry.Comment,
+			&entry.ProfileID, &entry.UserID, &entry.DateCreated)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning approval history: %w", err)
+		}
+		if entry.ObjectID, err = parseSQLServerUUID(objectIDBytes); err != nil {
+			return nil, fmt.Errorf("error parsing ObjectID: %w", err)
+		}
+		if entry.VersionID, err = parseSQLServerUUID(versionIDBytes); err != nil {
+			return nil, fmt.Errorf("error parsing VersionID: %w", err)
+		}
+		history = append(history, entry)
+	}
+
+	return history, rows.Err()
+}
+
+// GetPendingForProfile retrieves versions pending approval for object types the profile approves
+func (r *ApprovalRepository) GetPendingForProfile(profileID int) ([]models.PendingApproval, error) {
+	query := `
+		SELECT o.ObjectID, o.ObjectName, o.ExactObjectTypeID, v.ID, v.SystemVersionNo, v.CheckInReason,
+			ISNULL(sub.UserID, v.ModifiedBy), ISNULL(sub.DateCreated, v.DateModified)
+		FROM [Object] AS o
+		INNER JOIN [Version] AS v ON v.ID = o.CurrentVersionId
+		INNER JOIN ObjectTypeApprovers AS ota ON ota.ObjectTypeID = o.ExactObjectTypeID AND ota.ProfileID = @p1
+		OUTER APPLY (
+			SELECT TOP 1 va.UserID, va.DateCreated
+			FROM VersionApprovals AS va
+			WHERE va.VersionID = v.ID AND va.Action = @p2
+			ORDER BY va.DateCreated DESC
+		) AS sub
+		WHERE v.ApprovalStatus = dbo.const_ApprovalStatus_PendingApproval() AND ISNULL(o.DeleteFlag, 0) = 0
+		ORDER BY ISNULL(sub.DateCreated, v.DateModified)
+	`
+
+	rows, err := r.db.Query(query, profileID, models.ApprovalActionSubmitted)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving pending approvals: %w", err)
+	}
+	defer rows.Close()
+
+	pending := []models.PendingApproval{}
+	for rows.Next() {
+		var item models.PendingApproval
+		var objectIDBytes, versionIDBytes []byte
+		err := rows.Scan(&objectIDBytes, &item.ObjectName, &item.ExactObjectTypeID, &versionIDBytes,
+			&item.SystemVersionNo, &item.CheckInReason, &item.SubmittedBy, &item.DateSubmitted)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning pending approval: %w", err)
+		}
+		if item.ObjectID, err = parseSQLServerUUID(objectIDBytes); err != nil {
+			return nil, fmt.Errorf("error parsing ObjectID: %w", err)
+		}
+		if item.VersionID, err = parseSQLServerUUID(versionIDBytes); err != nil {
+			return nil, fmt.Errorf("error parsing VersionID: %w", err)
+		}
+		pending = append(pending, item)
+	}
+
+	return pending, rows.Err()
+}
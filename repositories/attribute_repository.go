@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"enterprise-architect-api/models"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,7 +19,7 @@ func NewAttributeRepository(db *sql.DB) *AttributeRepository {
 	return &AttributeRepository{db: db}
 }
 
-func (r *AttributeRepository) GetAttributeForObject(objectID uuid.UUID, objectTypeId *int) (*models.ObjectInstanceAttribute, error) {
+func (r *AttributeRepository) GetAttributeForObject(objectID uuid.UUID, objectTypeId *int, profileID int) (*models.ObjectInstanceAttribute, error) {
 	sql := `SELECT attr.AttributeId,
 			attr.objectId,
 			attr.versionId,
@@ -33,14 +34,14 @@ func (r *AttributeRepository) GetAttributeForObject(objectID uuid.UUID, objectTy
 			att.AttributeType,
 			att.IsMandatory
 			FROM [vwAttributeValue] AS attr 
-			INNER JOIN [AttributePermissions] AS attrPerm1 ON  attrPerm1.AttributeId = attr.AttributeId AND attrPerm1.ProfileId = 1 AND attrPerm1.HasRead = 1
+			INNER JOIN [AttributePermissions] AS attrPerm1 ON  attrPerm1.AttributeId = attr.AttributeId AND attrPerm1.ProfileId = @p2 AND attrPerm1.HasRead = 1
 			inner join Attribute att on att.AttributeId = attr.AttributeId
 			AND attr.objectId = @p1
 `
 	fmt.Println("UUID:", objectID.String())
 	objectID, _ = TransformUUID(objectID)
 	fmt.Println("UUID:", objectID.String())
-	rows, err := r.db.Query(sql, objectID)
+	rows, err := r.db.Query(sql, objectID, profileID)
 	if err != nil {
 		return nil, fmt.Errorf("error executing query: %w", err)
 	}
@@ -154,7 +155,13 @@ func (r *AttributeRepository) ExistsByName(name string) (bool, error) {
 }
 
 // Create creates a new attribute
-func (r *AttributeRepository) Create(attribute *models.Attribute) error {
+func (r *AttributeRepository) Create(attribute *models.Attribute, changes *ChangeLog) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
 		INSERT INTO Attribute (
 			AttributeId, AttributeName, AttributeType, IsMandatory, IsSynchronised,
@@ -170,7 +177,7 @@ func (r *AttributeRepository) Create(attribute *models.Attribute) error {
 		)
 	`
 
-	_, err := r.db.Exec(query,
+	_, err = tx.Exec(query,
 		attribute.AttributeId, attribute.AttributeName, attribute.AttributeType,
 		attribute.IsMandatory, attribute.IsSynchronised, attribute.VisioSyncName,
 		attribute.Description, attribute.TooltipText, attribute.TextDefaultValue,
@@ -185,12 +192,23 @@ func (r *AttributeRepository) Create(attribute *models.Attribute) error {
 	if err != nil {
 		return fmt.Errorf("error creating attribute: %w", err)
 	}
+	if err := changes.Record(tx, models.AuditEntityAttribute, attribute.AttributeId.String(), models.AuditActionCreate, nil, attribute); err != nil {
+		return err
+	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
 	return nil
 }
 
 // GetByID retrieves an attribute by its ID
 func (r *AttributeRepository) GetByID(id string) (*models.Attribute, error) {
+	return getAttribute(r.db, id)
+}
+
+// getAttribute retrieves an attribute by its ID with a database or transaction
+func getAttribute(q dbQuerier, id string) (*models.Attribute, error) {
 	query := `
 		SELECT AttributeId, AttributeName, AttributeType, IsMandatory, IsSynchronised,
 			VisioSyncName, Description, TooltipText, TextDefaultValue, TextRowCount,
@@ -203,7 +221,7 @@ func (r *AttributeRepository) GetByID(id string) (*models.Attribute, error) {
 	`
 
 	attribute := &models.Attribute{}
-	err := r.db.QueryRow(query, id).Scan(
+	err := q.QueryRow(query, id).Scan(
 		&attribute.AttributeId, &attribute.AttributeName, &attribute.AttributeType,
 		&attribute.IsMandatory, &attribute.IsSynchronised, &attribute.VisioSyncName,
 		&attribute.Description, &attribute.TooltipText, &attribute.TextDefaultValue,
@@ -226,40 +244,52 @@ func (r *AttributeRepository) GetByID(id string) (*models.Attribute, error) {
 }
 
 // GetAll retrieves all attributes with pagination
-func (r *AttributeRepository) GetAll(page, pageSize int) ([]models.Attribute, int, error) {
-	offset := (page - 1) * pageSize
+func (r *AttributeRepository) GetAll(req models.PageRequest) ([]models.Attribute, models.PageInfo, error) {
+	info := models.PageInfo{TotalCount: -1}
+	k, err := newKeyset([]keysetKey{{"AttributeName", false}, {"AttributeId", false}}, "attributes", req)
+	if err != nil {
+		return nil, info, err
+	}
 
 	// Get total count
-	var totalCount int
-	countQuery := `SELECT COUNT(*) FROM Attribute`
-	err := r.db.QueryRow(countQuery).Scan(&totalCount)
-	if err != nil {
-		return nil, 0, fmt.Errorf("error counting attributes: %w", err)
+	if !req.SkipCount {
+		countQuery := `SELECT COUNT(*) FROM Attribute`
+		if err := r.db.QueryRow(countQuery).Scan(&info.TotalCount); err != nil {
+			return nil, info, fmt.Errorf("error counting attributes: %w", err)
+		}
 	}
 
 	// Get paginated results
+	var args queryArgs
+	where := ""
+	if cond := k.condition(args.param); cond != "" {
+		where = "WHERE " + cond
+	}
 	query := `
 		SELECT AttributeId, AttributeName, AttributeType, IsMandatory, IsSynchronised,
 			VisioSyncName, Description, TooltipText, TextDefaultValue, TextRowCount,
 			IntDefaultValue, IntLowerLimit, IntUpperLimit, FloatDefaultValue, FloatLowerLimit,
 			FloatUpperLimit, DateDefaultValue, BoolDefaultValue, AutoIdPrefix, AutoIdSuffix,
 			AutoIdPadding, AutoIdStartValue, AutoIdNextValue, ListDefaultValue, ListType,
-			ListValues, IsCalculated
+			ListValues, IsCalculated, ` + k.columns() + `
 		FROM Attribute
-		ORDER BY AttributeName
-		OFFSET @p1 ROWS FETCH NEXT @p2 ROWS ONLY
+		` + where + `
+		ORDER BY ` + k.orderBy() + `
+		OFFSET ` + args.param(k.offset(req)) + ` ROWS FETCH NEXT ` + args.param(req.PageSize+1) + ` ROWS ONLY
 	`
 
-	rows, err := r.db.Query(query, offset, pageSize)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("error retrieving attributes: %w", err)
+		return nil, info, fmt.Errorf("error retrieving attributes: %w", err)
 	}
 	defer rows.Close()
 
 	var attributes []models.Attribute
+	var sortValues [][]interface{}
 	for rows.Next() {
 		var attribute models.Attribute
-		err := rows.Scan(
+		values, keyDests := scanKeys(2)
+		err := rows.Scan(append([]interface{}{
 			&attribute.AttributeId, &attribute.AttributeName, &attribute.AttributeType,
 			&attribute.IsMandatory, &attribute.IsSynchronised, &attribute.VisioSyncName,
 			&attribute.Description, &attribute.TooltipText, &attribute.TextDefaultValue,
@@ -269,19 +299,24 @@ func (r *AttributeRepository) GetAll(page, pageSize int) ([]models.Attribute, in
 			&attribute.AutoIdPrefix, &attribute.AutoIdSuffix, &attribute.AutoIdPadding,
 			&attribute.AutoIdStartValue, &attribute.AutoIdNextValue, &attribute.ListDefaultValue,
 			&attribute.ListType, &attribute.ListValues, &attribute.IsCalculated,
-		)
+		}, keyDests...)...)
 		if err != nil {
-			return nil, 0, fmt.Errorf("error scanning attribute: %w", err)
+			return nil, info, fmt.Errorf("error scanning attribute: %w", err)
 		}
 		attribute.AttributeId, _ = TransformUUID(attribute.AttributeId)
 		attributes = append(attributes, attribute)
+		sortValues = append(sortValues, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, info, fmt.Errorf("error iterating attributes: %w", err)
 	}
 
-	return attributes, totalCount, nil
+	attributes, err = keysetPage(k, req, attributes, sortValues, &info)
+	return attributes, info, err
 }
 
 // Update updates an existing attribute
-func (r *AttributeRepository) Update(id string, attribute *models.Attribute) error {
+func (r *AttributeRepository) Update(id string, attribute *models.Attribute, changes *ChangeLog) error {
 	// Build dynamic update query
 	var setClauses []string
 	var args []interface{}
@@ -394,18 +429,52 @@ func (r *AttributeRepository) Update(id string, attribute *models.Attribute) err
 	args = append(args, id)
 	query := fmt.Sprintf("UPDATE Attribute SET %s WHERE AttributeId = @p%d", strings.Join(setClauses, ", "), argIndex)
 
-	_, err := r.db.Exec(query, args...)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := getAttribute(tx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(query, args...)
 	if err != nil {
 		return fmt.Errorf("error updating attribute: %w", err)
 	}
+	if changes != nil {
+		after, err := getAttribute(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := changes.Record(tx, models.AuditEntityAttribute, id, models.AuditActionUpdate, before, after); err != nil {
+			return err
+		}
+	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
 	return nil
 }
 
 // Delete deletes an attribute by its ID
-func (r *AttributeRepository) Delete(id string) error {
+func (r *AttributeRepository) Delete(id string, changes *ChangeLog) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := getAttribute(tx, id)
+	if err != nil {
+		return err
+	}
+
 	query := `DELETE FROM Attribute WHERE AttributeId = @p1`
-	result, err := r.db.Exec(query, id)
+	result, err := tx.Exec(query, id)
 	if err != nil {
 		return fmt.Errorf("error deleting attribute: %w", err)
 	}
@@ -418,12 +487,18 @@ func (r *AttributeRepository) Delete(id string) error {
 	if rowsAffected == 0 {
 		return fmt.Errorf("attribute not found")
 	}
+	if err := changes.Record(tx, models.AuditEntityAttribute, id, models.AuditActionDelete, before, nil); err != nil {
+		return err
+	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
 	return nil
 }
 
 // AssignAttributeToObjectType assigns an attribute to an object type
-func (r *AttributeRepository) AssignAttributeToObjectType(req *models.AssignAttributeToObjectTypeRequest) error {
+func (r *AttributeRepository) AssignAttributeToObjectType(req *models.AssignAttributeToObjectTypeRequest, changes *ChangeLog) error {
 	// Start transaction
 	tx, err := r.db.Begin()
 	if err != nil {
@@ -543,6 +618,10 @@ func (r *AttributeRepository) AssignAttributeToObjectType(req *models.AssignAttr
 	if err != nil {
 		return fmt.Errorf("error inserting attribute assigned: %w", err)
 	}
+	if err := changes.Record(tx, models.AuditEntityAttributeAssignment, attributeAssignmentID(req.ObjectTypeId, req.AttributeId),
+		models.AuditActionCreate, nil, req); err != nil {
+		return err
+	}
 
 	// Commit transaction
 	if err = tx.Commit(); err != nil {
@@ -618,8 +697,49 @@ func (r *AttributeRepository) GetAttributeAssignments(objectTypeId int, relation
 	return assignments, nil
 }
 
+// GetAssignedAttributeDefinitions retrieves the type and list settings of
+// every attribute assigned to an object type. Attribute IDs are returned as
+// stored, matching GetAttributeAssignments.
+func (r *AttributeRepository) GetAssignedAttributeDefinitions(objectTypeId int) ([]models.ObjectTypeAssignedAttribute, error) {
+	query := `
+        SELECT DISTINCT a.AttributeId,
+            a.AttributeName,
+            a.AttributeType,
+            a.listType,
+            a.ListValues
+        FROM vwAttribute a
+        JOIN AttributeAssigned aa (NOLOCK)
+            ON a.AttributeId = aa.AttributeId
+        WHERE aa.ObjectTypeId = @p1
+    `
+
+	rows, err := r.db.Query(query, objectTypeId)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	var definitions []models.ObjectTypeAssignedAttribute
+	for rows.Next() {
+		var definition models.ObjectTypeAssignedAttribute
+		if err := rows.Scan(
+			&definition.AttributeId,
+			&definition.AttributeName,
+			&definition.AttributeType,
+			&definition.ListType,
+			&definition.ListValues,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning attribute definition: %w", err)
+		}
+		definition.ObjectTypeId = objectTypeId
+		definitions = append(definitions, definition)
+	}
+
+	return definitions, rows.Err()
+}
+
 // UnassignAttributeFromObjectType removes an attribute assignment from an object type
-func (r *AttributeRepository) UnassignAttributeFromObjectType(req *models.UnassignAttributeFromObjectTypeRequest) error {
+func (r *AttributeRepository) UnassignAttributeFromObjectType(req *models.UnassignAttributeFromObjectTypeRequest, changes *ChangeLog) error {
 	// Start transaction
 	tx, err := r.db.Begin()
 	if err != nil {
@@ -632,6 +752,7 @@ func (r *AttributeRepository) UnassignAttributeFromObjectType(req *models.Unassi
 	fmt.Println("req.AttributeGroupId : ", req.AttributeGroupId)
 	fmt.Println("req.ObjectTypeId : ", req.ObjectTypeId)
 	fmt.Println("req.RelationTypeId : ", req.RelationTypeId)
+	before := *req
 	req.AttributeId, _ = TransformUUID(req.AttributeId)
 	req.AttributeGroupId, _ = TransformUUID(req.AttributeGroupId)
 	// Delete from AttributeAssigned
@@ -697,6 +818,10 @@ func (r *AttributeRepository) UnassignAttributeFromObjectType(req *models.Unassi
 			return fmt.Errorf("error deleting attribute group: %w", err)
 		}
 	}
+	if err := changes.Record(tx, models.AuditEntityAttributeAssignment, attributeAssignmentID(before.ObjectTypeId, before.AttributeId),
+		models.AuditActionDelete, before, nil); err != nil {
+		return err
+	}
 
 	// Commit transaction
 	if err = tx.Commit(); err != nil {
@@ -706,14 +831,61 @@ func (r *AttributeRepository) UnassignAttributeFromObjectType(req *models.Unassi
 	return nil
 }
 
-// UpdateAttributeValue updates the values of multiple attributes
-func (r *AttributeRepository) UpdateAttributeValue(attrs []models.AssignedAttribute) error {
+// attributeAssignmentID identifies the assignment of an attribute to an
+// object type in the audit trail
+func attributeAssignmentID(objectTypeID int, attributeID uuid.UUID) string {
+	return strconv.Itoa(objectTypeID) + "/" + attributeID.String()
+}
+
+// UpdateAttributeValue updates the values of multiple attributes, after
+// locking each object they belong to and checking the user may edit it. Values
+// may only be written to an object's current version.
+func (r *AttributeRepository) UpdateAttributeValue(attrs []models.AssignedAttribute, userID int, changes *ChangeLog) error {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return fmt.Errorf("error starting transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	locked := make(map[uuid.UUID]bool)
+	for _, attr := range attrs {
+		objectID, _ := TransformUUID(attr.ObjectId)
+		if !locked[attr.ObjectId] {
+			if err := lockForEdit(tx, objectID, userID); err != nil {
+				return err
+			}
+			locked[attr.ObjectId] = true
+		}
+
+		versionID, _ := TransformUUID(attr.VersionId)
+		var current bool
+		err := tx.QueryRow(`
+			SELECT CAST(CASE WHEN EXISTS (
+				SELECT 1 FROM [Object] WHERE ObjectID = @p1 AND CurrentVersionId = @p2
+			) THEN 1 ELSE 0 END AS BIT)
+		`, objectID, versionID).Scan(&current)
+		if err != nil {
+			return fmt.Errorf("error checking object version: %w", err)
+		}
+		if !current {
+			return ErrNotCurrentVersion
+		}
+	}
+
+	if err := r.UpdateAttributeValueTx(tx, attrs, userID, changes); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateAttributeValueTx updates the values of multiple attributes within the
+// caller's transaction, recording each value in changes
+func (r *AttributeRepository) UpdateAttributeValueTx(tx *sql.Tx, attrs []models.AssignedAttribute, userID int, changes *ChangeLog) error {
 	query := `
 	IF EXISTS (
 		SELECT 1 
@@ -778,7 +950,7 @@ func (r *AttributeRepository) UpdateAttributeValue(attrs []models.AssignedAttrib
             ELSE NULL
         END,
         CASE WHEN @p10 = 3 THEN @p4 ELSE NULL END,
-        CASE WHEN @p10 = 4 THEN @p5 ELSE NULL END,
+        CASE WHEN @p10 = 2 THEN @p5 ELSE NULL END,
         CASE WHEN @p10 = 6 THEN @p6 ELSE NULL END,
 		CURRENT_TIMESTAMP,
 		@p11,
@@ -787,13 +959,20 @@ func (r *AttributeRepository) UpdateAttributeValue(attrs []models.AssignedAttrib
     );
 END;
 	`
-	fmt.Println("attrs : ", attrs[0].TextValue)
 	for _, attr := range attrs {
 		// Transform UUIDs
 		attributeID, _ := TransformUUIDToSQLServerV2(attr.AttributeID)
 		objectID, _ := TransformUUID(attr.ObjectId)
 		versionID, _ := TransformUUID(attr.VersionId)
 
+		var before *models.AssignedAttribute
+		if changes != nil {
+			var err error
+			if before, err = getAttributeValue(tx, attr, objectID, versionID, attributeID); err != nil {
+				return err
+			}
+		}
+
 		var boolVal interface{}
 		var attrDataType int
 		if attr.BooleanValue != nil {
@@ -802,6 +981,7 @@ END;
 			} else {
 				boolVal = 0
 			}
+			attrDataType = 5
 		}
 		var textValue string
 		if attr.TextValue != nil {
@@ -840,17 +1020,78 @@ END;
 			objectID,
 			versionID,
 			attrDataType,
-			62,
+			userID,
 		)
 
 		if err != nil {
 			return fmt.Errorf("error updating attribute value for AttributeId %s: %w", attr.AttributeID, err)
 		}
-	}
 
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("error committing transaction: %w", err)
+		if changes != nil {
+			after, err := getAttributeValue(tx, attr, objectID, versionID, attributeID)
+			if err != nil {
+				return err
+			}
+			entityID := attr.ObjectId.String() + "/" + attr.AttributeID.String()
+			if err := changes.Record(tx, models.AuditEntityAttributeValue, entityID, models.AuditActionUpdate, before, after); err != nil {
+				return err
+			}
+			err = changes.Publish(tx, models.WebhookEvent{
+				EntityType: models.WebhookEntityAttributeValue,
+				EntityId:   entityID,
+				Action:     models.AuditActionUpdate,
+				ObjectId:   attr.ObjectId,
+				Data:       after,
+			})
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
 }
+
+// getAttributeValue retrieves the stored value of attr on a database or
+// transaction, or nil when none is stored. The snapshot carries the IDs of
+// attr; dbObjectID, dbVersionID and dbAttributeID are the same IDs in SQL
+// Server byte order.
+func getAttributeValue(q dbQuerier, attr models.AssignedAttribute, dbObjectID, dbVersionID, dbAttributeID uuid.UUID) (*models.AssignedAttribute, error) {
+	value := &models.AssignedAttribute{
+		AttributeID: attr.AttributeID,
+		ObjectId:    attr.ObjectId,
+		VersionId:   attr.VersionId,
+	}
+	err := q.QueryRow(`
+		SELECT attr.textValue, attr.booleanValue, attr.dateValue, attr.floatValue, attr.intValue, attr.richTextValue
+		FROM [vwAttributeValue] AS attr
+		WHERE attr.objectId = @p1 AND attr.versionId = @p2 AND attr.AttributeId = @p3
+	`, dbObjectID, dbVersionID, dbAttributeID).Scan(&value.TextValue, &value.BooleanValue, &value.DateValue,
+		&value.FloatValue, &value.IntegerValue, &value.RichTextValue)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving attribute value: %w", err)
+	}
+	return value, nil
+}
+
+// GetReadableAttributeIDs returns the IDs of the attributes the profile may read
+func (r *AttributeRepository) GetReadableAttributeIDs(profileID int) (map[uuid.UUID]bool, error) {
+	rows, err := r.db.Query(`SELECT AttributeId FROM [AttributePermissions] WHERE ProfileId = @p1 AND HasRead = 1`, profileID)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving attribute permissions: %w", err)
+	}
+	defer rows.Close()
+
+	readable := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var attributeID uuid.UUID
+		if err := rows.Scan(&attributeID); err != nil {
+			return nil, fmt.Errorf("error scanning attribute permission: %w", err)
+		}
+		readable[attributeID] = true
+	}
+	return readable, rows.Err()
+}
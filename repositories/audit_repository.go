@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"enterprise-architect-api/models"
+	"fmt"
+	"strings"
+)
+
+// AuditRepository handles database operations for the audit trail
+type AuditRepository struct {
+	db *sql.DB
+}
+
+// NewAuditRepository creates a new AuditRepository
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// insertAuditEvent appends an event to the audit trail in the transaction of
+// the change it records
+func insertAuditEvent(tx *sql.Tx, event *models.AuditEvent) error {
+	err := tx.QueryRow(`
+		INSERT INTO AuditEvents (EntityType, EntityId, Action, Before, After, UserID, ProfileID, RequestID, Timestamp)
+		OUTPUT INSERTED.EventId, INSERTED.Timestamp
+		VALUES (@p1, @p2, @p3, @p4, @p5, @p6, @p7, @p8, SYSUTCDATETIME())
+	`, event.EntityType, event.EntityId, event.Action, nullableJSON(event.Before), nullableJSON(event.After),
+		event.UserId, event.ProfileId, sql.NullString{String: event.RequestId, Valid: event.RequestId != ""},
+	).Scan(&event.EventId, &event.Timestamp)
+	if err != nil {
+		return fmt.Errorf("error creating audit event: %w", err)
+	}
+	return nil
+}
+
+// List retrieves a page of the audit events matching the query, newest first
+func (r *AuditRepository) List(q models.AuditQuery) ([]models.AuditEvent, models.PageInfo, error) {
+	info := models.PageInfo{TotalCount: -1}
+	k, err := newKeyset([]keysetKey{{"EventId", true}}, "audit", q.PageRequest)
+	if err != nil {
+		return nil, info, err
+	}
+
+	var args queryArgs
+	conds := []string{"1 = 1"}
+	if q.EntityType != "" {
+		conds = append(conds, "EntityType = "+args.param(q.EntityType))
+	}
+	if q.EntityId != "" {
+		conds = append(conds, "EntityId = "+args.param(q.EntityId))
+	}
+	if q.UserId != nil {
+		conds = append(conds, "UserID = "+args.param(*q.UserId))
+	}
+	if q.From != nil {
+		conds = append(conds, "Timestamp >= "+args.param(q.From.UTC()))
+	}
+	if q.To != nil {
+		conds = append(conds, "Timestamp < "+args.param(q.To.UTC()))
+	}
+	where := strings.Join(conds, " AND ")
+
+	if !q.SkipCount {
+		if err := r.db.QueryRow(`SELECT COUNT(*) FROM AuditEvents WHERE `+where, args...).Scan(&info.TotalCount); err != nil {
+			return nil, info, fmt.Errorf("error counting audit events: %w", err)
+		}
+	}
+
+	if cond := k.condition(args.param); cond != "" {
+		where += " AND " + cond
+	}
+	query := `
+		SELECT EventId, EntityType, EntityId, Action, Before, After, UserID, ProfileID, RequestID, Timestamp, ` + k.columns() + `
+		FROM AuditEvents
+		WHERE ` + where + `
+		ORDER BY ` + k.orderBy() + `
+		OFFSET ` + args.param(k.offset(q.PageRequest)) + ` ROWS FETCH NEXT ` + args.param(q.PageSize+1) + ` ROWS ONLY
+	`
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, info, fmt.Errorf("error retrieving audit events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []models.AuditEvent{}
+	var sortValues [][]interface{}
+	for rows.Next() {
+		var event models.AuditEvent
+		var before, after, requestID sql.NullString
+		values, keyDests := scanKeys(1)
+		err := rows.Scan(append([]interface{}{
+			&event.EventId, &event.EntityType, &event.EntityId, &event.Action, &before, &after,
+			&event.UserId, &event.ProfileId, &requestID, &event.Timestamp,
+		}, keyDests...)...)
+		if err != nil {
+			return nil, info, fmt.Errorf("error scanning audit event: %w", err)
+		}
+		if before.Valid {
+			event.Before = json.RawMessage(before.String)
+		}
+		if after.Valid {
+			event.After = json.RawMessage(after.String)
+		}
+		event.RequestId = requestID.String
+		events = append(events, event)
+		sortValues = append(sortValues, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, info, fmt.Errorf("error iterating audit events: %w", err)
+	}
+
+	events, err = keysetPage(k, q.PageRequest, events, sortValues, &info)
+	return events, info, err
+}
+
+// nullableJSON stores empty JSON as NULL
+func nullableJSON(raw json.RawMessage) sql.NullString {
+	return sql.NullString{String: string(raw), Valid: len(raw) > 0}
+}
@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"enterprise-architect-api/models"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// dbQuerier is a database or a transaction. Getters that take one are used
+// both for reads and for the snapshots a change log takes inside a change.
+type dbQuerier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// ChangeLog writes the audit events and webhook events of the changes made by
+// one request or background job. Repositories write to it in the transaction
+// of the change, so that a change is saved together with its events or not at
+// all. A nil ChangeLog writes nothing.
+type ChangeLog struct {
+	UserID    int
+	ProfileID int
+	RequestID string
+}
+
+// NewChangeLog creates a ChangeLog for the changes a user makes
+func NewChangeLog(userID, profileID int, requestID string) *ChangeLog {
+	return &ChangeLog{UserID: userID, ProfileID: profileID, RequestID: requestID}
+}
+
+// Record appends an audit event for a change to the change's transaction,
+// with before and after, either of which may be nil, as its JSON snapshots
+func (l *ChangeLog) Record(tx *sql.Tx, entityType, entityID, action string, before, after interface{}) error {
+	if l == nil {
+		return nil
+	}
+	event := models.AuditEvent{
+		EntityType: entityType,
+		EntityId:   entityID,
+		Action:     action,
+		UserId:     l.UserID,
+		ProfileId:  l.ProfileID,
+		RequestId:  l.RequestID,
+	}
+	var err error
+	if event.Before, err = auditSnapshot(before); err != nil {
+		return err
+	}
+	if event.After, err = auditSnapshot(after); err != nil {
+		return err
+	}
+	return insertAuditEvent(tx, &event)
+}
+
+// Publish queues a webhook event for a change in the outbox of every
+// subscription it matches, in the change's transaction. The type and library
+// of the event's object are looked up unless the event carries them.
+func (l *ChangeLog) Publish(tx *sql.Tx, event models.WebhookEvent) error {
+	if l == nil {
+		return nil
+	}
+	event.EventId = uuid.New()
+	event.EventType = event.EntityType + "." + event.Action
+	event.UserId = l.UserID
+	event.RequestId = l.RequestID
+	event.OccurredAt = time.Now().UTC()
+	if event.ObjectTypeId == nil && event.LibraryId == nil && event.ObjectId != uuid.Nil {
+		var err error
+		if event.ObjectTypeId, event.LibraryId, err = objectScope(tx, event.ObjectId); err != nil {
+			return err
+		}
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error encoding webhook event: %w", err)
+	}
+	return enqueueWebhookEvent(tx, event, payload)
+}
+
+// auditSnapshot encodes an entity as JSON, or returns nil for a nil entity
+func auditSnapshot(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding audit snapshot: %w", err)
+	}
+	if string(raw) == "null" {
+		return nil, nil
+	}
+	return raw, nil
+}
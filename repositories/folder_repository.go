@@ -3,11 +3,24 @@ package repositories
 import (
 	"database/sql"
 	"enterprise-architect-api/models"
+	"errors"
 	"fmt"
 
 	"github.com/google/uuid"
 )
 
+var (
+	// ErrFolderNotFound is returned when an object does not exist or is not a
+	// folder
+	ErrFolderNotFound = errors.New("folder not found")
+	// ErrInvalidFolder is returned when a folder cannot be created, updated or
+	// reordered as requested
+	ErrInvalidFolder = errors.New("invalid folder")
+	// ErrFolderAutoSorted is returned when reordering a folder whose contents
+	// are sorted by name
+	ErrFolderAutoSorted = errors.New("folder contents are sorted automatically; turn off autoSort to reorder them")
+)
+
 // FolderRepository handles database operations for folders
 type FolderRepository struct {
 	db *sql.DB
@@ -264,3 +277,360 @@ func (r *FolderRepository) GetFoldersByLibrary(folderID uuid.UUID, profileID int
 
 	return contents, nil
 }
+
+// createFolderSql creates folder @p1 with version @p2, named @p3 with
+// description @p4, of folder type @p5 in library @p6 as user @p7, and places
+// it in the current version of container @p8. @p9 sets AutoSort.
+const createFolderSql = `
+	INSERT INTO [Version] (
+		ID, ObjectID, ObjectName, ObjectDescription, RichTextDescription,
+		SystemVersionNo, UserVersionNo, DateCreated, DateModified, ModifiedBy, CreatedBy
+	)
+	VALUES (@p2, @p1, @p3, @p4, '', 1, 'v1', GETDATE(), GETDATE(), @p7, @p7);
+
+	INSERT INTO [Object] (
+		ObjectID, ObjectName, ObjectDescription, ObjectTypeID, Locked, IsImported,
+		IsLibrary, LibraryId, DateCreated, CreatedBy, DateModified, ModifiedBy,
+		IsCheckedOut, ExactObjectTypeID, CurrentVersionId, CheckedInVersionId,
+		DeleteFlag, RichTextDescription, GeneralType, AutoSort
+	)
+	VALUES (
+		@p1, @p3, @p4, @p5, 0, 0,
+		0, @p6, GETDATE(), @p7, GETDATE(), @p7,
+		0, @p5, @p2, @p2,
+		0, '', dbo.const_GeneralType_Folder(), @p9
+	);
+
+	INSERT INTO ObjectContents (
+		DocumentObjectID, ContainerVersionID, ObjectID, Instances, IsShortCut,
+		ContainmentType, DateCreated, CreatedBy, DateModified, ModifiedBy
+	)
+	SELECT @p8, container.CurrentVersionId, @p1, 1, 0, 1, GETDATE(), @p7, GETDATE(), @p7
+	FROM [Object] AS container
+	WHERE container.ObjectID = @p8;
+`
+
+// updateFolderSql sets the name (@p2), description (@p3) and AutoSort (@p4)
+// of folder @p1 as user @p5, keeping the values that are NULL
+const updateFolderSql = `
+	UPDATE [Object] SET
+		ObjectName = ISNULL(@p2, ObjectName),
+		ObjectDescription = ISNULL(@p3, ObjectDescription),
+		AutoSort = ISNULL(@p4, AutoSort),
+		DateModified = GETDATE(),
+		ModifiedBy = @p5
+	WHERE ObjectID = @p1
+`
+
+// folderOrderSql selects the contents of container @p1 in display order: by
+// name when it sorts automatically, otherwise by sort order. Contents without
+// a sort order come last, oldest first.
+const folderOrderSql = `
+	SELECT fc.ObjectId
+	FROM vwFolderContents AS fc
+	INNER JOIN [Object] AS f ON f.ObjectID = fc.FolderId
+	INNER JOIN [Object] AS o ON o.ObjectID = fc.ObjectId
+	WHERE fc.FolderId = @p1
+		AND fc.IsDeleted = CAST(0 AS BIT)
+		AND ISNULL(o.DeleteFlag, 0) = 0
+	GROUP BY fc.ObjectId, f.AutoSort, o.ObjectName, o.SortOrder, o.DateCreated
+	ORDER BY CASE WHEN f.AutoSort = 1 THEN o.ObjectName END,
+		ISNULL(o.SortOrder, 2147483647), o.DateCreated
+`
+
+// IsFolder reports whether an object is a folder
+func (r *FolderRepository) IsFolder(id uuid.UUID) (bool, error) {
+	id, _ = TransformUUID(id)
+
+	var isFolder bool
+	err := r.db.QueryRow(`
+		SELECT CAST(CASE WHEN GeneralType = dbo.const_GeneralType_Folder() THEN 1 ELSE 0 END AS BIT)
+		FROM [Object]
+		WHERE ObjectID = @p1
+	`, id).Scan(&isFolder)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error retrieving folder: %w", err)
+	}
+	return isFolder, nil
+}
+
+// Create creates a folder in a folder or at the top of a library. The folder
+// type must be allowed in the parent's folder type, and the new folder takes
+// the requested position among the parent's contents unless the parent sorts
+// them by name.
+func (r *FolderRepository) Create(req models.CreateFolderRequest, userID int, changes *ChangeLog) (uuid.UUID, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var isFolderType bool
+	err = tx.QueryRow(`
+		SELECT CAST(CASE WHEN GeneralType = dbo.const_GeneralType_Folder() THEN 1 ELSE 0 END AS BIT)
+		FROM ObjectType
+		WHERE ObjectTypeID = @p1
+	`, req.ObjectTypeId).Scan(&isFolderType)
+	if err != nil && err != sql.ErrNoRows {
+		return uuid.Nil, fmt.Errorf("error retrieving object type: %w", err)
+	}
+	if !isFolderType {
+		return uuid.Nil, fmt.Errorf("%w: object type %d is not a folder type", ErrInvalidFolder, req.ObjectTypeId)
+	}
+
+	parent, err := lockPlacementTarget(tx, req.ParentId, req.LibraryId)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if err := checkTypeAllowed(tx, parent, req.ObjectTypeId); err != nil {
+		return uuid.Nil, err
+	}
+
+	id, versionID := uuid.New(), uuid.New()
+	if _, err := tx.Exec(createFolderSql, id, versionID, req.ObjectName, req.ObjectDescription,
+		req.ObjectTypeId, parent.libraryID, userID, parent.id, req.AutoSort); err != nil {
+		return uuid.Nil, fmt.Errorf("error creating folder: %w", err)
+	}
+
+	var arrange func([]uuid.UUID) ([]uuid.UUID, error)
+	if req.SortOrder != nil {
+		arrange = placeAt(id, *req.SortOrder)
+	}
+	if err := orderFolder(tx, parent.id, arrange); err != nil {
+		return uuid.Nil, err
+	}
+	if err := recordObject(tx, changes, id, models.AuditActionCreate, nil); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return uuid.Nil, fmt.Errorf("error committing folder: %w", err)
+	}
+	return id, nil
+}
+
+// Update renames a folder or changes its description or AutoSort. Turning
+// AutoSort on sorts the folder's contents by name, and a renamed folder is
+// re-sorted in its parent.
+func (r *FolderRepository) Update(id uuid.UUID, req models.UpdateFolderRequest, userID int, changes *ChangeLog) error {
+	id, _ = TransformUUID(id)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockFolder(tx, id); err != nil {
+		return err
+	}
+	if err := lockForEdit(tx, id, userID); err != nil {
+		return err
+	}
+	before, err := getObject(tx, id)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(updateFolderSql, id, req.ObjectName, req.ObjectDescription, req.AutoSort, userID); err != nil {
+		return fmt.Errorf("error updating folder: %w", err)
+	}
+	if _, err := tx.Exec(syncCurrentVersionSql, id); err != nil {
+		return fmt.Errorf("error updating folder version: %w", err)
+	}
+
+	if req.AutoSort != nil && *req.AutoSort {
+		if err := orderFolder(tx, id, nil); err != nil {
+			return err
+		}
+	}
+	if req.ObjectName != nil {
+		var parentBytes []byte
+		err := tx.QueryRow(currentFolderSql, id).Scan(&parentBytes)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("error retrieving parent folder: %w", err)
+		}
+		if parentBytes != nil {
+			parentID, err := parseSQLServerUUID(parentBytes)
+			if err != nil {
+				return fmt.Errorf("error parsing FolderId: %w", err)
+			}
+			if err := orderFolder(tx, parentID, nil); err != nil {
+				return err
+			}
+		}
+	}
+	if err := recordObject(tx, changes, id, models.AuditActionUpdate, before); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing folder update: %w", err)
+	}
+	return nil
+}
+
+// Reorder sets the order of a folder's or library's contents: the listed
+// objects first, in the order given, then the rest in their current order.
+// The orders before and after are recorded in changes.
+func (r *FolderRepository) Reorder(id uuid.UUID, objectIDs []uuid.UUID, changes *ChangeLog) error {
+	entityID := id.String()
+	id, _ = TransformUUID(id)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := lockPlacementTarget(tx, &id, nil); err != nil {
+		return err
+	}
+	autoSort, err := folderAutoSorts(tx, id)
+	if err != nil {
+		return err
+	}
+	if autoSort {
+		return ErrFolderAutoSorted
+	}
+
+	listed := make([]uuid.UUID, len(objectIDs))
+	for i, objectID := range objectIDs {
+		listed[i], _ = TransformUUID(objectID)
+	}
+	var before, after models.ReorderFolderRequest
+	err = orderFolder(tx, id, func(current []uuid.UUID) ([]uuid.UUID, error) {
+		rest := make(map[uuid.UUID]bool, len(current))
+		for _, objectID := range current {
+			rest[objectID] = true
+		}
+		ordered := make([]uuid.UUID, 0, len(current))
+		for _, objectID := range listed {
+			if !rest[objectID] {
+				return nil, fmt.Errorf("%w: object %s is not in the folder", ErrInvalidFolder, objectID)
+			}
+			delete(rest, objectID)
+			ordered = append(ordered, objectID)
+		}
+		for _, objectID := range current {
+			if rest[objectID] {
+				ordered = append(ordered, objectID)
+			}
+		}
+		before.ObjectIds, after.ObjectIds = current, ordered
+		return ordered, nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := changes.Record(tx, models.AuditEntityObject, entityID, models.AuditActionReorder, before, after); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing folder order: %w", err)
+	}
+	return nil
+}
+
+// lockFolder refuses objects that are not folders or are in the recycle bin
+func lockFolder(tx *sql.Tx, id uuid.UUID) error {
+	var isFolder, deleted bool
+	err := tx.QueryRow(`
+		SELECT CAST(CASE WHEN GeneralType = dbo.const_GeneralType_Folder() THEN 1 ELSE 0 END AS BIT),
+			CAST(ISNULL(DeleteFlag, 0) AS BIT)
+		FROM [Object] WITH (UPDLOCK)
+		WHERE ObjectID = @p1
+	`, id).Scan(&isFolder, &deleted)
+	if err == sql.ErrNoRows || (err == nil && !isFolder) {
+		return ErrFolderNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error retrieving folder: %w", err)
+	}
+	if deleted {
+		return ErrObjectDeleted
+	}
+	return nil
+}
+
+// folderAutoSorts reports whether a container sorts its contents by name
+func folderAutoSorts(tx *sql.Tx, id uuid.UUID) (bool, error) {
+	var autoSort bool
+	err := tx.QueryRow(`SELECT CAST(ISNULL(AutoSort, 0) AS BIT) FROM [Object] WHERE ObjectID = @p1`, id).Scan(&autoSort)
+	if err != nil {
+		return false, fmt.Errorf("error retrieving folder: %w", err)
+	}
+	return autoSort, nil
+}
+
+// orderFolder numbers the contents of a container from 1 in display order.
+// Unless the container sorts by name, arrange, when given, rearranges the
+// contents first.
+func orderFolder(tx *sql.Tx, id uuid.UUID, arrange func([]uuid.UUID) ([]uuid.UUID, error)) error {
+	autoSort, err := folderAutoSorts(tx, id)
+	if err != nil {
+		return err
+	}
+
+	// Read the contents before updating them, since the connection cannot
+	// run statements while rows are open
+	rows, err := tx.Query(folderOrderSql, id)
+	if err != nil {
+		return fmt.Errorf("error retrieving folder contents: %w", err)
+	}
+	var ids []uuid.UUID
+	for rows.Next() {
+		var idBytes []byte
+		if err := rows.Scan(&idBytes); err != nil {
+			rows.Close()
+			return fmt.Errorf("error scanning folder content: %w", err)
+		}
+		objectID, err := parseSQLServerUUID(idBytes)
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("error parsing ObjectId: %w", err)
+		}
+		ids = append(ids, objectID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error retrieving folder contents: %w", err)
+	}
+
+	if !autoSort && arrange != nil {
+		if ids, err = arrange(ids); err != nil {
+			return err
+		}
+	}
+	for i, objectID := range ids {
+		if _, err := tx.Exec(`
+			UPDATE [Object] SET SortOrder = @p2
+			WHERE ObjectID = @p1 AND (SortOrder IS NULL OR SortOrder <> @p2)
+		`, objectID, i+1); err != nil {
+			return fmt.Errorf("error updating sort order: %w", err)
+		}
+	}
+	return nil
+}
+
+// placeAt moves an object to a 1-based position among a container's
+// contents, or to the end when the position is past it
+func placeAt(id uuid.UUID, position int) func([]uuid.UUID) ([]uuid.UUID, error) {
+	return func(current []uuid.UUID) ([]uuid.UUID, error) {
+		ordered := make([]uuid.UUID, 0, len(current))
+		for _, objectID := range current {
+			if objectID != id {
+				ordered = append(ordered, objectID)
+			}
+		}
+		i := position - 1
+		if i > len(ordered) {
+			i = len(ordered)
+		}
+		ordered = append(ordered[:i], append([]uuid.UUID{id}, ordered[i:]...)...)
+		return ordered, nil
+	}
+}
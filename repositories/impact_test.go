@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"enterprise-architect-api/models"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestBuildImpactGraph(t *testing.T) {
+	a, b, c, d := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	node := func(id uuid.UUID) models.ImpactNode {
+		var n models.ImpactNode
+		n.ObjectId = id
+		return n
+	}
+	link := func(source, target uuid.UUID, readable bool) impactLink {
+		return impactLink{
+			edge:           models.ImpactEdge{RelationshipId: uuid.New(), SourceObjectId: source, TargetObjectId: target},
+			source:         node(source),
+			target:         node(target),
+			sourceReadable: true,
+			targetReadable: readable,
+		}
+	}
+
+	tests := []struct {
+		name       string
+		links      []impactLink
+		direction  string
+		depth      int
+		wantNodes  int
+		wantEdges  int
+		wantCycles bool
+	}{
+		{"chain", []impactLink{link(a, b, true), link(b, c, true)}, models.ImpactDirectionDown, 5, 3, 2, false},
+		{"depth limit", []impactLink{link(a, b, true), link(b, c, true)}, models.ImpactDirectionDown, 1, 2, 1, false},
+		{"diamond down", []impactLink{link(a, b, true), link(a, c, true), link(b, d, true), link(c, d, true)},
+			models.ImpactDirectionDown, 5, 4, 4, false},
+		{"diamond both ways", []impactLink{link(a, b, true), link(a, c, true), link(b, d, true), link(c, d, true)},
+			models.ImpactDirectionBoth, 5, 4, 4, true},
+		{"directed cycle", []impactLink{link(a, b, true), link(b, c, true), link(c, a, true)},
+			models.ImpactDirectionDown, 5, 3, 3, true},
+		{"self relationship", []impactLink{link(a, a, true)}, models.ImpactDirectionDown, 5, 1, 1, true},
+		{"cycle through an unreadable object", []impactLink{link(a, b, false), link(b, a, true)},
+			models.ImpactDirectionDown, 5, 1, 0, false},
+		{"walking up", []impactLink{link(b, a, true), link(c, b, true)}, models.ImpactDirectionUp, 5, 3, 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outgoing := tt.direction != models.ImpactDirectionUp
+			incoming := tt.direction != models.ImpactDirectionDown
+			graph := buildImpactGraph(node(a), tt.links, tt.direction, tt.depth, outgoing, incoming)
+			if len(graph.Nodes) != tt.wantNodes || len(graph.Edges) != tt.wantEdges || graph.HasCycles != tt.wantCycles {
+				t.Errorf("buildImpactGraph() = %d nodes, %d edges, hasCycles %v, want %d, %d, %v",
+					len(graph.Nodes), len(graph.Edges), graph.HasCycles, tt.wantNodes, tt.wantEdges, tt.wantCycles)
+			}
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"enterprise-architect-api/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Import job errors
+var (
+	ErrImportJobNotFound = errors.New("import job not found")
+	// ErrImportJobLeaseLost is returned when another worker has taken over a job
+	ErrImportJobLeaseLost = errors.New("import job lease lost")
+)
+
+// maxImportJobErrors caps the failed rows returned with a job
+const maxImportJobErrors = 1000
+
+// ClaimedImportJob is a job a worker has leased for processing
+type ClaimedImportJob struct {
+	JobID     uuid.UUID
+	NextRow   int
+	UserID    int
+	ProfileID int
+	Payload   models.ImportJobPayload
+}
+
+// ImportJobRepository handles database operations for background import jobs
+type ImportJobRepository struct {
+	db         *sql.DB
+	objectRepo *ObjectRepository
+}
+
+// NewImportJobRepository creates a new ImportJobRepository
+func NewImportJobRepository(db *sql.DB, objectRepo *ObjectRepository) *ImportJobRepository {
+	return &ImportJobRepository{db: db, objectRepo: objectRepo}
+}
+
+// Create stores a new queued job
+func (r *ImportJobRepository) Create(payload models.ImportJobPayload, fileName *string, userID, profileID int) (uuid.UUID, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error encoding import job payload: %w", err)
+	}
+
+	jobID := uuid.New()
+	query := `
+		INSERT INTO ImportJobs (JobID, Status, FileName, LibraryID, FolderID, ObjectTypeID, Payload, TotalRows, UserID, ProfileID)
+		VALUES (@p1, @p2, @p3, @p4, @p5, @p6, @p7, @p8, @p9, @p10)
+	`
+	_, err = r.db.Exec(query, jobID, models.ImportJobQueued, fileName, payload.Request.LibraryId, payload.Request.FolderId,
+		payload.Request.ObjectTypeId, string(body), len(payload.Request.Data), userID, profileID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error creating import job: %w", err)
+	}
+	return jobID, nil
+}
+
+// GetByID retrieves a job with its failed rows
+func (r *ImportJobRepository) GetByID(jobID uuid.UUID) (*models.ImportJob, error) {
+	query := `
+		SELECT JobID, Status, FileName, LibraryID, FolderID, ObjectTypeID, TotalRows, NextRow,
+			SuccessCount, FailedCount, SkippedCount, CancelRequested, ErrorMessage, UserID, DateCreated, DateStarted, DateCompleted
+		FROM ImportJobs
+		WHERE JobID = @p1
+	`
+
+	var job models.ImportJob
+	var jobIDBytes, libraryIDBytes, folderIDBytes []byte
+	err := r.db.QueryRow(query, jobID).Scan(
+		&jobIDBytes, &job.Status, &job.FileName, &libraryIDBytes, &folderIDBytes, &job.ObjectTypeId,
+		&job.TotalRows, &job.ProcessedRows, &job.SuccessCount, &job.FailedCount, &job.SkippedCount, &job.CancelRequested,
+		&job.ErrorMessage, &job.CreatedBy, &job.DateCreated, &job.DateStarted, &job.DateCompleted,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrImportJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving import job: %w", err)
+	}
+	job.JobID, _ = parseSQLServerUUID(jobIDBytes)
+	job.LibraryId, _ = parseSQLServerUUID(libraryIDBytes)
+	job.FolderId, _ = parseSQLServerUUID(folderIDBytes)
+	if job.TotalRows > 0 {
+		job.Progress = float64(job.ProcessedRows) * 100 / float64(job.TotalRows)
+	}
+
+	rows, err := r.db.Query(fmt.Sprintf(`
+		SELECT TOP (%d) RowNo, Action, ObjectID, ObjectName, Errors
+		FROM ImportJobRows
+		WHERE JobID = @p1 AND Errors IS NOT NULL
+		ORDER BY RowNo
+	`, maxImportJobErrors), jobID)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving import job rows: %w", err)
+	}
+	defer rows.Close()
+
+	job.Errors = []models.ImportRowResult{}
+	for rows.Next() {
+		var result models.ImportRowResult
+		var objectIDBytes []byte
+		var objectName sql.NullString
+		var rowErrors string
+		if err := rows.Scan(&result.Row, &result.Action, &objectIDBytes, &objectName, &rowErrors); err != nil {
+			return nil, fmt.Errorf("error scanning import job row: %w", err)
+		}
+		if objectIDBytes != nil {
+			objectID, _ := parseSQLServerUUID(objectIDBytes)
+			result.ObjectID = &objectID
+		}
+		result.ObjectName = objectName.String
+		if err := json.Unmarshal([]byte(rowErrors), &result.Errors); err != nil {
+			return nil, fmt.Errorf("error decoding import job row errors: %w", err)
+		}
+		job.Errors = append(job.Errors, result)
+	}
+
+	return &job, rows.Err()
+}
+
+// Claim leases the oldest queued job, or a running job whose lease has
+// expired because its worker stopped, to the given worker. It returns nil
+// when there is nothing to do.
+func (r *ImportJobRepository) Claim(workerID string, lease time.Duration) (*ClaimedImportJob, error) {
+	query := `
+		WITH next AS (
+			SELECT TOP (1) *
+			FROM ImportJobs WITH (UPDLOCK, READPAST, ROWLOCK)
+			WHERE Status = @p3 OR (Status = @p4 AND LeaseExpiresAt < GETDATE())
+			ORDER BY DateCreated
+		)
+		UPDATE next SET
+			Status = @p4,
+			LeaseOwner = @p1,
+			LeaseExpiresAt = DATEADD(SECOND, @p2, GETDATE()),
+			DateStarted = COALESCE(DateStarted, GETDATE()),
+			DateModified = GETDATE()
+		OUTPUT inserted.JobID, inserted.NextRow, inserted.UserID, inserted.ProfileID, inserted.Payload
+	`
+
+	var job ClaimedImportJob
+	var jobIDBytes []byte
+	var payload string
+	err := r.db.QueryRow(query, workerID, int(lease.Seconds()), models.ImportJobQueued, models.ImportJobRunning).Scan(
+		&jobIDBytes, &job.NextRow, &job.UserID, &job.ProfileID, &payload,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error claiming import job: %w", err)
+	}
+	job.JobID, _ = parseSQLServerUUID(jobIDBytes)
+	if err := json.Unmarshal([]byte(payload), &job.Payload); err != nil {
+		return &job, fmt.Errorf("error decoding import job payload: %w", err)
+	}
+	return &job, nil
+}
+
+// ProcessChunk imports the rows of req and records their outcome and the new
+// resume point in the same transaction, renewing the worker's lease. rowNumbers
+// gives the reported row number of each entry in req.Data. It returns true,
+// without importing anything, when the job has been cancelled.
+func (r *ImportJobRepository) ProcessChunk(jobID uuid.UUID, workerID string, req models.ObjectImportRequest, rowNumbers []int, nextRow int, userID, profileID int, lease time.Duration) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	cancelled, err := lockImportJob(tx, jobID, workerID)
+	if err != nil {
+		return false, err
+	}
+	if cancelled {
+		if err := finishImportJob(tx, jobID, models.ImportJobCancelled, nil); err != nil {
+			return false, err
+		}
+		if err := tx.Commit(); err != nil {
+			return false, fmt.Errorf("error committing transaction: %w", err)
+		}
+		return true, nil
+	}
+
+	// The job's changes are audited under the job ID, as the request that
+	// queued it has long finished
+	changes := NewChangeLog(userID, profileID, jobID.String())
+	result, err := r.objectRepo.ImportObjectsTx(tx, req, userID, profileID, changes)
+	if err != nil {
+		return false, err
+	}
+
+	insertRow := `
+		INSERT INTO ImportJobRows (JobID, RowNo, Action, ObjectID, ObjectName, Errors)
+		VALUES (@p1, @p2, @p3, @p4, @p5, @p6)
+	`
+	for _, row := range result.Rows {
+		var objectID, rowErrors interface{}
+		if row.ObjectID != nil {
+			objectID = *row.ObjectID
+		}
+		if len(row.Errors) > 0 {
+			body, _ := json.Marshal(row.Errors)
+			rowErrors = string(body)
+		}
+		if _, err := tx.Exec(insertRow, jobID, rowNumbers[row.Row-1], row.Action, objectID, row.ObjectName, rowErrors); err != nil {
+			return false, fmt.Errorf("error recording import job row: %w", err)
+		}
+	}
+
+	progress := `
+		UPDATE ImportJobs SET
+			NextRow = @p2,
+			SuccessCount = SuccessCount + @p3,
+			FailedCount = FailedCount + @p4,
+			SkippedCount = SkippedCount + @p5,
+			LeaseExpiresAt = DATEADD(SECOND, @p6, GETDATE()),
+			DateModified = GETDATE()
+		WHERE JobID = @p1
+	`
+	if _, err := tx.Exec(progress, jobID, nextRow, result.SuccessImportedObjectCount, result.FailedImportObjectCount, result.SkippedImportObjectCount, int(lease.Seconds())); err != nil {
+		return false, fmt.Errorf("error updating import job progress: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("error committing transaction: %w", err)
+	}
+	return false, nil
+}
+
+// Finish marks a job held by the worker as completed, failed or cancelled
+func (r *ImportJobRepository) Finish(jobID uuid.UUID, workerID, status string, errorMessage *string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := lockImportJob(tx, jobID, workerID); err != nil {
+		return err
+	}
+	if err := finishImportJob(tx, jobID, status, errorMessage); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
+
+// RequestCancel cancels a queued job immediately and flags a running job so
+// that its worker stops before the next chunk. It returns the job's status
+// after the request.
+func (r *ImportJobRepository) RequestCancel(jobID uuid.UUID) (string, error) {
+	query := `
+		UPDATE ImportJobs SET
+			CancelRequested = 1,
+			Status = CASE WHEN Status = @p2 THEN @p3 ELSE Status END,
+			DateCompleted = CASE WHEN Status = @p2 THEN GETDATE() ELSE DateCompleted END,
+			DateModified = GETDATE()
+		OUTPUT inserted.Status
+		WHERE JobID = @p1 AND Status IN (@p2, @p4)
+	`
+
+	var status string
+	err := r.db.QueryRow(query, jobID, models.ImportJobQueued, models.ImportJobCancelled, models.ImportJobRunning).Scan(&status)
+	if err == sql.ErrNoRows {
+		// Unknown, or already finished
+		err = r.db.QueryRow(`SELECT Status FROM ImportJobs WHERE JobID = @p1`, jobID).Scan(&status)
+		if err == sql.ErrNoRows {
+			return "", ErrImportJobNotFound
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("error cancelling import job: %w", err)
+	}
+	return status, nil
+}
+
+// lockImportJob locks a running job for the rest of the transaction and
+// reports whether it has been cancelled. It fails with ErrImportJobLeaseLost
+// if the worker no longer holds the job.
+func lockImportJob(tx *sql.Tx, jobID uuid.UUID, workerID string) (bool, error) {
+	query := `
+		SELECT Status, LeaseOwner, CancelRequested
+		FROM ImportJobs WITH (UPDLOCK, ROWLOCK)
+		WHERE JobID = @p1
+	`
+
+	var status string
+	var owner sql.NullString
+	var cancelRequested bool
+	err := tx.QueryRow(query, jobID).Scan(&status, &owner, &cancelRequested)
+	if err == sql.ErrNoRows {
+		return false, ErrImportJobNotFound
+	}
+	if err != nil {
+		return false, fmt.Errorf("error locking import job: %w", err)
+	}
+	if status != models.ImportJobRunning || owner.String != workerID {
+		return false, ErrImportJobLeaseLost
+	}
+	return cancelRequested, nil
+}
+
+func finishImportJob(tx *sql.Tx, jobID uuid.UUID, status string, errorMessage *string) error {
+	query := `
+		UPDATE ImportJobs SET
+			Status = @p2,
+			ErrorMessage = @p3,
+			LeaseOwner = NULL,
+			LeaseExpiresAt = NULL,
+			DateCompleted = GETDATE(),
+			DateModified = GETDATE()
+		WHERE JobID = @p1
+	`
+	if _, err := tx.Exec(query, jobID, status, errorMessage); err != nil {
+		return fmt.Errorf("error finishing import job: %w", err)
+	}
+	return nil
+}
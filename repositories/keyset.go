@@ -0,0 +1,175 @@
+package repositories
+
+import (
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/utils"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidCursor is returned for cursors that do not decode or belong to a
+// different list or sort order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// keysetKey is a sort key of a keyset-paginated query. The last key of a
+// query must be unique so that every row has a distinct position.
+type keysetKey struct {
+	expr string
+	desc bool
+}
+
+// keyset pages through a query by the values of its sort keys instead of
+// OFFSET, so reading far into a large list costs no more than the first page
+type keyset struct {
+	keys   []keysetKey
+	sort   string
+	cursor *utils.Cursor
+}
+
+// newKeyset decodes the request's cursor, if any. sort identifies the list
+// and its order; a cursor made for another list or order is rejected.
+func newKeyset(keys []keysetKey, sort string, req models.PageRequest) (*keyset, error) {
+	k := &keyset{keys: keys, sort: sort}
+	if req.Cursor == "" {
+		return k, nil
+	}
+	cursor, err := utils.DecodeCursor(req.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if cursor.Sort != sort || len(cursor.Values) != len(keys) {
+		return nil, fmt.Errorf("%w: the cursor belongs to a different list or sort order", ErrInvalidCursor)
+	}
+	k.cursor = cursor
+	return k, nil
+}
+
+func (k *keyset) backward() bool {
+	return k.cursor != nil && k.cursor.Backward
+}
+
+// columns selects the sort keys as extra columns after the row's own
+func (k *keyset) columns() string {
+	cols := make([]string, len(k.keys))
+	for i, key := range k.keys {
+		cols[i] = key.expr + " AS SortKey" + strconv.Itoa(i)
+	}
+	return strings.Join(cols, ", ")
+}
+
+// orderBy orders the rows in list order, or in reverse when reading the page
+// before a backward cursor
+func (k *keyset) orderBy() string {
+	terms := make([]string, len(k.keys))
+	for i, key := range k.keys {
+		terms[i] = key.expr
+		if key.desc != k.backward() {
+			terms[i] += " DESC"
+		}
+	}
+	return strings.Join(terms, ", ")
+}
+
+// condition restricts the rows to those after the cursor in the order of
+// orderBy, or returns "" without a cursor. NULLs sort first in ascending and
+// last in descending order, as SQL Server sorts them.
+func (k *keyset) condition(param func(interface{}) string) string {
+	if k.cursor == nil {
+		return ""
+	}
+	var alternatives []string
+	for i, key := range k.keys {
+		var terms []string
+		for j := 0; j < i; j++ {
+			if v := k.cursor.Values[j]; v == nil {
+				terms = append(terms, k.keys[j].expr+" IS NULL")
+			} else {
+				terms = append(terms, k.keys[j].expr+" = "+param(v))
+			}
+		}
+
+		v := k.cursor.Values[i]
+		switch desc := key.desc != k.backward(); {
+		case !desc && v == nil:
+			terms = append(terms, key.expr+" IS NOT NULL")
+		case !desc:
+			terms = append(terms, key.expr+" > "+param(v))
+		case v == nil:
+			// Nothing sorts after NULL in descending order
+			continue
+		default:
+			terms = append(terms, "("+key.expr+" < "+param(v)+" OR "+key.expr+" IS NULL)")
+		}
+		alternatives = append(alternatives, "("+strings.Join(terms, " AND ")+")")
+	}
+	if len(alternatives) == 0 {
+		return "1 = 0"
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")"
+}
+
+// offset is the number of rows to skip: the page offset without a cursor,
+// none with one
+func (k *keyset) offset(req models.PageRequest) int {
+	if k.cursor != nil || req.Page <= 1 {
+		return 0
+	}
+	return (req.Page - 1) * req.PageSize
+}
+
+// keysetPage finishes a page read with one row more than the page size,
+// together with the sort key values of each row. It puts the rows in list
+// order and sets the cursors of the page before and after them.
+func keysetPage[T any](k *keyset, req models.PageRequest, items []T, keys [][]interface{}, info *models.PageInfo) ([]T, error) {
+	more := len(items) > req.PageSize
+	if more {
+		items, keys = items[:req.PageSize], keys[:req.PageSize]
+	}
+	hasNext, hasPrev := more, k.cursor != nil || req.Page > 1
+	if k.backward() {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+			keys[i], keys[j] = keys[j], keys[i]
+		}
+		hasNext, hasPrev = true, more
+	}
+	if len(items) == 0 {
+		return items, nil
+	}
+
+	var err error
+	if hasNext {
+		info.NextCursor, err = utils.EncodeCursor(utils.Cursor{Sort: k.sort, Values: keys[len(keys)-1]})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if hasPrev {
+		info.PrevCursor, err = utils.EncodeCursor(utils.Cursor{Sort: k.sort, Values: keys[0], Backward: true})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// scanKeys returns destinations for the sort key columns of a row
+func scanKeys(n int) ([]interface{}, []interface{}) {
+	values := make([]interface{}, n)
+	dests := make([]interface{}, n)
+	for i := range values {
+		dests[i] = &values[i]
+	}
+	return values, dests
+}
+
+// queryArgs collects the parameters of a query as it is built
+type queryArgs []interface{}
+
+// param adds a parameter and returns its placeholder
+func (a *queryArgs) param(v interface{}) string {
+	*a = append(*a, v)
+	return "@p" + strconv.Itoa(len(*a))
+}
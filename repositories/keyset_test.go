@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/utils"
+	"errors"
+	"testing"
+)
+
+func TestNewKeysetCursor(t *testing.T) {
+	keys := []keysetKey{{expr: "o.ObjectName"}, {expr: "o.ObjectID"}}
+	cursor := func(c utils.Cursor) string {
+		encoded, err := utils.EncodeCursor(c)
+		if err != nil {
+			t.Fatalf("EncodeCursor() error = %v", err)
+		}
+		return encoded
+	}
+
+	tests := []struct {
+		name    string
+		cursor  string
+		wantErr bool
+	}{
+		{"no cursor", "", false},
+		{"same sort", cursor(utils.Cursor{Sort: "objects:name", Values: []interface{}{"Customer", []byte{1}}}), false},
+		{"backward", cursor(utils.Cursor{Sort: "objects:name", Values: []interface{}{"Customer", []byte{1}}, Backward: true}), false},
+		{"different sort order", cursor(utils.Cursor{Sort: "objects:-name", Values: []interface{}{"Customer", []byte{1}}}), true},
+		{"different list", cursor(utils.Cursor{Sort: "relationships:name", Values: []interface{}{"Customer", []byte{1}}}), true},
+		{"too few values", cursor(utils.Cursor{Sort: "objects:name", Values: []interface{}{"Customer"}}), true},
+		{"too many values", cursor(utils.Cursor{Sort: "objects:name", Values: []interface{}{"Customer", []byte{1}, int64(2)}}), true},
+		{"malformed", "not a cursor!", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := newKeyset(keys, "objects:name", models.PageRequest{Cursor: tt.cursor, PageSize: 20})
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCursor) {
+					t.Fatalf("newKeyset() error = %v, want ErrInvalidCursor", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newKeyset() error = %v", err)
+			}
+			if (tt.cursor != "") != (k.cursor != nil) {
+				t.Errorf("newKeyset() cursor = %+v, want one only when given", k.cursor)
+			}
+		})
+	}
+}
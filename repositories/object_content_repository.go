@@ -42,7 +42,7 @@ func (r *ObjectContentRepository) Create(req models.CreateObjectContentRequest)
 	var id int
 	err := r.db.QueryRow(query,
 		req.DocumentObjectID, req.ContainerVersionID, req.ObjectID, req.Instances, req.IsShortCut,
-		req.ContainmentType, now, req.CreatedBy, now, req.CreatedBy, time.Now(), 62,
+		req.ContainmentType, now, req.CreatedBy, now, req.CreatedBy,
 	).Scan(&id)
 
 	if err != nil {
@@ -130,48 +130,66 @@ func (r *ObjectContentRepository) GetByID(id int) (*models.ObjectContent, error)
 }
 
 // GetAll retrieves all object contents with pagination
-func (r *ObjectContentRepository) GetAll(page, pageSize int) ([]models.ObjectContent, int, error) {
-	offset := (page - 1) * pageSize
+func (r *ObjectContentRepository) GetAll(req models.PageRequest) ([]models.ObjectContent, models.PageInfo, error) {
+	info := models.PageInfo{TotalCount: -1}
+	k, err := newKeyset([]keysetKey{{"DateCreated", true}, {"ID", false}}, "objectContents", req)
+	if err != nil {
+		return nil, info, err
+	}
 
 	// Get total count
-	var totalCount int
-	countQuery := `SELECT COUNT(*) FROM ObjectContents`
-	err := r.db.QueryRow(countQuery).Scan(&totalCount)
-	if err != nil {
-		return nil, 0, fmt.Errorf("error counting object contents: %w", err)
+	if !req.SkipCount {
+		countQuery := `SELECT COUNT(*) FROM ObjectContents`
+		if err := r.db.QueryRow(countQuery).Scan(&info.TotalCount); err != nil {
+			return nil, info, fmt.Errorf("error counting object contents: %w", err)
+		}
 	}
 
 	// Get paginated results
+	var args queryArgs
+	where := ""
+	if cond := k.condition(args.param); cond != "" {
+		where = "WHERE " + cond
+	}
 	query := `
 		SELECT ID, DocumentObjectID, ContainerVersionID, ObjectID, Instances, IsShortCut, 
-			ShapeSheetKeysRequiringUpdateId, ContainmentType, DateCreated, CreatedBy, DateModified, ModifiedBy
+			ShapeSheetKeysRequiringUpdateId, ContainmentType, DateCreated, CreatedBy, DateModified, ModifiedBy,
+			` + k.columns() + `
 		FROM ObjectContents
-		ORDER BY DateCreated DESC
-		OFFSET @p1 ROWS FETCH NEXT @p2 ROWS ONLY
+		` + where + `
+		ORDER BY ` + k.orderBy() + `
+		OFFSET ` + args.param(k.offset(req)) + ` ROWS FETCH NEXT ` + args.param(req.PageSize+1) + ` ROWS ONLY
 	`
 
-	rows, err := r.db.Query(query, offset, pageSize)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("error retrieving object contents: %w", err)
+		return nil, info, fmt.Errorf("error retrieving object contents: %w", err)
 	}
 	defer rows.Close()
 
 	var objectContents []models.ObjectContent
+	var sortValues [][]interface{}
 	for rows.Next() {
 		var objContent models.ObjectContent
-		err := rows.Scan(
+		values, keyDests := scanKeys(2)
+		err := rows.Scan(append([]interface{}{
 			&objContent.ID, &objContent.DocumentObjectID, &objContent.ContainerVersionID, &objContent.ObjectID,
 			&objContent.Instances, &objContent.IsShortCut, &objContent.ShapeSheetKeysRequiringUpdateId,
 			&objContent.ContainmentType, &objContent.DateCreated, &objContent.CreatedBy, &objContent.DateModified,
 			&objContent.ModifiedBy,
-		)
+		}, keyDests...)...)
 		if err != nil {
-			return nil, 0, fmt.Errorf("error scanning object content: %w", err)
+			return nil, info, fmt.Errorf("error scanning object content: %w", err)
 		}
 		objectContents = append(objectContents, objContent)
+		sortValues = append(sortValues, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, info, fmt.Errorf("error iterating object contents: %w", err)
 	}
 
-	return objectContents, totalCount, nil
+	objectContents, err = keysetPage(k, req, objectContents, sortValues, &info)
+	return objectContents, info, err
 }
 
 // Update updates an existing object content
@@ -380,3 +398,44 @@ func (r *ObjectContentRepository) DashboardCountGrouped(libraryID uuid.UUID) ([]
 
 	return categories, nil
 }
+
+// ExportContainment streams the containment of a library to fn: the contents
+// of the current version of each container, where the profile can read both
+// the container and the contained object
+func (r *ObjectContentRepository) ExportContainment(libraryID uuid.UUID, profileID int, fn func(models.ContainmentEdge) error) error {
+	libraryID, _ = TransformUUID(libraryID)
+	query := `
+		SELECT oc.DocumentObjectID, oc.ObjectID, MIN(oc.ContainmentType)
+		FROM ObjectContents AS oc
+		INNER JOIN [Object] AS doc ON doc.ObjectID = oc.DocumentObjectID AND doc.CurrentVersionId = oc.ContainerVersionID
+		INNER JOIN [Object] AS obj ON obj.ObjectID = oc.ObjectID
+		WHERE (doc.LibraryId = @p1 OR doc.ObjectID = @p1) AND (obj.LibraryId = @p1 OR obj.ObjectID = @p1)
+			AND ISNULL(doc.DeleteFlag, 0) = 0 AND ISNULL(obj.DeleteFlag, 0) = 0
+			AND ` + fmt.Sprintf(readableObjectColumnFilter, "oc.DocumentObjectID", "@p2") + `
+			AND ` + fmt.Sprintf(readableObjectColumnFilter, "oc.ObjectID", "@p2") + `
+		GROUP BY oc.DocumentObjectID, oc.ObjectID
+	`
+
+	rows, err := r.db.Query(query, libraryID, profileID)
+	if err != nil {
+		return fmt.Errorf("error retrieving containment for graph export: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var edge models.ContainmentEdge
+		var containerBytes, objectBytes []byte
+		if err := rows.Scan(&containerBytes, &objectBytes, &edge.ContainmentType); err != nil {
+			return fmt.Errorf("error scanning containment: %w", err)
+		}
+		edge.ContainerObjectId, _ = parseSQLServerUUID(containerBytes)
+		edge.ObjectId, _ = parseSQLServerUUID(objectBytes)
+		if err := fn(edge); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating containment: %w", err)
+	}
+	return nil
+}
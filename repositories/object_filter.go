@@ -0,0 +1,549 @@
+package repositories
+
+import (
+	"database/sql"
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/utils"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidFilter is returned for filter, sort or facet parameters that do
+// not parse or name unknown fields
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// Value kinds of filterable fields
+const (
+	filterText = iota
+	filterInt
+	filterFloat
+	filterDate
+	filterBool
+	filterUUID
+	filterRichText
+)
+
+const (
+	// maxFacets is the number of facet fields a request may ask for
+	maxFacets = 10
+	// maxFacetValues is the number of values returned per facet
+	maxFacetValues = 50
+)
+
+// filterColumn is a column a filter, sort key or facet can refer to
+type filterColumn struct {
+	sql  string
+	kind int
+}
+
+// objectFilterColumns maps the lower-cased filter field names, which follow
+// the JSON names of models.Object, to their columns
+var objectFilterColumns = map[string]filterColumn{
+	"name":              {"[Object].ObjectName", filterText},
+	"objectname":        {"[Object].ObjectName", filterText},
+	"description":       {"[Object].ObjectDescription", filterText},
+	"objectdescription": {"[Object].ObjectDescription", filterText},
+	"objecttypeid":      {"[Object].ObjectTypeID", filterInt},
+	"exactobjecttypeid": {"[Object].ExactObjectTypeID", filterInt},
+	"generaltype":       {"[Object].GeneralType", filterInt},
+	"libraryid":         {"[Object].LibraryId", filterUUID},
+	"islibrary":         {"[Object].IsLibrary", filterBool},
+	"isimported":        {"[Object].IsImported", filterBool},
+	"ischeckedout":      {"[Object].IsCheckedOut", filterBool},
+	"checkedoutuserid":  {"[Object].CheckedOutUserId", filterInt},
+	"locked":            {"[Object].Locked", filterBool},
+	"prefix":            {"[Object].Prefix", filterText},
+	"suffix":            {"[Object].Suffix", filterText},
+	"datecreated":       {"[Object].DateCreated", filterDate},
+	"createdby":         {"[Object].CreatedBy", filterInt},
+	"datemodified":      {"[Object].DateModified", filterDate},
+	"modifiedby":        {"[Object].ModifiedBy", filterInt},
+}
+
+// attributeValueColumns maps attribute data types to their vwAttributeValue
+// column
+var attributeValueColumns = map[int]filterColumn{
+	1: {"fv.intValue", filterInt},
+	2: {"fv.dateValue", filterDate},
+	3: {"fv.floatValue", filterFloat},
+	4: {"fv.textValue", filterText},
+	5: {"fv.booleanValue", filterBool},
+	6: {"fv.richTextValue", filterRichText},
+}
+
+// objectListColumns are the [Object] columns scanned by scanObjectRows
+const objectListColumns = `[Object].ObjectID, [Object].ObjectName, [Object].ObjectDescription, [Object].ObjectTypeID, [Object].CheckedInVersionId,
+			[Object].DeleteFlag, [Object].Locked, [Object].RequiresShapeSheetUpdate, [Object].TemplateID, [Object].IsImported, [Object].IsLibrary,
+			[Object].LibraryId, [Object].FileExtension, [Object].SortOrder, [Object].Prefix, [Object].Suffix, [Object].ProvenanceId, [Object].ProvenanceVersionId,
+			[Object].GeneralType, [Object].CurrentVersionId, [Object].VisioAlias, [Object].HasVisioAlias, [Object].DateCreated, [Object].CreatedBy,
+			[Object].DateModified, [Object].ModifiedBy, [Object].IsCheckedOut, [Object].CheckedOutUserId, [Object].DeleteTransactionId,
+			[Object].NameChecksum, [Object].ExactObjectTypeID, [Object].RichTextDescription, [Object].AutoSort`
+
+// objectFilter builds the WHERE clause, ORDER BY and facet queries of an
+// object list. Every value is passed as a parameter; only column names from
+// objectFilterColumns and attributeValueColumns are written into the SQL.
+type objectFilter struct {
+	db         *sql.DB
+	args       []interface{}
+	attributes map[string]filterColumn
+}
+
+// newObjectFilter creates an objectFilter whose first parameter, @p1, is the
+// profile the list is read for
+func (r *ObjectRepository) newObjectFilter(profileID int) *objectFilter {
+	return &objectFilter{db: r.db, args: []interface{}{profileID}, attributes: make(map[string]filterColumn)}
+}
+
+func (f *objectFilter) param(v interface{}) string {
+	f.args = append(f.args, v)
+	return "@p" + strconv.Itoa(len(f.args))
+}
+
+// attributeSource selects the current version's values of an attribute as fv
+func (f *objectFilter) attributeSource(name string) string {
+	return `FROM vwAttributeValue AS fv
+				JOIN Attribute AS fa ON fa.AttributeId = fv.AttributeId
+				WHERE fv.objectId = [Object].ObjectID AND fv.versionId = [Object].CurrentVersionId
+					AND fa.AttributeName = ` + f.param(name)
+}
+
+// column resolves a field. Attributes must exist and be readable by the
+// profile, so that filtering cannot reveal values the profile cannot see.
+func (f *objectFilter) column(field utils.FilterField) (filterColumn, error) {
+	if !field.Attribute {
+		col, ok := objectFilterColumns[strings.ToLower(field.Name)]
+		if !ok {
+			return col, fmt.Errorf("%w: unknown field %s", ErrInvalidFilter, field.Name)
+		}
+		return col, nil
+	}
+
+	if col, ok := f.attributes[field.Name]; ok {
+		return col, nil
+	}
+	var attributeType string
+	err := f.db.QueryRow(`
+		SELECT TOP 1 a.AttributeType
+		FROM Attribute AS a
+		WHERE a.AttributeName = @p1
+			AND EXISTS (
+				SELECT 1 FROM [AttributePermissions] AS ap
+				WHERE ap.AttributeId = a.AttributeId AND ap.ProfileId = @p2 AND ap.HasRead = 1
+			)`, field.Name, f.args[0]).Scan(&attributeType)
+	if err == sql.ErrNoRows {
+		return filterColumn{}, fmt.Errorf("%w: unknown attribute %q", ErrInvalidFilter, field.Name)
+	}
+	if err != nil {
+		return filterColumn{}, fmt.Errorf("error retrieving filter attribute: %w", err)
+	}
+	col := attributeValueColumns[int(getTypeId(attributeType))]
+	f.attributes[field.Name] = col
+	return col, nil
+}
+
+// where compiles a filter expression
+func (f *objectFilter) where(expr utils.FilterExpr) (string, error) {
+	switch e := expr.(type) {
+	case *utils.FilterLogical:
+		terms := make([]string, len(e.Terms))
+		for i, term := range e.Terms {
+			sql, err := f.where(term)
+			if err != nil {
+				return "", err
+			}
+			terms[i] = sql
+		}
+		return "(" + strings.Join(terms, " "+strings.ToUpper(e.Op)+" ") + ")", nil
+	case *utils.FilterNot:
+		sql, err := f.where(e.Expr)
+		if err != nil {
+			return "", err
+		}
+		return "NOT " + sql, nil
+	case *utils.FilterComparison:
+		return f.comparison(e)
+	}
+	return "", fmt.Errorf("%w: unsupported expression", ErrInvalidFilter)
+}
+
+// comparison compiles a comparison. Comparing a missing attribute value is
+// false, except with != and is null, which match objects without a value.
+func (f *objectFilter) comparison(c *utils.FilterComparison) (string, error) {
+	col, err := f.column(c.Field)
+	if err != nil {
+		return "", err
+	}
+	if col.kind == filterRichText {
+		return "", fmt.Errorf("%w: rich text attribute %s cannot be filtered", ErrInvalidFilter, c.Field)
+	}
+
+	var cond string
+	switch c.Op {
+	case utils.FilterIsNull, utils.FilterIsNotNull:
+		cond = col.sql + " IS NOT NULL"
+	case utils.FilterNe:
+		cond, err = f.condition(col, c.Field, utils.FilterEq, c.Values)
+	default:
+		cond, err = f.condition(col, c.Field, c.Op, c.Values)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	negate := c.Op == utils.FilterIsNull || c.Op == utils.FilterNe
+	if c.Field.Attribute {
+		sql := "EXISTS (SELECT 1 " + f.attributeSource(c.Field.Name) + " AND " + cond + ")"
+		if negate {
+			sql = "NOT " + sql
+		}
+		return sql, nil
+	}
+	switch c.Op {
+	case utils.FilterIsNull:
+		return col.sql + " IS NULL", nil
+	case utils.FilterNe:
+		return "(NOT " + cond + " OR " + col.sql + " IS NULL)", nil
+	}
+	return cond, nil
+}
+
+// condition compiles a positive comparison of a column with its values
+func (f *objectFilter) condition(col filterColumn, field utils.FilterField, op string, values []utils.FilterValue) (string, error) {
+	if op == utils.FilterIn {
+		conds := make([]string, len(values))
+		for i, value := range values {
+			cond, err := f.condition(col, field, utils.FilterEq, []utils.FilterValue{value})
+			if err != nil {
+				return "", err
+			}
+			conds[i] = cond
+		}
+		return "(" + strings.Join(conds, " OR ") + ")", nil
+	}
+
+	value := values[0]
+	invalid := func(expected string) error {
+		return fmt.Errorf("%w: %s expects %s, got %q", ErrInvalidFilter, field, expected, value.Text)
+	}
+	relational := op == utils.FilterEq || op == utils.FilterLt || op == utils.FilterLe || op == utils.FilterGt || op == utils.FilterGe
+	if !relational && (op != utils.FilterContains && op != utils.FilterStartsWith || col.kind != filterText) {
+		return "", fmt.Errorf("%w: %s cannot be used with %s", ErrInvalidFilter, op, field)
+	}
+
+	var arg interface{}
+	switch col.kind {
+	case filterText:
+		if value.Kind != utils.FilterString {
+			return "", invalid("a quoted string")
+		}
+		switch op {
+		case utils.FilterContains:
+			return col.sql + " LIKE N'%' + " + f.param(escapeLike(value.Text)) + " + N'%'", nil
+		case utils.FilterStartsWith:
+			return col.sql + " LIKE " + f.param(escapeLike(value.Text)) + " + N'%'", nil
+		}
+		arg = value.Text
+	case filterInt:
+		if value.Kind != utils.FilterNumber || value.Number != math.Trunc(value.Number) {
+			return "", invalid("an integer")
+		}
+		arg = int64(value.Number)
+	case filterFloat:
+		if value.Kind != utils.FilterNumber {
+			return "", invalid("a number")
+		}
+		arg = value.Number
+	case filterBool:
+		if value.Kind != utils.FilterBool || op != utils.FilterEq {
+			return "", invalid("= or != with true or false")
+		}
+		arg = value.Bool
+	case filterUUID:
+		id, err := uuid.Parse(value.Text)
+		if value.Kind != utils.FilterString || err != nil || op != utils.FilterEq {
+			return "", invalid("= or != with a quoted ID")
+		}
+		id, _ = TransformUUID(id)
+		arg = id
+	case filterDate:
+		if value.Kind != utils.FilterDate {
+			return "", invalid("a date such as 2025-01-01")
+		}
+		if value.DateOnly {
+			return f.dayCondition(col.sql, op, value.Date), nil
+		}
+		arg = value.Date
+	}
+	return col.sql + " " + op + " " + f.param(arg), nil
+}
+
+// dayCondition compares a date and time column with a whole day, so that
+// dateModified = 2025-01-01 matches any time that day and > matches the days
+// after it
+func (f *objectFilter) dayCondition(col, op string, day time.Time) string {
+	next := day.AddDate(0, 0, 1)
+	switch op {
+	case utils.FilterEq:
+		return "(" + col + " >= " + f.param(day) + " AND " + col + " < " + f.param(next) + ")"
+	case utils.FilterGt:
+		return col + " >= " + f.param(next)
+	case utils.FilterLe:
+		return col + " < " + f.param(next)
+	}
+	return col + " " + op + " " + f.param(day)
+}
+
+// sortKeys compiles sort keys, defaulting to the newest objects first, and
+// returns them with a canonical form of the sort order for cursors. The
+// object ID breaks ties so that pages do not overlap.
+func (f *objectFilter) sortKeys(keys []utils.FilterSortKey) ([]keysetKey, string, error) {
+	if len(keys) == 0 {
+		return []keysetKey{{"[Object].DateCreated", true}, {"[Object].ObjectID", false}}, "-dateCreated", nil
+	}
+	sortKeys := make([]keysetKey, 0, len(keys)+1)
+	spec := make([]string, len(keys))
+	for i, key := range keys {
+		col, err := f.column(key.Field)
+		if err != nil {
+			return nil, "", err
+		}
+		if col.kind == filterRichText {
+			return nil, "", fmt.Errorf("%w: cannot sort by rich text attribute %s", ErrInvalidFilter, key.Field)
+		}
+		expr := col.sql
+		if key.Field.Attribute {
+			expr = "(SELECT TOP 1 " + col.sql + " " + f.attributeSource(key.Field.Name) + ")"
+		}
+		sortKeys = append(sortKeys, keysetKey{expr, key.Desc})
+		spec[i] = key.Field.String()
+		if key.Desc {
+			spec[i] = "-" + spec[i]
+		}
+	}
+	return append(sortKeys, keysetKey{"[Object].ObjectID", false}), strings.Join(spec, ","), nil
+}
+
+// facetQuery builds the query counting the values of a field among the rows
+// matching where
+func (f *objectFilter) facetQuery(field utils.FilterField, where string) (string, filterColumn, error) {
+	col, err := f.column(field)
+	if err != nil {
+		return "", col, err
+	}
+	if col.kind == filterRichText {
+		return "", col, fmt.Errorf("%w: rich text attribute %s cannot be a facet", ErrInvalidFilter, field)
+	}
+	expr := col.sql
+	if col.kind == filterDate {
+		expr = "CAST(" + col.sql + " AS date)"
+	}
+
+	from := "[Object]"
+	if field.Attribute {
+		from += `
+		JOIN vwAttributeValue AS fv ON fv.objectId = [Object].ObjectID AND fv.versionId = [Object].CurrentVersionId
+		JOIN Attribute AS fa ON fa.AttributeId = fv.AttributeId AND fa.AttributeName = ` + f.param(field.Name)
+		where += " AND " + col.sql + " IS NOT NULL"
+	}
+	return `
+		SELECT TOP (` + strconv.Itoa(maxFacetValues) + `) ` + expr + `, COUNT(*)
+		FROM ` + from + `
+		WHERE ` + where + `
+		GROUP BY ` + expr + `
+		ORDER BY COUNT(*) DESC, ` + expr, col, nil
+}
+
+// listObjects returns a page of the readable objects outside the recycle bin
+// that match base and the query's filter, with the total count unless skipped
+// and the requested facets. base is a condition built with f's parameters, or
+// empty.
+func (r *ObjectRepository) listObjects(f *objectFilter, base string, q models.ObjectListQuery) ([]models.Object, models.PageInfo, []models.Facet, error) {
+	info := models.PageInfo{TotalCount: -1}
+	conds := []string{"ISNULL([Object].DeleteFlag, 0) = 0", fmt.Sprintf(readableObjectFilter, "@p1")}
+	if base != "" {
+		conds = append(conds, base)
+	}
+	if strings.TrimSpace(q.Filter) != "" {
+		expr, err := utils.ParseFilter(q.Filter)
+		if err != nil {
+			return nil, info, nil, fmt.Errorf("%w: %v", ErrInvalidFilter, err)
+		}
+		cond, err := f.where(expr)
+		if err != nil {
+			return nil, info, nil, err
+		}
+		conds = append(conds, cond)
+	}
+	where := strings.Join(conds, "\n\t\t\tAND ")
+
+	sortKeys, err := utils.ParseFilterSort(q.Sort)
+	if err != nil {
+		return nil, info, nil, fmt.Errorf("%w: sort: %v", ErrInvalidFilter, err)
+	}
+	keys, spec, err := f.sortKeys(sortKeys)
+	if err != nil {
+		return nil, info, nil, err
+	}
+	k, err := newKeyset(keys, "objects:"+spec, q.PageRequest)
+	if err != nil {
+		return nil, info, nil, err
+	}
+
+	facetFields, err := utils.ParseFilterFields(q.Facets)
+	if err != nil {
+		return nil, info, nil, fmt.Errorf("%w: facets: %v", ErrInvalidFilter, err)
+	}
+	if len(facetFields) > maxFacets {
+		return nil, info, nil, fmt.Errorf("%w: at most %d facets may be requested", ErrInvalidFilter, maxFacets)
+	}
+	facetQueries := make([]string, len(facetFields))
+	facetColumns := make([]filterColumn, len(facetFields))
+	for i, field := range facetFields {
+		if facetQueries[i], facetColumns[i], err = f.facetQuery(field, where); err != nil {
+			return nil, info, nil, err
+		}
+	}
+
+	if !q.SkipCount {
+		if err := r.db.QueryRow(`SELECT COUNT(*) FROM [Object] WHERE `+where, f.args...).Scan(&info.TotalCount); err != nil {
+			return nil, info, nil, fmt.Errorf("error counting objects: %w", err)
+		}
+	}
+
+	pageWhere := where
+	if cond := k.condition(f.param); cond != "" {
+		pageWhere += "\n\t\t\tAND " + cond
+	}
+	query := `
+		SELECT ` + objectListColumns + `, ` + k.columns() + `
+		FROM [Object]
+		WHERE ` + pageWhere + `
+		ORDER BY ` + k.orderBy() + `
+		OFFSET ` + f.param(k.offset(q.PageRequest)) + ` ROWS FETCH NEXT ` + f.param(q.PageSize+1) + ` ROWS ONLY
+	`
+	rows, err := r.db.Query(query, f.args...)
+	if err != nil {
+		return nil, info, nil, fmt.Errorf("error retrieving objects: %w", err)
+	}
+	objects, sortValues, err := scanObjectRows(rows, len(keys))
+	rows.Close()
+	if err != nil {
+		return nil, info, nil, err
+	}
+	if objects, err = keysetPage(k, q.PageRequest, objects, sortValues, &info); err != nil {
+		return nil, info, nil, err
+	}
+
+	var facets []models.Facet
+	for i, field := range facetFields {
+		facet, err := r.queryFacet(facetQueries[i], facetColumns[i], f.args)
+		if err != nil {
+			return nil, info, nil, err
+		}
+		facet.Field = field.String()
+		facets = append(facets, *facet)
+	}
+
+	return objects, info, facets, nil
+}
+
+// queryFacet runs a facet query built by facetQuery
+func (r *ObjectRepository) queryFacet(query string, col filterColumn, args []interface{}) (*models.Facet, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error counting facet values: %w", err)
+	}
+	defer rows.Close()
+
+	facet := &models.Facet{Values: []models.FacetValue{}}
+	for rows.Next() {
+		var value models.FacetValue
+		if err := rows.Scan(&value.Value, &value.Count); err != nil {
+			return nil, fmt.Errorf("error scanning facet value: %w", err)
+		}
+		switch v := value.Value.(type) {
+		case []byte:
+			if col.kind == filterUUID {
+				id, err := parseSQLServerUUID(v)
+				if err != nil {
+					return nil, fmt.Errorf("error parsing facet value: %w", err)
+				}
+				value.Value = id
+			} else {
+				value.Value = string(v)
+			}
+		case time.Time:
+			value.Value = v.Format("2006-01-02")
+		}
+		facet.Values = append(facet.Values, value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating facet values: %w", err)
+	}
+	return facet, nil
+}
+
+// scanObjectRows scans rows selecting objectListColumns followed by keys sort
+// key columns, and returns the objects and their sort key values
+func scanObjectRows(rows *sql.Rows, keys int) ([]models.Object, [][]interface{}, error) {
+	var objects []models.Object
+	var sortValues [][]interface{}
+	for rows.Next() {
+		var obj models.Object
+		var objectIDBytes []byte
+		var checkedInVersionIDBytes, libraryIDBytes, provenanceIDBytes, provenanceVersionIDBytes, currentVersionIDBytes, deleteTransactionIDBytes []byte
+
+		values, keyDests := scanKeys(keys)
+		err := rows.Scan(append([]interface{}{
+			&objectIDBytes, &obj.ObjectName, &obj.ObjectDescription, &obj.ObjectTypeID, &checkedInVersionIDBytes,
+			&obj.DeleteFlag, &obj.Locked, &obj.RequiresShapeSheetUpdate, &obj.TemplateID, &obj.IsImported,
+			&obj.IsLibrary, &libraryIDBytes, &obj.FileExtension, &obj.SortOrder, &obj.Prefix, &obj.Suffix,
+			&provenanceIDBytes, &provenanceVersionIDBytes, &obj.GeneralType, &currentVersionIDBytes,
+			&obj.VisioAlias, &obj.HasVisioAlias, &obj.DateCreated, &obj.CreatedBy, &obj.DateModified,
+			&obj.ModifiedBy, &obj.IsCheckedOut, &obj.CheckedOutUserId, &deleteTransactionIDBytes,
+			&obj.NameChecksum, &obj.ExactObjectTypeID, &obj.RichTextDescription, &obj.AutoSort,
+		}, keyDests...)...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error scanning object: %w", err)
+		}
+
+		obj.ObjectID, err = parseSQLServerUUID(objectIDBytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing ObjectID: %w", err)
+		}
+		for _, id := range []struct {
+			name  string
+			bytes []byte
+			dest  **uuid.UUID
+		}{
+			{"CheckedInVersionId", checkedInVersionIDBytes, &obj.CheckedInVersionId},
+			{"LibraryId", libraryIDBytes, &obj.LibraryId},
+			{"ProvenanceId", provenanceIDBytes, &obj.ProvenanceId},
+			{"ProvenanceVersionId", provenanceVersionIDBytes, &obj.ProvenanceVersionId},
+			{"CurrentVersionId", currentVersionIDBytes, &obj.CurrentVersionId},
+			{"DeleteTransactionId", deleteTransactionIDBytes, &obj.DeleteTransactionId},
+		} {
+			if id.bytes == nil {
+				continue
+			}
+			parsedUUID, err := parseSQLServerUUID(id.bytes)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error parsing %s: %w", id.name, err)
+			}
+			*id.dest = &parsedUUID
+		}
+
+		objects = append(objects, obj)
+		sortValues = append(sortValues, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating objects: %w", err)
+	}
+	return objects, sortValues, nil
+}
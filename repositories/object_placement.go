@@ -0,0 +1,453 @@
+package repositories
+
+import (
+	"database/sql"
+	"enterprise-architect-api/models"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidPlacement is returned when an object cannot be moved or copied
+	// to the requested target
+	ErrInvalidPlacement = errors.New("invalid target folder")
+	// ErrTypeNotAllowedInFolder is returned when the target folder's type does
+	// not allow the object's type in FolderObjectTypes
+	ErrTypeNotAllowedInFolder = errors.New("object type is not allowed in the target folder")
+	// ErrTargetFolderDeleted is returned when the target folder is in the
+	// recycle bin
+	ErrTargetFolderDeleted = errors.New("the target folder is in the recycle bin")
+	// ErrMoveNotPermitted is returned when the profile may not take an object
+	// out of its folder or may not modify everything moved with it
+	ErrMoveNotPermitted = errors.New("move not permitted")
+)
+
+// currentFolderSql selects the folder an object (@p1) is in
+const currentFolderSql = `
+	SELECT TOP 1 fc.FolderId
+	FROM vwFolderContents AS fc
+	INNER JOIN [Object] AS f ON f.ObjectID = fc.FolderId
+	WHERE fc.ObjectId = @p1 AND ISNULL(f.DeleteFlag, 0) = 0
+`
+
+// moveObjectSql moves an object (@p1) from folder @p3, which may be NULL, to
+// the current version of folder @p2, and sets library @p4 on it and every
+// object below it, as user @p5. It selects whether @p2 is below the object
+// and whether an object below it outside the recycle bin cannot be modified
+// by profile @p6, in either of which cases nothing is moved, and the size of
+// the subtree.
+const moveObjectSql = `
+	DECLARE @ids TABLE (ObjectID UNIQUEIDENTIFIER PRIMARY KEY);
+	INSERT INTO @ids (ObjectID) VALUES (@p1);
+	WHILE @@ROWCOUNT > 0
+		INSERT INTO @ids (ObjectID)
+		SELECT DISTINCT fc.ObjectId
+		FROM vwFolderContents AS fc
+		INNER JOIN @ids AS folder ON folder.ObjectID = fc.FolderId
+		WHERE NOT EXISTS (SELECT 1 FROM @ids AS seen WHERE seen.ObjectID = fc.ObjectId);
+
+	IF EXISTS (SELECT 1 FROM @ids WHERE ObjectID = @p2)
+		SELECT CAST(1 AS BIT), CAST(0 AS BIT), COUNT(*) FROM @ids;
+	ELSE IF EXISTS (
+		SELECT 1 FROM @ids AS d
+		INNER JOIN [Object] AS o ON o.ObjectID = d.ObjectID
+		WHERE ISNULL(o.DeleteFlag, 0) = 0 AND NOT EXISTS (
+			SELECT 1 FROM dbo.fn_EffectiveObjectPermissions(@p6) AS perm
+			WHERE perm.ObjectID = d.ObjectID AND perm.HasModify = 1
+		)
+	)
+		SELECT CAST(0 AS BIT), CAST(1 AS BIT), COUNT(*) FROM @ids;
+	ELSE
+	BEGIN
+		DECLARE @containment INT = ISNULL((
+			SELECT MIN(oc.ContainmentType)
+			FROM ObjectContents AS oc
+			INNER JOIN [Object] AS doc ON doc.ObjectID = oc.DocumentObjectID AND doc.CurrentVersionId = oc.ContainerVersionID
+			WHERE oc.ObjectID = @p1 AND oc.DocumentObjectID = @p3
+		), 1);
+
+		DELETE oc
+		FROM ObjectContents AS oc
+		INNER JOIN [Object] AS doc ON doc.ObjectID = oc.DocumentObjectID AND doc.CurrentVersionId = oc.ContainerVersionID
+		WHERE oc.ObjectID = @p1 AND oc.DocumentObjectID = @p3;
+
+		INSERT INTO ObjectContents (
+			DocumentObjectID, ContainerVersionID, ObjectID, Instances, IsShortCut,
+			ContainmentType, DateCreated, CreatedBy, DateModified, ModifiedBy
+		)
+		SELECT @p2, container.CurrentVersionId, @p1, 1, 0, @containment, GETDATE(), @p5, GETDATE(), @p5
+		FROM [Object] AS container
+		WHERE container.ObjectID = @p2;
+
+		UPDATE o SET LibraryId = @p4, DateModified = GETDATE(), ModifiedBy = @p5
+		FROM [Object] AS o
+		INNER JOIN @ids AS d ON d.ObjectID = o.ObjectID
+		WHERE o.IsLibrary = 0 AND (o.LibraryId IS NULL OR o.LibraryId <> @p4);
+
+		SELECT CAST(0 AS BIT), CAST(0 AS BIT), COUNT(*) FROM @ids;
+	END
+`
+
+// copyObjectSql creates object @p2 with version @p3 as a copy of the
+// checked-in version of object @p1, named @p4 if given, in library @p5 as
+// user @p6, and places it in the current version of folder @p7 with
+// containment type @p8
+const copyObjectSql = `
+	INSERT INTO [Version] (
+		ID, ObjectID, ObjectName, ObjectDescription, RichTextDescription,
+		SystemVersionNo, UserVersionNo, DateCreated, DateModified, ModifiedBy, CreatedBy
+	)
+	SELECT @p3, @p2, ISNULL(@p4, v.ObjectName), v.ObjectDescription, v.RichTextDescription,
+		1, 'v1', GETDATE(), GETDATE(), @p6, @p6
+	FROM [Object] AS o
+	INNER JOIN [Version] AS v ON v.ID = ISNULL(o.CheckedInVersionId, o.CurrentVersionId)
+	WHERE o.ObjectID = @p1;
+
+	INSERT INTO [Object] (
+		ObjectID, ObjectName, ObjectDescription, ObjectTypeID, Locked, IsImported,
+		IsLibrary, LibraryId, FileExtension, Prefix, Suffix, DateCreated, CreatedBy,
+		DateModified, ModifiedBy, IsCheckedOut, ExactObjectTypeID, CurrentVersionId, CheckedInVersionId,
+		DeleteFlag, RichTextDescription, GeneralType, SortOrder, AutoSort
+	)
+	SELECT @p2, v.ObjectName, v.ObjectDescription, o.ObjectTypeID, 0, 0,
+		0, @p5, o.FileExtension, o.Prefix, o.Suffix, GETDATE(), @p6,
+		GETDATE(), @p6, 0, o.ExactObjectTypeID, @p3, @p3,
+		0, v.RichTextDescription, o.GeneralType, o.SortOrder, o.AutoSort
+	FROM [Object] AS o
+	INNER JOIN [Version] AS v ON v.ID = @p3
+	WHERE o.ObjectID = @p1;
+
+	INSERT INTO ObjectContents (
+		DocumentObjectID, ContainerVersionID, ObjectID, Instances, IsShortCut,
+		ContainmentType, DateCreated, CreatedBy, DateModified, ModifiedBy
+	)
+	SELECT @p7, container.CurrentVersionId, @p2, 1, 0, @p8, GETDATE(), @p6, GETDATE(), @p6
+	FROM [Object] AS container
+	WHERE container.ObjectID = @p7;
+`
+
+// copyAttributeValuesSql copies the attribute values of the checked-in
+// version of object @p1 to version @p3 of object @p2 as user @p4
+const copyAttributeValuesSql = `
+	INSERT INTO AttributeValue (ObjectId, VersionId, AttributeId, DataType, ValueBigInt, ValueDate, ValueFloat, ValueText, ValueRichText, ValueHTML, DateCreated, CreatedBy, DateModified, ModifiedBy)
+	SELECT @p2, @p3, av.AttributeId, av.DataType, av.ValueBigInt, av.ValueDate, av.ValueFloat, av.ValueText, av.ValueRichText, av.ValueHTML, GETDATE(), @p4, GETDATE(), @p4
+	FROM AttributeValue AS av
+	INNER JOIN [Object] AS o ON o.ObjectID = av.ObjectId AND av.VersionId = ISNULL(o.CheckedInVersionId, o.CurrentVersionId)
+	WHERE av.ObjectId = @p1
+`
+
+// folderChildrenSql selects the objects in folder @p1 that profile @p2 can
+// read, with the containment type linking them to the folder
+var folderChildrenSql = `
+	SELECT DISTINCT fc.ObjectId, ISNULL((
+		SELECT MIN(oc.ContainmentType)
+		FROM ObjectContents AS oc
+		INNER JOIN [Object] AS doc ON doc.ObjectID = oc.DocumentObjectID AND doc.CurrentVersionId = oc.ContainerVersionID
+		WHERE oc.DocumentObjectID = fc.FolderId AND oc.ObjectID = fc.ObjectId
+	), 1)
+	FROM vwFolderContents AS fc
+	INNER JOIN [Object] AS o ON o.ObjectID = fc.ObjectId
+	WHERE fc.FolderId = @p1 AND ISNULL(o.DeleteFlag, 0) = 0 AND o.IsLibrary = 0
+		AND ` + fmt.Sprintf(readableObjectColumnFilter, "fc.ObjectId", "@p2")
+
+// placementTarget is the folder or library an object is moved, copied or
+// created in
+type placementTarget struct {
+	id        uuid.UUID
+	libraryID uuid.UUID
+	typeID    int
+}
+
+// lockPlacementTarget resolves a target folder and library to the container
+// to place an object in: the folder, or the library itself when no folder is
+// given
+func lockPlacementTarget(tx *sql.Tx, folderID, libraryID *uuid.UUID) (*placementTarget, error) {
+	var targetID uuid.UUID
+	switch {
+	case folderID != nil:
+		targetID, _ = TransformUUID(*folderID)
+	case libraryID != nil:
+		targetID, _ = TransformUUID(*libraryID)
+	default:
+		return nil, fmt.Errorf("%w: a target folder or library is required", ErrInvalidPlacement)
+	}
+
+	var typeID int
+	var container, deleted bool
+	var libraryBytes []byte
+	err := tx.QueryRow(`
+		SELECT ISNULL(ExactObjectTypeID, ObjectTypeID),
+			CAST(CASE WHEN IsLibrary = 1 OR GeneralType IN (dbo.const_GeneralType_Folder(), dbo.const_GeneralType_SystemRepository()) THEN 1 ELSE 0 END AS BIT),
+			COALESCE(LibraryId, CASE WHEN IsLibrary = 1 THEN ObjectID END),
+			CAST(ISNULL(DeleteFlag, 0) AS BIT)
+		FROM [Object] WITH (UPDLOCK)
+		WHERE ObjectID = @p1
+	`, targetID).Scan(&typeID, &container, &libraryBytes, &deleted)
+	if err == sql.ErrNoRows {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving target folder: %w", err)
+	}
+	if deleted {
+		return nil, ErrTargetFolderDeleted
+	}
+	if !container || libraryBytes == nil {
+		return nil, fmt.Errorf("%w: the target is not a folder or library", ErrInvalidPlacement)
+	}
+
+	target := &placementTarget{id: targetID, typeID: typeID}
+	if target.libraryID, err = parseSQLServerUUID(libraryBytes); err != nil {
+		return nil, fmt.Errorf("error parsing LibraryId: %w", err)
+	}
+	if libraryID != nil {
+		if want, _ := TransformUUID(*libraryID); want != target.libraryID {
+			return nil, fmt.Errorf("%w: the target folder is not in the target library", ErrInvalidPlacement)
+		}
+	}
+	return target, nil
+}
+
+// checkTypeAllowed returns ErrTypeNotAllowedInFolder unless FolderObjectTypes
+// allows objects of the type in the target's folder type
+func checkTypeAllowed(tx *sql.Tx, target *placementTarget, objectTypeID int) error {
+	var allowed bool
+	err := tx.QueryRow(`
+		SELECT CAST(CASE WHEN EXISTS (
+			SELECT 1 FROM FolderObjectTypes WHERE FolderObjectTypeId = @p1 AND ObjectTypeId = @p2
+		) THEN 1 ELSE 0 END AS BIT)
+	`, target.typeID, objectTypeID).Scan(&allowed)
+	if err != nil {
+		return fmt.Errorf("error checking folder object types: %w", err)
+	}
+	if !allowed {
+		return fmt.Errorf("%w: object type %d in folder type %d", ErrTypeNotAllowedInFolder, objectTypeID, target.typeID)
+	}
+	return nil
+}
+
+// lockPlacementSource returns the type of an object being moved or copied,
+// refusing libraries and objects in the recycle bin
+func lockPlacementSource(tx *sql.Tx, id uuid.UUID) (int, error) {
+	var typeID int
+	var isLibrary, deleted bool
+	err := tx.QueryRow(`
+		SELECT ISNULL(ExactObjectTypeID, ObjectTypeID), IsLibrary, CAST(ISNULL(DeleteFlag, 0) AS BIT)
+		FROM [Object] WITH (UPDLOCK)
+		WHERE ObjectID = @p1
+	`, id).Scan(&typeID, &isLibrary, &deleted)
+	if err == sql.ErrNoRows {
+		return 0, ErrObjectNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error retrieving object: %w", err)
+	}
+	if deleted {
+		return 0, ErrObjectDeleted
+	}
+	if isLibrary {
+		return 0, fmt.Errorf("%w: a library cannot be placed in a folder", ErrInvalidPlacement)
+	}
+	return typeID, nil
+}
+
+// Move moves an object into another folder or library. Everything in the
+// object's folder tree moves with it and takes the target's library, so the
+// profile needs modify contents permission on the folder the object leaves
+// and modify permission on every object moved that is not in the recycle bin.
+func (r *ObjectRepository) Move(id uuid.UUID, req models.MoveObjectRequest, userID, profileID int, changes *ChangeLog) (*models.ObjectPlacementResult, error) {
+	id, _ = TransformUUID(id)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	typeID, err := lockPlacementSource(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	target, err := lockPlacementTarget(tx, req.TargetFolderId, req.TargetLibraryId)
+	if err != nil {
+		return nil, err
+	}
+	if target.id == id {
+		return nil, fmt.Errorf("%w: an object cannot be moved into itself", ErrInvalidPlacement)
+	}
+	if err := checkTypeAllowed(tx, target, typeID); err != nil {
+		return nil, err
+	}
+	before, err := getObject(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ObjectPlacementResult{ParentId: target.id, LibraryId: target.libraryID}
+	var previousBytes []byte
+	err = tx.QueryRow(currentFolderSql, id).Scan(&previousBytes)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("error retrieving current folder: %w", err)
+	}
+	if previousBytes != nil {
+		previousID, err := parseSQLServerUUID(previousBytes)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing FolderId: %w", err)
+		}
+		result.PreviousParentId = &previousID
+
+		dbPreviousID, _ := TransformUUID(previousID)
+		perm, err := getEffectivePermission(tx, dbPreviousID, profileID)
+		if err != nil {
+			return nil, err
+		}
+		if !perm.HasModifyContents {
+			return nil, fmt.Errorf("%w: profile %d does not have modify contents permission on folder %s", ErrMoveNotPermitted, profileID, previousID)
+		}
+	}
+
+	var cycle, denied bool
+	if err := tx.QueryRow(moveObjectSql, id, target.id, result.PreviousParentId, target.libraryID, userID, profileID).
+		Scan(&cycle, &denied, &result.ObjectCount); err != nil {
+		return nil, fmt.Errorf("error moving object: %w", err)
+	}
+	if cycle {
+		return nil, fmt.Errorf("%w: an object cannot be moved into a folder below it", ErrInvalidPlacement)
+	}
+	if denied {
+		return nil, fmt.Errorf("%w: profile %d cannot modify every object below the object", ErrMoveNotPermitted, profileID)
+	}
+	if result.Object, err = getObject(tx, id); err != nil {
+		return nil, err
+	}
+	if err := recordObjectEvent(tx, changes, id, models.AuditActionMove, before, result); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing object move: %w", err)
+	}
+	return result, nil
+}
+
+// Copy copies the checked-in version of an object into a folder or library.
+// A deep copy also copies its attribute values and every object in its
+// folder tree that the profile can read.
+func (r *ObjectRepository) Copy(id uuid.UUID, req models.CopyObjectRequest, userID, profileID int, changes *ChangeLog) (*models.ObjectPlacementResult, error) {
+	id, _ = TransformUUID(id)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	typeID, err := lockPlacementSource(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	target, err := lockPlacementTarget(tx, req.TargetFolderId, req.TargetLibraryId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTypeAllowed(tx, target, typeID); err != nil {
+		return nil, err
+	}
+
+	result := &models.ObjectPlacementResult{ParentId: target.id, LibraryId: target.libraryID}
+	c := &objectCopier{tx: tx, changes: changes, libraryID: target.libraryID, userID: userID, profileID: profileID, deep: req.Deep, seen: map[uuid.UUID]bool{}}
+	copyID, err := c.copy(id, target.id, req.ObjectName, 1)
+	if err != nil {
+		return nil, err
+	}
+	result.ObjectCount = c.count
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing object copy: %w", err)
+	}
+
+	if result.Object, err = r.GetByID(copyID); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// objectCopier copies objects within one transaction, recording each copy
+// in changes. seen holds both the objects copied and their copies, so that
+// copying a folder into a folder below it does not copy the copies.
+type objectCopier struct {
+	tx        *sql.Tx
+	changes   *ChangeLog
+	libraryID uuid.UUID
+	userID    int
+	profileID int
+	deep      bool
+	seen      map[uuid.UUID]bool
+	count     int
+}
+
+type folderChild struct {
+	id              uuid.UUID
+	containmentType int
+}
+
+func (c *objectCopier) copy(sourceID, parentID uuid.UUID, name *string, containmentType int) (uuid.UUID, error) {
+	copyID, versionID := uuid.New(), uuid.New()
+	c.seen[sourceID] = true
+	c.seen[copyID] = true
+
+	if _, err := c.tx.Exec(copyObjectSql, sourceID, copyID, versionID, name, c.libraryID, c.userID, parentID, containmentType); err != nil {
+		return uuid.Nil, fmt.Errorf("error copying object: %w", err)
+	}
+	c.count++
+	if !c.deep {
+		return copyID, recordObject(c.tx, c.changes, copyID, models.AuditActionCreate, nil)
+	}
+
+	if _, err := c.tx.Exec(copyAttributeValuesSql, sourceID, copyID, versionID, c.userID); err != nil {
+		return uuid.Nil, fmt.Errorf("error copying attribute values: %w", err)
+	}
+	if err := recordObject(c.tx, c.changes, copyID, models.AuditActionCreate, nil); err != nil {
+		return uuid.Nil, err
+	}
+
+	children, err := c.children(sourceID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	for _, child := range children {
+		if c.seen[child.id] {
+			continue
+		}
+		if _, err := c.copy(child.id, copyID, nil, child.containmentType); err != nil {
+			return uuid.Nil, err
+		}
+	}
+	return copyID, nil
+}
+
+// children reads a folder's readable contents before any of them is copied,
+// since the connection cannot run statements while rows are open
+func (c *objectCopier) children(folderID uuid.UUID) ([]folderChild, error) {
+	rows, err := c.tx.Query(folderChildrenSql, folderID, c.profileID)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving folder contents: %w", err)
+	}
+	defer rows.Close()
+
+	var children []folderChild
+	for rows.Next() {
+		var child folderChild
+		var idBytes []byte
+		if err := rows.Scan(&idBytes, &child.containmentType); err != nil {
+			return nil, fmt.Errorf("error scanning folder content: %w", err)
+		}
+		if child.id, err = parseSQLServerUUID(idBytes); err != nil {
+			return nil, fmt.Errorf("error parsing ObjectId: %w", err)
+		}
+		children = append(children, child)
+	}
+	return children, rows.Err()
+}
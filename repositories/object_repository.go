@@ -1,9 +1,13 @@
 package repositories
 
 import (
+	"bytes"
 	"database/sql"
 	"enterprise-architect-api/models"
+	"enterprise-architect-api/utils"
+	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -23,185 +27,720 @@ func NewObjectRepository(db *sql.DB, attrRepo *AttributeRepository) *ObjectRepos
 	return &ObjectRepository{db: db, attributeRepository: attrRepo}
 }
 
-func (r *ObjectRepository) ImportObjects(req models.ObjectImportRequest) (*models.ObjectImportResponse, error) {
+// ErrInvalidImportKey is returned when an import's matching key or strategy
+// cannot be used with the object type being imported
+var ErrInvalidImportKey = errors.New("invalid import key")
+
+// Import SQL shared by the row-level import steps
+const (
+	importMatchByNameSql = `
+		SELECT TOP 2 ObjectID, CurrentVersionId, ObjectName, ObjectDescription
+		FROM [Object]
+		WHERE ObjectName = @p1 AND ExactObjectTypeID = @p2 AND LibraryId = @p3 AND ISNULL(DeleteFlag, 0) = 0
+	`
+
+	importMatchByObjectIDSql = `
+		SELECT TOP 2 ObjectID, CurrentVersionId, ObjectName, ObjectDescription
+		FROM [Object]
+		WHERE ObjectID = @p1 AND ExactObjectTypeID = @p2 AND LibraryId = @p3 AND ISNULL(DeleteFlag, 0) = 0
+	`
+
+	importMatchByAttributeSql = `
+		SELECT TOP 2 o.ObjectID, o.CurrentVersionId, o.ObjectName, o.ObjectDescription
+		FROM [Object] AS o
+		INNER JOIN [vwAttributeValue] AS attr ON attr.objectId = o.ObjectID AND attr.versionId = o.CurrentVersionId
+		WHERE o.ExactObjectTypeID = @p1 AND o.LibraryId = @p2 AND attr.AttributeId = @p3 AND ISNULL(o.DeleteFlag, 0) = 0
+			AND (attr.textValue = @p4 OR attr.intValue = @p5)
+	`
+
+	importUpdateSql = `
+		UPDATE [Object] SET
+			ObjectName = @p1,
+			ObjectDescription = CASE WHEN @p2 = 1 THEN @p3 ELSE ObjectDescription END,
+			RichTextDescription = CASE WHEN @p2 = 1 THEN @p4 ELSE RichTextDescription END,
+			IsImported = 1,
+			DateModified = @p5,
+			ModifiedBy = @p6
+		WHERE ObjectID = @p7
+	`
+
+	importCurrentValuesSql = `
+		SELECT attr.AttributeId, attr.textValue, attr.booleanValue, attr.dateValue,
+			attr.floatValue, attr.intValue, attr.richTextValue
+		FROM [vwAttributeValue] AS attr
+		WHERE attr.objectId = @p1 AND attr.versionId = @p2
+	`
+
+	// syncCurrentVersionSql keeps the current (checked-out) version in step
+	// with the object
+	syncCurrentVersionSql = `
+		UPDATE v SET
+			ObjectName = o.ObjectName,
+			ObjectDescription = o.ObjectDescription,
+			RichTextDescription = o.RichTextDescription,
+			DateModified = o.DateModified,
+			ModifiedBy = o.ModifiedBy
+		FROM [Version] AS v
+		INNER JOIN [Object] AS o ON o.CurrentVersionId = v.ID
+		WHERE o.ObjectID = @p1
+	`
+)
+
+// ImportObjects inserts or updates one object per row of req.Data and reports
+// the outcome of every row. A failed row is rolled back on its own without
+// affecting the others; with req.DryRun the whole import is rolled back once
+// the report has been built.
+func (r *ObjectRepository) ImportObjects(req models.ObjectImportRequest, userID, profileID int, changes *ChangeLog) (*models.ObjectImportResponse, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	response, err := r.ImportObjectsTx(tx, req, userID, profileID, changes)
+	if err != nil {
+		return nil, err
+	}
+
+	if !req.DryRun {
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("error committing transaction: %w", err)
+		}
+	}
+
+	return response, nil
+}
+
+// ImportObjectsTx runs an import within the caller's transaction, leaving the
+// commit or rollback to the caller. Every object and attribute value written
+// is recorded in changes.
+func (r *ObjectRepository) ImportObjectsTx(tx *sql.Tx, req models.ObjectImportRequest, userID, profileID int, changes *ChangeLog) (*models.ObjectImportResponse, error) {
 	folderID, _ := TransformUUID(req.FolderId)
 	libraryID, _ := TransformUUID(req.LibraryId)
 
-	fmt.Println("transform: folder id", folderID)
-	fmt.Println("transform: library id", libraryID)
-	//checks
-	checkFolderSql := `
-	  SELECT ObjectTypeId,LibraryId 
-	  FROM Object WHERE ObjectID = @p1
-	`
-	var objectTypeId *int64
-	var libraryId uuid.UUID
-	err := r.db.QueryRow(checkFolderSql, folderID).Scan(
-		&objectTypeId,
-		&libraryId,
-	)
-	fmt.Println("transform: library id after", libraryID)
+	// The handler authorizes the folder only, so it must be a folder or
+	// library of the library being imported into
+	if _, err := lockPlacementTarget(tx, &req.FolderId, &req.LibraryId); err != nil {
+		return nil, err
+	}
+
+	locale, err := utils.LookupValueLocale(req.Locale)
+	if err != nil {
+		return nil, err
+	}
+	locale.ExcelDates = req.ExcelDates
+	definitions, err := r.attributeRepository.GetAssignedAttributeDefinitions(req.ObjectTypeId)
 	if err != nil {
-		fmt.Println(err)
+		return nil, err
+	}
+	parser := importValueParser{locale: locale, definitions: make(map[uuid.UUID]models.ObjectTypeAssignedAttribute, len(definitions))}
+	for _, definition := range definitions {
+		parser.definitions[definition.AttributeId] = definition
+	}
+	if parser.key, err = resolveImportKey(req, definitions); err != nil {
 		return nil, err
 	}
 
-	// Check if object exists query
-	checkExistsSql := `
-		SELECT ObjectID,CurrentVersionId FROM [Object] 
-		WHERE ObjectName = @p1 AND ExactObjectTypeID = @p2 and libraryid = @p3
-	`
+	response := &models.ObjectImportResponse{
+		DryRun: req.DryRun,
+		Rows:   make([]models.ImportRowResult, 0, len(req.Data)),
+	}
+	for i, data := range req.Data {
+		result, err := r.importRow(tx, req, parser, data, folderID, libraryID, userID, profileID, changes)
+		if err != nil {
+			return nil, err
+		}
+		result.Row = i + 1
+		switch {
+		case len(result.Errors) > 0:
+			response.FailedImportObjectCount++
+		case result.Action == models.ImportActionUnchanged:
+			response.SkippedImportObjectCount++
+		default:
+			response.SuccessImportedObjectCount++
+		}
+		response.Rows = append(response.Rows, result)
+	}
+	response.TotalImportedObjectCount = response.SuccessImportedObjectCount + response.FailedImportObjectCount + response.SkippedImportObjectCount
 
-	// Update existing object query
-	updateSql := `
-		UPDATE [Object] SET 
-			ObjectDescription = @p1, 
-			ObjectTypeID = @p2, 
-			Locked = @p3, 
-			IsImported = @p4, 
-			IsLibrary = @p5, 
-			LibraryId = @p6, 
-			FileExtension = @p7, 
-			Prefix = @p8, 
-			Suffix = @p9, 
-			DateModified = @p10, 
-			ModifiedBy = @p11, 
-			RichTextDescription = @p12, 
-			GeneralType = @p13, 
-			HasVisioAlias = @p14
-		WHERE ObjectID = @p15
-	`
+	response.Success = true
+	return response, nil
+}
 
-	var insertedObjectCount, insertedFailedObjectCount int
-	insertedObjectCount = 0
-	insertedFailedObjectCount = 0
-	var attrs []models.AssignedAttribute
-	tx, _ := r.db.Begin()
-	for _, data := range req.Data {
-		var objectName string
-		var description string
-		var objectId uuid.UUID
-		var versionId uuid.UUID
-		var currentAttrs []models.AssignedAttribute
-
-		// First pass: collect data
-		for key, entry := range data {
-			if key == "Object Name" {
-				if entry.AttributeValue != nil {
-					objectName = *entry.AttributeValue
-				}
-			} else if key == "Description" {
-				if entry.AttributeValue != nil {
-					description = *entry.AttributeValue
-				}
-			} else {
-				// Attribute processing
-				var row models.AssignedAttribute
-				if entry.AttributeId != nil {
-					var attrUUID, _ = uuid.Parse(*entry.AttributeId)
-					fmt.Print("attribute_id", attrUUID)
-					row.AttributeID = attrUUID
-				}
-				if entry.AttributeName != nil {
-					row.AttributeName = *entry.AttributeName
-				}
-				if entry.AttributeType != nil {
-					row.AttributeType = *entry.AttributeType
-				}
-				if r.GetTypeId(row.AttributeType) == 4 {
-					row.TextValue = entry.AttributeValue
-				}
-				if r.GetTypeId(row.AttributeType) == 1 {
-					if entry.AttributeValue != nil {
-						val, err := strconv.Atoi(*entry.AttributeValue)
-						if err == nil {
-							row.IntegerValue = &val
-						}
-					}
-				}
-				if row.AttributeID != uuid.Nil {
-					currentAttrs = append(currentAttrs, row)
-				}
+// importKey is the matching key and strategy of an import. attribute is set
+// for the externalId and autoId keys.
+type importKey struct {
+	matchBy   string
+	strategy  string
+	attribute *models.ObjectTypeAssignedAttribute
+}
+
+// ValidateImportKey checks the matching key and strategy of req against the
+// attributes assigned to its object type
+func ValidateImportKey(req models.ObjectImportRequest, definitions []models.ObjectTypeAssignedAttribute) error {
+	_, err := resolveImportKey(req, definitions)
+	return err
+}
+
+// resolveImportKey validates the matching key and strategy of req, applying
+// the defaults of matching by name and upserting
+func resolveImportKey(req models.ObjectImportRequest, definitions []models.ObjectTypeAssignedAttribute) (importKey, error) {
+	key := importKey{matchBy: req.MatchBy, strategy: req.Strategy}
+	if key.matchBy == "" {
+		key.matchBy = models.ImportMatchByName
+	}
+	if key.strategy == "" {
+		key.strategy = models.ImportStrategyUpsert
+	}
+
+	switch key.strategy {
+	case models.ImportStrategyUpsert, models.ImportStrategyInsertOnly, models.ImportStrategyUpdateOnly, models.ImportStrategySkipIfUnchanged:
+	default:
+		return key, fmt.Errorf("%w: unknown strategy %q", ErrInvalidImportKey, req.Strategy)
+	}
+
+	switch key.matchBy {
+	case models.ImportMatchByName, models.ImportMatchByObjectID:
+		return key, nil
+	case models.ImportMatchByExternalID, models.ImportMatchByAutoID:
+	default:
+		return key, fmt.Errorf("%w: unknown matchBy %q", ErrInvalidImportKey, req.MatchBy)
+	}
+
+	if req.MatchAttributeId == nil || strings.TrimSpace(*req.MatchAttributeId) == "" {
+		return key, fmt.Errorf("%w: matchAttributeId is required when matching by %s", ErrInvalidImportKey, key.matchBy)
+	}
+	attrID, err := uuid.Parse(strings.TrimSpace(*req.MatchAttributeId))
+	if err != nil {
+		return key, fmt.Errorf("%w: invalid matchAttributeId %q", ErrInvalidImportKey, *req.MatchAttributeId)
+	}
+	for i := range definitions {
+		if definitions[i].AttributeId == attrID {
+			key.attribute = &definitions[i]
+			break
+		}
+	}
+	if key.attribute == nil {
+		return key, fmt.Errorf("%w: attribute %s is not assigned to this object type", ErrInvalidImportKey, attrID)
+	}
+	if typeID := getTypeId(key.attribute.AttributeType); typeID != 1 && typeID != 4 {
+		return key, fmt.Errorf("%w: %s keys must be text or integer attributes", ErrInvalidImportKey, key.matchBy)
+	}
+	return key, nil
+}
+
+// importMatch is the existing object an import row was matched to. objectID
+// and versionID are parsed from SQL Server's byte order, so they are both
+// reported and passed to queries as they are.
+type importMatch struct {
+	objectID    uuid.UUID
+	versionID   uuid.UUID
+	objectName  string
+	description string
+}
+
+// importRow validates and writes a single import row inside a savepoint. Row
+// problems are reported in the result; the returned error is reserved for
+// failures that leave the transaction unusable or the row unrecorded.
+func (r *ObjectRepository) importRow(tx *sql.Tx, req models.ObjectImportRequest, parser importValueParser, data map[string]models.ObjectImportRow, folderID, libraryID uuid.UUID, userID, profileID int, changes *ChangeLog) (models.ImportRowResult, error) {
+	values := r.parseImportRow(parser, data)
+	result := models.ImportRowResult{
+		Action:     models.ImportActionSkip,
+		ObjectName: values.objectName,
+		Errors:     values.errors,
+	}
+	if parser.key.matchBy == models.ImportMatchByName && values.objectName == "" {
+		result.Errors = append(result.Errors, models.ImportRowError{Field: "Object Name", Message: "object name is required"})
+		return result, nil
+	}
+	if len(result.Errors) > 0 {
+		return result, nil
+	}
+
+	match, err := r.findImportMatch(tx, req, parser.key, values, libraryID)
+	if err != nil {
+		result.Errors = append(result.Errors, *err)
+		return result, nil
+	}
+
+	if match == nil {
+		switch {
+		case parser.key.strategy == models.ImportStrategyUpdateOnly:
+			result.Action = models.ImportActionUnchanged
+			result.Message = "no matching object"
+			return result, nil
+		case values.objectName == "":
+			result.Errors = append(result.Errors, models.ImportRowError{Field: "Object Name", Message: "object name is required to create an object"})
+			return result, nil
+		}
+		result.Action = models.ImportActionInsert
+	} else {
+		objectID := match.objectID
+		result.ObjectID = &objectID
+		if result.ObjectName == "" {
+			result.ObjectName = match.objectName
+		}
+
+		if parser.key.strategy == models.ImportStrategyInsertOnly {
+			result.Action = models.ImportActionUnchanged
+			result.Message = "object already exists"
+			return result, nil
+		}
+		if parser.key.strategy == models.ImportStrategySkipIfUnchanged {
+			unchanged, err := r.importRowUnchanged(tx, match, values)
+			if err != nil {
+				result.Errors = append(result.Errors, models.ImportRowError{Message: fmt.Sprintf("error comparing object values: %v", err)})
+				return result, nil
+			}
+			if unchanged {
+				result.Action = models.ImportActionUnchanged
+				result.Message = "no changes"
+				return result, nil
 			}
 		}
 
-		if objectName == "" {
-			insertedFailedObjectCount++
-			continue
+		// Existing objects may only be updated if the profile may modify them
+		perm, err := getEffectivePermission(tx, match.objectID, profileID)
+		if err != nil {
+			result.Errors = append(result.Errors, models.ImportRowError{Message: fmt.Sprintf("error checking permissions: %v", err)})
+			return result, nil
+		}
+		if !perm.HasModify {
+			result.Errors = append(result.Errors, models.ImportRowError{Message: "profile does not have modify permission on the existing object"})
+			return result, nil
+		}
+		// A checked-out object may only be changed by its checkout holder
+		if err := lockForEdit(tx, match.objectID, userID); err != nil {
+			result.Errors = append(result.Errors, models.ImportRowError{Message: err.Error()})
+			return result, nil
+		}
+		result.Action = models.ImportActionUpdate
+	}
+
+	if _, err := tx.Exec("SAVE TRANSACTION importRow"); err != nil {
+		return result, fmt.Errorf("error creating import savepoint: %w", err)
+	}
+	fail := func(message string, err error) (models.ImportRowResult, error) {
+		result.Action = models.ImportActionSkip
+		result.Errors = append(result.Errors, models.ImportRowError{Message: fmt.Sprintf("%s: %v", message, err)})
+		if _, err := tx.Exec("ROLLBACK TRANSACTION importRow"); err != nil {
+			return result, fmt.Errorf("error rolling back import row: %w", err)
+		}
+		return result, nil
+	}
+
+	var objectId, versionId uuid.UUID
+	var before *models.Object
+	action := models.AuditActionCreate
+	if result.Action == models.ImportActionInsert {
+		var description string
+		if values.description != nil {
+			description = *values.description
+		}
+		genType := int(r.GetTypeId("string"))
+		createReq := models.CreateObjectRequest{
+			ObjectName:          values.objectName,
+			ObjectDescription:   description,
+			ObjectTypeID:        int(r.GetTypeId("string")),
+			ExactObjectTypeID:   req.ObjectTypeId,
+			RichTextDescription: r.toRTFUnicode(description),
+			IsLibrary:           false,
+			IsImported:          true,
+			LibraryId:           &libraryID,
+			DirectParentId:      &folderID,
+			CreatedBy:           userID,
+			GeneralType:         &genType,
 		}
 
-		// Check if object exists with the same ObjectName and ExactObjectTypeID
-		var existingObjectId uuid.UUID
-		var existingVersionId uuid.UUID
-		err = tx.QueryRow(checkExistsSql, objectName, req.ObjectTypeId, libraryID).Scan(&existingObjectId, &existingVersionId)
-
-		if err == sql.ErrNoRows {
-			// Object doesn't exist, insert new one using CreateV2
-			genType := int(r.GetTypeId("string"))
-			createReq := models.CreateObjectRequest{
-				ObjectName:          objectName,
-				ObjectDescription:   description,
-				ObjectTypeID:        int(r.GetTypeId("string")),
-				ExactObjectTypeID:   req.ObjectTypeId,
-				RichTextDescription: r.toRTFUnicode(description),
-				IsLibrary:           false,
-				IsImported:          true,
-				LibraryId:           &libraryID,
-				DirectParentId:      &folderID,
-				CreatedBy:           62,
-				GeneralType:         &genType,
+		createdObj, err := r.CreateV2(tx, createReq)
+		if err != nil {
+			return fail("error inserting object", err)
+		}
+		objectId = createdObj.ObjectID
+		versionId = *createdObj.CurrentVersionId
+		if !req.DryRun {
+			result.ObjectID = &objectId
+		}
+	} else {
+		// Only the columns present in the row are changed; a non-name key
+		// lets the row rename the object
+		objectName := match.objectName
+		if values.objectName != "" {
+			objectName = values.objectName
+		}
+		var description, richText *string
+		if values.description != nil {
+			rtf := r.toRTFUnicode(*values.description)
+			description, richText = values.description, &rtf
+		}
+		if changes != nil {
+			var err error
+			if before, err = getObject(tx, match.objectID); err != nil {
+				return fail("error reading object", err)
 			}
+		}
+		action = models.AuditActionUpdate
+		_, err := tx.Exec(importUpdateSql, objectName, values.description != nil, description, richText,
+			time.Now(), userID, match.objectID)
+		if err != nil {
+			return fail("error updating object", err)
+		}
+		if _, err := tx.Exec(syncCurrentVersionSql, match.objectID); err != nil {
+			return fail("error updating object version", err)
+		}
+		objectId = match.objectID
+		versionId = match.versionID
+	}
 
-			createdObj, err := r.CreateV2(tx, createReq)
-			if err != nil {
-				insertedFailedObjectCount++
-				fmt.Println("error insert Object", err)
-				continue
+	for i := range values.attrs {
+		values.attrs[i].ObjectId = objectId
+		values.attrs[i].VersionId = versionId
+	}
+	if err := r.attributeRepository.UpdateAttributeValueTx(tx, values.attrs, userID, changes); err != nil {
+		return fail("error writing attribute values", err)
+	}
+	if err := recordObject(tx, changes, objectId, action, before); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// findImportMatch looks up the existing object a row refers to by the import
+// key. It returns nil when the row matches no object and may be inserted.
+func (r *ObjectRepository) findImportMatch(tx *sql.Tx, req models.ObjectImportRequest, key importKey, values importRowValues, libraryID uuid.UUID) (*importMatch, *models.ImportRowError) {
+	var query string
+	var args []interface{}
+	field := "Object Name"
+	if key.attribute != nil {
+		field = key.attribute.AttributeName
+	}
+
+	switch key.matchBy {
+	case models.ImportMatchByName:
+		query, args = importMatchByNameSql, []interface{}{values.objectName, req.ObjectTypeId, libraryID}
+	case models.ImportMatchByObjectID:
+		field = "Object ID"
+		if values.objectID == "" {
+			return nil, nil
+		}
+		id, err := uuid.Parse(values.objectID)
+		if err != nil {
+			return nil, &models.ImportRowError{Field: field, Value: &values.objectID, Message: "invalid object ID"}
+		}
+		id, _ = TransformUUID(id)
+		query, args = importMatchByObjectIDSql, []interface{}{id, req.ObjectTypeId, libraryID}
+	default:
+		if values.key == "" {
+			return nil, nil
+		}
+		var number *int
+		if key.matchBy == models.ImportMatchByAutoID {
+			number = autoIDNumber(values.key)
+		} else if getTypeId(key.attribute.AttributeType) == 1 {
+			if n, err := utils.ParseInteger(values.key, utils.ValueLocale{Detect: true}); err == nil {
+				number = &n
 			}
-			objectId = createdObj.ObjectID
-			versionId = *createdObj.CurrentVersionId
-		} else if err != nil {
-			// Error checking for existence
-			insertedFailedObjectCount++
-			fmt.Println("error checking object existence", err)
+		}
+		attrID, _ := TransformUUIDToSQLServerV2(key.attribute.AttributeId)
+		query, args = importMatchByAttributeSql, []interface{}{req.ObjectTypeId, libraryID, attrID, values.key, number}
+	}
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, &models.ImportRowError{Message: fmt.Sprintf("error checking object existence: %v", err)}
+	}
+	defer rows.Close()
+
+	var matches []importMatch
+	for rows.Next() {
+		var m importMatch
+		var objectIDBytes, versionIDBytes []byte
+		var description *string
+		if err := rows.Scan(&objectIDBytes, &versionIDBytes, &m.objectName, &description); err != nil {
+			return nil, &models.ImportRowError{Message: fmt.Sprintf("error checking object existence: %v", err)}
+		}
+		var err error
+		if m.objectID, err = parseSQLServerUUID(objectIDBytes); err != nil {
+			return nil, &models.ImportRowError{Message: fmt.Sprintf("error parsing ObjectID: %v", err)}
+		}
+		if m.versionID, err = parseSQLServerUUID(versionIDBytes); err != nil {
+			return nil, &models.ImportRowError{Message: fmt.Sprintf("error parsing CurrentVersionId: %v", err)}
+		}
+		if description != nil {
+			m.description = *description
+		}
+		matches = append(matches, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &models.ImportRowError{Message: fmt.Sprintf("error checking object existence: %v", err)}
+	}
+
+	keyValue := values.key
+	switch {
+	case len(matches) > 1:
+		return nil, &models.ImportRowError{Field: field, Value: &keyValue, Message: "more than one object matches this key"}
+	case len(matches) == 1:
+		return &matches[0], nil
+	case key.matchBy == models.ImportMatchByObjectID:
+		return nil, &models.ImportRowError{Field: field, Value: &keyValue, Message: "no object of this type in the library has this ID"}
+	case key.matchBy == models.ImportMatchByAutoID:
+		// Auto IDs are assigned by the system, so an unknown one cannot be created
+		return nil, &models.ImportRowError{Field: field, Value: &keyValue, Message: "no object has this auto ID"}
+	}
+	return nil, nil
+}
+
+// autoIDNumber returns the sequence number of an auto ID such as "APP-0042",
+// which is its last run of digits
+func autoIDNumber(value string) *int {
+	value = utils.NormalizeDigits(value)
+	end := len(value)
+	for end > 0 && (value[end-1] < '0' || value[end-1] > '9') {
+		end--
+	}
+	start := end
+	for start > 0 && value[start-1] >= '0' && value[start-1] <= '9' {
+		start--
+	}
+	n, err := strconv.Atoi(value[start:end])
+	if err != nil {
+		return nil
+	}
+	return &n
+}
+
+// importRowUnchanged reports whether applying the row would leave the
+// matched object's name, description and attribute values as they are
+func (r *ObjectRepository) importRowUnchanged(tx *sql.Tx, match *importMatch, values importRowValues) (bool, error) {
+	if values.objectName != "" && values.objectName != match.objectName {
+		return false, nil
+	}
+	if values.description != nil && *values.description != match.description {
+		return false, nil
+	}
+	if len(values.attrs) == 0 {
+		return true, nil
+	}
+
+	rows, err := tx.Query(importCurrentValuesSql, match.objectID, match.versionID)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	current := make(map[uuid.UUID]models.AssignedAttribute)
+	for rows.Next() {
+		var attr models.AssignedAttribute
+		if err := rows.Scan(&attr.AttributeID, &attr.TextValue, &attr.BooleanValue, &attr.DateValue,
+			&attr.FloatValue, &attr.IntegerValue, &attr.RichTextValue); err != nil {
+			return false, err
+		}
+		current[attr.AttributeID] = attr
+	}
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	for _, attr := range values.attrs {
+		existing, ok := current[attr.AttributeID]
+		if !ok {
+			return false, nil
+		}
+		switch {
+		case attr.TextValue != nil && !equalImportValue(attr.TextValue, existing.TextValue),
+			attr.RichTextValue != nil && !equalImportValue(attr.RichTextValue, existing.RichTextValue),
+			attr.IntegerValue != nil && !equalImportValue(attr.IntegerValue, existing.IntegerValue),
+			attr.FloatValue != nil && !equalImportValue(attr.FloatValue, existing.FloatValue),
+			attr.BooleanValue != nil && !equalImportValue(attr.BooleanValue, existing.BooleanValue),
+			attr.DateValue != nil && (existing.DateValue == nil || !attr.DateValue.Equal(*existing.DateValue)):
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func equalImportValue[T comparable](a, b *T) bool {
+	return a != nil && b != nil && *a == *b
+}
+
+// importValueParser converts import cell text to attribute values of the
+// object type being imported
+type importValueParser struct {
+	locale      utils.ValueLocale
+	definitions map[uuid.UUID]models.ObjectTypeAssignedAttribute
+	key         importKey
+}
+
+// importRowValues is the content of an import row. description is nil when
+// the row has no description, and key holds the text of the matching
+// attribute for the externalId and autoId keys.
+type importRowValues struct {
+	objectName  string
+	description *string
+	objectID    string
+	key         string
+	attrs       []models.AssignedAttribute
+	errors      []models.ImportRowError
+}
+
+// parseImportRow splits an import row into the object name, description and
+// attribute values, reporting every value that cannot be used
+func (r *ObjectRepository) parseImportRow(parser importValueParser, data map[string]models.ObjectImportRow) importRowValues {
+	var values importRowValues
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		entry := data[key]
+		if key == "Object Name" {
+			if entry.AttributeValue != nil {
+				values.objectName = strings.TrimSpace(*entry.AttributeValue)
+			}
+			continue
+		}
+		if key == "Object ID" {
+			if entry.AttributeValue != nil {
+				values.objectID = strings.TrimSpace(*entry.AttributeValue)
+			}
+			continue
+		}
+		if key == "Description" {
+			values.description = entry.AttributeValue
 			continue
-		} else {
-			// Object exists, update it
-			_, err = tx.Exec(updateSql, description, r.GetTypeId("string"), 0, 1, 0, libraryID, "", nil, nil,
-				time.Now(), 62, r.toRTFUnicode(description), r.GetTypeId("string"), 0, existingObjectId)
+		}
 
-			if err != nil {
-				insertedFailedObjectCount++
-				fmt.Println("error updating Object", err)
+		// Attribute processing
+		name := key
+		if entry.AttributeName != nil {
+			name = *entry.AttributeName
+		}
+		if entry.AttributeId == nil {
+			values.errors = append(values.errors, models.ImportRowError{Field: name, Value: entry.AttributeValue, Message: "attributeId is required"})
+			continue
+		}
+		attrUUID, err := uuid.Parse(*entry.AttributeId)
+		if err != nil {
+			values.errors = append(values.errors, models.ImportRowError{Field: name, Value: entry.AttributeValue, Message: fmt.Sprintf("invalid attributeId %q", *entry.AttributeId)})
+			continue
+		}
+		definition, ok := parser.definitions[attrUUID]
+		if !ok {
+			values.errors = append(values.errors, models.ImportRowError{Field: name, Value: entry.AttributeValue, Message: "attribute is not assigned to this object type"})
+			continue
+		}
+		if entry.AttributeValue == nil {
+			continue
+		}
+
+		if parser.key.attribute != nil && parser.key.attribute.AttributeId == attrUUID {
+			values.key = strings.TrimSpace(*entry.AttributeValue)
+			// Auto IDs are assigned by the system and never written by an import
+			if parser.key.matchBy == models.ImportMatchByAutoID {
 				continue
 			}
-			objectId = existingObjectId
-			versionId = existingVersionId
 		}
 
-		// Assign IDs to attributes and add to main list
-		for _, row := range currentAttrs {
-			row.ObjectId = objectId
-			row.VersionId = versionId
-			fmt.Print("row data", row)
-			attrs = append(attrs, row)
+		row := models.AssignedAttribute{
+			AttributeID:   attrUUID,
+			AttributeName: definition.AttributeName,
+			AttributeType: definition.AttributeType,
+		}
+		set, err := r.setImportValue(&row, definition, *entry.AttributeValue, parser.locale)
+		if err != nil {
+			values.errors = append(values.errors, models.ImportRowError{Field: definition.AttributeName, Value: entry.AttributeValue, Message: err.Error()})
+			continue
+		}
+		if set {
+			values.attrs = append(values.attrs, row)
 		}
-		insertedObjectCount++
 	}
-	err = r.attributeRepository.UpdateAttributeValue(attrs)
-	if err != nil {
-		tx.Rollback()
-		return nil, err
+
+	return values
+}
+
+// setImportValue parses value according to the attribute's data type and
+// stores it in the matching field of row. Blank values of non-text types set
+// nothing.
+func (r *ObjectRepository) setImportValue(row *models.AssignedAttribute, definition models.ObjectTypeAssignedAttribute, value string, loc utils.ValueLocale) (bool, error) {
+	typeID := r.GetTypeId(definition.AttributeType)
+	if strings.TrimSpace(value) == "" && typeID != 4 {
+		return false, nil
 	}
-	tx.Commit()
-	var response models.ObjectImportResponse
-	response.Success = true
-	response.FailedImportObjectCount = insertedFailedObjectCount
-	response.SuccessImportedObjectCount = insertedObjectCount
-	response.TotalImportedObjectCount = insertedFailedObjectCount + insertedObjectCount
-	return &response, nil
+
+	switch typeID {
+	case 1:
+		n, err := utils.ParseInteger(value, loc)
+		if err != nil {
+			return false, err
+		}
+		row.IntegerValue = &n
+	case 2:
+		t, err := utils.ParseDate(value, loc)
+		if err != nil {
+			return false, err
+		}
+		row.DateValue = &t
+	case 3:
+		f, err := utils.ParseNumber(value, loc)
+		if err != nil {
+			return false, err
+		}
+		row.FloatValue = &f
+	case 5:
+		b, err := utils.ParseBool(value)
+		if err != nil {
+			return false, err
+		}
+		row.BooleanValue = &b
+	case 6:
+		rtf := value
+		if !utils.IsRTF(value) {
+			rtf = r.toRTFUnicode(value)
+		}
+		row.RichTextValue = &rtf
+	default:
+		text := value
+		if options := importListValues(definition); options != nil && strings.TrimSpace(value) != "" {
+			match, ok := "", false
+			for _, option := range options {
+				if strings.EqualFold(option, strings.TrimSpace(value)) {
+					match, ok = option, true
+					break
+				}
+			}
+			if !ok {
+				return false, fmt.Errorf("%q is not one of the list values: %s", value, strings.Join(options, ", "))
+			}
+			text = match
+		}
+		row.TextValue = &text
+	}
+	return true, nil
+}
+
+// importListValues returns the allowed values of a list attribute, or nil for
+// attributes that accept free text
+func importListValues(definition models.ObjectTypeAssignedAttribute) []string {
+	if definition.ListValues == nil {
+		return nil
+	}
+	if !strings.EqualFold(definition.AttributeType, "list") && (definition.ListType == nil || *definition.ListType == 0) {
+		return nil
+	}
+	return utils.SplitListValues(*definition.ListValues)
 }
+
 func (r *ObjectRepository) GetTypeId(attributeType string) int64 {
+	return getTypeId(attributeType)
+}
+
+// getTypeId maps an attribute type name to its AttributeValue DataType code
+func getTypeId(attributeType string) int64 {
 	switch strings.ToLower(attributeType) {
 	case "string":
 		return 4
@@ -226,14 +765,14 @@ func (r *ObjectRepository) toRTFUnicode(s string) string {
 	}
 	return rtf
 }
-func (r *ObjectRepository) CreateObjectVersion(objectId uuid.UUID, objectName string, objectDescription string) (*uuid.UUID, error) {
+func (r *ObjectRepository) CreateObjectVersion(objectId uuid.UUID, objectName string, objectDescription string, userID int) (*uuid.UUID, error) {
 	var versionId uuid.UUID
 	query := `INSERT INTO [VERSION] (ID, ObjectID,objectName,ObjectDescription, SystemVersionNo, userVersionNo,DateCreated,DateModified,ModifiedBy, CreatedBy) VALUES(
 		@p1, @p2, @p3, @p4, @p5, @p6, @p7, @p8, @p9, @p10
 	)`
 	versionId = uuid.New()
 	_, err := r.db.Exec(query,
-		versionId, objectId, objectName, objectDescription, 1, "v1", time.Now(), time.Now(), 62, 62,
+		versionId, objectId, objectName, objectDescription, 1, "v1", time.Now(), time.Now(), userID, userID,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error creating object version: %w", err)
@@ -241,14 +780,14 @@ func (r *ObjectRepository) CreateObjectVersion(objectId uuid.UUID, objectName st
 	return &versionId, nil
 }
 
-func (r *ObjectRepository) CreateObjectVersionWithTx(tx *sql.Tx, objectId uuid.UUID, objectName string, objectDescription string) (*uuid.UUID, error) {
+func (r *ObjectRepository) CreateObjectVersionWithTx(tx *sql.Tx, objectId uuid.UUID, objectName string, objectDescription string, userID int) (*uuid.UUID, error) {
 	var versionId uuid.UUID
 	query := `INSERT INTO [VERSION] (ID, ObjectID,objectName,ObjectDescription, SystemVersionNo, userVersionNo,DateCreated,DateModified,ModifiedBy, CreatedBy) VALUES(
 		@p1, @p2, @p3, @p4, @p5, @p6, @p7, @p8, @p9, @p10
 	)`
 	versionId = uuid.New()
 	_, err := tx.Exec(query,
-		versionId, objectId, objectName, objectDescription, 1, "v1", time.Now(), time.Now(), 62, 62,
+		versionId, objectId, objectName, objectDescription, 1, "v1", time.Now(), time.Now(), userID, userID,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error creating object version with tx: %w", err)
@@ -287,7 +826,7 @@ func (r *ObjectRepository) CreateV2(tx *sql.Tx, req models.CreateObjectRequest)
 			@p1, @p2, @p3, @p4, @p5, @p6, @p7, @p8, @p9, @p10, @p11, @p12, @p13, @p14, @p15, @p16, @p17, @p18, @p19,@p20,@p21,@p22
 		)
 	`
-	versionId, err := r.CreateObjectVersionWithTx(tx, objectID, req.ObjectName, req.ObjectDescription)
+	versionId, err := r.CreateObjectVersionWithTx(tx, objectID, req.ObjectName, req.ObjectDescription, req.CreatedBy)
 	if err != nil {
 		return nil, fmt.Errorf("error creating object version: %w", err)
 	}
@@ -364,7 +903,7 @@ func (r *ObjectRepository) CreateV2(tx *sql.Tx, req models.CreateObjectRequest)
 }
 
 // Create creates a new object in the database
-func (r *ObjectRepository) Create(req models.CreateObjectRequest) (*models.Object, error) {
+func (r *ObjectRepository) Create(req models.CreateObjectRequest, changes *ChangeLog) (*models.Object, error) {
 	objectID := uuid.New()
 	now := time.Now()
 
@@ -376,10 +915,16 @@ func (r *ObjectRepository) Create(req models.CreateObjectRequest) (*models.Objec
 		libraryId = &val
 	}
 
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := ` SELECT GeneralType from ObjectType where ObjectTypeID =@p1`
 	if req.GeneralType == nil {
 		req.GeneralType = new(int)
-		err := r.db.QueryRow(query, req.ObjectTypeID).Scan(req.GeneralType)
+		err := tx.QueryRow(query, req.ObjectTypeID).Scan(req.GeneralType)
 		if err != nil {
 			return nil, fmt.Errorf("error getting object type general type: %w", err)
 		}
@@ -394,11 +939,11 @@ func (r *ObjectRepository) Create(req models.CreateObjectRequest) (*models.Objec
 			@p1, @p2, @p3, @p4, @p5, @p6, @p7, @p8, @p9, @p10, @p11, @p12, @p13, @p14, @p15, @p16, @p17, @p18, @p19,@p20,@p21,@p22
 		)
 	`
-	versionId, err := r.CreateObjectVersion(objectID, req.ObjectName, req.ObjectDescription)
+	versionId, err := r.CreateObjectVersionWithTx(tx, objectID, req.ObjectName, req.ObjectDescription, req.CreatedBy)
 	if err != nil {
 		return nil, fmt.Errorf("error creating object version: %w", err)
 	}
-	_, err = r.db.Exec(query,
+	_, err = tx.Exec(query,
 		objectID, req.ObjectName, req.ObjectDescription, req.ObjectTypeID, false, false,
 		req.IsLibrary, libraryId, req.FileExtension, req.Prefix, req.Suffix, now, req.CreatedBy,
 		now, req.CreatedBy, false, req.ExactObjectTypeID, versionId, versionId, 0, req.RichTextDescription, req.GeneralType,
@@ -408,6 +953,12 @@ func (r *ObjectRepository) Create(req models.CreateObjectRequest) (*models.Objec
 	if err != nil {
 		return nil, fmt.Errorf("error creating object: %w", err)
 	}
+	if err := recordObject(tx, changes, objectID, models.AuditActionCreate, nil); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing object create: %w", err)
+	}
 	objectData, _ := r.GetByID(objectID)
 	fmt.Println("get db version id", objectData.CurrentVersionId)
 	return objectData, nil
@@ -415,6 +966,13 @@ func (r *ObjectRepository) Create(req models.CreateObjectRequest) (*models.Objec
 
 // GetByID retrieves an object by its ID
 func (r *ObjectRepository) GetByID(id uuid.UUID) (*models.Object, error) {
+	id, _ = TransformUUID(id)
+	return getObject(r.db, id)
+}
+
+// getObject retrieves an object that is not in the recycle bin on a database
+// or transaction. dbID must already be in SQL Server byte order.
+func getObject(q dbQuerier, dbID uuid.UUID) (*models.Object, error) {
 	query := `
 		SELECT ObjectID, ObjectName, ObjectDescription, ObjectTypeID, CheckedInVersionId, 
 			DeleteFlag, Locked, RequiresShapeSheetUpdate, TemplateID, IsImported, IsLibrary, 
@@ -423,11 +981,10 @@ func (r *ObjectRepository) GetByID(id uuid.UUID) (*models.Object, error) {
 			DateModified, ModifiedBy, IsCheckedOut, CheckedOutUserId, DeleteTransactionId, 
 			NameChecksum, ExactObjectTypeID, RichTextDescription, AutoSort
 		FROM [Object]
-		WHERE ObjectID = @p1
+		WHERE ObjectID = @p1 AND ISNULL(DeleteFlag, 0) = 0
 	`
-	id, _ = TransformUUID(id)
 	obj := &models.Object{}
-	err := r.db.QueryRow(query, id).Scan(
+	err := q.QueryRow(query, dbID).Scan(
 		&obj.ObjectID, &obj.ObjectName, &obj.ObjectDescription, &obj.ObjectTypeID, &obj.CheckedInVersionId,
 		&obj.DeleteFlag, &obj.Locked, &obj.RequiresShapeSheetUpdate, &obj.TemplateID, &obj.IsImported,
 		&obj.IsLibrary, &obj.LibraryId, &obj.FileExtension, &obj.SortOrder, &obj.Prefix, &obj.Suffix,
@@ -436,10 +993,8 @@ func (r *ObjectRepository) GetByID(id uuid.UUID) (*models.Object, error) {
 		&obj.ModifiedBy, &obj.IsCheckedOut, &obj.CheckedOutUserId, &obj.DeleteTransactionId,
 		&obj.NameChecksum, &obj.ExactObjectTypeID, &obj.RichTextDescription, &obj.AutoSort,
 	)
-	fmt.Println("current version get from db", obj.CurrentVersionId)
-	fmt.Println("current name get from db", obj.ObjectName)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("object not found")
+		return nil, ErrObjectNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving object: %w", err)
@@ -448,60 +1003,54 @@ func (r *ObjectRepository) GetByID(id uuid.UUID) (*models.Object, error) {
 	return obj, nil
 }
 
-// GetAll retrieves all objects with pagination
-func (r *ObjectRepository) GetAll(page, pageSize int) ([]models.Object, int, error) {
-	offset := (page - 1) * pageSize
+// recordObject reads an object in a change's transaction, records the change
+// in the log and queues a webhook event with the object after the change as
+// its data. A missing object is recorded as nil, so that objects created or
+// deleted by the change have no before or after snapshot.
+func recordObject(tx *sql.Tx, changes *ChangeLog, dbID uuid.UUID, action string, before *models.Object) error {
+	return recordObjectEvent(tx, changes, dbID, action, before, nil)
+}
 
-	// Get total count
-	var totalCount int
-	countQuery := `SELECT COUNT(*) FROM [Object]`
-	err := r.db.QueryRow(countQuery).Scan(&totalCount)
-	if err != nil {
-		return nil, 0, fmt.Errorf("error counting objects: %w", err)
+// recordObjectEvent is recordObject with data, unless nil, as the data of the
+// webhook event
+func recordObjectEvent(tx *sql.Tx, changes *ChangeLog, dbID uuid.UUID, action string, before *models.Object, data interface{}) error {
+	if changes == nil {
+		return nil
 	}
-
-	// Get paginated results
-	query := `
-		SELECT ObjectID, ObjectName, ObjectDescription, ObjectTypeID, CheckedInVersionId, 
-			DeleteFlag, Locked, RequiresShapeSheetUpdate, TemplateID, IsImported, IsLibrary, 
-			LibraryId, FileExtension, SortOrder, Prefix, Suffix, ProvenanceId, ProvenanceVersionId, 
-			GeneralType, CurrentVersionId, VisioAlias, HasVisioAlias, DateCreated, CreatedBy, 
-			DateModified, ModifiedBy, IsCheckedOut, CheckedOutUserId, DeleteTransactionId, 
-			NameChecksum, ExactObjectTypeID, RichTextDescription, AutoSort
-		FROM [Object]
-		ORDER BY DateCreated DESC
-		OFFSET @p1 ROWS FETCH NEXT @p2 ROWS ONLY
-	`
-
-	rows, err := r.db.Query(query, offset, pageSize)
-	if err != nil {
-		return nil, 0, fmt.Errorf("error retrieving objects: %w", err)
+	after, err := getObject(tx, dbID)
+	if err != nil && err != ErrObjectNotFound {
+		return err
 	}
-	defer rows.Close()
-
-	var objects []models.Object
-	for rows.Next() {
-		var obj models.Object
-		err := rows.Scan(
-			&obj.ObjectID, &obj.ObjectName, &obj.ObjectDescription, &obj.ObjectTypeID, &obj.CheckedInVersionId,
-			&obj.DeleteFlag, &obj.Locked, &obj.RequiresShapeSheetUpdate, &obj.TemplateID, &obj.IsImported,
-			&obj.IsLibrary, &obj.LibraryId, &obj.FileExtension, &obj.SortOrder, &obj.Prefix, &obj.Suffix,
-			&obj.ProvenanceId, &obj.ProvenanceVersionId, &obj.GeneralType, &obj.CurrentVersionId,
-			&obj.VisioAlias, &obj.HasVisioAlias, &obj.DateCreated, &obj.CreatedBy, &obj.DateModified,
-			&obj.ModifiedBy, &obj.IsCheckedOut, &obj.CheckedOutUserId, &obj.DeleteTransactionId,
-			&obj.NameChecksum, &obj.ExactObjectTypeID, &obj.RichTextDescription, &obj.AutoSort,
-		)
-		if err != nil {
-			return nil, 0, fmt.Errorf("error scanning object: %w", err)
-		}
-		objects = append(objects, obj)
+	entity := after
+	if entity == nil {
+		entity = before
+	}
+	if entity == nil {
+		return nil
 	}
+	if err := changes.Record(tx, models.AuditEntityObject, entity.ObjectID.String(), action, before, after); err != nil {
+		return err
+	}
+	if data == nil && after != nil {
+		data = after
+	}
+	return changes.Publish(tx, models.WebhookEvent{
+		EntityType: models.WebhookEntityObject,
+		EntityId:   entity.ObjectID.String(),
+		Action:     action,
+		ObjectId:   entity.ObjectID,
+		Data:       data,
+	})
+}
 
-	return objects, totalCount, nil
+// GetAll retrieves a page of the objects the profile can read that match the
+// query's filter
+func (r *ObjectRepository) GetAll(q models.ObjectListQuery, profileID int) ([]models.Object, models.PageInfo, []models.Facet, error) {
+	return r.listObjects(r.newObjectFilter(profileID), "", q)
 }
 
 // Update updates an existing object
-func (r *ObjectRepository) Update(id uuid.UUID, req models.UpdateObjectRequest) (*models.Object, error) {
+func (r *ObjectRepository) Update(id uuid.UUID, req models.UpdateObjectRequest, changes *ChangeLog) (*models.Object, error) {
 	// Build dynamic update query
 	var setClauses []string
 	var args []interface{}
@@ -571,42 +1120,99 @@ func (r *ObjectRepository) Update(id uuid.UUID, req models.UpdateObjectRequest)
 	args = append(args, id)
 	query := fmt.Sprintf("UPDATE [Object] SET %s WHERE ObjectID = @p%d", strings.Join(setClauses, ", "), argIndex)
 
-	_, err := r.db.Exec(query, args...)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockForEdit(tx, id, req.ModifiedBy); err != nil {
+		return nil, err
+	}
+	before, err := getObject(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("error updating object: %w", err)
 	}
 
+	_, err = tx.Exec(syncCurrentVersionSql, id)
+	if err != nil {
+		return nil, fmt.Errorf("error updating object version: %w", err)
+	}
+
+	if err := recordObject(tx, changes, id, models.AuditActionUpdate, before); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing object update: %w", err)
+	}
+
 	return r.GetByID(id)
 }
 
-// Delete deletes an object by its ID
-func (r *ObjectRepository) Delete(id uuid.UUID) error {
-	query := `DELETE FROM [Object] WHERE ObjectID = @p1`
-	result, err := r.db.Exec(query, id)
+// Delete moves an object and everything in its folder tree to the recycle
+// bin as one delete transaction, which it returns
+func (r *ObjectRepository) Delete(id uuid.UUID, userID int, changes *ChangeLog) (*models.RecycleBinEntry, error) {
+	id, _ = TransformUUID(id)
+
+	tx, err := r.db.Begin()
 	if err != nil {
-		return fmt.Errorf("error deleting object: %w", err)
+		return nil, fmt.Errorf("error starting transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	rowsAffected, err := result.RowsAffected()
+	var deleted bool
+	err = tx.QueryRow(`SELECT CAST(ISNULL(DeleteFlag, 0) AS BIT) FROM [Object] WITH (UPDLOCK) WHERE ObjectID = @p1`, id).Scan(&deleted)
+	if err == sql.ErrNoRows {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving object: %w", err)
+	}
+	if deleted {
+		return nil, ErrObjectDeleted
+	}
+	before, err := getObject(tx, id)
 	if err != nil {
-		return fmt.Errorf("error getting rows affected: %w", err)
+		return nil, err
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("object not found")
+	transactionID := uuid.New()
+	var blocked bool
+	if err := tx.QueryRow(softDeleteSql, id, transactionID, userID).Scan(&blocked); err != nil {
+		return nil, fmt.Errorf("error deleting object: %w", err)
+	}
+	if blocked {
+		return nil, ErrDeleteCheckedOut
 	}
 
-	return nil
+	entry, err := getRecycleBinEntry(tx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordObjectEvent(tx, changes, id, models.AuditActionDelete, before, entry); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing object delete: %w", err)
+	}
+	return entry, nil
 }
 
-// GetLibraries retrieves all objects where IsLibrary is true
-func (r *ObjectRepository) GetLibraries(page, pageSize int) ([]models.Object, int, error) {
+// GetLibraries retrieves all readable objects where IsLibrary is true
+func (r *ObjectRepository) GetLibraries(page, pageSize, profileID int) ([]models.Object, int, error) {
 	offset := (page - 1) * pageSize
 
 	// Get total count
 	var totalCount int
-	countQuery := `SELECT COUNT(*) FROM [Object] WHERE IsLibrary = 1`
-	err := r.db.QueryRow(countQuery).Scan(&totalCount)
+	countQuery := `SELECT COUNT(*) FROM [Object] WHERE IsLibrary = 1 AND ISNULL(DeleteFlag, 0) = 0 AND ` + fmt.Sprintf(readableObjectFilter, "@p1")
+	err := r.db.QueryRow(countQuery, profileID).Scan(&totalCount)
 	if err != nil {
 		return nil, 0, fmt.Errorf("error counting libraries: %w", err)
 	}
@@ -620,12 +1226,12 @@ func (r *ObjectRepository) GetLibraries(page, pageSize int) ([]models.Object, in
 			DateModified, ModifiedBy, IsCheckedOut, CheckedOutUserId, DeleteTransactionId, 
 			NameChecksum, ExactObjectTypeID, RichTextDescription, AutoSort
 		FROM [Object]
-		WHERE IsLibrary = 1
+		WHERE IsLibrary = 1 AND ISNULL(DeleteFlag, 0) = 0 AND ` + fmt.Sprintf(readableObjectFilter, "@p3") + `
 		ORDER BY DateCreated DESC
 		OFFSET @p1 ROWS FETCH NEXT @p2 ROWS ONLY
 	`
 
-	rows, err := r.db.Query(query, offset, pageSize)
+	rows, err := r.db.Query(query, offset, pageSize, profileID)
 	if err != nil {
 		return nil, 0, fmt.Errorf("error retrieving libraries: %w", err)
 	}
@@ -710,57 +1316,11 @@ func (r *ObjectRepository) GetLibraries(page, pageSize int) ([]models.Object, in
 	return objects, totalCount, nil
 }
 
-// GetByObjectTypeID retrieves all objects by ObjectTypeID with pagination
-func (r *ObjectRepository) GetByObjectTypeID(objectTypeID, page, pageSize int) ([]models.Object, int, error) {
-	offset := (page - 1) * pageSize
-
-	// Get total count
-	var totalCount int
-	countQuery := `SELECT COUNT(*) FROM [Object] WHERE ObjectTypeID = @p1`
-	err := r.db.QueryRow(countQuery, objectTypeID).Scan(&totalCount)
-	if err != nil {
-		return nil, 0, fmt.Errorf("error counting objects by type: %w", err)
-	}
-
-	// Get paginated results
-	query := `
-		SELECT ObjectID, ObjectName, ObjectDescription, ObjectTypeID, CheckedInVersionId, 
-			DeleteFlag, Locked, RequiresShapeSheetUpdate, TemplateID, IsImported, IsLibrary, 
-			LibraryId, FileExtension, SortOrder, Prefix, Suffix, ProvenanceId, ProvenanceVersionId, 
-			GeneralType, CurrentVersionId, VisioAlias, HasVisioAlias, DateCreated, CreatedBy, 
-			DateModified, ModifiedBy, IsCheckedOut, CheckedOutUserId, DeleteTransactionId, 
-			NameChecksum, ExactObjectTypeID, RichTextDescription, AutoSort
-		FROM [Object]
-		WHERE ObjectTypeID = @p1
-		ORDER BY DateCreated DESC
-		OFFSET @p2 ROWS FETCH NEXT @p3 ROWS ONLY
-	`
-
-	rows, err := r.db.Query(query, objectTypeID, offset, pageSize)
-	if err != nil {
-		return nil, 0, fmt.Errorf("error retrieving objects by type: %w", err)
-	}
-	defer rows.Close()
-
-	var objects []models.Object
-	for rows.Next() {
-		var obj models.Object
-		err := rows.Scan(
-			&obj.ObjectID, &obj.ObjectName, &obj.ObjectDescription, &obj.ObjectTypeID, &obj.CheckedInVersionId,
-			&obj.DeleteFlag, &obj.Locked, &obj.RequiresShapeSheetUpdate, &obj.TemplateID, &obj.IsImported,
-			&obj.IsLibrary, &obj.LibraryId, &obj.FileExtension, &obj.SortOrder, &obj.Prefix, &obj.Suffix,
-			&obj.ProvenanceId, &obj.ProvenanceVersionId, &obj.GeneralType, &obj.CurrentVersionId,
-			&obj.VisioAlias, &obj.HasVisioAlias, &obj.DateCreated, &obj.CreatedBy, &obj.DateModified,
-			&obj.ModifiedBy, &obj.IsCheckedOut, &obj.CheckedOutUserId, &obj.DeleteTransactionId,
-			&obj.NameChecksum, &obj.ExactObjectTypeID, &obj.RichTextDescription, &obj.AutoSort,
-		)
-		if err != nil {
-			return nil, 0, fmt.Errorf("error scanning object: %w", err)
-		}
-		objects = append(objects, obj)
-	}
-
-	return objects, totalCount, nil
+// GetByObjectTypeID retrieves a page of the readable objects with an
+// ObjectTypeID that match the query's filter
+func (r *ObjectRepository) GetByObjectTypeID(objectTypeID int, q models.ObjectListQuery, profileID int) ([]models.Object, models.PageInfo, []models.Facet, error) {
+	f := r.newObjectFilter(profileID)
+	return r.listObjects(f, "[Object].ObjectTypeID = "+f.param(objectTypeID), q)
 }
 
 func (r *ObjectRepository) GetHierarchyFolder(ObjectID uuid.UUID, profileID int, isFolder bool) ([]models.ObjectTree, error) {
@@ -1177,124 +1737,13 @@ LEFT JOIN dbo.ObjectPermissions AS op
 	return objects, nil
 }
 
-func (r *ObjectRepository) GetByObjectTypeIDAndLibraryID(objectTypeID int, libraryID uuid.UUID, page, pageSize int) ([]models.Object, int, error) {
-	offset := (page - 1) * pageSize
-	if offset < 0 {
-		offset = 0
-	}
+// GetByObjectTypeIDAndLibraryID retrieves a page of the readable objects of a
+// type in a library that match the query's filter
+func (r *ObjectRepository) GetByObjectTypeIDAndLibraryID(objectTypeID int, libraryID uuid.UUID, q models.ObjectListQuery, profileID int) ([]models.Object, models.PageInfo, []models.Facet, error) {
 	// Transform UUID to SQL Server format before queries
 	libraryID, _ = TransformUUID(libraryID)
-
-	// Get total count
-	var totalCount int
-	countQuery := `SELECT COUNT(*) FROM [Object] WHERE ExactObjectTypeID = @p1 AND LibraryId = @p2`
-	err := r.db.QueryRow(countQuery, objectTypeID, libraryID).Scan(&totalCount)
-	if err != nil {
-		return nil, 0, fmt.Errorf("error counting objects by type: %w", err)
-	}
-	// Get paginated results
-	query := `
-		SELECT ObjectID, ObjectName, ObjectDescription, ObjectTypeID, CheckedInVersionId, 
-			DeleteFlag, Locked, RequiresShapeSheetUpdate, TemplateID, IsImported, IsLibrary, 
-			LibraryId, FileExtension, SortOrder, Prefix, Suffix, ProvenanceId, ProvenanceVersionId, 
-			GeneralType, CurrentVersionId, VisioAlias, HasVisioAlias, DateCreated, CreatedBy, 
-			DateModified, ModifiedBy, IsCheckedOut, CheckedOutUserId, DeleteTransactionId, 
-			NameChecksum, ExactObjectTypeID, RichTextDescription, AutoSort
-		FROM [Object]
-		WHERE ExactObjectTypeID = @p1 AND LibraryId = @p2
-		ORDER BY DateCreated DESC
-		OFFSET @p3 ROWS FETCH NEXT @p4 ROWS ONLY
-	`
-	fmt.Println(query)
-	fmt.Println(objectTypeID)
-	fmt.Println(libraryID)
-	fmt.Println(offset)
-	fmt.Println(pageSize)
-	rows, err := r.db.Query(query, objectTypeID, libraryID, offset, pageSize)
-	fmt.Println(rows)
-	fmt.Println(err)
-	if err != nil {
-		return nil, 0, fmt.Errorf("error retrieving objects by type: %w", err)
-	}
-	defer rows.Close()
-
-	var objects []models.Object
-	for rows.Next() {
-		var obj models.Object
-		var objectIDBytes []byte
-		var checkedInVersionIDBytes, libraryIDBytes, provenanceIDBytes, provenanceVersionIDBytes, currentVersionIDBytes, deleteTransactionIDBytes []byte
-
-		err := rows.Scan(
-			&objectIDBytes, &obj.ObjectName, &obj.ObjectDescription, &obj.ObjectTypeID, &checkedInVersionIDBytes,
-			&obj.DeleteFlag, &obj.Locked, &obj.RequiresShapeSheetUpdate, &obj.TemplateID, &obj.IsImported,
-			&obj.IsLibrary, &libraryIDBytes, &obj.FileExtension, &obj.SortOrder, &obj.Prefix, &obj.Suffix,
-			&provenanceIDBytes, &provenanceVersionIDBytes, &obj.GeneralType, &currentVersionIDBytes,
-			&obj.VisioAlias, &obj.HasVisioAlias, &obj.DateCreated, &obj.CreatedBy, &obj.DateModified,
-			&obj.ModifiedBy, &obj.IsCheckedOut, &obj.CheckedOutUserId, &deleteTransactionIDBytes,
-			&obj.NameChecksum, &obj.ExactObjectTypeID, &obj.RichTextDescription, &obj.AutoSort,
-		)
-		if err != nil {
-			return nil, 0, fmt.Errorf("error scanning object: %w", err)
-		}
-
-		// Parse UUID from bytes
-		obj.ObjectID, err = parseSQLServerUUID(objectIDBytes)
-		if err != nil {
-			return nil, 0, fmt.Errorf("error parsing ObjectID: %w", err)
-		}
-
-		if checkedInVersionIDBytes != nil {
-			parsedUUID, err := parseSQLServerUUID(checkedInVersionIDBytes)
-			if err != nil {
-				return nil, 0, fmt.Errorf("error parsing CheckedInVersionId: %w", err)
-			}
-			obj.CheckedInVersionId = &parsedUUID
-		}
-
-		if libraryIDBytes != nil {
-			parsedUUID, err := parseSQLServerUUID(libraryIDBytes)
-			if err != nil {
-				return nil, 0, fmt.Errorf("error parsing LibraryId: %w", err)
-			}
-			obj.LibraryId = &parsedUUID
-		}
-
-		if provenanceIDBytes != nil {
-			parsedUUID, err := parseSQLServerUUID(provenanceIDBytes)
-			if err != nil {
-				return nil, 0, fmt.Errorf("error parsing ProvenanceId: %w", err)
-			}
-			obj.ProvenanceId = &parsedUUID
-		}
-
-		if provenanceVersionIDBytes != nil {
-			parsedUUID, err := parseSQLServerUUID(provenanceVersionIDBytes)
-			if err != nil {
-				return nil, 0, fmt.Errorf("error parsing ProvenanceVersionId: %w", err)
-			}
-			obj.ProvenanceVersionId = &parsedUUID
-		}
-
-		if currentVersionIDBytes != nil {
-			parsedUUID, err := parseSQLServerUUID(currentVersionIDBytes)
-			if err != nil {
-				return nil, 0, fmt.Errorf("error parsing CurrentVersionId: %w", err)
-			}
-			obj.CurrentVersionId = &parsedUUID
-		}
-
-		if deleteTransactionIDBytes != nil {
-			parsedUUID, err := parseSQLServerUUID(deleteTransactionIDBytes)
-			if err != nil {
-				return nil, 0, fmt.Errorf("error parsing DeleteTransactionId: %w", err)
-			}
-			obj.DeleteTransactionId = &parsedUUID
-		}
-
-		objects = append(objects, obj)
-	}
-
-	return objects, totalCount, nil
+	f := r.newObjectFilter(profileID)
+	return r.listObjects(f, "[Object].ExactObjectTypeID = "+f.param(objectTypeID)+" AND [Object].LibraryId = "+f.param(libraryID), q)
 }
 
 /*
@@ -1337,3 +1786,116 @@ func (r *ObjectRepository) GetByObjectTypeIDAndLibraryID(objectTypeID int, libra
     AND		IsDeleted = CAST(0 AS BIT)
     AND		perm.HasReadPermission = CAST(1 AS BIT)
     ORDER BY SortOrder,ObjectName */
+
+// ExportObjects streams the objects of a type in a library that the profile
+// can read to fn, in name order, with the attribute values of their current
+// version. Values of attributes the profile cannot read are left out.
+func (r *ObjectRepository) ExportObjects(objectTypeID int, libraryID uuid.UUID, profileID int, fn func(*models.ExportedObject) error) error {
+	libraryID, _ = TransformUUID(libraryID)
+	query := `
+		SELECT [Object].ObjectID, [Object].ObjectName, [Object].ObjectDescription, [Object].DateCreated, [Object].DateModified,
+			attr.AttributeId, attr.textValue, attr.booleanValue, attr.dateValue, attr.floatValue, attr.intValue, attr.richTextValue
+		FROM [Object]
+		LEFT JOIN [vwAttributeValue] AS attr ON attr.objectId = [Object].ObjectID AND attr.versionId = [Object].CurrentVersionId
+			AND EXISTS (
+				SELECT 1 FROM [AttributePermissions] AS attrPerm
+				WHERE attrPerm.AttributeId = attr.AttributeId AND attrPerm.ProfileId = @p3 AND attrPerm.HasRead = 1
+			)
+		WHERE [Object].ExactObjectTypeID = @p1 AND [Object].LibraryId = @p2 AND ISNULL([Object].DeleteFlag, 0) = 0 AND ` + fmt.Sprintf(readableObjectFilter, "@p3") + `
+		ORDER BY [Object].ObjectName, [Object].ObjectID
+	`
+
+	rows, err := r.db.Query(query, objectTypeID, libraryID, profileID)
+	if err != nil {
+		return fmt.Errorf("error retrieving objects for export: %w", err)
+	}
+	defer rows.Close()
+
+	var current *models.ExportedObject
+	var currentIDBytes []byte
+	for rows.Next() {
+		var obj models.ExportedObject
+		var objectIDBytes, attributeIDBytes []byte
+		var attr models.AssignedAttribute
+		err := rows.Scan(
+			&objectIDBytes, &obj.ObjectName, &obj.Description, &obj.DateCreated, &obj.DateModified,
+			&attributeIDBytes, &attr.TextValue, &attr.BooleanValue, &attr.DateValue, &attr.FloatValue,
+			&attr.IntegerValue, &attr.RichTextValue,
+		)
+		if err != nil {
+			return fmt.Errorf("error scanning exported object: %w", err)
+		}
+
+		// Rows arrive grouped by object, one per attribute value
+		if current == nil || !bytes.Equal(objectIDBytes, currentIDBytes) {
+			if current != nil {
+				if err := fn(current); err != nil {
+					return err
+				}
+			}
+			obj.ObjectID, err = parseSQLServerUUID(objectIDBytes)
+			if err != nil {
+				return fmt.Errorf("error parsing ObjectID: %w", err)
+			}
+			obj.Values = make(map[uuid.UUID]models.AssignedAttribute)
+			current, currentIDBytes = &obj, objectIDBytes
+		}
+
+		if attributeIDBytes != nil {
+			// Attribute IDs are exposed in their stored byte order
+			attr.AttributeID, err = uuid.FromBytes(attributeIDBytes)
+			if err != nil {
+				return fmt.Errorf("error parsing AttributeId: %w", err)
+			}
+			current.Values[attr.AttributeID] = attr
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating exported objects: %w", err)
+	}
+
+	if current != nil {
+		return fn(current)
+	}
+	return nil
+}
+
+// ExportGraphNodes streams a library and the objects in it the profile can
+// read to fn, with their object types, ordered by name
+func (r *ObjectRepository) ExportGraphNodes(libraryID uuid.UUID, profileID int, fn func(models.GraphNode) error) error {
+	libraryID, _ = TransformUUID(libraryID)
+	query := `
+		SELECT [Object].ObjectID, [Object].ObjectName, [Object].ExactObjectTypeID, ot.ObjectTypeName, ot.Color
+		FROM [Object]
+		LEFT JOIN ObjectType AS ot ON ot.ObjectTypeID = [Object].ExactObjectTypeID
+		WHERE ([Object].LibraryId = @p1 OR [Object].ObjectID = @p1)
+			AND ISNULL([Object].DeleteFlag, 0) = 0
+			AND ` + fmt.Sprintf(readableObjectFilter, "@p2") + `
+		ORDER BY [Object].ObjectName, [Object].ObjectID
+	`
+
+	rows, err := r.db.Query(query, libraryID, profileID)
+	if err != nil {
+		return fmt.Errorf("error retrieving objects for graph export: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var node models.GraphNode
+		var objectIDBytes []byte
+		if err := rows.Scan(&objectIDBytes, &node.ObjectName, &node.ObjectTypeId, &node.ObjectTypeName, &node.Color); err != nil {
+			return fmt.Errorf("error scanning graph node: %w", err)
+		}
+		node.ObjectId, err = parseSQLServerUUID(objectIDBytes)
+		if err != nil {
+			return fmt.Errorf("error parsing ObjectID: %w", err)
+		}
+		if err := fn(node); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating graph nodes: %w", err)
+	}
+	return nil
+}
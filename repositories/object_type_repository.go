@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"enterprise-architect-api/models"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,9 +22,15 @@ func NewObjectTypeRepository(db *sql.DB) *ObjectTypeRepository {
 }
 
 // Create creates a new object type in the database
-func (r *ObjectTypeRepository) Create(req models.CreateObjectTypeRequest) (*models.ObjectType, error) {
+func (r *ObjectTypeRepository) Create(req models.CreateObjectTypeRequest, changes *ChangeLog) (*models.ObjectType, error) {
 	now := time.Now()
 
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
 		INSERT INTO ObjectType (
 			ObjectTypeName, IsTemplateType, IsDefaultTemplate, ActiveType, 
@@ -39,7 +46,7 @@ func (r *ObjectTypeRepository) Create(req models.CreateObjectTypeRequest) (*mode
 	`
 
 	var objectTypeID int
-	err := r.db.QueryRow(query,
+	err = tx.QueryRow(query,
 		req.ObjectTypeName, req.IsTemplateType, false, req.ActiveType,
 		false, false, false, false,
 		false, now, req.CreatedBy, now, req.CreatedBy,
@@ -51,12 +58,28 @@ func (r *ObjectTypeRepository) Create(req models.CreateObjectTypeRequest) (*mode
 	if err != nil {
 		return nil, fmt.Errorf("error creating object type: %w", err)
 	}
+	objectType, err := getObjectType(tx, objectTypeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := changes.Record(tx, models.AuditEntityObjectType, strconv.Itoa(objectTypeID), models.AuditActionCreate, nil, objectType); err != nil {
+		return nil, err
+	}
 
-	return r.GetByID(objectTypeID)
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+	return objectType, nil
 }
 
 // GetByID retrieves an object type by its ID
 func (r *ObjectTypeRepository) GetByID(id int) (*models.ObjectType, error) {
+	return getObjectType(r.db, id)
+}
+
+// getObjectType retrieves an object type by its ID with a database or
+// transaction
+func getObjectType(q dbQuerier, id int) (*models.ObjectType, error) {
 	query := `
 		SELECT ObjectTypeID, ObjectTypeName, ObjectTypeImage, IsTemplateType, GeneralType, 
 			TemplateFileName, IsDefaultTemplate, ActiveType, EnforceUniqueNaming, CanHaveVisioAlias, 
@@ -70,7 +93,7 @@ func (r *ObjectTypeRepository) GetByID(id int) (*models.ObjectType, error) {
 	`
 
 	objType := &models.ObjectType{}
-	err := r.db.QueryRow(query, id).Scan(
+	err := q.QueryRow(query, id).Scan(
 		&objType.ObjectTypeID, &objType.ObjectTypeName, &objType.ObjectTypeImage, &objType.IsTemplateType,
 		&objType.GeneralType, &objType.TemplateFileName, &objType.IsDefaultTemplate, &objType.ActiveType,
 		&objType.EnforceUniqueNaming, &objType.CanHaveVisioAlias, &objType.IsConnector,
@@ -148,7 +171,7 @@ func (r *ObjectTypeRepository) GetAll(page, pageSize int) ([]models.ObjectType,
 }
 
 // Update updates an existing object type
-func (r *ObjectTypeRepository) Update(id int, req models.UpdateObjectTypeRequest) (*models.ObjectType, error) {
+func (r *ObjectTypeRepository) Update(id int, req models.UpdateObjectTypeRequest, changes *ChangeLog) (*models.ObjectType, error) {
 	// Build dynamic update query
 	var setClauses []string
 	var args []interface{}
@@ -196,18 +219,49 @@ func (r *ObjectTypeRepository) Update(id int, req models.UpdateObjectTypeRequest
 	args = append(args, id)
 	query := fmt.Sprintf("UPDATE ObjectType SET %s WHERE ObjectTypeID = @p%d", strings.Join(setClauses, ", "), argIndex)
 
-	_, err := r.db.Exec(query, args...)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := getObjectType(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("error updating object type: %w", err)
 	}
+	objectType, err := getObjectType(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := changes.Record(tx, models.AuditEntityObjectType, strconv.Itoa(id), models.AuditActionUpdate, before, objectType); err != nil {
+		return nil, err
+	}
 
-	return r.GetByID(id)
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+	return objectType, nil
 }
 
 // Delete deletes an object type by its ID
-func (r *ObjectTypeRepository) Delete(id int) error {
+func (r *ObjectTypeRepository) Delete(id int, changes *ChangeLog) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := getObjectType(tx, id)
+	if err != nil {
+		return err
+	}
+
 	query := `DELETE FROM ObjectType WHERE ObjectTypeID = @p1`
-	result, err := r.db.Exec(query, id)
+	result, err := tx.Exec(query, id)
 	if err != nil {
 		return fmt.Errorf("error deleting object type: %w", err)
 	}
@@ -220,7 +274,13 @@ func (r *ObjectTypeRepository) Delete(id int) error {
 	if rowsAffected == 0 {
 		return fmt.Errorf("object type not found")
 	}
+	if err := changes.Record(tx, models.AuditEntityObjectType, strconv.Itoa(id), models.AuditActionDelete, before, nil); err != nil {
+		return err
+	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
 	return nil
 }
 
@@ -293,9 +353,14 @@ func (r *ObjectTypeRepository) GetFolderRepositoryTree() ([]models.ObjectTypeHie
 }
 
 // AddFolderToTree adds a new folder to the folder hierarchy tree
-func (r *ObjectTypeRepository) AddFolderToTree(req models.AddFolderToTreeRequest) (*uuid.UUID, error) {
+func (r *ObjectTypeRepository) AddFolderToTree(req models.AddFolderToTreeRequest, userID int, changes *ChangeLog) (*uuid.UUID, error) {
 	var folderObjectTypeId int
-	var err error
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
 
 	// If FolderObjectTypeId is 0, create a new ObjectType
 	if req.FolderObjectTypeId == 0 {
@@ -319,10 +384,10 @@ func (r *ObjectTypeRepository) AddFolderToTree(req models.AddFolderToTreeRequest
 		`
 
 		now := time.Now()
-		err := r.db.QueryRow(insertObjectTypeQuery,
+		err := tx.QueryRow(insertObjectTypeQuery,
 			req.ObjectTypeName, false, false, true,
 			true, false, false, false,
-			false, now, 62, now, 62,
+			false, now, userID, now, userID,
 			false, false,
 			false, false, false,
 			false, false, false,
@@ -332,11 +397,20 @@ func (r *ObjectTypeRepository) AddFolderToTree(req models.AddFolderToTreeRequest
 		if err != nil {
 			return nil, fmt.Errorf("error creating new object type: %w", err)
 		}
+		if changes != nil {
+			objectType, err := getObjectType(tx, folderObjectTypeId)
+			if err != nil {
+				return nil, err
+			}
+			if err := changes.Record(tx, models.AuditEntityObjectType, strconv.Itoa(folderObjectTypeId), models.AuditActionCreate, nil, objectType); err != nil {
+				return nil, err
+			}
+		}
 	} else {
 		// Validate that the FolderObjectTypeId exists
 		var exists bool
 		checkQuery := `SELECT CASE WHEN EXISTS (SELECT 1 FROM ObjectType WHERE ObjectTypeID = @p1) THEN 1 ELSE 0 END`
-		err := r.db.QueryRow(checkQuery, req.FolderObjectTypeId).Scan(&exists)
+		err := tx.QueryRow(checkQuery, req.FolderObjectTypeId).Scan(&exists)
 		if err != nil {
 			return nil, fmt.Errorf("error checking if object type exists: %w", err)
 		}
@@ -355,7 +429,7 @@ func (r *ObjectTypeRepository) AddFolderToTree(req models.AddFolderToTreeRequest
 	if req.ParentHierarchyId != nil {
 		var parentExists bool
 		checkParentQuery := `SELECT CASE WHEN EXISTS (SELECT 1 FROM FolderTypeHierarchy WHERE FolderTypeHierarchyId = @p1) THEN 1 ELSE 0 END`
-		err = r.db.QueryRow(checkParentQuery, parentHierarchyId).Scan(&parentExists)
+		err = tx.QueryRow(checkParentQuery, parentHierarchyId).Scan(&parentExists)
 		if err != nil {
 			return nil, fmt.Errorf("error checking if parent hierarchy exists: %w", err)
 		}
@@ -377,16 +451,25 @@ func (r *ObjectTypeRepository) AddFolderToTree(req models.AddFolderToTreeRequest
 	var folderTypeHierarchyId uuid.UUID
 
 	fmt.Println("Parent Hierarchy ID: ", parentHierarchyId)
-	err = r.db.QueryRow(insertQuery, folderObjectTypeId, parentHierarchyId).Scan(&folderTypeHierarchyId)
+	err = tx.QueryRow(insertQuery, folderObjectTypeId, parentHierarchyId).Scan(&folderTypeHierarchyId)
 	if err != nil {
 		return nil, fmt.Errorf("error adding folder to tree: %w", err)
 	}
 
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
 	return &folderTypeHierarchyId, nil
 }
 
 // AssignObjectTypeToFolder assigns an object type to a folder type
-func (r *ObjectTypeRepository) AssignObjectTypeToFolder(req models.FolderObjectTypes) error {
+func (r *ObjectTypeRepository) AssignObjectTypeToFolder(req models.FolderObjectTypes, changes *ChangeLog) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
 		INSERT INTO FolderObjectTypes (
 			FolderObjectTypeId, 
@@ -395,14 +478,27 @@ func (r *ObjectTypeRepository) AssignObjectTypeToFolder(req models.FolderObjectT
 		) VALUES (@p1, @p2, @p3)
 	`
 
-	_, err := r.db.Exec(query, req.FolderObjectTypeId, req.ObjectTypeID, req.IsDocumentType)
+	_, err = tx.Exec(query, req.FolderObjectTypeId, req.ObjectTypeID, req.IsDocumentType)
 	if err != nil {
 		return fmt.Errorf("error assigning object type to folder: %w", err)
 	}
+	if err := changes.Record(tx, models.AuditEntityFolderObjectType, folderObjectTypeID(req.FolderObjectTypeId, req.ObjectTypeID),
+		models.AuditActionCreate, nil, req); err != nil {
+		return err
+	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
 	return nil
 }
 
+// folderObjectTypeID identifies an object type allowed in a folder type in
+// the audit trail
+func folderObjectTypeID(folderObjectTypeId, objectTypeId int) string {
+	return strconv.Itoa(folderObjectTypeId) + "/" + strconv.Itoa(objectTypeId)
+}
+
 // GetAvailableTypesForFolder retrieves available object types for a specific folder
 func (r *ObjectTypeRepository) GetAvailableTypesForFolder(folderObjectTypeId int) ([]models.FolderObjectTypesNames, error) {
 	query := `
@@ -437,13 +533,27 @@ func (r *ObjectTypeRepository) GetAvailableTypesForFolder(folderObjectTypeId int
 }
 
 // DeleteObjectTypeFromFolder removes an object type assignment from a folder
-func (r *ObjectTypeRepository) DeleteObjectTypeFromFolder(folderObjectTypeId, objectTypeId int) error {
+func (r *ObjectTypeRepository) DeleteObjectTypeFromFolder(folderObjectTypeId, objectTypeId int, changes *ChangeLog) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before := models.FolderObjectTypes{FolderObjectTypeId: folderObjectTypeId, ObjectTypeID: objectTypeId}
+	err = tx.QueryRow(`
+		SELECT IsDocumentType FROM FolderObjectTypes WHERE FolderObjectTypeId = @p1 AND ObjectTypeId = @p2
+	`, folderObjectTypeId, objectTypeId).Scan(&before.IsDocumentType)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("error retrieving object type assignment: %w", err)
+	}
+
 	query := `
 		DELETE FROM FolderObjectTypes 
 		WHERE FolderObjectTypeId = @p1 AND ObjectTypeId = @p2
 	`
 
-	result, err := r.db.Exec(query, folderObjectTypeId, objectTypeId)
+	result, err := tx.Exec(query, folderObjectTypeId, objectTypeId)
 	if err != nil {
 		return fmt.Errorf("error deleting object type from folder: %w", err)
 	}
@@ -456,7 +566,14 @@ func (r *ObjectTypeRepository) DeleteObjectTypeFromFolder(folderObjectTypeId, ob
 	if rowsAffected == 0 {
 		return fmt.Errorf("object type assignment not found")
 	}
+	if err := changes.Record(tx, models.AuditEntityFolderObjectType, folderObjectTypeID(folderObjectTypeId, objectTypeId),
+		models.AuditActionDelete, before, nil); err != nil {
+		return err
+	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
 	return nil
 }
 
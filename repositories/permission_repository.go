@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"database/sql"
+	"enterprise-architect-api/models"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// ErrObjectNotFound is returned when an object ID does not exist
+var ErrObjectNotFound = errors.New("object not found")
+
+// readableObjectFilter restricts a query on [Object] to rows the profile bound
+// to the given parameter can read
+const readableObjectFilter = `EXISTS (
+			SELECT 1 FROM dbo.fn_EffectiveObjectPermissions(%s) AS perm
+			WHERE perm.ObjectID = [Object].ObjectID AND perm.HasRead = 1
+		)`
+
+// PermissionRepository handles database operations for object permissions
+type PermissionRepository struct {
+	db *sql.DB
+}
+
+// NewPermissionRepository creates a new PermissionRepository
+func NewPermissionRepository(db *sql.DB) *PermissionRepository {
+	return &PermissionRepository{db: db}
+}
+
+// GetEffectivePermission retrieves the profile's effective permissions on an object
+func (r *PermissionRepository) GetEffectivePermission(objectID uuid.UUID, profileID int) (*models.ObjectPermission, error) {
+	dbID, _ := TransformUUID(objectID)
+	perm, err := getEffectivePermission(r.db, dbID, profileID)
+	if err != nil {
+		return nil, err
+	}
+	perm.ObjectID = objectID
+	return perm, nil
+}
+
+// getEffectivePermission runs the permission lookup on a database or transaction.
+// dbObjectID must already be in SQL Server byte order.
+func getEffectivePermission(q interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}, dbObjectID uuid.UUID, profileID int) (*models.ObjectPermission, error) {
+	query := `
+		SELECT
+			ISNULL(perm.HasRead, 0),
+			ISNULL(perm.HasModify, 0),
+			ISNULL(perm.HasDelete, 0),
+			ISNULL(perm.HasModifyContents, 0),
+			ISNULL(perm.HasModifyRelationships, 0)
+		FROM [Object] AS o
+		LEFT JOIN dbo.fn_EffectiveObjectPermissions(@p1) AS perm ON perm.ObjectID = o.ObjectID
+		WHERE o.ObjectID = @p2
+	`
+
+	perm := &models.ObjectPermission{ObjectID: dbObjectID, ProfileID: profileID}
+	err := q.QueryRow(query, profileID, dbObjectID).Scan(
+		&perm.HasRead, &perm.HasModify, &perm.HasDelete, &perm.HasModifyContents, &perm.HasModifyRelationships,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving object permissions: %w", err)
+	}
+
+	return perm, nil
+}
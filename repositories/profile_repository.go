@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"enterprise-architect-api/models"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -19,9 +20,15 @@ func NewProfileRepository(db *sql.DB) *ProfileRepository {
 }
 
 // Create creates a new profile in the database
-func (r *ProfileRepository) Create(req models.CreateProfileRequest) (*models.Profile, error) {
+func (r *ProfileRepository) Create(req models.CreateProfileRequest, changes *ChangeLog) (*models.Profile, error) {
 	now := time.Now()
 
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
 		INSERT INTO Profile (
 			ProfileName, ProfileDescription, PortalStartPageId, DateCreated, CreatedBy, DateModified, ModifiedBy
@@ -31,19 +38,34 @@ func (r *ProfileRepository) Create(req models.CreateProfileRequest) (*models.Pro
 	`
 
 	var profileID int
-	err := r.db.QueryRow(query,
+	err = tx.QueryRow(query,
 		req.ProfileName, req.ProfileDescription, req.PortalStartPageId, now, req.CreatedBy, now, req.CreatedBy,
 	).Scan(&profileID)
 
 	if err != nil {
 		return nil, fmt.Errorf("error creating profile: %w", err)
 	}
+	profile, err := getProfile(tx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	if err := changes.Record(tx, models.AuditEntityProfile, strconv.Itoa(profileID), models.AuditActionCreate, nil, profile); err != nil {
+		return nil, err
+	}
 
-	return r.GetByID(profileID)
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+	return profile, nil
 }
 
 // GetByID retrieves a profile by its ID
 func (r *ProfileRepository) GetByID(id int) (*models.Profile, error) {
+	return getProfile(r.db, id)
+}
+
+// getProfile retrieves a profile by its ID with a database or transaction
+func getProfile(q dbQuerier, id int) (*models.Profile, error) {
 	query := `
 		SELECT ProfileID, ProfileName, ProfileDescription, PortalStartPageId, DateCreated, CreatedBy, DateModified, ModifiedBy
 		FROM Profile
@@ -51,7 +73,7 @@ func (r *ProfileRepository) GetByID(id int) (*models.Profile, error) {
 	`
 
 	profile := &models.Profile{}
-	err := r.db.QueryRow(query, id).Scan(
+	err := q.QueryRow(query, id).Scan(
 		&profile.ProfileID, &profile.ProfileName, &profile.ProfileDescription, &profile.PortalStartPageId,
 		&profile.DateCreated, &profile.CreatedBy, &profile.DateModified, &profile.ModifiedBy,
 	)
@@ -109,7 +131,7 @@ func (r *ProfileRepository) GetAll(page, pageSize int) ([]models.Profile, int, e
 }
 
 // Update updates an existing profile
-func (r *ProfileRepository) Update(id int, req models.UpdateProfileRequest) (*models.Profile, error) {
+func (r *ProfileRepository) Update(id int, req models.UpdateProfileRequest, changes *ChangeLog) (*models.Profile, error) {
 	// Build dynamic update query
 	var setClauses []string
 	var args []interface{}
@@ -147,18 +169,49 @@ func (r *ProfileRepository) Update(id int, req models.UpdateProfileRequest) (*mo
 	args = append(args, id)
 	query := fmt.Sprintf("UPDATE Profile SET %s WHERE ProfileID = @p%d", strings.Join(setClauses, ", "), argIndex)
 
-	_, err := r.db.Exec(query, args...)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := getProfile(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("error updating profile: %w", err)
 	}
+	profile, err := getProfile(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := changes.Record(tx, models.AuditEntityProfile, strconv.Itoa(id), models.AuditActionUpdate, before, profile); err != nil {
+		return nil, err
+	}
 
-	return r.GetByID(id)
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+	return profile, nil
 }
 
 // Delete deletes a profile by its ID
-func (r *ProfileRepository) Delete(id int) error {
+func (r *ProfileRepository) Delete(id int, changes *ChangeLog) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := getProfile(tx, id)
+	if err != nil {
+		return err
+	}
+
 	query := `DELETE FROM Profile WHERE ProfileID = @p1`
-	result, err := r.db.Exec(query, id)
+	result, err := tx.Exec(query, id)
 	if err != nil {
 		return fmt.Errorf("error deleting profile: %w", err)
 	}
@@ -171,7 +224,13 @@ func (r *ProfileRepository) Delete(id int) error {
 	if rowsAffected == 0 {
 		return fmt.Errorf("profile not found")
 	}
+	if err := changes.Record(tx, models.AuditEntityProfile, strconv.Itoa(id), models.AuditActionDelete, before, nil); err != nil {
+		return err
+	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
 	return nil
 }
 
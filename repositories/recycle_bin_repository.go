@@ -0,0 +1,416 @@
+package repositories
+
+import (
+	"database/sql"
+	"enterprise-architect-api/models"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrDeleteTransactionNotFound is returned for unknown or purged delete transactions
+	ErrDeleteTransactionNotFound = errors.New("delete transaction not found")
+	// ErrObjectDeleted is returned when deleting an object already in the recycle bin
+	ErrObjectDeleted = errors.New("object is already in the recycle bin")
+	// ErrDeleteCheckedOut is returned when an object that would be deleted is
+	// checked out by another user
+	ErrDeleteCheckedOut = errors.New("an object to be deleted is checked out by another user")
+	// ErrRestoreParentDeleted is returned when restoring into a folder that is
+	// itself in the recycle bin
+	ErrRestoreParentDeleted = errors.New("the folder the object was deleted from is in the recycle bin; restore it first")
+)
+
+// softDeleteSql marks an object (@p1) and, through vwFolderContents, every
+// object below it that is not already deleted as deleted in transaction @p2
+// by user @p3, unless one of them is checked out by someone else; they are
+// locked as they are checked, so no checkout can start in between. The folder
+// the object was deleted from is recorded for restore and permission checks.
+const softDeleteSql = `
+	DECLARE @parent UNIQUEIDENTIFIER = (
+		SELECT TOP 1 fc.FolderId
+		FROM vwFolderContents AS fc
+		INNER JOIN [Object] AS f ON f.ObjectID = fc.FolderId
+		WHERE fc.ObjectId = @p1 AND ISNULL(f.DeleteFlag, 0) = 0
+	);
+	DECLARE @ids TABLE (ObjectID UNIQUEIDENTIFIER PRIMARY KEY);
+	INSERT INTO @ids (ObjectID) VALUES (@p1);
+	WHILE @@ROWCOUNT > 0
+		INSERT INTO @ids (ObjectID)
+		SELECT DISTINCT fc.ObjectId
+		FROM vwFolderContents AS fc
+		INNER JOIN @ids AS folder ON folder.ObjectID = fc.FolderId
+		INNER JOIN [Object] AS o ON o.ObjectID = fc.ObjectId
+		WHERE ISNULL(o.DeleteFlag, 0) = 0
+			AND NOT EXISTS (SELECT 1 FROM @ids AS seen WHERE seen.ObjectID = fc.ObjectId);
+
+	DECLARE @blocked BIT = CASE WHEN EXISTS (
+		SELECT 1 FROM [Object] AS o WITH (UPDLOCK)
+		INNER JOIN @ids AS d ON d.ObjectID = o.ObjectID
+		WHERE o.IsCheckedOut = 1 AND ISNULL(o.CheckedOutUserId, 0) <> @p3
+	) THEN 1 ELSE 0 END;
+
+	IF @blocked = 0
+	BEGIN
+		UPDATE o SET DeleteFlag = 1, DeleteTransactionId = @p2, DateModified = GETDATE(), ModifiedBy = @p3
+		FROM [Object] AS o
+		INNER JOIN @ids AS d ON d.ObjectID = o.ObjectID;
+
+		INSERT INTO DeleteTransactions (DeleteTransactionId, RootObjectID, ParentObjectID, ObjectCount, DeletedBy, DateDeleted)
+		VALUES (@p2, @p1, @parent, @@ROWCOUNT, @p3, GETDATE());
+	END
+
+	SELECT @blocked;
+`
+
+// recycleBinEntrySql selects delete transactions with their root object
+const recycleBinEntrySql = `
+	SELECT dt.DeleteTransactionId, dt.RootObjectID, o.ObjectName, o.ExactObjectTypeID,
+		COALESCE(o.LibraryId, CASE WHEN o.IsLibrary = 1 THEN o.ObjectID END), dt.ParentObjectID,
+		dt.ObjectCount, dt.DeletedBy, dt.DateDeleted`
+
+// recycleBinPermissionSql is the profile's permission %s (HasRead, HasDelete,
+// ...) on the root object of a delete transaction. Deleted folders pass no
+// permissions on, so as fn_EffectiveObjectPermissions would, it takes the
+// root's own permission row or else the folder it was deleted from.
+const recycleBinPermissionSql = `CAST(CASE WHEN own.ObjectID IS NOT NULL THEN own.%[1]s ELSE ISNULL(parent.%[1]s, 0) END AS BIT)`
+
+// recycleBinFromSql joins delete transactions to their root object and the
+// profile's (%s) permissions on it
+const recycleBinFromSql = `
+	FROM DeleteTransactions AS dt
+	INNER JOIN [Object] AS o ON o.ObjectID = dt.RootObjectID
+	LEFT JOIN ObjectPermissions AS own ON own.ObjectID = dt.RootObjectID AND own.ProfileID = %[1]s
+	LEFT JOIN dbo.fn_EffectiveObjectPermissions(%[1]s) AS parent ON parent.ObjectID = dt.ParentObjectID`
+
+// RecycleBinRepository handles database operations for soft-deleted objects
+type RecycleBinRepository struct {
+	db *sql.DB
+}
+
+// NewRecycleBinRepository creates a new RecycleBinRepository
+func NewRecycleBinRepository(db *sql.DB) *RecycleBinRepository {
+	return &RecycleBinRepository{db: db}
+}
+
+// List retrieves a page of the delete transactions whose root object the
+// profile can read, most recent first
+func (r *RecycleBinRepository) List(req models.PageRequest, profileID int) ([]models.RecycleBinEntry, models.PageInfo, error) {
+	info := models.PageInfo{TotalCount: -1}
+	k, err := newKeyset([]keysetKey{{"dt.DateDeleted", true}, {"dt.DeleteTransactionId", false}}, "recycleBin", req)
+	if err != nil {
+		return nil, info, err
+	}
+
+	args := queryArgs{profileID}
+	from := fmt.Sprintf(recycleBinFromSql, "@p1")
+	where := `
+	WHERE ` + fmt.Sprintf(recycleBinPermissionSql, "HasRead") + ` = 1`
+
+	if !req.SkipCount {
+		if err := r.db.QueryRow(`SELECT COUNT(*)`+from+where, args...).Scan(&info.TotalCount); err != nil {
+			return nil, info, fmt.Errorf("error counting recycle bin entries: %w", err)
+		}
+	}
+
+	if cond := k.condition(args.param); cond != "" {
+		where += " AND " + cond
+	}
+	query := recycleBinEntrySql + `, ` + k.columns() + from + where + `
+	ORDER BY ` + k.orderBy() + `
+	OFFSET ` + args.param(k.offset(req)) + ` ROWS FETCH NEXT ` + args.param(req.PageSize+1) + ` ROWS ONLY`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, info, fmt.Errorf("error retrieving recycle bin entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.RecycleBinEntry{}
+	var sortValues [][]interface{}
+	for rows.Next() {
+		values, keyDests := scanKeys(2)
+		entry, err := scanRecycleBinEntry(rows, keyDests...)
+		if err != nil {
+			return nil, info, err
+		}
+		entries = append(entries, *entry)
+		sortValues = append(sortValues, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, info, fmt.Errorf("error iterating recycle bin entries: %w", err)
+	}
+
+	entries, err = keysetPage(k, req, entries, sortValues, &info)
+	return entries, info, err
+}
+
+// Get retrieves a delete transaction with the profile's effective permissions
+// on its root object
+func (r *RecycleBinRepository) Get(transactionID uuid.UUID, profileID int) (*models.RecycleBinEntry, *models.ObjectPermission, error) {
+	transactionID, _ = TransformUUID(transactionID)
+	query := recycleBinEntrySql + `,
+		` + fmt.Sprintf(recycleBinPermissionSql, "HasRead") + `,
+		` + fmt.Sprintf(recycleBinPermissionSql, "HasModify") + `,
+		` + fmt.Sprintf(recycleBinPermissionSql, "HasDelete") + `,
+		` + fmt.Sprintf(recycleBinPermissionSql, "HasModifyContents") + `,
+		` + fmt.Sprintf(recycleBinPermissionSql, "HasModifyRelationships") +
+		fmt.Sprintf(recycleBinFromSql, "@p1") + `
+	WHERE dt.DeleteTransactionId = @p2`
+
+	perm := &models.ObjectPermission{ProfileID: profileID}
+	entry, err := scanRecycleBinEntry(r.db.QueryRow(query, profileID, transactionID),
+		&perm.HasRead, &perm.HasModify, &perm.HasDelete, &perm.HasModifyContents, &perm.HasModifyRelationships)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, ErrDeleteTransactionNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	perm.ObjectID = entry.RootObjectId
+	return entry, perm, nil
+}
+
+// GetObjects retrieves the objects deleted in a delete transaction
+func (r *RecycleBinRepository) GetObjects(transactionID uuid.UUID) ([]models.RecycleBinObject, error) {
+	transactionID, _ = TransformUUID(transactionID)
+	rows, err := r.db.Query(`
+		SELECT ObjectID, ObjectName, ExactObjectTypeID
+		FROM [Object]
+		WHERE DeleteTransactionId = @p1
+		ORDER BY ObjectName, ObjectID
+	`, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving deleted objects: %w", err)
+	}
+	defer rows.Close()
+
+	objects := []models.RecycleBinObject{}
+	for rows.Next() {
+		var obj models.RecycleBinObject
+		var objectIDBytes []byte
+		if err := rows.Scan(&objectIDBytes, &obj.ObjectName, &obj.ObjectTypeId); err != nil {
+			return nil, fmt.Errorf("error scanning deleted object: %w", err)
+		}
+		if obj.ObjectId, err = parseSQLServerUUID(objectIDBytes); err != nil {
+			return nil, fmt.Errorf("error parsing ObjectID: %w", err)
+		}
+		objects = append(objects, obj)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating deleted objects: %w", err)
+	}
+	return objects, nil
+}
+
+// Restore undeletes every object of a delete transaction and removes the
+// transaction from the recycle bin, recording the restored root object in
+// changes. It returns the number of objects restored.
+func (r *RecycleBinRepository) Restore(transactionID uuid.UUID, userID int, changes *ChangeLog) (int, error) {
+	transactionID, _ = TransformUUID(transactionID)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var parentDeleted bool
+	err = tx.QueryRow(`
+		SELECT CAST(CASE WHEN ISNULL(parent.DeleteFlag, 0) = 1 THEN 1 ELSE 0 END AS BIT)
+		FROM DeleteTransactions AS dt WITH (UPDLOCK)
+		LEFT JOIN [Object] AS parent ON parent.ObjectID = dt.ParentObjectID
+		WHERE dt.DeleteTransactionId = @p1
+	`, transactionID).Scan(&parentDeleted)
+	if err == sql.ErrNoRows {
+		return 0, ErrDeleteTransactionNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error retrieving delete transaction: %w", err)
+	}
+	if parentDeleted {
+		return 0, ErrRestoreParentDeleted
+	}
+	entry, err := getRecycleBinEntry(tx, transactionID)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec(`
+		UPDATE [Object]
+		SET DeleteFlag = 0, DeleteTransactionId = NULL, DateModified = GETDATE(), ModifiedBy = @p2
+		WHERE DeleteTransactionId = @p1
+	`, transactionID, userID)
+	if err != nil {
+		return 0, fmt.Errorf("error restoring objects: %w", err)
+	}
+	restored, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM DeleteTransactions WHERE DeleteTransactionId = @p1`, transactionID); err != nil {
+		return 0, fmt.Errorf("error removing delete transaction: %w", err)
+	}
+	if changes != nil {
+		root, err := getObject(tx, entry.RootObjectId)
+		if err != nil {
+			return 0, err
+		}
+		if err := changes.Record(tx, models.AuditEntityObject, root.ObjectID.String(), models.AuditActionRestore, entry, root); err != nil {
+			return 0, err
+		}
+		if err := publishDeleteTransaction(tx, changes, entry, models.AuditActionRestore); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing restore: %w", err)
+	}
+	return int(restored), nil
+}
+
+// Purge permanently deletes every object of a delete transaction with its
+// versions, values, contents, relationships and permissions. It returns the
+// number of objects purged. The purged delete transaction is recorded in
+// changes.
+func (r *RecycleBinRepository) Purge(transactionID uuid.UUID, changes *ChangeLog) (int, error) {
+	transactionID, _ = TransformUUID(transactionID)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRow(`SELECT 1 FROM DeleteTransactions WITH (UPDLOCK) WHERE DeleteTransactionId = @p1`, transactionID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return 0, ErrDeleteTransactionNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error retrieving delete transaction: %w", err)
+	}
+	entry, err := getRecycleBinEntry(tx, transactionID)
+	if err != nil {
+		return 0, err
+	}
+
+	const purged = `(SELECT ObjectID FROM [Object] WHERE DeleteTransactionId = @p1)`
+	cleanup := []string{
+		`DELETE FROM AttributeValue WHERE ObjectId IN ` + purged,
+		`DELETE FROM ObjectContents WHERE ObjectID IN ` + purged + ` OR DocumentObjectID IN ` + purged,
+		`DELETE FROM RelationDocument WHERE DocumentId IN ` + purged,
+		`DELETE FROM VisioPageShapes WHERE DocumentObjectId IN ` + purged + ` OR ShapeObjectId IN ` + purged,
+		`DELETE FROM VisioPageRelationships WHERE DocumentObjectId IN ` + purged,
+		`DELETE FROM RelationDocument WHERE RelationshipId IN (
+			SELECT RelationshipId FROM Relationships WHERE SourceObjectID IN ` + purged + ` OR TargetObjectID IN ` + purged + `)`,
+		`DELETE FROM Relationships WHERE SourceObjectID IN ` + purged + ` OR TargetObjectID IN ` + purged,
+		`DELETE FROM ObjectPermissions WHERE ObjectID IN ` + purged,
+		`DELETE FROM VersionApprovals WHERE ObjectID IN ` + purged,
+	}
+	for _, query := range cleanup {
+		if _, err := tx.Exec(query, transactionID); err != nil {
+			return 0, fmt.Errorf("error purging object data: %w", err)
+		}
+	}
+
+	var count int
+	err = tx.QueryRow(`
+		DECLARE @ids TABLE (ObjectID UNIQUEIDENTIFIER PRIMARY KEY);
+		INSERT INTO @ids (ObjectID) SELECT ObjectID FROM [Object] WHERE DeleteTransactionId = @p1;
+		DELETE FROM [Object] WHERE ObjectID IN (SELECT ObjectID FROM @ids);
+		DELETE FROM [Version] WHERE ObjectId IN (SELECT ObjectID FROM @ids);
+		SELECT COUNT(*) FROM @ids;
+	`, transactionID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error purging objects: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM DeleteTransactions WHERE DeleteTransactionId = @p1`, transactionID); err != nil {
+		return 0, fmt.Errorf("error removing delete transaction: %w", err)
+	}
+	if err := changes.Record(tx, models.AuditEntityObject, entry.RootObjectId.String(), models.AuditActionPurge, entry, nil); err != nil {
+		return 0, err
+	}
+	if err := publishDeleteTransaction(tx, changes, entry, models.AuditActionPurge); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing purge: %w", err)
+	}
+	return count, nil
+}
+
+// getRecycleBinEntry retrieves a delete transaction on a database or transaction
+func getRecycleBinEntry(q interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}, dbTransactionID uuid.UUID) (*models.RecycleBinEntry, error) {
+	query := recycleBinEntrySql + `
+	FROM DeleteTransactions AS dt
+	INNER JOIN [Object] AS o ON o.ObjectID = dt.RootObjectID
+	WHERE dt.DeleteTransactionId = @p1`
+	entry, err := scanRecycleBinEntry(q.QueryRow(query, dbTransactionID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDeleteTransactionNotFound
+	}
+	return entry, err
+}
+
+// scanRecycleBinEntry scans a row selected by recycleBinEntrySql into an
+// entry, followed by any extra columns into extra
+func scanRecycleBinEntry(row interface{ Scan(...interface{}) error }, extra ...interface{}) (*models.RecycleBinEntry, error) {
+	entry := &models.RecycleBinEntry{}
+	var transactionIDBytes, rootIDBytes, libraryIDBytes, parentIDBytes []byte
+	err := row.Scan(append([]interface{}{
+		&transactionIDBytes, &rootIDBytes, &entry.RootObjectName, &entry.RootObjectTypeId, &libraryIDBytes, &parentIDBytes,
+		&entry.ObjectCount, &entry.DeletedBy, &entry.DateDeleted,
+	}, extra...)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error scanning recycle bin entry: %w", err)
+	}
+
+	if entry.DeleteTransactionId, err = parseSQLServerUUID(transactionIDBytes); err != nil {
+		return nil, fmt.Errorf("error parsing DeleteTransactionId: %w", err)
+	}
+	if entry.RootObjectId, err = parseSQLServerUUID(rootIDBytes); err != nil {
+		return nil, fmt.Errorf("error parsing RootObjectID: %w", err)
+	}
+	if libraryIDBytes != nil {
+		libraryID, err := parseSQLServerUUID(libraryIDBytes)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing LibraryId: %w", err)
+		}
+		entry.LibraryId = &libraryID
+	}
+	if parentIDBytes != nil {
+		parentID, err := parseSQLServerUUID(parentIDBytes)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing ParentObjectID: %w", err)
+		}
+		entry.ParentObjectId = &parentID
+	}
+	return entry, nil
+}
+
+// publishDeleteTransaction queues a webhook event for the root object of a
+// delete transaction. The transaction carries the object's type and library,
+// which a purged object no longer has.
+func publishDeleteTransaction(tx *sql.Tx, changes *ChangeLog, entry *models.RecycleBinEntry, action string) error {
+	objectTypeID := entry.RootObjectTypeId
+	return changes.Publish(tx, models.WebhookEvent{
+		EntityType:   models.WebhookEntityObject,
+		EntityId:     entry.RootObjectId.String(),
+		Action:       action,
+		ObjectId:     entry.RootObjectId,
+		ObjectTypeId: &objectTypeID,
+		LibraryId:    entry.LibraryId,
+		Data:         entry,
+	})
+}
@@ -0,0 +1,1108 @@
+package repositories
+
+import (
+	"database/sql"
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/utils"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Relationship errors
+var (
+	ErrRelationTypeNotFound   = errors.New("relation type not found")
+	ErrRelationTypeExists     = errors.New("a relation type with this name already exists")
+	ErrRelationTypeInUse      = errors.New("relation type is used by relationships")
+	ErrRelationshipNotFound   = errors.New("relationship not found")
+	ErrRelationshipExists     = errors.New("a relationship of this type already links these objects")
+	ErrRelationshipNotAllowed = errors.New("relation type does not allow a relationship between these object types")
+)
+
+// readableObjectColumnFilter restricts a query to rows whose object in the
+// given column the profile bound to the given parameter can read. It is the
+// counterpart of readableObjectFilter for queries that join [Object] twice.
+const readableObjectColumnFilter = `EXISTS (
+			SELECT 1 FROM dbo.fn_EffectiveObjectPermissions(%[2]s) AS perm
+			WHERE perm.ObjectID = %[1]s AND perm.HasRead = 1
+		)`
+
+// relationshipSelectSql selects relationships with their type and the names
+// of both objects. Relationships of objects in the recycle bin are left out.
+const relationshipSelectSql = `
+	SELECT rel.RelationshipId, rel.RelationTypeId, rt.RelationTypeName,
+		rel.SourceObjectID, src.ObjectName, rel.TargetObjectID, tgt.ObjectName,
+		rel.Description, rel.DateCreated, rel.CreatedBy, rel.DateModified, rel.ModifiedBy
+	FROM Relationships rel
+	JOIN RelationTypes rt ON rt.RelationTypeId = rel.RelationTypeId
+	JOIN [Object] src ON src.ObjectID = rel.SourceObjectID AND ISNULL(src.DeleteFlag, 0) = 0
+	JOIN [Object] tgt ON tgt.ObjectID = rel.TargetObjectID AND ISNULL(tgt.DeleteFlag, 0) = 0
+`
+
+// RelationshipRepository handles database operations for relation types and
+// relationships
+type RelationshipRepository struct {
+	db         *sql.DB
+	objectRepo *ObjectRepository
+}
+
+// NewRelationshipRepository creates a new RelationshipRepository
+func NewRelationshipRepository(db *sql.DB, objectRepo *ObjectRepository) *RelationshipRepository {
+	return &RelationshipRepository{db: db, objectRepo: objectRepo}
+}
+
+// ========== Relation types ==========
+
+// GetRelationTypes retrieves all relation types with their rules
+func (r *RelationshipRepository) GetRelationTypes() ([]models.RelationType, error) {
+	rows, err := r.db.Query(`
+		SELECT RelationTypeId, RelationTypeName, ReverseName, Description, ArchiMateType,
+			DateCreated, CreatedBy, DateModified, ModifiedBy
+		FROM RelationTypes
+		ORDER BY RelationTypeName
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving relation types: %w", err)
+	}
+	defer rows.Close()
+
+	types := []models.RelationType{}
+	index := make(map[uuid.UUID]int)
+	for rows.Next() {
+		relationType, err := scanRelationType(rows)
+		if err != nil {
+			return nil, err
+		}
+		index[relationType.RelationTypeId] = len(types)
+		types = append(types, *relationType)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating relation types: %w", err)
+	}
+
+	rules, err := r.db.Query(`SELECT RelationTypeId, SourceObjectTypeID, TargetObjectTypeID FROM RelationTypeRules ORDER BY SourceObjectTypeID, TargetObjectTypeID`)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving relation type rules: %w", err)
+	}
+	defer rules.Close()
+	for rules.Next() {
+		var idBytes []byte
+		var rule models.RelationTypeRule
+		if err := rules.Scan(&idBytes, &rule.SourceObjectTypeId, &rule.TargetObjectTypeId); err != nil {
+			return nil, fmt.Errorf("error scanning relation type rule: %w", err)
+		}
+		id, _ := parseSQLServerUUID(idBytes)
+		if i, ok := index[id]; ok {
+			types[i].Rules = append(types[i].Rules, rule)
+		}
+	}
+
+	return types, rules.Err()
+}
+
+// GetRelationType retrieves a relation type with its rules
+func (r *RelationshipRepository) GetRelationType(id uuid.UUID) (*models.RelationType, error) {
+	row := r.db.QueryRow(`
+		SELECT RelationTypeId, RelationTypeName, ReverseName, Description, ArchiMateType,
+			DateCreated, CreatedBy, DateModified, ModifiedBy
+		FROM RelationTypes
+		WHERE RelationTypeId = @p1
+	`, id)
+	relationType, err := scanRelationType(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRelationTypeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`SELECT SourceObjectTypeID, TargetObjectTypeID FROM RelationTypeRules WHERE RelationTypeId = @p1 ORDER BY SourceObjectTypeID, TargetObjectTypeID`, id)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving relation type rules: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var rule models.RelationTypeRule
+		if err := rows.Scan(&rule.SourceObjectTypeId, &rule.TargetObjectTypeId); err != nil {
+			return nil, fmt.Errorf("error scanning relation type rule: %w", err)
+		}
+		relationType.Rules = append(relationType.Rules, rule)
+	}
+
+	return relationType, rows.Err()
+}
+
+// scanRelationType scans a relation type without its rules
+func scanRelationType(row interface{ Scan(...interface{}) error }) (*models.RelationType, error) {
+	var idBytes []byte
+	relationType := &models.RelationType{Rules: []models.RelationTypeRule{}}
+	err := row.Scan(&idBytes, &relationType.RelationTypeName, &relationType.ReverseName, &relationType.Description,
+		&relationType.ArchiMateType, &relationType.DateCreated, &relationType.CreatedBy, &relationType.DateModified, &relationType.ModifiedBy)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error scanning relation type: %w", err)
+	}
+	relationType.RelationTypeId, _ = parseSQLServerUUID(idBytes)
+	return relationType, nil
+}
+
+// CreateRelationType creates a relation type with its rules
+func (r *RelationshipRepository) CreateRelationType(req models.RelationTypeRequest, userID int) (*models.RelationType, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := r.checkRelationTypeName(tx, req.RelationTypeName, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	id := uuid.New()
+	_, err = tx.Exec(`
+		INSERT INTO RelationTypes (RelationTypeId, RelationTypeName, ReverseName, Description, ArchiMateType, DateCreated, CreatedBy, DateModified, ModifiedBy)
+		VALUES (@p1, @p2, @p3, @p4, @p5, GETDATE(), @p6, GETDATE(), @p6)
+	`, id, req.RelationTypeName, req.ReverseName, req.Description, req.ArchiMateType, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error creating relation type: %w", err)
+	}
+	if err := r.setRelationTypeRules(tx, id, req.Rules); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+	return r.GetRelationType(id)
+}
+
+// UpdateRelationType replaces the name, description, ArchiMate type and rules
+// of a relation type
+func (r *RelationshipRepository) UpdateRelationType(id uuid.UUID, req models.RelationTypeRequest, userID int) (*models.RelationType, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := r.checkRelationTypeName(tx, req.RelationTypeName, id); err != nil {
+		return nil, err
+	}
+
+	result, err := tx.Exec(`
+		UPDATE RelationTypes
+		SET RelationTypeName = @p2, ReverseName = @p3, Description = @p4, ArchiMateType = @p5,
+			DateModified = GETDATE(), ModifiedBy = @p6
+		WHERE RelationTypeId = @p1
+	`, id, req.RelationTypeName, req.ReverseName, req.Description, req.ArchiMateType, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error updating relation type: %w", err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("error checking rows affected: %w", err)
+	} else if rowsAffected == 0 {
+		return nil, ErrRelationTypeNotFound
+	}
+
+	// Existing relationships are kept when a rule they rely on is removed
+	if _, err := tx.Exec(`DELETE FROM RelationTypeRules WHERE RelationTypeId = @p1`, id); err != nil {
+		return nil, fmt.Errorf("error clearing relation type rules: %w", err)
+	}
+	if err := r.setRelationTypeRules(tx, id, req.Rules); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+	return r.GetRelationType(id)
+}
+
+// DeleteRelationType deletes a relation type that no relationship uses
+func (r *RelationshipRepository) DeleteRelationType(id uuid.UUID) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var inUse bool
+	err = tx.QueryRow(`SELECT CASE WHEN EXISTS (SELECT 1 FROM Relationships WITH (UPDLOCK) WHERE RelationTypeId = @p1) THEN 1 ELSE 0 END`, id).Scan(&inUse)
+	if err != nil {
+		return fmt.Errorf("error checking relation type usage: %w", err)
+	}
+	if inUse {
+		return ErrRelationTypeInUse
+	}
+
+	result, err := tx.Exec(`DELETE FROM RelationTypes WHERE RelationTypeId = @p1`, id)
+	if err != nil {
+		return fmt.Errorf("error deleting relation type: %w", err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("error checking rows affected: %w", err)
+	} else if rowsAffected == 0 {
+		return ErrRelationTypeNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
+
+// checkRelationTypeName fails when another relation type has the name
+func (r *RelationshipRepository) checkRelationTypeName(tx *sql.Tx, name string, id uuid.UUID) error {
+	var exists bool
+	err := tx.QueryRow(`
+		SELECT CASE WHEN EXISTS (
+			SELECT 1 FROM RelationTypes WITH (UPDLOCK, HOLDLOCK)
+			WHERE RelationTypeName = @p1 AND RelationTypeId <> @p2
+		) THEN 1 ELSE 0 END
+	`, name, id).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("error checking relation type name: %w", err)
+	}
+	if exists {
+		return ErrRelationTypeExists
+	}
+	return nil
+}
+
+func (r *RelationshipRepository) setRelationTypeRules(tx *sql.Tx, id uuid.UUID, rules []models.RelationTypeRule) error {
+	for _, rule := range rules {
+		_, err := tx.Exec(`INSERT INTO RelationTypeRules (RelationTypeId, SourceObjectTypeID, TargetObjectTypeID) VALUES (@p1, @p2, @p3)`,
+			id, rule.SourceObjectTypeId, rule.TargetObjectTypeId)
+		if err != nil {
+			return fmt.Errorf("error adding relation type rule %d -> %d: %w", rule.SourceObjectTypeId, rule.TargetObjectTypeId, err)
+		}
+	}
+	return nil
+}
+
+// ========== Relationships ==========
+
+// GetRelationship retrieves a relationship with its attribute values
+func (r *RelationshipRepository) GetRelationship(id uuid.UUID) (*models.Relationship, error) {
+	return getRelationship(r.db, id)
+}
+
+// getRelationship retrieves a relationship with its attribute values on a
+// database or transaction
+func getRelationship(q dbQuerier, id uuid.UUID) (*models.Relationship, error) {
+	rows, err := q.Query(relationshipSelectSql+` WHERE rel.RelationshipId = @p1`, id)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving relationship: %w", err)
+	}
+	relationships, err := scanRelationships(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(relationships) == 0 {
+		return nil, ErrRelationshipNotFound
+	}
+
+	values, err := relationshipValues(q, `WHERE v.RelationshipId = @p1`, id)
+	if err != nil {
+		return nil, err
+	}
+	relationships[0].Attributes = append(relationships[0].Attributes, values[relationships[0].RelationshipId]...)
+	return &relationships[0], nil
+}
+
+// GetObjectRelationships retrieves the relationships of an object whose
+// other end the profile can read. direction is one of the
+// RelationshipDirection constants; relationTypeID filters by type unless nil.
+func (r *RelationshipRepository) GetObjectRelationships(objectID uuid.UUID, direction string, relationTypeID *uuid.UUID, profileID int) ([]models.Relationship, error) {
+	objectID, _ = TransformUUID(objectID)
+
+	var where []string
+	outgoing := `rel.SourceObjectID = @p1 AND ` + fmt.Sprintf(readableObjectColumnFilter, "rel.TargetObjectID", "@p2")
+	incoming := `rel.TargetObjectID = @p1 AND ` + fmt.Sprintf(readableObjectColumnFilter, "rel.SourceObjectID", "@p2")
+	switch direction {
+	case models.RelationshipDirectionOutgoing:
+		where = append(where, outgoing)
+	case models.RelationshipDirectionIncoming:
+		where = append(where, incoming)
+	default:
+		where = append(where, "(("+outgoing+") OR ("+incoming+"))")
+	}
+	args := []interface{}{objectID, profileID}
+	if relationTypeID != nil {
+		where = append(where, "rel.RelationTypeId = @p3")
+		args = append(args, *relationTypeID)
+	}
+	filter := " WHERE " + strings.Join(where, " AND ")
+
+	rows, err := r.db.Query(relationshipSelectSql+filter+` ORDER BY rt.RelationTypeName, src.ObjectName, tgt.ObjectName`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving relationships: %w", err)
+	}
+	relationships, err := scanRelationships(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := relationshipValues(r.db, `
+		JOIN Relationships rel ON rel.RelationshipId = v.RelationshipId
+		WHERE rel.SourceObjectID = @p1 OR rel.TargetObjectID = @p1`, objectID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range relationships {
+		relationships[i].Attributes = append(relationships[i].Attributes, values[relationships[i].RelationshipId]...)
+	}
+	return relationships, nil
+}
+
+// GetLibraryRelationships retrieves the relationships between objects of a
+// library where the profile can read both objects
+func (r *RelationshipRepository) GetLibraryRelationships(libraryID uuid.UUID, profileID int) ([]models.Relationship, error) {
+	libraryID, _ = TransformUUID(libraryID)
+	filter := ` WHERE src.LibraryId = @p1 AND tgt.LibraryId = @p1 AND ` +
+		fmt.Sprintf(readableObjectColumnFilter, "rel.SourceObjectID", "@p2") + ` AND ` +
+		fmt.Sprintf(readableObjectColumnFilter, "rel.TargetObjectID", "@p2")
+
+	rows, err := r.db.Query(relationshipSelectSql+filter+` ORDER BY rt.RelationTypeName, src.ObjectName, tgt.ObjectName`, libraryID, profileID)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving library relationships: %w", err)
+	}
+	relationships, err := scanRelationships(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := relationshipValues(r.db, `
+		JOIN Relationships rel ON rel.RelationshipId = v.RelationshipId
+		JOIN [Object] src ON src.ObjectID = rel.SourceObjectID
+		WHERE src.LibraryId = @p1`, libraryID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range relationships {
+		relationships[i].Attributes = append(relationships[i].Attributes, values[relationships[i].RelationshipId]...)
+	}
+	return relationships, nil
+}
+
+func scanRelationships(rows *sql.Rows) ([]models.Relationship, error) {
+	defer rows.Close()
+
+	relationships := []models.Relationship{}
+	for rows.Next() {
+		var idBytes, typeBytes, sourceBytes, targetBytes []byte
+		rel := models.Relationship{Attributes: []models.AssignedAttribute{}}
+		err := rows.Scan(&idBytes, &typeBytes, &rel.RelationTypeName, &sourceBytes, &rel.SourceObjectName,
+			&targetBytes, &rel.TargetObjectName, &rel.Description, &rel.DateCreated, &rel.CreatedBy, &rel.DateModified, &rel.ModifiedBy)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning relationship: %w", err)
+		}
+		rel.RelationshipId, _ = parseSQLServerUUID(idBytes)
+		rel.RelationTypeId, _ = parseSQLServerUUID(typeBytes)
+		rel.SourceObjectId, _ = parseSQLServerUUID(sourceBytes)
+		rel.TargetObjectId, _ = parseSQLServerUUID(targetBytes)
+		relationships = append(relationships, rel)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating relationships: %w", err)
+	}
+	return relationships, nil
+}
+
+// relationshipValues loads attribute values of relationships on a database or
+// transaction, keyed by relationship ID. filter is appended to the query on
+// RelationshipAttributeValues aliased v.
+func relationshipValues(q dbQuerier, filter string, args ...interface{}) (map[uuid.UUID][]models.AssignedAttribute, error) {
+	rows, err := q.Query(`
+		SELECT v.RelationshipId, v.AttributeId, a.AttributeName, a.AttributeType,
+			v.DataType, v.ValueBigInt, v.ValueFloat, v.ValueDate, v.ValueText, v.ValueRichText
+		FROM RelationshipAttributeValues v
+		JOIN vwAttribute a ON a.AttributeId = v.AttributeId
+	`+filter+` ORDER BY a.AttributeName`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving relationship attribute values: %w", err)
+	}
+	defer rows.Close()
+
+	values := make(map[uuid.UUID][]models.AssignedAttribute)
+	for rows.Next() {
+		var idBytes []byte
+		var dataType int64
+		var bigInt *int64
+		var float *float64
+		var date *time.Time
+		var text, richText *string
+		var value models.AssignedAttribute
+		err := rows.Scan(&idBytes, &value.AttributeID, &value.AttributeName, &value.AttributeType,
+			&dataType, &bigInt, &float, &date, &text, &richText)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning relationship attribute value: %w", err)
+		}
+		relationshipID, _ := parseSQLServerUUID(idBytes)
+		value.DataType = fmt.Sprint(dataType)
+		switch dataType {
+		case 1:
+			if bigInt != nil {
+				n := int(*bigInt)
+				value.IntegerValue = &n
+			}
+		case 2:
+			value.DateValue = date
+		case 3:
+			value.FloatValue = float
+		case 5:
+			if bigInt != nil {
+				b := *bigInt == 1
+				value.BooleanValue = &b
+			}
+		case 6:
+			value.RichTextValue = richText
+		default:
+			value.TextValue = text
+		}
+		values[relationshipID] = append(values[relationshipID], value)
+	}
+	return values, rows.Err()
+}
+
+// CreateRelationship creates a relationship after checking that its relation
+// type allows the object types of both ends
+func (r *RelationshipRepository) CreateRelationship(req models.CreateRelationshipRequest, userID int, changes *ChangeLog) (uuid.UUID, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	id, err := r.CreateRelationshipTx(tx, req, userID, changes)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return uuid.Nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+	return id, nil
+}
+
+// CreateRelationshipTx creates a relationship within the caller's transaction
+func (r *RelationshipRepository) CreateRelationshipTx(tx *sql.Tx, req models.CreateRelationshipRequest, userID int, changes *ChangeLog) (uuid.UUID, error) {
+	sourceID, _ := TransformUUID(req.SourceObjectId)
+	targetID, _ := TransformUUID(req.TargetObjectId)
+
+	allowed, existing, err := r.checkRelationship(tx, req.RelationTypeId, sourceID, targetID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if existing != nil {
+		return uuid.Nil, ErrRelationshipExists
+	}
+	if !allowed {
+		return uuid.Nil, ErrRelationshipNotAllowed
+	}
+
+	id := uuid.New()
+	_, err = tx.Exec(`
+		INSERT INTO Relationships (RelationshipId, RelationTypeId, SourceObjectID, TargetObjectID, Description, DateCreated, CreatedBy, DateModified, ModifiedBy)
+		VALUES (@p1, @p2, @p3, @p4, @p5, GETDATE(), @p6, GETDATE(), @p6)
+	`, id, req.RelationTypeId, sourceID, targetID, req.Description, userID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error creating relationship: %w", err)
+	}
+
+	if err := r.setRelationshipValues(tx, id, req.Attributes, userID); err != nil {
+		return uuid.Nil, err
+	}
+	if err := publishRelationship(tx, changes, id, models.AuditActionCreate, nil); err != nil {
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+// publishRelationship queues a webhook event for a change to a relationship,
+// filed under its source object, with the relationship after the change as
+// its data. deleted is the relationship before a delete.
+func publishRelationship(tx *sql.Tx, changes *ChangeLog, id uuid.UUID, action string, deleted *models.Relationship) error {
+	if changes == nil {
+		return nil
+	}
+	relationship := deleted
+	if relationship == nil {
+		var err error
+		if relationship, err = getRelationship(tx, id); err != nil {
+			return err
+		}
+	}
+	return changes.Publish(tx, models.WebhookEvent{
+		EntityType: models.WebhookEntityRelationship,
+		EntityId:   relationship.RelationshipId.String(),
+		Action:     action,
+		ObjectId:   relationship.SourceObjectId,
+		Data:       relationship,
+	})
+}
+
+// checkRelationship reports whether the relation type allows a relationship
+// between the types of two stored objects, and returns the ID of an existing
+// relationship of the type between them
+func (r *RelationshipRepository) checkRelationship(tx *sql.Tx, relationTypeID, sourceID, targetID uuid.UUID) (bool, *uuid.UUID, error) {
+	var allowed bool
+	var existingBytes []byte
+	err := tx.QueryRow(`
+		SELECT
+			CASE WHEN EXISTS (
+				SELECT 1 FROM RelationTypeRules rule
+				WHERE rule.RelationTypeId = @p1 AND rule.SourceObjectTypeID = src.ExactObjectTypeID AND rule.TargetObjectTypeID = tgt.ExactObjectTypeID
+			) THEN 1 ELSE 0 END,
+			(
+				SELECT RelationshipId FROM Relationships WITH (UPDLOCK, HOLDLOCK)
+				WHERE RelationTypeId = @p1 AND SourceObjectID = @p2 AND TargetObjectID = @p3
+			)
+		FROM [Object] src, [Object] tgt
+		WHERE src.ObjectID = @p2 AND tgt.ObjectID = @p3
+			AND ISNULL(src.DeleteFlag, 0) = 0 AND ISNULL(tgt.DeleteFlag, 0) = 0
+	`, relationTypeID, sourceID, targetID).Scan(&allowed, &existingBytes)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return false, nil, fmt.Errorf("error checking relationship: %w", err)
+	}
+	if existingBytes == nil {
+		return allowed, nil, nil
+	}
+	existing, _ := parseSQLServerUUID(existingBytes)
+	return allowed, &existing, nil
+}
+
+// ImportWithRelationships runs several object imports and then creates the
+// relationships between their rows, all in one transaction, so that a model
+// holding objects of different types is imported as a whole. The object
+// import responses are in the order of reqs; with dryRun everything is rolled
+// back.
+func (r *RelationshipRepository) ImportWithRelationships(reqs []models.ObjectImportRequest, relationships []models.RelationshipImport, dryRun bool, locale string, userID, profileID int, changes *ChangeLog) ([]*models.ObjectImportResponse, []models.RelationshipImportResult, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	responses := make([]*models.ObjectImportResponse, 0, len(reqs))
+	for _, req := range reqs {
+		req.DryRun = dryRun
+		response, err := r.objectRepo.ImportObjectsTx(tx, req, userID, profileID, changes)
+		if err != nil {
+			return nil, nil, err
+		}
+		responses = append(responses, response)
+	}
+
+	loc, err := utils.LookupValueLocale(locale)
+	if err != nil {
+		return nil, nil, err
+	}
+	results := make([]models.RelationshipImportResult, 0, len(relationships))
+	for _, rel := range relationships {
+		result, err := r.importRelationship(tx, rel, responses, loc, userID, changes)
+		if err != nil {
+			return nil, nil, err
+		}
+		results = append(results, result)
+	}
+
+	if !dryRun {
+		if err := tx.Commit(); err != nil {
+			return nil, nil, fmt.Errorf("error committing transaction: %w", err)
+		}
+	}
+
+	return responses, results, nil
+}
+
+// importRelationship creates a relationship between two imported rows, or
+// updates the attribute values of the one that already links them
+func (r *RelationshipRepository) importRelationship(tx *sql.Tx, rel models.RelationshipImport, responses []*models.ObjectImportResponse, loc utils.ValueLocale, userID int, changes *ChangeLog) (models.RelationshipImportResult, error) {
+	result := models.RelationshipImportResult{Identifier: rel.Identifier, Action: models.ImportActionSkip}
+
+	source := importedObjectID(responses, rel.Source)
+	target := importedObjectID(responses, rel.Target)
+	if source == nil || target == nil {
+		result.Message = "source or target was not imported"
+		return result, nil
+	}
+	if *source == *target {
+		result.Message = "an object cannot be related to itself"
+		return result, nil
+	}
+
+	var attrs []models.AssignedAttribute
+	for _, value := range rel.Values {
+		if value.AttributeId == nil || value.AttributeValue == nil {
+			continue
+		}
+		attributeID, err := uuid.Parse(*value.AttributeId)
+		if err != nil {
+			result.Message = fmt.Sprintf("invalid attributeId %q", *value.AttributeId)
+			return result, nil
+		}
+		definition := models.ObjectTypeAssignedAttribute{AttributeId: attributeID}
+		if value.AttributeName != nil {
+			definition.AttributeName = *value.AttributeName
+		}
+		if value.AttributeType != nil {
+			definition.AttributeType = *value.AttributeType
+		}
+		attr := models.AssignedAttribute{AttributeID: attributeID, AttributeName: definition.AttributeName, AttributeType: definition.AttributeType}
+		set, err := r.objectRepo.setImportValue(&attr, definition, *value.AttributeValue, loc)
+		if err != nil {
+			result.Message = fmt.Sprintf("%s: %v", definition.AttributeName, err)
+			return result, nil
+		}
+		if set {
+			attrs = append(attrs, attr)
+		}
+	}
+
+	sourceID, _ := TransformUUID(*source)
+	targetID, _ := TransformUUID(*target)
+	allowed, existing, err := r.checkRelationship(tx, rel.RelationTypeId, sourceID, targetID)
+	if err != nil {
+		return result, err
+	}
+	if existing != nil {
+		result.RelationshipId = existing
+		result.Action = models.ImportActionUnchanged
+		if len(attrs) > 0 || rel.Description != nil {
+			if _, err := tx.Exec(`
+				UPDATE Relationships
+				SET Description = COALESCE(@p2, Description), DateModified = GETDATE(), ModifiedBy = @p3
+				WHERE RelationshipId = @p1
+			`, *existing, rel.Description, userID); err != nil {
+				return result, fmt.Errorf("error updating relationship: %w", err)
+			}
+			if err := r.setRelationshipValues(tx, *existing, attrs, userID); err != nil {
+				return result, err
+			}
+			if err := publishRelationship(tx, changes, *existing, models.AuditActionUpdate, nil); err != nil {
+				return result, err
+			}
+			result.Action = models.ImportActionUpdate
+		}
+		return result, nil
+	}
+	if !allowed {
+		result.Message = ErrRelationshipNotAllowed.Error()
+		return result, nil
+	}
+
+	id, err := r.CreateRelationshipTx(tx, models.CreateRelationshipRequest{
+		RelationTypeId: rel.RelationTypeId,
+		SourceObjectId: *source,
+		TargetObjectId: *target,
+		Description:    rel.Description,
+		Attributes:     attrs,
+	}, userID, changes)
+	if err != nil {
+		return result, err
+	}
+	result.RelationshipId = &id
+	result.Action = models.ImportActionInsert
+	return result, nil
+}
+
+// importedObjectID returns the object a row of an import was written to, or
+// nil when the row failed or does not exist
+func importedObjectID(responses []*models.ObjectImportResponse, ref models.ImportRowRef) *uuid.UUID {
+	if ref.Request < 0 || ref.Request >= len(responses) {
+		return nil
+	}
+	rows := responses[ref.Request].Rows
+	if ref.Row < 0 || ref.Row >= len(rows) || len(rows[ref.Row].Errors) > 0 {
+		return nil
+	}
+	return rows[ref.Row].ObjectID
+}
+
+// UpdateRelationship updates the description and attribute values of a relationship
+func (r *RelationshipRepository) UpdateRelationship(id uuid.UUID, req models.UpdateRelationshipRequest, userID int, changes *ChangeLog) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE Relationships
+		SET Description = CASE WHEN @p2 = 1 THEN @p3 ELSE Description END,
+			DateModified = GETDATE(), ModifiedBy = @p4
+		WHERE RelationshipId = @p1
+	`, id, req.Description != nil, req.Description, userID)
+	if err != nil {
+		return fmt.Errorf("error updating relationship: %w", err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("error checking rows affected: %w", err)
+	} else if rowsAffected == 0 {
+		return ErrRelationshipNotFound
+	}
+
+	if err := r.setRelationshipValues(tx, id, req.Attributes, userID); err != nil {
+		return err
+	}
+	if err := publishRelationship(tx, changes, id, models.AuditActionUpdate, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
+
+// DeleteRelationship deletes a relationship and its attribute values
+func (r *RelationshipRepository) DeleteRelationship(id uuid.UUID, changes *ChangeLog) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := getRelationship(tx, id)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM Relationships WHERE RelationshipId = @p1`, id); err != nil {
+		return fmt.Errorf("error deleting relationship: %w", err)
+	}
+	if err := publishRelationship(tx, changes, id, models.AuditActionDelete, before); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
+
+// setRelationshipValues stores attribute values of a relationship. The value
+// field matching the attribute's type is stored; an attribute with no value
+// has its stored value removed.
+func (r *RelationshipRepository) setRelationshipValues(tx *sql.Tx, id uuid.UUID, attrs []models.AssignedAttribute, userID int) error {
+	for _, attr := range attrs {
+		attributeID, _ := TransformUUIDToSQLServerV2(attr.AttributeID)
+		dataType := getTypeId(attr.AttributeType)
+
+		var bigInt, float, date, text, richText interface{}
+		set := true
+		switch dataType {
+		case 1:
+			set = attr.IntegerValue != nil
+			if set {
+				bigInt = int64(*attr.IntegerValue)
+			}
+		case 2:
+			set = attr.DateValue != nil
+			if set {
+				date = *attr.DateValue
+			}
+		case 3:
+			set = attr.FloatValue != nil
+			if set {
+				float = *attr.FloatValue
+			}
+		case 5:
+			set = attr.BooleanValue != nil
+			if set {
+				bigInt = int64(0)
+				if *attr.BooleanValue {
+					bigInt = int64(1)
+				}
+			}
+		case 6:
+			set = attr.RichTextValue != nil
+			if set {
+				richText = *attr.RichTextValue
+			}
+		default:
+			set = attr.TextValue != nil
+			if set {
+				text = *attr.TextValue
+			}
+		}
+
+		if !set {
+			if _, err := tx.Exec(`DELETE FROM RelationshipAttributeValues WHERE RelationshipId = @p1 AND AttributeId = @p2`, id, attributeID); err != nil {
+				return fmt.Errorf("error clearing relationship attribute %s: %w", attr.AttributeID, err)
+			}
+			continue
+		}
+
+		_, err := tx.Exec(`
+			MERGE RelationshipAttributeValues AS target
+			USING (SELECT @p1 AS RelationshipId, @p2 AS AttributeId) AS source
+			ON target.RelationshipId = source.RelationshipId AND target.AttributeId = source.AttributeId
+			WHEN MATCHED THEN UPDATE SET DataType = @p3, ValueBigInt = @p4, ValueFloat = @p5, ValueDate = @p6,
+				ValueText = @p7, ValueRichText = @p8, DateModified = GETDATE(), ModifiedBy = @p9
+			WHEN NOT MATCHED THEN INSERT (RelationshipId, AttributeId, DataType, ValueBigInt, ValueFloat, ValueDate, ValueText, ValueRichText, DateModified, ModifiedBy)
+				VALUES (@p1, @p2, @p3, @p4, @p5, @p6, @p7, @p8, GETDATE(), @p9);
+		`, id, attributeID, dataType, bigInt, float, date, text, richText, userID)
+		if err != nil {
+			return fmt.Errorf("error setting relationship attribute %s: %w", attr.AttributeID, err)
+		}
+	}
+	return nil
+}
+
+// ========== Impact analysis ==========
+
+// impactNextObjectSql is the object at the other end of a relationship from
+// the walked object w
+const impactNextObjectSql = `CASE WHEN rel.SourceObjectID = w.ObjectID THEN rel.TargetObjectID ELSE rel.SourceObjectID END`
+
+// impactLink is a relationship found by the impact walk, with both objects
+type impactLink struct {
+	edge           models.ImpactEdge
+	source, target models.ImpactNode
+	sourceReadable bool
+	targetReadable bool
+}
+
+// GetImpact walks the relationship graph from an object up to depth
+// relationships away, following outgoing relationships for down and incoming
+// ones for up, optionally only of the given relation types. The walk goes
+// level by level and expands each object once, at its shortest distance, so
+// its cost is bounded by the objects and relationships reached rather than
+// by the paths between them. Objects the profile cannot read are neither
+// returned nor walked through, and objects in the recycle bin are skipped.
+func (r *RelationshipRepository) GetImpact(objectID uuid.UUID, direction string, depth int, relationTypeIDs []uuid.UUID, profileID int) (*models.ImpactGraph, error) {
+	objectID, _ = TransformUUID(objectID)
+
+	root := models.ImpactNode{}
+	var rootBytes []byte
+	err := r.db.QueryRow(`
+		SELECT o.ObjectID, o.ObjectName, o.ExactObjectTypeID, ot.ObjectTypeName, ot.Color
+		FROM [Object] o
+		LEFT JOIN ObjectType ot ON ot.ObjectTypeID = o.ExactObjectTypeID
+		WHERE o.ObjectID = @p1 AND ISNULL(o.DeleteFlag, 0) = 0
+	`, objectID).Scan(&rootBytes, &root.ObjectName, &root.ObjectTypeId, &root.ObjectTypeName, &root.Color)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving object: %w", err)
+	}
+	root.ObjectId, _ = parseSQLServerUUID(rootBytes)
+
+	outgoing := direction != models.ImpactDirectionUp
+	incoming := direction != models.ImpactDirectionDown
+	args := []interface{}{objectID, depth, outgoing, incoming, profileID}
+	typeFilter := ""
+	if len(relationTypeIDs) > 0 {
+		params := make([]string, len(relationTypeIDs))
+		for i, id := range relationTypeIDs {
+			args = append(args, id)
+			params[i] = fmt.Sprintf("@p%d", len(args))
+		}
+		typeFilter = " AND rel.RelationTypeId IN (" + strings.Join(params, ", ") + ")"
+	}
+
+	query := `
+		SET NOCOUNT ON;
+		DECLARE @visited TABLE (ObjectID UNIQUEIDENTIFIER PRIMARY KEY, Depth INT NOT NULL);
+		DECLARE @edges TABLE (RelationshipId UNIQUEIDENTIFIER PRIMARY KEY);
+		DECLARE @step TABLE (RelationshipId UNIQUEIDENTIFIER NOT NULL, ObjectID UNIQUEIDENTIFIER NOT NULL);
+		DECLARE @level INT = 0;
+		INSERT INTO @visited (ObjectID, Depth) VALUES (@p1, 0);
+
+		WHILE @level < @p2
+		BEGIN
+			DELETE FROM @step;
+			INSERT INTO @step (RelationshipId, ObjectID)
+			SELECT rel.RelationshipId, nxt.ObjectID
+			FROM @visited w
+			INNER JOIN Relationships rel
+				ON (@p3 = 1 AND rel.SourceObjectID = w.ObjectID) OR (@p4 = 1 AND rel.TargetObjectID = w.ObjectID)
+			INNER JOIN [Object] nxt
+				ON nxt.ObjectID = ` + impactNextObjectSql + ` AND ISNULL(nxt.DeleteFlag, 0) = 0
+			WHERE w.Depth = @level
+				AND NOT EXISTS (SELECT 1 FROM @edges e WHERE e.RelationshipId = rel.RelationshipId)` + typeFilter + `;
+			IF @@ROWCOUNT = 0 BREAK;
+
+			INSERT INTO @edges (RelationshipId)
+			SELECT DISTINCT RelationshipId FROM @step;
+
+			SET @level = @level + 1;
+			INSERT INTO @visited (ObjectID, Depth)
+			SELECT DISTINCT s.ObjectID, @level
+			FROM @step s
+			WHERE NOT EXISTS (SELECT 1 FROM @visited v WHERE v.ObjectID = s.ObjectID);
+		END
+
+		SELECT rel.RelationshipId, rel.RelationTypeId, rt.RelationTypeName,
+			rel.SourceObjectID, src.ObjectName, src.ExactObjectTypeID, srcType.ObjectTypeName, srcType.Color,
+			CAST(CASE WHEN ` + fmt.Sprintf(readableObjectColumnFilter, "rel.SourceObjectID", "@p5") + ` THEN 1 ELSE 0 END AS BIT),
+			rel.TargetObjectID, tgt.ObjectName, tgt.ExactObjectTypeID, tgtType.ObjectTypeName, tgtType.Color,
+			CAST(CASE WHEN ` + fmt.Sprintf(readableObjectColumnFilter, "rel.TargetObjectID", "@p5") + ` THEN 1 ELSE 0 END AS BIT)
+		FROM @edges e
+		JOIN Relationships rel ON rel.RelationshipId = e.RelationshipId
+		JOIN RelationTypes rt ON rt.RelationTypeId = rel.RelationTypeId
+		JOIN [Object] src ON src.ObjectID = rel.SourceObjectID
+		LEFT JOIN ObjectType srcType ON srcType.ObjectTypeID = src.ExactObjectTypeID
+		JOIN [Object] tgt ON tgt.ObjectID = rel.TargetObjectID
+		LEFT JOIN ObjectType tgtType ON tgtType.ObjectTypeID = tgt.ExactObjectTypeID
+		ORDER BY rt.RelationTypeName, src.ObjectName, tgt.ObjectName
+	`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error walking relationships: %w", err)
+	}
+	defer rows.Close()
+
+	var links []impactLink
+	for rows.Next() {
+		var link impactLink
+		var idBytes, typeBytes, sourceBytes, targetBytes []byte
+		err := rows.Scan(&idBytes, &typeBytes, &link.edge.RelationTypeName,
+			&sourceBytes, &link.source.ObjectName, &link.source.ObjectTypeId, &link.source.ObjectTypeName, &link.source.Color, &link.sourceReadable,
+			&targetBytes, &link.target.ObjectName, &link.target.ObjectTypeId, &link.target.ObjectTypeName, &link.target.Color, &link.targetReadable)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning relationship: %w", err)
+		}
+		link.edge.RelationshipId, _ = parseSQLServerUUID(idBytes)
+		link.edge.RelationTypeId, _ = parseSQLServerUUID(typeBytes)
+		link.edge.SourceObjectId, _ = parseSQLServerUUID(sourceBytes)
+		link.edge.TargetObjectId, _ = parseSQLServerUUID(targetBytes)
+		link.source.ObjectId = link.edge.SourceObjectId
+		link.target.ObjectId = link.edge.TargetObjectId
+		links = append(links, link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating relationships: %w", err)
+	}
+
+	return buildImpactGraph(root, links, direction, depth, outgoing, incoming), nil
+}
+
+// buildImpactGraph walks the links breadth-first from the root through
+// readable objects only, so that objects reachable solely through an object
+// the profile cannot read are left out, and gives each object its shortest
+// distance from the root
+func buildImpactGraph(root models.ImpactNode, links []impactLink, direction string, depth int, outgoing, incoming bool) *models.ImpactGraph {
+	type step struct {
+		link *impactLink
+		next models.ImpactNode
+		ok   bool
+	}
+	adjacent := make(map[uuid.UUID][]step)
+	for i := range links {
+		link := &links[i]
+		if outgoing {
+			adjacent[link.edge.SourceObjectId] = append(adjacent[link.edge.SourceObjectId], step{link, link.target, link.targetReadable})
+		}
+		if incoming {
+			adjacent[link.edge.TargetObjectId] = append(adjacent[link.edge.TargetObjectId], step{link, link.source, link.sourceReadable})
+		}
+	}
+
+	graph := &models.ImpactGraph{
+		RootObjectId: root.ObjectId,
+		Direction:    direction,
+		Depth:        depth,
+		Nodes:        []models.ImpactNode{root},
+		Edges:        []models.ImpactEdge{},
+	}
+	visited := map[uuid.UUID]bool{root.ObjectId: true}
+	followed := make(map[uuid.UUID]bool)
+	queue := []models.ImpactNode{root}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		if node.Depth >= depth {
+			continue
+		}
+		for _, s := range adjacent[node.ObjectId] {
+			if !s.ok || followed[s.link.edge.RelationshipId] {
+				continue
+			}
+			followed[s.link.edge.RelationshipId] = true
+			graph.Edges = append(graph.Edges, s.link.edge)
+			if !visited[s.next.ObjectId] {
+				visited[s.next.ObjectId] = true
+				next := s.next
+				next.Depth = node.Depth + 1
+				graph.Nodes = append(graph.Nodes, next)
+				queue = append(queue, next)
+			}
+		}
+	}
+	graph.HasCycles = impactHasCycle(graph.Edges, outgoing != incoming)
+	return graph
+}
+
+// impactHasCycle reports whether the followed relationships can be walked
+// from an object back to itself. Walked one way, that is a directed cycle;
+// walked both ways, any relationship joining two objects that are already
+// connected closes one.
+func impactHasCycle(edges []models.ImpactEdge, directed bool) bool {
+	if !directed {
+		parent := make(map[uuid.UUID]uuid.UUID)
+		var find func(id uuid.UUID) uuid.UUID
+		find = func(id uuid.UUID) uuid.UUID {
+			p, ok := parent[id]
+			if !ok || p == id {
+				return id
+			}
+			root := find(p)
+			parent[id] = root
+			return root
+		}
+		for _, edge := range edges {
+			source, target := find(edge.SourceObjectId), find(edge.TargetObjectId)
+			if source == target {
+				return true
+			}
+			parent[source] = target
+		}
+		return false
+	}
+
+	// Walking up reverses every relationship, which keeps the same cycles
+	next := make(map[uuid.UUID][]uuid.UUID)
+	for _, edge := range edges {
+		next[edge.SourceObjectId] = append(next[edge.SourceObjectId], edge.TargetObjectId)
+	}
+	const (
+		unseen = iota
+		onPath
+		done
+	)
+	state := make(map[uuid.UUID]int)
+	var visit func(id uuid.UUID) bool
+	visit = func(id uuid.UUID) bool {
+		state[id] = onPath
+		for _, n := range next[id] {
+			switch state[n] {
+			case onPath:
+				return true
+			case unseen:
+				if visit(n) {
+					return true
+				}
+			}
+		}
+		state[id] = done
+		return false
+	}
+	for id := range next {
+		if state[id] == unseen && visit(id) {
+			return true
+		}
+	}
+	return false
+}
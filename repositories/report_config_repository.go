@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"enterprise-architect-api/models"
 	"fmt"
+	"strconv"
 
 	"github.com/google/uuid"
 )
@@ -72,28 +73,47 @@ func (r *ReportConfigRepository) DashboardCount(libraryID uuid.UUID) ([]models.D
 // ========== EA_Tags CRUD Operations ==========
 
 // CreateEATag creates a new EA tag in the database
-func (r *ReportConfigRepository) CreateEATag(req models.CreateEATagRequest) (*models.EATag, error) {
+func (r *ReportConfigRepository) CreateEATag(req models.CreateEATagRequest, changes *ChangeLog) (*models.EATag, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `INSERT INTO EA_Tags (name_ar, name_en) VALUES (@p1, @p2); SELECT SCOPE_IDENTITY()`
 
 	var id int
-	err := r.db.QueryRow(query, req.NameAr, req.NameEn).Scan(&id)
+	err = tx.QueryRow(query, req.NameAr, req.NameEn).Scan(&id)
 	if err != nil {
 		return nil, fmt.Errorf("error creating EA tag: %w", err)
 	}
 
-	return &models.EATag{
+	tag := &models.EATag{
 		ID:     id,
 		NameAr: req.NameAr,
 		NameEn: req.NameEn,
-	}, nil
+	}
+	if err := changes.Record(tx, models.AuditEntityEATag, strconv.Itoa(id), models.AuditActionCreate, nil, tag); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+	return tag, nil
 }
 
 // GetEATagByID retrieves an EA tag by its ID
 func (r *ReportConfigRepository) GetEATagByID(id int) (*models.EATag, error) {
+	return getEATag(r.db, id)
+}
+
+// getEATag retrieves an EA tag by its ID with a database or transaction
+func getEATag(q dbQuerier, id int) (*models.EATag, error) {
 	query := `SELECT id, name_ar, name_en FROM EA_Tags WHERE id = @p1`
 
 	var tag models.EATag
-	err := r.db.QueryRow(query, id).Scan(&tag.ID, &tag.NameAr, &tag.NameEn)
+	err := q.QueryRow(query, id).Scan(&tag.ID, &tag.NameAr, &tag.NameEn)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("EA tag not found with ID: %d", id)
@@ -105,45 +125,69 @@ func (r *ReportConfigRepository) GetEATagByID(id int) (*models.EATag, error) {
 }
 
 // GetAllEATags retrieves all EA tags with pagination
-func (r *ReportConfigRepository) GetAllEATags(page, pageSize int) ([]models.EATag, int, error) {
-	offset := (page - 1) * pageSize
+func (r *ReportConfigRepository) GetAllEATags(req models.PageRequest) ([]models.EATag, models.PageInfo, error) {
+	info := models.PageInfo{TotalCount: -1}
+	k, err := newKeyset([]keysetKey{{"id", false}}, "eaTags", req)
+	if err != nil {
+		return nil, info, err
+	}
 
 	// Get total count
-	countQuery := `SELECT COUNT(*) FROM EA_Tags`
-	var totalCount int
-	err := r.db.QueryRow(countQuery).Scan(&totalCount)
-	if err != nil {
-		return nil, 0, fmt.Errorf("error counting EA tags: %w", err)
+	if !req.SkipCount {
+		countQuery := `SELECT COUNT(*) FROM EA_Tags`
+		if err := r.db.QueryRow(countQuery).Scan(&info.TotalCount); err != nil {
+			return nil, info, fmt.Errorf("error counting EA tags: %w", err)
+		}
 	}
 
 	// Get paginated data
-	query := `SELECT id, name_ar, name_en FROM EA_Tags ORDER BY id OFFSET @p1 ROWS FETCH NEXT @p2 ROWS ONLY`
+	var args queryArgs
+	where := ""
+	if cond := k.condition(args.param); cond != "" {
+		where = " WHERE " + cond
+	}
+	query := `SELECT id, name_ar, name_en, ` + k.columns() + ` FROM EA_Tags` + where + ` ORDER BY ` + k.orderBy() +
+		` OFFSET ` + args.param(k.offset(req)) + ` ROWS FETCH NEXT ` + args.param(req.PageSize+1) + ` ROWS ONLY`
 
-	rows, err := r.db.Query(query, offset, pageSize)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("error getting EA tags: %w", err)
+		return nil, info, fmt.Errorf("error getting EA tags: %w", err)
 	}
 	defer rows.Close()
 
 	var tags []models.EATag
+	var sortValues [][]interface{}
 	for rows.Next() {
 		var tag models.EATag
-		if err := rows.Scan(&tag.ID, &tag.NameAr, &tag.NameEn); err != nil {
-			return nil, 0, fmt.Errorf("error scanning EA tag: %w", err)
+		values, keyDests := scanKeys(1)
+		if err := rows.Scan(append([]interface{}{&tag.ID, &tag.NameAr, &tag.NameEn}, keyDests...)...); err != nil {
+			return nil, info, fmt.Errorf("error scanning EA tag: %w", err)
 		}
 		tags = append(tags, tag)
+		sortValues = append(sortValues, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, info, fmt.Errorf("error iterating EA tags: %w", err)
 	}
 
-	return tags, totalCount, nil
+	tags, err = keysetPage(k, req, tags, sortValues, &info)
+	return tags, info, err
 }
 
 // UpdateEATag updates an existing EA tag
-func (r *ReportConfigRepository) UpdateEATag(id int, req models.UpdateEATagRequest) (*models.EATag, error) {
+func (r *ReportConfigRepository) UpdateEATag(id int, req models.UpdateEATagRequest, changes *ChangeLog) (*models.EATag, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	// Check if the tag exists
-	existing, err := r.GetEATagByID(id)
+	before, err := getEATag(tx, id)
 	if err != nil {
 		return nil, err
 	}
+	existing := *before
 
 	// Build dynamic update query
 	query := `UPDATE EA_Tags SET `
@@ -171,19 +215,36 @@ func (r *ReportConfigRepository) UpdateEATag(id int, req models.UpdateEATagReque
 	query += fmt.Sprintf(" WHERE id = @p%d", paramIndex)
 	params = append(params, id)
 
-	_, err = r.db.Exec(query, params...)
+	_, err = tx.Exec(query, params...)
 	if err != nil {
 		return nil, fmt.Errorf("error updating EA tag: %w", err)
 	}
+	if err := changes.Record(tx, models.AuditEntityEATag, strconv.Itoa(id), models.AuditActionUpdate, before, &existing); err != nil {
+		return nil, err
+	}
 
-	return existing, nil
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+	return &existing, nil
 }
 
 // DeleteEATag deletes an EA tag by its ID
-func (r *ReportConfigRepository) DeleteEATag(id int) error {
+func (r *ReportConfigRepository) DeleteEATag(id int, changes *ChangeLog) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := getEATag(tx, id)
+	if err != nil {
+		return err
+	}
+
 	query := `DELETE FROM EA_Tags WHERE id = @p1`
 
-	result, err := r.db.Exec(query, id)
+	result, err := tx.Exec(query, id)
 	if err != nil {
 		return fmt.Errorf("error deleting EA tag: %w", err)
 	}
@@ -196,16 +257,39 @@ func (r *ReportConfigRepository) DeleteEATag(id int) error {
 	if rowsAffected == 0 {
 		return fmt.Errorf("EA tag not found with ID: %d", id)
 	}
+	if err := changes.Record(tx, models.AuditEntityEATag, strconv.Itoa(id), models.AuditActionDelete, before, nil); err != nil {
+		return err
+	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
 	return nil
 }
 
 // ========== EA_Tags_Dimentions Operations ==========
 
 // AssignObjectTypeToDimention assigns an object type to a dimension
-func (r *ReportConfigRepository) AssignObjectTypeToDimention(req models.AssignObjectTypeToDimentionRequest) (*models.EATagDimention, error) {
+func (r *ReportConfigRepository) AssignObjectTypeToDimention(req models.AssignObjectTypeToDimentionRequest, changes *ChangeLog) (*models.EATagDimention, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var before *models.EATagDimention
+	var existing models.EATagDimention
+	err = tx.QueryRow(`SELECT TOP 1 id, ea_tag_id, object_type_id FROM EA_Tags_Dimentions WHERE object_type_id = @p1`,
+		req.ObjectTypeID).Scan(&existing.ID, &existing.EATagID, &existing.ObjectTypeID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("error getting object type dimension: %w", err)
+	}
+	if err == nil {
+		before = &existing
+	}
+
 	query := `delete from EA_Tags_Dimentions where object_type_id = @p1`
-	_, err := r.db.Exec(query, req.ObjectTypeID)
+	_, err = tx.Exec(query, req.ObjectTypeID)
 	if err != nil {
 		return nil, fmt.Errorf("error deleting object type from dimension: %w", err)
 	}
@@ -213,14 +297,155 @@ func (r *ReportConfigRepository) AssignObjectTypeToDimention(req models.AssignOb
 	query = `INSERT INTO EA_Tags_Dimentions (ea_tag_id, object_type_id) VALUES (@p1, @p2); SELECT SCOPE_IDENTITY()`
 
 	var id int
-	err = r.db.QueryRow(query, req.EAID, req.ObjectTypeID).Scan(&id)
+	err = tx.QueryRow(query, req.EAID, req.ObjectTypeID).Scan(&id)
 	if err != nil {
 		return nil, fmt.Errorf("error assigning object type to dimension: %w", err)
 	}
 
-	return &models.EATagDimention{
+	dimension := &models.EATagDimention{
 		ID:           id,
 		EATagID:      req.EAID,
 		ObjectTypeID: req.ObjectTypeID,
-	}, nil
+	}
+	action := models.AuditActionUpdate
+	if before == nil {
+		action = models.AuditActionCreate
+	}
+	if err := changes.Record(tx, models.AuditEntityDimension, strconv.Itoa(req.ObjectTypeID), action, before, dimension); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+	return dimension, nil
+}
+
+// ========== EA_ArchiMate_Mappings Operations ==========
+
+// GetArchiMateMappings retrieves the ArchiMate element type of every mapped object type
+func (r *ReportConfigRepository) GetArchiMateMappings() ([]models.ArchiMateMapping, error) {
+	query := `
+		SELECT m.object_type_id, t.ObjectTypeName, m.element_type
+		FROM EA_ArchiMate_Mappings m
+		LEFT JOIN ObjectType t ON t.ObjectTypeID = m.object_type_id
+		ORDER BY m.object_type_id
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving ArchiMate mappings: %w", err)
+	}
+	defer rows.Close()
+
+	mappings := []models.ArchiMateMapping{}
+	for rows.Next() {
+		var mapping models.ArchiMateMapping
+		if err := rows.Scan(&mapping.ObjectTypeID, &mapping.ObjectTypeName, &mapping.ElementType); err != nil {
+			return nil, fmt.Errorf("error scanning ArchiMate mapping: %w", err)
+		}
+		mappings = append(mappings, mapping)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating ArchiMate mappings: %w", err)
+	}
+
+	return mappings, nil
+}
+
+// SetArchiMateMapping maps an object type to an ArchiMate element type,
+// replacing any existing mapping
+func (r *ReportConfigRepository) SetArchiMateMapping(objectTypeID int, elementType string, changes *ChangeLog) (*models.ArchiMateMapping, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := getArchiMateMapping(tx, objectTypeID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		MERGE EA_ArchiMate_Mappings AS target
+		USING (SELECT @p1 AS object_type_id, @p2 AS element_type) AS source
+		ON target.object_type_id = source.object_type_id
+		WHEN MATCHED THEN UPDATE SET element_type = source.element_type
+		WHEN NOT MATCHED THEN INSERT (object_type_id, element_type) VALUES (source.object_type_id, source.element_type);
+	`
+
+	if _, err := tx.Exec(query, objectTypeID, elementType); err != nil {
+		return nil, fmt.Errorf("error saving ArchiMate mapping: %w", err)
+	}
+
+	mapping := &models.ArchiMateMapping{
+		ObjectTypeID: objectTypeID,
+		ElementType:  elementType,
+	}
+	action := models.AuditActionUpdate
+	if before == nil {
+		action = models.AuditActionCreate
+	}
+	if err := changes.Record(tx, models.AuditEntityArchiMateMapping, strconv.Itoa(objectTypeID), action, before, mapping); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+	return mapping, nil
+}
+
+// DeleteArchiMateMapping removes the ArchiMate mapping of an object type
+func (r *ReportConfigRepository) DeleteArchiMateMapping(objectTypeID int, changes *ChangeLog) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := getArchiMateMapping(tx, objectTypeID)
+	if err != nil {
+		return err
+	}
+
+	query := `DELETE FROM EA_ArchiMate_Mappings WHERE object_type_id = @p1`
+
+	result, err := tx.Exec(query, objectTypeID)
+	if err != nil {
+		return fmt.Errorf("error deleting ArchiMate mapping: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("ArchiMate mapping not found for object type ID: %d", objectTypeID)
+	}
+	if err := changes.Record(tx, models.AuditEntityArchiMateMapping, strconv.Itoa(objectTypeID), models.AuditActionDelete, before, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
+
+// getArchiMateMapping retrieves the ArchiMate mapping of an object type, or
+// nil when it has none
+func getArchiMateMapping(q dbQuerier, objectTypeID int) (*models.ArchiMateMapping, error) {
+	var mapping models.ArchiMateMapping
+	err := q.QueryRow(`SELECT object_type_id, element_type FROM EA_ArchiMate_Mappings WHERE object_type_id = @p1`,
+		objectTypeID).Scan(&mapping.ObjectTypeID, &mapping.ElementType)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving ArchiMate mapping: %w", err)
+	}
+	return &mapping, nil
 }
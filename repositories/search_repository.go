@@ -0,0 +1,237 @@
+package repositories
+
+import (
+	"database/sql"
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/utils"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// readableAttributeFilter restricts a query on AttributeValue av to values of
+// attributes the profile may read; %s is the profile parameter
+const readableAttributeFilter = `EXISTS (
+			SELECT 1 FROM [AttributePermissions] AS ap
+			WHERE ap.AttributeId = av.AttributeId AND ap.ProfileId = %s AND ap.HasRead = 1
+		)`
+
+// SearchRepository handles the database side of object search
+type SearchRepository struct {
+	db *sql.DB
+}
+
+// NewSearchRepository creates a new SearchRepository
+func NewSearchRepository(db *sql.DB) *SearchRepository {
+	return &SearchRepository{db: db}
+}
+
+// searchFoldSQL applies utils.SearchFolds to a text expression
+func searchFoldSQL(expr string) string {
+	for _, f := range utils.SearchFolds {
+		to := "N''"
+		if f.To != "" {
+			r, _ := utf8.DecodeRuneInString(f.To)
+			to = fmt.Sprintf("NCHAR(%d)", r)
+		}
+		expr = fmt.Sprintf("REPLACE(%s, NCHAR(%d), %s)", expr, f.From, to)
+	}
+	return expr
+}
+
+// searchFoldRTFSQL applies utils.SearchFolds to the \uN? escapes of a rich
+// text expression
+func searchFoldRTFSQL(expr string) string {
+	for _, f := range utils.SearchFolds {
+		expr = fmt.Sprintf("REPLACE(%s, N'%s', N'%s')", expr, utils.RTFEscape(string(f.From)), utils.RTFEscape(f.To))
+	}
+	return expr
+}
+
+// escapeLike escapes the LIKE wildcards of s
+func escapeLike(s string) string {
+	return strings.NewReplacer("[", "[[]", "%", "[%]", "_", "[_]").Replace(s)
+}
+
+// FindSearchCandidates returns up to limit readable, non-deleted objects in
+// which every normalised term occurs in the name, the description, the rich
+// text description or a readable text or rich text attribute value of the
+// current version. Names matching all terms come first. Matching is a
+// superset of what the service verifies: rich text is searched through its
+// \uN? escapes in lower, upper and capitalised case only.
+func (r *SearchRepository) FindSearchCandidates(terms []string, filter models.SearchFilter, profileID, limit int) ([]models.SearchCandidate, error) {
+	args := []interface{}{profileID}
+	param := func(v interface{}) string {
+		args = append(args, v)
+		return "@p" + strconv.Itoa(len(args))
+	}
+
+	var where []string
+	if filter.LibraryID != nil {
+		libraryID, _ := TransformUUID(*filter.LibraryID)
+		where = append(where, "[Object].LibraryId = "+param(libraryID))
+	}
+	if filter.ObjectTypeID != nil {
+		where = append(where, "[Object].ExactObjectTypeID = "+param(*filter.ObjectTypeID))
+	}
+	if filter.EATagID != nil {
+		where = append(where, `EXISTS (
+			SELECT 1 FROM EA_Tags_Dimentions AS dim
+			WHERE dim.ea_tag_id = `+param(*filter.EATagID)+` AND dim.object_type_id = [Object].ExactObjectTypeID
+		)`)
+	}
+
+	var nameMatches []string
+	for _, term := range terms {
+		plain := "N'%' + " + param(escapeLike(term)) + " + N'%'"
+		escaped := []string{
+			param(utils.RTFEscape(term)),
+			param(utils.RTFEscape(strings.ToUpper(term))),
+			param(utils.RTFEscape(capitalize(term))),
+		}
+		richTextMatch := func(raw, folded string) string {
+			conds := []string{raw + " LIKE " + plain}
+			for _, p := range escaped {
+				conds = append(conds, folded+" LIKE N'%' + "+p+" + N'%'")
+			}
+			return "(" + strings.Join(conds, " OR ") + ")"
+		}
+
+		nameMatches = append(nameMatches, "folded.Name LIKE "+plain)
+		where = append(where, `(
+			folded.Name LIKE `+plain+`
+			OR folded.Description LIKE `+plain+`
+			OR `+richTextMatch("[Object].RichTextDescription", "folded.RichText")+`
+			OR EXISTS (
+				SELECT 1 FROM AttributeValue AS av
+				CROSS APPLY (SELECT `+searchFoldSQL("av.ValueText")+` AS Text, `+searchFoldRTFSQL("av.ValueRichText")+` AS RichText) AS fav
+				WHERE av.ObjectId = [Object].ObjectID AND av.VersionId = [Object].CurrentVersionId
+					AND ((av.DataType = 4 AND fav.Text LIKE `+plain+`)
+						OR (av.DataType = 6 AND `+richTextMatch("av.ValueRichText", "fav.RichText")+`))
+					AND `+fmt.Sprintf(readableAttributeFilter, "@p1")+`
+			)
+		)`)
+	}
+
+	query := `
+		SELECT TOP (` + strconv.Itoa(limit) + `) [Object].ObjectID, [Object].ObjectName, [Object].ObjectDescription,
+			[Object].RichTextDescription, [Object].ExactObjectTypeID, ot.ObjectTypeName, [Object].LibraryId
+		FROM [Object]
+		LEFT JOIN ObjectType AS ot ON ot.ObjectTypeID = [Object].ExactObjectTypeID
+		CROSS APPLY (
+			SELECT ` + searchFoldSQL("[Object].ObjectName") + ` AS Name,
+				` + searchFoldSQL("[Object].ObjectDescription") + ` AS Description,
+				` + searchFoldRTFSQL("[Object].RichTextDescription") + ` AS RichText
+		) AS folded
+		WHERE ISNULL([Object].DeleteFlag, 0) = 0
+			AND ` + fmt.Sprintf(readableObjectFilter, "@p1") + `
+			AND ` + strings.Join(where, "\n\t\t\tAND ") + `
+		ORDER BY CASE WHEN ` + strings.Join(nameMatches, " AND ") + ` THEN 0 ELSE 1 END, [Object].ObjectName, [Object].ObjectID
+	`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error searching objects: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []models.SearchCandidate
+	var objectIDs [][]byte
+	for rows.Next() {
+		var c models.SearchCandidate
+		var objectIDBytes, libraryIDBytes []byte
+		if err := rows.Scan(&objectIDBytes, &c.ObjectName, &c.ObjectDescription, &c.RichTextDescription,
+			&c.ObjectTypeId, &c.ObjectTypeName, &libraryIDBytes); err != nil {
+			return nil, fmt.Errorf("error scanning search candidate: %w", err)
+		}
+		c.ObjectId, err = parseSQLServerUUID(objectIDBytes)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing ObjectID: %w", err)
+		}
+		if libraryIDBytes != nil {
+			libraryID, err := parseSQLServerUUID(libraryIDBytes)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing LibraryId: %w", err)
+			}
+			c.LibraryId = &libraryID
+		}
+		candidates = append(candidates, c)
+		objectIDs = append(objectIDs, objectIDBytes)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating search candidates: %w", err)
+	}
+
+	if err := r.loadSearchAttributes(candidates, objectIDs, profileID); err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+// loadSearchAttributes adds the readable text and rich text attribute values
+// of the current versions to the candidates
+func (r *SearchRepository) loadSearchAttributes(candidates []models.SearchCandidate, objectIDs [][]byte, profileID int) error {
+	if len(candidates) == 0 {
+		return nil
+	}
+	byID := make(map[uuid.UUID]*models.SearchCandidate, len(candidates))
+	args := []interface{}{profileID}
+	placeholders := make([]string, len(objectIDs))
+	for i, id := range objectIDs {
+		byID[candidates[i].ObjectId] = &candidates[i]
+		args = append(args, id)
+		placeholders[i] = "@p" + strconv.Itoa(len(args))
+	}
+
+	query := `
+		SELECT av.ObjectId, av.AttributeId, a.AttributeName, av.DataType, av.ValueText, av.ValueRichText
+		FROM AttributeValue AS av
+		JOIN [Object] ON [Object].ObjectID = av.ObjectId AND [Object].CurrentVersionId = av.VersionId
+		JOIN Attribute AS a ON a.AttributeId = av.AttributeId
+		WHERE av.ObjectId IN (` + strings.Join(placeholders, ", ") + `)
+			AND av.DataType IN (4, 6)
+			AND ` + fmt.Sprintf(readableAttributeFilter, "@p1") + `
+		ORDER BY a.AttributeName
+	`
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("error retrieving attribute values for search: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var objectIDBytes []byte
+		var value models.SearchAttributeValue
+		var dataType int
+		var text, richText sql.NullString
+		if err := rows.Scan(&objectIDBytes, &value.AttributeId, &value.AttributeName, &dataType, &text, &richText); err != nil {
+			return fmt.Errorf("error scanning attribute value for search: %w", err)
+		}
+		objectID, err := parseSQLServerUUID(objectIDBytes)
+		if err != nil {
+			return fmt.Errorf("error parsing ObjectId: %w", err)
+		}
+		if dataType == 6 {
+			value.Value, value.RichText = richText.String, true
+		} else {
+			value.Value = text.String
+		}
+		if c := byID[objectID]; c != nil && value.Value != "" {
+			c.Attributes = append(c.Attributes, value)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating attribute values for search: %w", err)
+	}
+	return nil
+}
+
+// capitalize upper-cases the first letter of s
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
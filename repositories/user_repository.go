@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"database/sql"
+	"enterprise-architect-api/models"
+	"fmt"
+)
+
+// UserRepository handles database operations for users
+type UserRepository struct {
+	db *sql.DB
+}
+
+// NewUserRepository creates a new UserRepository
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// GetByUserName retrieves a user by its login name
+func (r *UserRepository) GetByUserName(userName string) (*models.User, error) {
+	query := `
+		SELECT UserID, UserName, DisplayName, Email, PasswordHash, ProfileID, IsActive, DateCreated, DateModified
+		FROM Users
+		WHERE UserName = @p1
+	`
+
+	user := &models.User{}
+	err := r.db.QueryRow(query, userName).Scan(
+		&user.UserID, &user.UserName, &user.DisplayName, &user.Email, &user.PasswordHash,
+		&user.ProfileID, &user.IsActive, &user.DateCreated, &user.DateModified,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving user: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetByID retrieves a user by its ID
+func (r *UserRepository) GetByID(id int) (*models.User, error) {
+	query := `
+		SELECT UserID, UserName, DisplayName, Email, PasswordHash, ProfileID, IsActive, DateCreated, DateModified
+		FROM Users
+		WHERE UserID = @p1
+	`
+
+	user := &models.User{}
+	err := r.db.QueryRow(query, id).Scan(
+		&user.UserID, &user.UserName, &user.DisplayName, &user.Email, &user.PasswordHash,
+		&user.ProfileID, &user.IsActive, &user.DateCreated, &user.DateModified,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving user: %w", err)
+	}
+
+	return user, nil
+}
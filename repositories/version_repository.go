@@ -0,0 +1,657 @@
+package repositories
+
+import (
+	"bytes"
+	"database/sql"
+	"enterprise-architect-api/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrVersionNotFound is returned when a version does not belong to the object
+	ErrVersionNotFound = errors.New("version not found")
+	// ErrCheckedOut is returned when checking out an object that another
+	// request checked out first
+	ErrCheckedOut = errors.New("object is already checked out")
+	// ErrCheckoutRequired is returned when editing an object of a governed
+	// type that is not checked out
+	ErrCheckoutRequired = errors.New("objects of a governed type must be checked out before they are edited")
+	// ErrNotCheckedOut is returned when checking in an object that is not checked out
+	ErrNotCheckedOut = errors.New("object is not checked out")
+	// ErrCheckedOutByOther is returned when a user changes an object that
+	// another user has checked out
+	ErrCheckedOutByOther = errors.New("object is checked out by another user")
+	// ErrPendingApproval is returned when changing the versions of an object
+	// whose current version is pending approval
+	ErrPendingApproval = errors.New("object has a version pending approval")
+	// ErrNotCurrentVersion is returned when writing to a version of an object
+	// other than its current one
+	ErrNotCurrentVersion = errors.New("version is not the object's current version")
+)
+
+// lockObjectSql locks object @p1 and reads whether it is deleted, who holds
+// its checkout and whether its type has approvers
+const lockObjectSql = `
+	SELECT CAST(ISNULL(o.DeleteFlag, 0) AS BIT), CAST(ISNULL(o.IsCheckedOut, 0) AS BIT), o.CheckedOutUserId,
+		CAST(CASE WHEN EXISTS (SELECT 1 FROM ObjectTypeApprovers AS ota WHERE ota.ObjectTypeID = o.ExactObjectTypeID) THEN 1 ELSE 0 END AS BIT)
+	FROM [Object] AS o WITH (UPDLOCK)
+	WHERE o.ObjectID = @p1
+`
+
+// objectLock is the checkout state of an object read under lockObjectSql
+type objectLock struct {
+	checkedOut bool
+	holder     sql.NullInt64
+	governed   bool
+}
+
+// heldBy reports whether the object is checked out to the user
+func (l objectLock) heldBy(userID int) bool {
+	return l.checkedOut && l.holder.Valid && int(l.holder.Int64) == userID
+}
+
+// lockObject locks the object row for the rest of tx and reads its checkout
+// state. dbID must be in SQL Server byte order.
+func lockObject(tx *sql.Tx, dbID uuid.UUID) (objectLock, error) {
+	var lock objectLock
+	var deleted bool
+	err := tx.QueryRow(lockObjectSql, dbID).Scan(&deleted, &lock.checkedOut, &lock.holder, &lock.governed)
+	if err == sql.ErrNoRows || (err == nil && deleted) {
+		return lock, ErrObjectNotFound
+	}
+	if err != nil {
+		return lock, fmt.Errorf("error locking object: %w", err)
+	}
+	return lock, nil
+}
+
+// lockForEdit locks the object row for the rest of tx and checks that the
+// user may edit it. A checked-out object may only be edited by its checkout
+// holder, and the current version of a governed object that is not checked
+// out is its approved one, so it may only change through a checkout and
+// sign-off. dbID must be in SQL Server byte order.
+func lockForEdit(tx *sql.Tx, dbID uuid.UUID, userID int) error {
+	lock, err := lockObject(tx, dbID)
+	if err != nil {
+		return err
+	}
+	if lock.checkedOut && !lock.heldBy(userID) {
+		return ErrCheckedOutByOther
+	}
+	if lock.governed && !lock.checkedOut {
+		return ErrCheckoutRequired
+	}
+	return nil
+}
+
+// lockCheckout locks the object row for the rest of tx and checks that it is
+// checked out to the user. dbID must be in SQL Server byte order.
+func lockCheckout(tx *sql.Tx, dbID uuid.UUID, userID int) error {
+	lock, err := lockObject(tx, dbID)
+	if err != nil {
+		return err
+	}
+	if !lock.checkedOut {
+		return ErrNotCheckedOut
+	}
+	if !lock.heldBy(userID) {
+		return ErrCheckedOutByOther
+	}
+	return nil
+}
+
+// VersionRepository handles database operations for object versions
+type VersionRepository struct {
+	db *sql.DB
+}
+
+// NewVersionRepository creates a new VersionRepository
+func NewVersionRepository(db *sql.DB) *VersionRepository {
+	return &VersionRepository{db: db}
+}
+
+// CheckOut creates a new checked-out version of the object for the user and
+// makes it current. The object row is locked for the whole checkout, so two
+// users cannot both check out the same object and it cannot be submitted for
+// approval meanwhile.
+func (r *VersionRepository) CheckOut(objectID uuid.UUID, userID int, changes *ChangeLog) error {
+	objectID, _ = TransformUUID(objectID)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	lock, err := lockObject(tx, objectID)
+	if err != nil {
+		return err
+	}
+	if lock.checkedOut {
+		if lock.heldBy(userID) {
+			return ErrCheckedOut
+		}
+		return ErrCheckedOutByOther
+	}
+	state, err := getApprovalState(tx, objectID, "WITH (UPDLOCK)")
+	if err != nil {
+		return err
+	}
+	if state.IsPending {
+		return ErrPendingApproval
+	}
+
+	var systemVersionNo int
+	err = tx.QueryRow(`
+		SELECT v.SystemVersionNo
+		FROM [Object] AS o
+		INNER JOIN [Version] AS v ON v.ID = o.CurrentVersionId
+		WHERE o.ObjectID = @p1
+	`, objectID).Scan(&systemVersionNo)
+	if err != nil {
+		return fmt.Errorf("error retrieving current version: %w", err)
+	}
+	before, err := getObject(tx, objectID)
+	if err != nil {
+		return err
+	}
+
+	newVersionID := uuid.New()
+	_, err = tx.Exec(`EXEC [dbo].[usp_InsertNewVersionForExistingObject] @ObjectId = @p1, @NewVersionId = @p2, @UserVersionNo = @p3, @UserId = @p4, @NewVersionIsCheckedOut = 1`,
+		objectID, newVersionID, fmt.Sprintf("v%d", systemVersionNo+1), userID)
+	if err != nil {
+		return fmt.Errorf("error checking out object: %w", err)
+	}
+
+	// The procedure rolls back silently on failure, so confirm the new version is current
+	var created bool
+	err = tx.QueryRow(`
+		SELECT CAST(CASE WHEN EXISTS (
+			SELECT 1 FROM [Object] WHERE ObjectID = @p1 AND CurrentVersionId = @p2
+		) THEN 1 ELSE 0 END AS BIT)
+	`, objectID, newVersionID).Scan(&created)
+	if err != nil {
+		return fmt.Errorf("error verifying checkout: %w", err)
+	}
+	if !created {
+		return fmt.Errorf("error checking out object: new version was not created")
+	}
+	if err := recordObject(tx, changes, objectID, models.AuditActionCheckOut, before); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing checkout: %w", err)
+	}
+	return nil
+}
+
+// CheckIn marks the user's checked-out version as checked in with the given
+// reason. For governed object types the version is submitted for approval
+// instead of becoming the checked-in version; whether the type is governed is
+// read under the same lock as the checkout.
+func (r *VersionRepository) CheckIn(objectID uuid.UUID, userID, profileID int, reason string, changes *ChangeLog) error {
+	objectID, _ = TransformUUID(objectID)
+	now := time.Now()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockCheckout(tx, objectID, userID); err != nil {
+		return err
+	}
+	state, err := getApprovalState(tx, objectID, "WITH (UPDLOCK)")
+	if err != nil {
+		return err
+	}
+	requireApproval := state.IsGoverned
+	before, err := getObject(tx, objectID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE v SET
+			IsCheckedOut = 0,
+			CheckedOutBy = NULL,
+			CheckInReason = @p2,
+			DateModified = @p3,
+			ModifiedBy = @p4
+		FROM [Version] AS v
+		INNER JOIN [Object] AS o ON o.CurrentVersionId = v.ID
+		WHERE o.ObjectID = @p1
+	`, objectID, reason, now, userID)
+	if err != nil {
+		return fmt.Errorf("error checking in version: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE [Object] SET
+			IsCheckedOut = 0,
+			CheckedOutUserId = NULL,
+			CheckedInVersionId = CASE WHEN @p4 = 1 THEN CheckedInVersionId ELSE CurrentVersionId END,
+			DateModified = @p2,
+			ModifiedBy = @p3
+		WHERE ObjectID = @p1
+	`, objectID, now, userID, requireApproval)
+	if err != nil {
+		return fmt.Errorf("error checking in object: %w", err)
+	}
+
+	if requireApproval {
+		err = recordApproval(tx, objectID, "dbo.const_ApprovalStatus_PendingApproval()", false, models.ApprovalActionSubmitted, userID, profileID, reason)
+		if err != nil {
+			return err
+		}
+	}
+	if err := recordObject(tx, changes, objectID, models.AuditActionCheckIn, before); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing check-in: %w", err)
+	}
+
+	return nil
+}
+
+// UndoCheckOut discards the user's checked-out version and restores the last checked-in version
+func (r *VersionRepository) UndoCheckOut(objectID uuid.UUID, userID int, changes *ChangeLog) error {
+	objectID, _ = TransformUUID(objectID)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockCheckout(tx, objectID, userID); err != nil {
+		return err
+	}
+	var currentBytes, checkedInBytes []byte
+	err = tx.QueryRow(`SELECT CurrentVersionId, CheckedInVersionId FROM [Object] WITH (UPDLOCK) WHERE ObjectID = @p1`, objectID).
+		Scan(&currentBytes, &checkedInBytes)
+	if err == sql.ErrNoRows {
+		return ErrObjectNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error retrieving object versions: %w", err)
+	}
+	currentVersionID, err := parseSQLServerUUID(currentBytes)
+	if err != nil {
+		return fmt.Errorf("error parsing CurrentVersionId: %w", err)
+	}
+	before, err := getObject(tx, objectID)
+	if err != nil {
+		return err
+	}
+
+	if checkedInBytes != nil && !bytes.Equal(checkedInBytes, currentBytes) {
+		// Remove everything usp_InsertNewVersionForExistingObject cloned for the checked-out version
+		cleanup := []string{
+			`DELETE FROM AttributeValue WHERE ObjectId = @p1 AND VersionId = @p2`,
+			`DELETE FROM ObjectContents WHERE DocumentObjectID = @p1 AND ContainerVersionID = @p2`,
+			`DELETE FROM RelationDocument WHERE DocumentId = @p1 AND DocumentVersionId = @p2`,
+			`DELETE FROM VisioPageShapes WHERE DocumentObjectId = @p1 AND DocumentVersionId = @p2`,
+			`DELETE FROM VisioPageRelationships WHERE DocumentObjectId = @p1 AND DocumentVersionId = @p2`,
+		}
+		for _, query := range cleanup {
+			if _, err := tx.Exec(query, objectID, currentVersionID); err != nil {
+				return fmt.Errorf("error discarding checked-out version: %w", err)
+			}
+		}
+
+		_, err = tx.Exec(`
+			UPDATE o SET
+				CurrentVersionId = ci.ID,
+				ObjectName = ci.ObjectName,
+				ObjectDescription = ci.ObjectDescription,
+				RichTextDescription = ci.RichTextDescription
+			FROM [Object] AS o
+			INNER JOIN [Version] AS ci ON ci.ID = o.CheckedInVersionId
+			WHERE o.ObjectID = @p1
+		`, objectID)
+		if err != nil {
+			return fmt.Errorf("error restoring checked-in version: %w", err)
+		}
+
+		if _, err := tx.Exec(`DELETE FROM [Version] WHERE ID = @p1`, currentVersionID); err != nil {
+			return fmt.Errorf("error deleting checked-out version: %w", err)
+		}
+	} else {
+		_, err = tx.Exec(`UPDATE [Version] SET IsCheckedOut = 0, CheckedOutBy = NULL WHERE ID = @p1`, currentVersionID)
+		if err != nil {
+			return fmt.Errorf("error clearing version checkout: %w", err)
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE [Object] SET IsCheckedOut = 0, CheckedOutUserId = NULL WHERE ObjectID = @p1`, objectID)
+	if err != nil {
+		return fmt.Errorf("error clearing object checkout: %w", err)
+	}
+	if err := recordObject(tx, changes, objectID, models.AuditActionUndoCheckOut, before); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing undo checkout: %w", err)
+	}
+
+	return nil
+}
+
+// Restore creates a new checked-in version whose name, descriptions and attribute
+// values are copied from a historical version, and makes it current. The object
+// row is locked while its state is checked, so it cannot be checked out or
+// submitted for approval meanwhile. For governed object types the new version
+// is submitted for approval instead of becoming the checked-in version.
+func (r *VersionRepository) Restore(objectID, versionID uuid.UUID, userID, profileID int, changes *ChangeLog) (uuid.UUID, error) {
+	objectID, _ = TransformUUID(objectID)
+	versionID, _ = TransformUUID(versionID)
+	newVersionID := uuid.New()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	lock, err := lockObject(tx, objectID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if lock.checkedOut {
+		if lock.heldBy(userID) {
+			return uuid.Nil, ErrCheckedOut
+		}
+		return uuid.Nil, ErrCheckedOutByOther
+	}
+	state, err := getApprovalState(tx, objectID, "")
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if state.IsPending {
+		return uuid.Nil, ErrPendingApproval
+	}
+	requireApproval := lock.governed
+
+	var currentBytes []byte
+	var sourceVersionNo, nextVersionNo int
+	err = tx.QueryRow(`
+		SELECT o.CurrentVersionId, src.SystemVersionNo,
+			(SELECT MAX(SystemVersionNo) FROM [Version] WHERE ObjectId = o.ObjectID) + 1
+		FROM [Object] AS o
+		INNER JOIN [Version] AS src ON src.ObjectId = o.ObjectID AND src.ID = @p2
+		WHERE o.ObjectID = @p1
+	`, objectID, versionID).Scan(&currentBytes, &sourceVersionNo, &nextVersionNo)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, ErrVersionNotFound
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error retrieving versions: %w", err)
+	}
+	currentVersionID, err := parseSQLServerUUID(currentBytes)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error parsing CurrentVersionId: %w", err)
+	}
+	before, err := getObject(tx, objectID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	// The new version takes its content from the source version but keeps the
+	// current version's diagram-related settings
+	_, err = tx.Exec(`
+		INSERT INTO [Version] (
+			[Id], ObjectId, ObjectName, ObjectDescription, RichTextDescription,
+			UserVersionNo, SystemVersionNo, HasVisioAlias, VisioAlias, VersionImage,
+			IsCheckedOut, CheckedOutBy, CheckInReason, FileExtension,
+			PrefixForRollback, SuffixForRollback, ProvenanceIdForRollback, ProvenanceVersionIdForRollback,
+			RequiresShapeSheetUpdateForRollback, HasValidContentsHistory, HasValidVisioPageInstances,
+			ApprovalStatus, DateCreated, CreatedBy, DateModified, ModifiedBy
+		)
+		SELECT @p1, src.ObjectId, src.ObjectName, src.ObjectDescription, src.RichTextDescription,
+			@p4, @p5, cur.HasVisioAlias, cur.VisioAlias, cur.VersionImage,
+			0, NULL, @p6, cur.FileExtension,
+			src.PrefixForRollback, src.SuffixForRollback, src.ProvenanceIdForRollback, src.ProvenanceVersionIdForRollback,
+			cur.RequiresShapeSheetUpdateForRollback, 1, cur.HasValidVisioPageInstances,
+			dbo.const_ApprovalStatus_Approved(), GETDATE(), @p7, GETDATE(), @p7
+		FROM [Version] AS src
+		INNER JOIN [Version] AS cur ON cur.ID = @p3
+		WHERE src.ID = @p2
+	`, newVersionID, versionID, currentVersionID, fmt.Sprintf("v%d", nextVersionNo), nextVersionNo,
+		fmt.Sprintf("Restored from version %d", sourceVersionNo), userID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error creating restored version: %w", err)
+	}
+
+	// Contents and relationships carry over from the current version, as on checkout
+	clones := []string{
+		`INSERT INTO ObjectContents(DocumentObjectID, ContainerVersionID, ObjectID, ContainmentType, Instances, IsShortCut, DateCreated, CreatedBy, DateModified, ModifiedBy)
+		SELECT DocumentObjectID, @p2, ObjectID, ContainmentType, Instances, IsShortcut, GETDATE(), @p4, GETDATE(), @p4
+		FROM ObjectContents WHERE DocumentObjectID = @p1 AND ContainerVersionID = @p3`,
+		`INSERT INTO RelationDocument(RelationshipId, DocumentId, DocumentVersionId, Instances, ShapeSheetKeysRequiringUpdateId)
+		SELECT RelationshipId, DocumentId, @p2, Instances, ShapeSheetKeysRequiringUpdateId
+		FROM RelationDocument WHERE DocumentId = @p1 AND DocumentVersionId = @p3`,
+		`INSERT INTO VisioPageShapes(DocumentObjectId, DocumentVersionId, ShapeObjectId, VisioPageId, VisioShapeId, VisioShapeHeight, VisioShapeWidth, VisioShapeLeft, VisioShapeTop, VisioShapeRight, VisioShapeBottom, SequenceFlow)
+		SELECT DocumentObjectId, @p2, ShapeObjectId, VisioPageId, VisioShapeId, VisioShapeHeight, VisioShapeWidth, VisioShapeLeft, VisioShapeTop, VisioShapeRight, VisioShapeBottom, SequenceFlow
+		FROM VisioPageShapes WHERE DocumentObjectId = @p1 AND DocumentVersionId = @p3`,
+		`INSERT INTO VisioPageRelationships(DocumentObjectId, DocumentVersionId, RelationshipId, VisioPageId, BeginVisioId, EndVisioId, ConnectorVisioId, ConnectorMasterBaseId, ConnectorBeginX, ConnectorBeginY, ConnectorEndX, ConnectorEndY)
+		SELECT DocumentObjectId, @p2, RelationshipId, VisioPageId, BeginVisioId, EndVisioId, ConnectorVisioId, ConnectorMasterBaseId, ConnectorBeginX, ConnectorBeginY, ConnectorEndX, ConnectorEndY
+		FROM VisioPageRelationships WHERE DocumentObjectId = @p1 AND DocumentVersionId = @p3`,
+	}
+	for _, query := range clones {
+		if _, err := tx.Exec(query, objectID, newVersionID, currentVersionID, userID); err != nil {
+			return uuid.Nil, fmt.Errorf("error copying version contents: %w", err)
+		}
+	}
+
+	// Attribute values come from the source version
+	_, err = tx.Exec(`
+		INSERT INTO AttributeValue (ObjectId, VersionId, AttributeId, DataType, ValueBigInt, ValueDate, ValueFloat, ValueText, ValueRichText, ValueHTML, DateCreated, CreatedBy, DateModified, ModifiedBy)
+		SELECT ObjectId, @p2, AttributeId, DataType, ValueBigInt, ValueDate, ValueFloat, ValueText, ValueRichText, ValueHTML, GETDATE(), @p4, GETDATE(), @p4
+		FROM AttributeValue
+		WHERE ObjectId = @p1 AND VersionId = @p3
+	`, objectID, newVersionID, versionID, userID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error copying attribute values: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE o SET
+			CurrentVersionId = v.ID,
+			CheckedInVersionId = CASE WHEN @p4 = 1 THEN o.CheckedInVersionId ELSE v.ID END,
+			ObjectName = v.ObjectName,
+			ObjectDescription = v.ObjectDescription,
+			RichTextDescription = v.RichTextDescription,
+			Prefix = ISNULL(v.PrefixForRollback, o.Prefix),
+			Suffix = ISNULL(v.SuffixForRollback, o.Suffix),
+			DateModified = GETDATE(),
+			ModifiedBy = @p3
+		FROM [Object] AS o
+		INNER JOIN [Version] AS v ON v.ID = @p2
+		WHERE o.ObjectID = @p1
+	`, objectID, newVersionID, userID, requireApproval)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error making restored version current: %w", err)
+	}
+
+	if requireApproval {
+		reason := fmt.Sprintf("Restored from version %d", sourceVersionNo)
+		err = recordApproval(tx, objectID, "dbo.const_ApprovalStatus_PendingApproval()", false, models.ApprovalActionSubmitted, userID, profileID, reason)
+		if err != nil {
+			return uuid.Nil, err
+		}
+	}
+	if err := recordObject(tx, changes, objectID, models.AuditActionRestoreVersion, before); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return uuid.Nil, fmt.Errorf("error committing restore: %w", err)
+	}
+
+	return newVersionID, nil
+}
+
+// versionColumns lists the Version columns scanned by scanVersion
+const versionColumns = `
+		v.ID, v.ObjectId, v.ObjectName, v.ObjectDescription, v.RichTextDescription,
+		v.SystemVersionNo, v.UserVersionNo, v.IsCheckedOut, v.CheckedOutBy, v.CheckInReason,
+		v.ApprovalStatus,
+		CAST(CASE WHEN o.CurrentVersionId = v.ID THEN 1 ELSE 0 END AS BIT) AS IsCurrent,
+		CAST(CASE WHEN o.CheckedInVersionId = v.ID THEN 1 ELSE 0 END AS BIT) AS IsCheckedIn,
+		v.DateCreated, v.CreatedBy, v.DateModified, v.ModifiedBy`
+
+// ListVersions retrieves the version history of an object, newest first
+func (r *VersionRepository) ListVersions(objectID uuid.UUID) ([]models.ObjectVersion, error) {
+	query := `
+		SELECT ` + versionColumns + `
+		FROM [Version] AS v
+		INNER JOIN [Object] AS o ON o.ObjectID = v.ObjectId
+		WHERE v.ObjectId = @p1
+		ORDER BY v.SystemVersionNo DESC
+	`
+
+	objectID, _ = TransformUUID(objectID)
+	rows, err := r.db.Query(query, objectID)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []models.ObjectVersion
+	for rows.Next() {
+		version, err := scanVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, *version)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating versions: %w", err)
+	}
+
+	return versions, nil
+}
+
+// GetVersion retrieves a single version of an object
+func (r *VersionRepository) GetVersion(objectID, versionID uuid.UUID) (*models.ObjectVersion, error) {
+	query := `
+		SELECT ` + versionColumns + `
+		FROM [Version] AS v
+		INNER JOIN [Object] AS o ON o.ObjectID = v.ObjectId
+		WHERE v.ObjectId = @p1 AND v.ID = @p2
+	`
+
+	objectID, _ = TransformUUID(objectID)
+	versionID, _ = TransformUUID(versionID)
+	version, err := scanVersion(r.db.QueryRow(query, objectID, versionID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrVersionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return version, nil
+}
+
+// GetVersionAttributeValues retrieves the attribute values stored for a version
+// that the profile is allowed to read
+func (r *VersionRepository) GetVersionAttributeValues(objectID, versionID uuid.UUID, profileID int) ([]models.AssignedAttribute, error) {
+	query := `
+		SELECT attr.AttributeId,
+			attr.objectId,
+			attr.versionId,
+			attr.DataType,
+			attr.textValue,
+			attr.booleanValue,
+			attr.dateValue,
+			attr.floatValue,
+			attr.intValue,
+			attr.richTextValue,
+			att.AttributeName,
+			att.AttributeType,
+			att.IsMandatory
+		FROM [vwAttributeValue] AS attr
+		INNER JOIN [AttributePermissions] AS attrPerm ON attrPerm.AttributeId = attr.AttributeId AND attrPerm.ProfileId = @p3 AND attrPerm.HasRead = 1
+		INNER JOIN Attribute att ON att.AttributeId = attr.AttributeId
+		WHERE attr.objectId = @p1 AND attr.versionId = @p2
+	`
+
+	objectID, _ = TransformUUID(objectID)
+	versionID, _ = TransformUUID(versionID)
+	rows, err := r.db.Query(query, objectID, versionID, profileID)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving version attribute values: %w", err)
+	}
+	defer rows.Close()
+
+	var attributes []models.AssignedAttribute
+	for rows.Next() {
+		var attribute models.AssignedAttribute
+		err := rows.Scan(
+			&attribute.AttributeID,
+			&attribute.ObjectId,
+			&attribute.VersionId,
+			&attribute.DataType,
+			&attribute.TextValue,
+			&attribute.BooleanValue,
+			&attribute.DateValue,
+			&attribute.FloatValue,
+			&attribute.IntegerValue,
+			&attribute.RichTextValue,
+			&attribute.AttributeName,
+			&attribute.AttributeType,
+			&attribute.IsMandatory,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning version attribute value: %w", err)
+		}
+		attributes = append(attributes, attribute)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating version attribute values: %w", err)
+	}
+
+	return attributes, nil
+}
+
+// scanVersion scans a row selected with versionColumns
+func scanVersion(row interface {
+	Scan(dest ...interface{}) error
+}) (*models.ObjectVersion, error) {
+	var version models.ObjectVersion
+	var versionIDBytes, objectIDBytes []byte
+	err := row.Scan(
+		&versionIDBytes, &objectIDBytes, &version.ObjectName, &version.ObjectDescription, &version.RichTextDescription,
+		&version.SystemVersionNo, &version.UserVersionNo, &version.IsCheckedOut, &version.CheckedOutBy, &version.CheckInReason,
+		&version.ApprovalStatus, &version.IsCurrent, &version.IsCheckedIn,
+		&version.DateCreated, &version.CreatedBy, &version.DateModified, &version.ModifiedBy,
+	)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error scanning version: %w", err)
+	}
+
+	if version.VersionID, err = parseSQLServerUUID(versionIDBytes); err != nil {
+		return nil, fmt.Errorf("error parsing version ID: %w", err)
+	}
+	if version.ObjectID, err = parseSQLServerUUID(objectIDBytes); err != nil {
+		return nil, fmt.Errorf("error parsing ObjectId: %w", err)
+	}
+
+	return &version, nil
+}
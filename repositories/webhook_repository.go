@@ -0,0 +1,421 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"enterprise-architect-api/models"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook errors
+var (
+	ErrWebhookNotFound         = errors.New("webhook subscription not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	// ErrWebhookDeliveryPending is returned when redelivering a delivery that
+	// is still waiting to be sent
+	ErrWebhookDeliveryPending = errors.New("webhook delivery is already pending")
+)
+
+// ClaimedWebhookDelivery is a delivery a worker has leased for sending, with
+// the URL and secret of its subscription
+type ClaimedWebhookDelivery struct {
+	DeliveryId uuid.UUID
+	EventId    uuid.UUID
+	EventType  string
+	Payload    []byte
+	Attempts   int
+	Url        string
+	Secret     string
+}
+
+// WebhookRepository handles database operations for webhook subscriptions
+// and their outbox of deliveries
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+// NewWebhookRepository creates a new WebhookRepository
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+const webhookSubscriptionSql = `
+	SELECT SubscriptionId, Url, Secret, EntityTypes, ObjectTypeID, LibraryID, IsActive, CreatedBy, DateCreated, DateModified
+	FROM WebhookSubscriptions`
+
+// CreateSubscription stores a new subscription, setting its ID and dates
+func (r *WebhookRepository) CreateSubscription(sub *models.WebhookSubscription) error {
+	sub.SubscriptionId = uuid.New()
+	err := r.db.QueryRow(`
+		INSERT INTO WebhookSubscriptions (SubscriptionId, Url, Secret, EntityTypes, ObjectTypeID, LibraryID, IsActive, CreatedBy)
+		OUTPUT INSERTED.DateCreated, INSERTED.DateModified
+		VALUES (@p1, @p2, @p3, @p4, @p5, @p6, @p7, @p8)
+	`, sub.SubscriptionId, sub.Url, sub.Secret, webhookEntityTypes(sub.EntityTypes), sub.ObjectTypeId,
+		sub.LibraryId, sub.IsActive, sub.CreatedBy,
+	).Scan(&sub.DateCreated, &sub.DateModified)
+	if err != nil {
+		return fmt.Errorf("error creating webhook subscription: %w", err)
+	}
+	return nil
+}
+
+// GetSubscription retrieves a subscription with its secret
+func (r *WebhookRepository) GetSubscription(id uuid.UUID) (*models.WebhookSubscription, error) {
+	sub, err := scanWebhookSubscription(r.db.QueryRow(webhookSubscriptionSql+` WHERE SubscriptionId = @p1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWebhookNotFound
+	}
+	return sub, err
+}
+
+// ListSubscriptions retrieves every subscription, oldest first
+func (r *WebhookRepository) ListSubscriptions() ([]models.WebhookSubscription, error) {
+	rows, err := r.db.Query(webhookSubscriptionSql + ` ORDER BY DateCreated, SubscriptionId`)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving webhook subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subs := []models.WebhookSubscription{}
+	for rows.Next() {
+		sub, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, *sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+// UpdateSubscription saves the URL, secret, filters and state of a
+// subscription
+func (r *WebhookRepository) UpdateSubscription(sub *models.WebhookSubscription) error {
+	err := r.db.QueryRow(`
+		UPDATE WebhookSubscriptions SET
+			Url = @p2, Secret = @p3, EntityTypes = @p4, ObjectTypeID = @p5, LibraryID = @p6, IsActive = @p7,
+			DateModified = SYSUTCDATETIME()
+		OUTPUT INSERTED.DateModified
+		WHERE SubscriptionId = @p1
+	`, sub.SubscriptionId, sub.Url, sub.Secret, webhookEntityTypes(sub.EntityTypes), sub.ObjectTypeId,
+		sub.LibraryId, sub.IsActive,
+	).Scan(&sub.DateModified)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrWebhookNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error updating webhook subscription: %w", err)
+	}
+	return nil
+}
+
+// DeleteSubscription deletes a subscription with its deliveries
+func (r *WebhookRepository) DeleteSubscription(id uuid.UUID) error {
+	result, err := r.db.Exec(`DELETE FROM WebhookSubscriptions WHERE SubscriptionId = @p1`, id)
+	if err != nil {
+		return fmt.Errorf("error deleting webhook subscription: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// objectScope returns the type and library of an object for matching
+// subscription filters. A library is its own library. Both are nil for an
+// object that no longer exists.
+func objectScope(q dbQuerier, objectID uuid.UUID) (*int, *uuid.UUID, error) {
+	objectID, _ = TransformUUID(objectID)
+	var objectTypeID sql.NullInt64
+	var libraryIDBytes []byte
+	err := q.QueryRow(`
+		SELECT ExactObjectTypeID, COALESCE(LibraryId, CASE WHEN IsLibrary = 1 THEN ObjectID END)
+		FROM [Object]
+		WHERE ObjectID = @p1
+	`, objectID).Scan(&objectTypeID, &libraryIDBytes)
+	if err == sql.ErrNoRows {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("error retrieving object scope: %w", err)
+	}
+
+	var typeID *int
+	if objectTypeID.Valid {
+		v := int(objectTypeID.Int64)
+		typeID = &v
+	}
+	var libraryID *uuid.UUID
+	if libraryIDBytes != nil {
+		v, _ := parseSQLServerUUID(libraryIDBytes)
+		libraryID = &v
+	}
+	return typeID, libraryID, nil
+}
+
+// enqueueWebhookEvent adds the event to the outbox of every active
+// subscription whose filters match it
+func enqueueWebhookEvent(q dbQuerier, event models.WebhookEvent, payload []byte) error {
+	_, err := q.Exec(`
+		INSERT INTO WebhookDeliveries (DeliveryId, SubscriptionId, EventId, EventType, Payload, Status)
+		SELECT NEWID(), SubscriptionId, @p1, @p2, @p3, @p4
+		FROM WebhookSubscriptions
+		WHERE IsActive = 1
+			AND (EntityTypes IS NULL OR ',' + EntityTypes + ',' LIKE '%,' + @p5 + ',%')
+			AND (ObjectTypeID IS NULL OR ObjectTypeID = @p6)
+			AND (LibraryID IS NULL OR LibraryID = @p7)
+	`, event.EventId, event.EventType, string(payload), models.WebhookDeliveryPending, event.EntityType,
+		event.ObjectTypeId, event.LibraryId)
+	if err != nil {
+		return fmt.Errorf("error queuing webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// ClaimDeliveries leases up to limit pending deliveries that are due, oldest
+// first, to the given worker. Deliveries of inactive subscriptions wait until
+// they are reactivated.
+func (r *WebhookRepository) ClaimDeliveries(workerID string, lease time.Duration, limit int) ([]ClaimedWebhookDelivery, error) {
+	rows, err := r.db.Query(`
+		UPDATE d SET
+			LeaseOwner = @p1,
+			LeaseExpiresAt = DATEADD(SECOND, @p2, SYSUTCDATETIME())
+		OUTPUT inserted.DeliveryId, inserted.EventId, inserted.EventType, inserted.Payload, inserted.Attempts, s.Url, s.Secret
+		FROM WebhookDeliveries AS d
+		INNER JOIN WebhookSubscriptions AS s ON s.SubscriptionId = d.SubscriptionId
+		WHERE d.DeliveryId IN (
+			SELECT TOP (@p3) due.DeliveryId
+			FROM WebhookDeliveries AS due WITH (UPDLOCK, READPAST, ROWLOCK)
+			INNER JOIN WebhookSubscriptions AS active ON active.SubscriptionId = due.SubscriptionId AND active.IsActive = 1
+			WHERE due.Status = @p4 AND due.NextAttemptAt <= SYSUTCDATETIME()
+				AND (due.LeaseExpiresAt IS NULL OR due.LeaseExpiresAt < SYSUTCDATETIME())
+			ORDER BY due.NextAttemptAt
+		)
+	`, workerID, int(lease.Seconds()), limit, models.WebhookDeliveryPending)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []ClaimedWebhookDelivery
+	for rows.Next() {
+		var d ClaimedWebhookDelivery
+		var deliveryIDBytes, eventIDBytes []byte
+		var payload string
+		if err := rows.Scan(&deliveryIDBytes, &eventIDBytes, &d.EventType, &payload, &d.Attempts, &d.Url, &d.Secret); err != nil {
+			return nil, fmt.Errorf("error scanning webhook delivery: %w", err)
+		}
+		d.DeliveryId, _ = parseSQLServerUUID(deliveryIDBytes)
+		d.EventId, _ = parseSQLServerUUID(eventIDBytes)
+		d.Payload = []byte(payload)
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// RecordAttempt stores the outcome of a worker's attempt and releases its
+// lease. status is the new status; a pending delivery is retried after
+// retryIn. It returns false when the worker no longer held the lease.
+func (r *WebhookRepository) RecordAttempt(deliveryID uuid.UUID, workerID, status string, statusCode *int, message *string, retryIn time.Duration) (bool, error) {
+	if message != nil && len(*message) > 2000 {
+		truncated := (*message)[:2000]
+		message = &truncated
+	}
+	result, err := r.db.Exec(`
+		UPDATE WebhookDeliveries SET
+			Status = @p3,
+			Attempts = Attempts + 1,
+			LastStatusCode = @p4,
+			LastError = @p5,
+			NextAttemptAt = DATEADD(SECOND, @p6, SYSUTCDATETIME()),
+			DateDelivered = CASE WHEN @p3 = @p7 THEN SYSUTCDATETIME() END,
+			LeaseOwner = NULL,
+			LeaseExpiresAt = NULL
+		WHERE DeliveryId = @p1 AND LeaseOwner = @p2
+	`, deliveryID, workerID, status, statusCode, message, int(retryIn.Seconds()), models.WebhookDeliveryDelivered)
+	if err != nil {
+		return false, fmt.Errorf("error recording webhook delivery attempt: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+const webhookDeliverySql = `
+	SELECT DeliveryId, SubscriptionId, EventId, EventType, Status, Attempts, NextAttemptAt, LastStatusCode, LastError,
+		Payload, DateCreated, DateDelivered`
+
+// GetDelivery retrieves a delivery
+func (r *WebhookRepository) GetDelivery(id uuid.UUID) (*models.WebhookDelivery, error) {
+	delivery, err := scanWebhookDelivery(r.db.QueryRow(webhookDeliverySql+` FROM WebhookDeliveries WHERE DeliveryId = @p1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	return delivery, err
+}
+
+// ListDeliveries retrieves a page of the deliveries matching the query,
+// newest first
+func (r *WebhookRepository) ListDeliveries(q models.WebhookDeliveryQuery) ([]models.WebhookDelivery, models.PageInfo, error) {
+	info := models.PageInfo{TotalCount: -1}
+	k, err := newKeyset([]keysetKey{{"DateCreated", true}, {"DeliveryId", true}}, "webhookDeliveries", q.PageRequest)
+	if err != nil {
+		return nil, info, err
+	}
+
+	var args queryArgs
+	conds := []string{"1 = 1"}
+	if q.SubscriptionId != nil {
+		conds = append(conds, "SubscriptionId = "+args.param(*q.SubscriptionId))
+	}
+	if q.Status != "" {
+		conds = append(conds, "Status = "+args.param(q.Status))
+	}
+	where := strings.Join(conds, " AND ")
+
+	if !q.SkipCount {
+		if err := r.db.QueryRow(`SELECT COUNT(*) FROM WebhookDeliveries WHERE `+where, args...).Scan(&info.TotalCount); err != nil {
+			return nil, info, fmt.Errorf("error counting webhook deliveries: %w", err)
+		}
+	}
+
+	if cond := k.condition(args.param); cond != "" {
+		where += " AND " + cond
+	}
+	query := webhookDeliverySql + `, ` + k.columns() + `
+		FROM WebhookDeliveries
+		WHERE ` + where + `
+		ORDER BY ` + k.orderBy() + `
+		OFFSET ` + args.param(k.offset(q.PageRequest)) + ` ROWS FETCH NEXT ` + args.param(q.PageSize+1) + ` ROWS ONLY
+	`
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, info, fmt.Errorf("error retrieving webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	var sortValues [][]interface{}
+	for rows.Next() {
+		values, keyDests := scanKeys(2)
+		delivery, err := scanWebhookDelivery(rows, keyDests...)
+		if err != nil {
+			return nil, info, err
+		}
+		deliveries = append(deliveries, *delivery)
+		sortValues = append(sortValues, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, info, fmt.Errorf("error iterating webhook deliveries: %w", err)
+	}
+
+	deliveries, err = keysetPage(k, q.PageRequest, deliveries, sortValues, &info)
+	return deliveries, info, err
+}
+
+// Redeliver queues a delivered or dead delivery to be sent again at once,
+// with a fresh set of attempts
+func (r *WebhookRepository) Redeliver(id uuid.UUID) error {
+	result, err := r.db.Exec(`
+		UPDATE WebhookDeliveries SET
+			Status = @p2,
+			Attempts = 0,
+			NextAttemptAt = SYSUTCDATETIME(),
+			DateDelivered = NULL,
+			LeaseOwner = NULL,
+			LeaseExpiresAt = NULL
+		WHERE DeliveryId = @p1 AND Status <> @p2
+	`, id, models.WebhookDeliveryPending)
+	if err != nil {
+		return fmt.Errorf("error redelivering webhook delivery: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		if _, err := r.GetDelivery(id); err != nil {
+			return err
+		}
+		return ErrWebhookDeliveryPending
+	}
+	return nil
+}
+
+func scanWebhookSubscription(row interface{ Scan(...interface{}) error }) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	var idBytes, libraryIDBytes []byte
+	var entityTypes sql.NullString
+	var objectTypeID sql.NullInt64
+	err := row.Scan(&idBytes, &sub.Url, &sub.Secret, &entityTypes, &objectTypeID, &libraryIDBytes, &sub.IsActive,
+		&sub.CreatedBy, &sub.DateCreated, &sub.DateModified)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error scanning webhook subscription: %w", err)
+	}
+	sub.SubscriptionId, _ = parseSQLServerUUID(idBytes)
+	sub.EntityTypes = []string{}
+	if entityTypes.Valid && entityTypes.String != "" {
+		sub.EntityTypes = strings.Split(entityTypes.String, ",")
+	}
+	if objectTypeID.Valid {
+		v := int(objectTypeID.Int64)
+		sub.ObjectTypeId = &v
+	}
+	if libraryIDBytes != nil {
+		v, _ := parseSQLServerUUID(libraryIDBytes)
+		sub.LibraryId = &v
+	}
+	return &sub, nil
+}
+
+// scanWebhookDelivery scans the columns of webhookDeliverySql followed by
+// extra destinations
+func scanWebhookDelivery(row interface{ Scan(...interface{}) error }, extra ...interface{}) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	var deliveryIDBytes, subscriptionIDBytes, eventIDBytes []byte
+	var statusCode sql.NullInt64
+	var lastError sql.NullString
+	var nextAttemptAt, dateDelivered sql.NullTime
+	var payload string
+	err := row.Scan(append([]interface{}{
+		&deliveryIDBytes, &subscriptionIDBytes, &eventIDBytes, &d.EventType, &d.Status, &d.Attempts, &nextAttemptAt,
+		&statusCode, &lastError, &payload, &d.DateCreated, &dateDelivered,
+	}, extra...)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error scanning webhook delivery: %w", err)
+	}
+	d.DeliveryId, _ = parseSQLServerUUID(deliveryIDBytes)
+	d.SubscriptionId, _ = parseSQLServerUUID(subscriptionIDBytes)
+	d.EventId, _ = parseSQLServerUUID(eventIDBytes)
+	if d.Status == models.WebhookDeliveryPending && nextAttemptAt.Valid {
+		d.NextAttemptAt = &nextAttemptAt.Time
+	}
+	if statusCode.Valid {
+		v := int(statusCode.Int64)
+		d.LastStatusCode = &v
+	}
+	if lastError.Valid {
+		d.LastError = &lastError.String
+	}
+	d.Payload = json.RawMessage(payload)
+	if dateDelivered.Valid {
+		d.DateDelivered = &dateDelivered.Time
+	}
+	return &d, nil
+}
+
+// webhookEntityTypes stores an empty entity type filter as NULL
+func webhookEntityTypes(types []string) sql.NullString {
+	return sql.NullString{String: strings.Join(types, ","), Valid: len(types) > 0}
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/repositories"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Approval workflow errors
+var (
+	ErrObjectCheckedOut    = errors.New("object is checked out")
+	ErrNotGoverned         = errors.New("object type has no approvers configured")
+	ErrNotPendingApproval  = repositories.ErrNotPendingApproval
+	ErrAlreadyApproved     = errors.New("current version is already approved")
+	ErrNotApprover         = errors.New("profile is not an approver for this object type")
+	ErrCommentRequired     = errors.New("a comment is required when rejecting")
+	ErrSelfApproval        = errors.New("a version cannot be approved by the user who submitted it")
+	ErrApproversNotAllowed = errors.New("profile may not change approvers")
+	ErrInvalidApprovers    = errors.New("invalid approvers")
+)
+
+// ApprovalService handles submitting, approving and rejecting object versions
+type ApprovalService struct {
+	repo   *repositories.ApprovalRepository
+	admins map[int]bool
+}
+
+// NewApprovalService creates a new ApprovalService. Only adminProfiles may
+// change the approvers of object types.
+func NewApprovalService(repo *repositories.ApprovalRepository, adminProfiles []int) *ApprovalService {
+	admins := make(map[int]bool, len(adminProfiles))
+	for _, profileID := range adminProfiles {
+		admins[profileID] = true
+	}
+	return &ApprovalService{repo: repo, admins: admins}
+}
+
+// Submit submits the object's current version for approval
+func (s *ApprovalService) Submit(objectID uuid.UUID, userID, profileID int, req models.ApprovalRequest, changes *repositories.ChangeLog) (*models.ApprovalState, error) {
+	check := func(state *models.ApprovalState) error {
+		switch {
+		case !state.IsGoverned:
+			return ErrNotGoverned
+		case state.IsCheckedOut:
+			return ErrObjectCheckedOut
+		case state.IsPending:
+			return ErrPendingApproval
+		case state.IsApproved:
+			return ErrAlreadyApproved
+		}
+		return nil
+	}
+
+	if err := s.repo.Submit(objectID, userID, profileID, req.Comment, check, changes); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetApprovalState(objectID)
+}
+
+// Approve approves the object's pending version and makes it the checked-in
+// version. The user who submitted the version may not approve it.
+func (s *ApprovalService) Approve(objectID uuid.UUID, userID, profileID int, req models.ApprovalRequest, changes *repositories.ChangeLog) (*models.ApprovalState, error) {
+	check := func(state *models.ApprovalState) error {
+		if err := s.pendingApproverError(state, profileID); err != nil {
+			return err
+		}
+		if state.SubmittedBy != nil && *state.SubmittedBy == userID {
+			return ErrSelfApproval
+		}
+		return nil
+	}
+
+	if err := s.repo.Approve(objectID, userID, profileID, req.Comment, check, changes); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetApprovalState(objectID)
+}
+
+// Reject rejects the object's pending version and returns the object to its
+// last checked-in version; a comment is required
+func (s *ApprovalService) Reject(objectID uuid.UUID, userID, profileID int, req models.ApprovalRequest, changes *repositories.ChangeLog) (*models.ApprovalState, error) {
+	if req.Comment == "" {
+		return nil, ErrCommentRequired
+	}
+	check := func(state *models.ApprovalState) error {
+		return s.pendingApproverError(state, profileID)
+	}
+
+	if err := s.repo.Reject(objectID, userID, profileID, req.Comment, check, changes); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetApprovalState(objectID)
+}
+
+// GetHistory retrieves the approval history of an object
+func (s *ApprovalService) GetHistory(objectID uuid.UUID) ([]models.ApprovalHistoryEntry, error) {
+	return s.repo.GetHistory(objectID)
+}
+
+// GetPendingApprovals retrieves versions waiting for the profile's sign-off
+func (s *ApprovalService) GetPendingApprovals(profileID int) ([]models.PendingApproval, error) {
+	return s.repo.GetPendingForProfile(profileID)
+}
+
+// GetApprovers retrieves the approver profiles of an object type
+func (s *ApprovalService) GetApprovers(objectTypeID int) ([]int, error) {
+	return s.repo.GetApproverProfiles(objectTypeID)
+}
+
+// SetApprovers replaces the approver profiles of an object type. An empty list
+// removes governance from the type. Only approval admin profiles may do this.
+func (s *ApprovalService) SetApprovers(objectTypeID int, req models.ObjectTypeApproversRequest, userID, callerProfileID int, changes *repositories.ChangeLog) ([]int, error) {
+	if !s.admins[callerProfileID] {
+		return nil, ErrApproversNotAllowed
+	}
+
+	seen := make(map[int]bool)
+	var profileIDs []int
+	for _, profileID := range req.ProfileIDs {
+		if profileID <= 0 {
+			return nil, fmt.Errorf("%w: invalid profile ID %d", ErrInvalidApprovers, profileID)
+		}
+		if !seen[profileID] {
+			seen[profileID] = true
+			profileIDs = append(profileIDs, profileID)
+		}
+	}
+
+	if err := s.repo.SetApproverProfiles(objectTypeID, profileIDs, userID, changes); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetApproverProfiles(objectTypeID)
+}
+
+// pendingApproverError reports why the profile may not decide on the
+// object's current version, or nil if it is pending and the profile approves
+// its type
+func (s *ApprovalService) pendingApproverError(state *models.ApprovalState, profileID int) error {
+	if !state.IsPending {
+		return ErrNotPendingApproval
+	}
+
+	isApprover, err := s.repo.IsApprover(state.ExactObjectTypeID, profileID)
+	if err != nil {
+		return err
+	}
+	if !isApprover {
+		return ErrNotApprover
+	}
+
+	return nil
+}
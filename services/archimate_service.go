@@ -0,0 +1,556 @@
+package services
+
+import (
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/repositories"
+	"enterprise-architect-api/utils"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Identifier prefixes of exported concepts. Exchange file identifiers are
+// xs:ID values, which may not start with a digit.
+const (
+	archiMateObjectPrefix   = "id-"
+	archiMatePropertyPrefix = "propid-"
+)
+
+// ArchiMateService exchanges library contents as ArchiMate Open Exchange
+// Format files, using the object type mappings of the EA configuration
+type ArchiMateService struct {
+	objectRepo       *repositories.ObjectRepository
+	attributeRepo    *repositories.AttributeRepository
+	mappingRepo      *repositories.ReportConfigRepository
+	relationshipRepo *repositories.RelationshipRepository
+	exports          *ExportService
+	imports          *ImportService
+}
+
+// NewArchiMateService creates a new ArchiMateService
+func NewArchiMateService(objectRepo *repositories.ObjectRepository, attributeRepo *repositories.AttributeRepository, mappingRepo *repositories.ReportConfigRepository, relationshipRepo *repositories.RelationshipRepository, exports *ExportService, imports *ImportService) *ArchiMateService {
+	return &ArchiMateService{
+		objectRepo:       objectRepo,
+		attributeRepo:    attributeRepo,
+		mappingRepo:      mappingRepo,
+		relationshipRepo: relationshipRepo,
+		exports:          exports,
+		imports:          imports,
+	}
+}
+
+// CheckExport validates an export of a library before anything is written
+// and returns the library name and the mappings to export
+func (s *ArchiMateService) CheckExport(libraryID uuid.UUID) (string, []models.ArchiMateMapping, error) {
+	if libraryID == uuid.Nil {
+		return "", nil, fmt.Errorf("%w: libraryId is required", ErrInvalidExportRequest)
+	}
+	library, err := s.objectRepo.GetByID(libraryID)
+	if err != nil {
+		return "", nil, err
+	}
+	mappings, err := s.mappingRepo.GetArchiMateMappings()
+	if err != nil {
+		return "", nil, err
+	}
+	if len(mappings) == 0 {
+		return "", nil, fmt.Errorf("%w: no object types are mapped to ArchiMate element types", ErrInvalidExportRequest)
+	}
+	return library.ObjectName, mappings, nil
+}
+
+// ExportLibrary streams the objects of the mapped object types in a library
+// to out as elements, with the attribute values the profile can read as
+// properties. Objects of unmapped types are left out. Relationships between
+// exported objects follow, for relation types that have an ArchiMate type.
+func (s *ArchiMateService) ExportLibrary(out io.Writer, libraryID uuid.UUID, libraryName string, mappings []models.ArchiMateMapping, profileID int) error {
+	writer, err := utils.NewArchiMateWriter(out, archiMateObjectPrefix+libraryID.String(), libraryName)
+	if err != nil {
+		return err
+	}
+
+	var definitions []utils.ArchiMatePropertyDefinition
+	defined := make(map[uuid.UUID]bool)
+	define := func(attributeID uuid.UUID, name, attributeType string) {
+		// Attributes shared by several object or relation types are defined once
+		if !defined[attributeID] {
+			defined[attributeID] = true
+			definitions = append(definitions, utils.ArchiMatePropertyDefinition{
+				Identifier: archiMatePropertyPrefix + attributeID.String(),
+				Type:       archiMatePropertyType(s.objectRepo.GetTypeId(attributeType)),
+				Name:       []utils.ArchiMateLangString{{Lang: "en", Value: name}},
+			})
+		}
+	}
+	exported := make(map[uuid.UUID]bool)
+	for _, mapping := range mappings {
+		columns, err := s.exports.ExportColumns(mapping.ObjectTypeID, models.ExportFormatCSV, profileID)
+		if err != nil {
+			return err
+		}
+		var attributes []models.ExportColumn
+		for _, col := range columns {
+			if col.AttributeId == uuid.Nil {
+				continue
+			}
+			attributes = append(attributes, col)
+			define(col.AttributeId, col.Header, col.AttributeType)
+		}
+
+		err = s.objectRepo.ExportObjects(mapping.ObjectTypeID, libraryID, profileID, func(obj *models.ExportedObject) error {
+			element := utils.ArchiMateElement{
+				Identifier: archiMateObjectPrefix + obj.ObjectID.String(),
+				Type:       mapping.ElementType,
+				Name:       []utils.ArchiMateLangString{{Lang: "en", Value: obj.ObjectName}},
+			}
+			if obj.Description != nil && strings.TrimSpace(*obj.Description) != "" {
+				element.Documentation = []utils.ArchiMateLangString{{Lang: "en", Value: *obj.Description}}
+			}
+			for _, col := range attributes {
+				value := s.exports.exportCell(col, obj)
+				if value == nil {
+					continue
+				}
+				element.Properties = append(element.Properties, utils.ArchiMateProperty{
+					DefinitionRef: archiMatePropertyPrefix + col.AttributeId.String(),
+					Values:        []utils.ArchiMateLangString{{Value: utils.FormatCell(value)}},
+				})
+			}
+			exported[obj.ObjectID] = true
+			return writer.WriteElement(element)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := s.exportRelationships(writer, libraryID, exported, define, profileID); err != nil {
+		return err
+	}
+	return writer.Close(definitions)
+}
+
+// exportRelationships writes the relationships of a library between exported
+// objects, with the attribute values the profile can read as properties
+func (s *ArchiMateService) exportRelationships(writer *utils.ArchiMateWriter, libraryID uuid.UUID, exported map[uuid.UUID]bool, define func(uuid.UUID, string, string), profileID int) error {
+	relationTypes, err := s.relationshipRepo.GetRelationTypes()
+	if err != nil {
+		return err
+	}
+	archiMateTypes := make(map[uuid.UUID]string, len(relationTypes))
+	for _, relationType := range relationTypes {
+		if relationType.ArchiMateType != nil {
+			archiMateTypes[relationType.RelationTypeId] = *relationType.ArchiMateType
+		}
+	}
+
+	relationships, err := s.relationshipRepo.GetLibraryRelationships(libraryID, profileID)
+	if err != nil {
+		return err
+	}
+	readable, err := s.attributeRepo.GetReadableAttributeIDs(profileID)
+	if err != nil {
+		return err
+	}
+
+	for _, rel := range relationships {
+		archiMateType, ok := archiMateTypes[rel.RelationTypeId]
+		if !ok || !exported[rel.SourceObjectId] || !exported[rel.TargetObjectId] {
+			continue
+		}
+		relationship := utils.ArchiMateRelationship{
+			Identifier: archiMateObjectPrefix + rel.RelationshipId.String(),
+			Type:       archiMateType,
+			Source:     archiMateObjectPrefix + rel.SourceObjectId.String(),
+			Target:     archiMateObjectPrefix + rel.TargetObjectId.String(),
+		}
+		if rel.Description != nil && strings.TrimSpace(*rel.Description) != "" {
+			relationship.Documentation = []utils.ArchiMateLangString{{Lang: "en", Value: *rel.Description}}
+		}
+
+		// Values are rendered like object attribute values
+		values := &models.ExportedObject{Values: make(map[uuid.UUID]models.AssignedAttribute, len(rel.Attributes))}
+		for _, attr := range rel.Attributes {
+			values.Values[attr.AttributeID] = attr
+		}
+		for _, attr := range rel.Attributes {
+			if !readable[attr.AttributeID] {
+				continue
+			}
+			col := models.ExportColumn{Header: attr.AttributeName, AttributeId: attr.AttributeID, AttributeType: attr.AttributeType}
+			value := s.exports.exportCell(col, values)
+			if value == nil {
+				continue
+			}
+			define(attr.AttributeID, attr.AttributeName, attr.AttributeType)
+			relationship.Properties = append(relationship.Properties, utils.ArchiMateProperty{
+				DefinitionRef: archiMatePropertyPrefix + attr.AttributeID.String(),
+				Values:        []utils.ArchiMateLangString{{Value: utils.FormatCell(value)}},
+			})
+		}
+
+		if err := writer.WriteRelationship(relationship); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archiMatePropertyType maps an AttributeValue DataType code to the data type
+// of a property definition
+func archiMatePropertyType(typeID int64) string {
+	switch typeID {
+	case 1, 3:
+		return "number"
+	case 2:
+		return "date"
+	case 5:
+		return "boolean"
+	default:
+		return "string"
+	}
+}
+
+// archiMateAttributes resolves properties to the attributes assigned to an
+// object type or relation type
+type archiMateAttributes struct {
+	byName map[string]models.AttributeAssignment
+	byID   map[uuid.UUID]models.AttributeAssignment
+}
+
+func newArchiMateAttributes(assignments []models.AttributeAssignment) archiMateAttributes {
+	attributes := archiMateAttributes{
+		byName: make(map[string]models.AttributeAssignment, len(assignments)),
+		byID:   make(map[uuid.UUID]models.AttributeAssignment, len(assignments)),
+	}
+	for _, a := range assignments {
+		attributes.byName[strings.ToLower(a.AttributeName)] = a
+		attributes.byID[a.AttributeId] = a
+	}
+	return attributes
+}
+
+// archiMateBatch collects the elements of a file that import as one object type
+type archiMateBatch struct {
+	archiMateAttributes
+	req      models.ObjectImportRequest
+	elements []int
+}
+
+// attribute resolves a property by its definition name, or by the attribute
+// ID of an exported definition
+func (b archiMateAttributes) attribute(name, definitionRef string) (models.AttributeAssignment, bool) {
+	if a, ok := b.byName[strings.ToLower(name)]; ok {
+		return a, true
+	}
+	for _, candidate := range []string{strings.TrimPrefix(definitionRef, archiMatePropertyPrefix), name} {
+		if id, err := uuid.Parse(candidate); err == nil {
+			if a, ok := b.byID[id]; ok {
+				return a, true
+			}
+		}
+	}
+	return models.AttributeAssignment{}, false
+}
+
+// ImportFile creates or updates objects from the elements of an exchange file
+// through the object import, and then the relationships between them, in one
+// transaction. Each element is imported as the object type mapped to its
+// element type; elements of unmapped types are skipped and properties that
+// match no attribute are ignored. Each relationship is imported as the
+// relation type with its ArchiMate type that allows the object types of its
+// ends. Views are not imported.
+func (s *ArchiMateService) ImportFile(req models.ArchiMateImportRequest, data []byte, userID, profileID int, changes *repositories.ChangeLog) (*models.ArchiMateImportResponse, error) {
+	if req.FolderId == uuid.Nil || req.LibraryId == uuid.Nil {
+		return nil, fmt.Errorf("%w: libraryId and folderId are required", ErrInvalidImportFile)
+	}
+	if req.MatchBy == "" {
+		req.MatchBy = models.ImportMatchByName
+	}
+	if req.MatchBy != models.ImportMatchByName && req.MatchBy != models.ImportMatchByObjectID {
+		return nil, fmt.Errorf("%w: matchBy must be %q or %q for ArchiMate imports", ErrInvalidImportRequest, models.ImportMatchByName, models.ImportMatchByObjectID)
+	}
+
+	model, err := utils.ReadArchiMate(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+	}
+
+	mappings, err := s.mappingRepo.GetArchiMateMappings()
+	if err != nil {
+		return nil, err
+	}
+	objectTypes := make(map[string][]int, len(mappings))
+	for _, mapping := range mappings {
+		objectTypes[mapping.ElementType] = append(objectTypes[mapping.ElementType], mapping.ObjectTypeID)
+	}
+	propertyNames := make(map[string]string, len(model.PropertyDefinitions))
+	for _, definition := range model.PropertyDefinitions {
+		propertyNames[definition.Identifier] = utils.ArchiMateText(definition.Name)
+	}
+
+	response := &models.ArchiMateImportResponse{
+		SkippedElements:   []models.ArchiMateSkippedElement{},
+		IgnoredProperties: []string{},
+	}
+	batches := make(map[int]*archiMateBatch)
+	var order []int
+	ignored := make(map[string]bool)
+	// Imported elements by identifier, as the object type and batch row
+	refs := make(map[string]archiMateRef, len(model.Elements))
+
+	for i, element := range model.Elements {
+		name := utils.ArchiMateText(element.Name)
+		elementType, ok := utils.ArchiMateElementType(element.Type)
+		if !ok {
+			elementType = element.Type
+		}
+		types := objectTypes[elementType]
+		if len(types) == 0 {
+			response.SkippedElements = append(response.SkippedElements, models.ArchiMateSkippedElement{
+				Identifier: element.Identifier,
+				Type:       element.Type,
+				Name:       name,
+				Message:    "element type is not mapped to an object type",
+			})
+			continue
+		}
+		if len(types) > 1 {
+			return nil, fmt.Errorf("%w: element type %s is mapped to several object types", ErrInvalidImportRequest, elementType)
+		}
+
+		batch, err := s.importBatch(batches, types[0], req)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch.elements) == 0 {
+			order = append(order, types[0])
+		}
+
+		row := map[string]models.ObjectImportRow{}
+		setArchiMateValue(row, importColumnObjectName, name)
+		if documentation := utils.ArchiMateText(element.Documentation); documentation != "" {
+			setArchiMateValue(row, importColumnDescription, documentation)
+		}
+		if req.MatchBy == models.ImportMatchByObjectID && strings.HasPrefix(element.Identifier, archiMateObjectPrefix) {
+			if id, err := uuid.Parse(strings.TrimPrefix(element.Identifier, archiMateObjectPrefix)); err == nil {
+				setArchiMateValue(row, importColumnObjectID, id.String())
+			}
+		}
+
+		for _, value := range archiMateValues(batch.archiMateAttributes, element.Properties, propertyNames, ignored) {
+			row[*value.AttributeId] = value
+		}
+
+		refs[element.Identifier] = archiMateRef{objectTypeID: types[0], row: len(batch.elements)}
+		batch.req.Data = append(batch.req.Data, row)
+		batch.elements = append(batch.elements, i+1)
+	}
+
+	reqs := make([]models.ObjectImportRequest, len(order))
+	requestIndex := make(map[int]int, len(order))
+	for i, objectTypeID := range order {
+		reqs[i] = batches[objectTypeID].req
+		requestIndex[objectTypeID] = i
+	}
+
+	relationships, skipped, err := s.importRelationships(model, refs, requestIndex, propertyNames, ignored)
+	if err != nil {
+		return nil, err
+	}
+	results, relationshipResults, err := s.relationshipRepo.ImportWithRelationships(reqs, relationships, req.DryRun, req.Locale, userID, profileID, changes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Results are reported in file order, skipped relationships included
+	response.Relationships = make([]models.RelationshipImportResult, 0, len(model.Relationships))
+	next := 0
+	for i, relationship := range model.Relationships {
+		result, ok := skipped[i]
+		if !ok {
+			result = relationshipResults[next]
+			next++
+		}
+		result.Type = relationship.Type
+		response.Relationships = append(response.Relationships, result)
+	}
+
+	response.Rows = []models.ImportRowResult{}
+	for i, result := range results {
+		elements := batches[order[i]].elements
+		for _, row := range result.Rows {
+			row.Row = elements[row.Row-1]
+			response.Rows = append(response.Rows, row)
+		}
+		response.SuccessImportedObjectCount += result.SuccessImportedObjectCount
+		response.FailedImportObjectCount += result.FailedImportObjectCount
+		response.SkippedImportObjectCount += result.SkippedImportObjectCount
+		response.TotalImportedObjectCount += result.TotalImportedObjectCount
+	}
+	sort.Slice(response.Rows, func(a, b int) bool { return response.Rows[a].Row < response.Rows[b].Row })
+
+	for name := range ignored {
+		response.IgnoredProperties = append(response.IgnoredProperties, name)
+	}
+	sort.Strings(response.IgnoredProperties)
+
+	response.DryRun = req.DryRun
+	response.Success = true
+	return response, nil
+}
+
+// archiMateRef locates an imported element as a row of the batch of its
+// object type
+type archiMateRef struct {
+	objectTypeID int
+	row          int
+}
+
+// importRelationships resolves the relationships of a file to relation types
+// and imported rows. Relationships that cannot be imported are returned as
+// skip results keyed by their position in the file.
+func (s *ArchiMateService) importRelationships(model *utils.ArchiMateModel, refs map[string]archiMateRef, requestIndex map[int]int, propertyNames map[string]string, ignored map[string]bool) ([]models.RelationshipImport, map[int]models.RelationshipImportResult, error) {
+	skipped := make(map[int]models.RelationshipImportResult)
+	if len(model.Relationships) == 0 {
+		return nil, skipped, nil
+	}
+
+	relationTypes, err := s.relationshipRepo.GetRelationTypes()
+	if err != nil {
+		return nil, nil, err
+	}
+	byArchiMateType := make(map[string][]models.RelationType)
+	for _, relationType := range relationTypes {
+		if relationType.ArchiMateType != nil {
+			byArchiMateType[*relationType.ArchiMateType] = append(byArchiMateType[*relationType.ArchiMateType], relationType)
+		}
+	}
+	attributes := make(map[uuid.UUID]archiMateAttributes)
+
+	var relationships []models.RelationshipImport
+	for i, relationship := range model.Relationships {
+		skip := func(message string) {
+			skipped[i] = models.RelationshipImportResult{Identifier: relationship.Identifier, Action: models.ImportActionSkip, Message: message}
+		}
+
+		source, sourceOK := refs[relationship.Source]
+		target, targetOK := refs[relationship.Target]
+		if !sourceOK || !targetOK {
+			skip("source or target element was not imported")
+			continue
+		}
+
+		relationshipType, ok := utils.ArchiMateRelationshipType(relationship.Type)
+		if !ok {
+			relationshipType = relationship.Type
+		}
+		var candidates []models.RelationType
+		for _, relationType := range byArchiMateType[relationshipType] {
+			for _, rule := range relationType.Rules {
+				if rule.SourceObjectTypeId == source.objectTypeID && rule.TargetObjectTypeId == target.objectTypeID {
+					candidates = append(candidates, relationType)
+					break
+				}
+			}
+		}
+		switch {
+		case len(candidates) == 0:
+			skip(fmt.Sprintf("no relation type with ArchiMate type %s allows these object types", relationshipType))
+			continue
+		case len(candidates) > 1:
+			skip(fmt.Sprintf("several relation types with ArchiMate type %s allow these object types", relationshipType))
+			continue
+		}
+		relationType := candidates[0]
+
+		attrs, ok := attributes[relationType.RelationTypeId]
+		if !ok {
+			assignments, err := s.attributeRepo.GetAttributeAssignments(0, relationType.RelationTypeId)
+			if err != nil {
+				return nil, nil, err
+			}
+			attrs = newArchiMateAttributes(assignments)
+			attributes[relationType.RelationTypeId] = attrs
+		}
+
+		rel := models.RelationshipImport{
+			Identifier:     relationship.Identifier,
+			RelationTypeId: relationType.RelationTypeId,
+			Source:         models.ImportRowRef{Request: requestIndex[source.objectTypeID], Row: source.row},
+			Target:         models.ImportRowRef{Request: requestIndex[target.objectTypeID], Row: target.row},
+			Values:         archiMateValues(attrs, relationship.Properties, propertyNames, ignored),
+		}
+		if documentation := utils.ArchiMateText(relationship.Documentation); documentation != "" {
+			rel.Description = &documentation
+		}
+		relationships = append(relationships, rel)
+	}
+	return relationships, skipped, nil
+}
+
+// archiMateValues converts properties to import values of the attributes they
+// resolve to, recording the names of properties that resolve to none
+func archiMateValues(attributes archiMateAttributes, properties []utils.ArchiMateProperty, propertyNames map[string]string, ignored map[string]bool) []models.ObjectImportRow {
+	var values []models.ObjectImportRow
+	for _, property := range properties {
+		propertyName := propertyNames[property.DefinitionRef]
+		a, ok := attributes.attribute(propertyName, property.DefinitionRef)
+		if !ok {
+			if propertyName == "" {
+				propertyName = property.DefinitionRef
+			}
+			ignored[propertyName] = true
+			continue
+		}
+		value := utils.ArchiMateText(property.Values)
+		// Blank values are left out so that they do not clear existing values
+		if value == "" {
+			continue
+		}
+		id, attrName, attrType := a.AttributeId.String(), a.AttributeName, a.AttributeType
+		values = append(values, models.ObjectImportRow{
+			AttributeId:    &id,
+			AttributeName:  &attrName,
+			AttributeType:  &attrType,
+			AttributeValue: &value,
+		})
+	}
+	return values
+}
+
+// importBatch returns the batch of an object type, validating the import
+// options and loading the type's attributes the first time it is seen
+func (s *ArchiMateService) importBatch(batches map[int]*archiMateBatch, objectTypeID int, req models.ArchiMateImportRequest) (*archiMateBatch, error) {
+	if batch, ok := batches[objectTypeID]; ok {
+		return batch, nil
+	}
+
+	importReq := models.ObjectImportRequest{
+		LibraryId:    req.LibraryId,
+		FolderId:     req.FolderId,
+		ObjectTypeId: objectTypeID,
+		Locale:       req.Locale,
+		MatchBy:      req.MatchBy,
+		Strategy:     req.Strategy,
+	}
+	if err := s.imports.ValidateImportRequest(importReq); err != nil {
+		return nil, err
+	}
+	assignments, err := s.attributeRepo.GetAttributeAssignments(objectTypeID, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &archiMateBatch{archiMateAttributes: newArchiMateAttributes(assignments), req: importReq}
+	batches[objectTypeID] = batch
+	return batch, nil
+}
+
+// setArchiMateValue sets a system field of an import row
+func setArchiMateValue(row map[string]models.ObjectImportRow, target, value string) {
+	row[target] = models.ObjectImportRow{AttributeName: &target, AttributeValue: &value}
+}
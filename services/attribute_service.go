@@ -4,25 +4,25 @@ import (
 	"enterprise-architect-api/models"
 	"enterprise-architect-api/repositories"
 	"fmt"
-	"math"
 
 	"github.com/google/uuid"
 )
 
 type AttributeService struct {
 	attributeRepository *repositories.AttributeRepository
+	objectRepository    *repositories.ObjectRepository
 }
 
-func NewAttributeService(attributeRepository *repositories.AttributeRepository) *AttributeService {
-	return &AttributeService{attributeRepository: attributeRepository}
+func NewAttributeService(attributeRepository *repositories.AttributeRepository, objectRepository *repositories.ObjectRepository) *AttributeService {
+	return &AttributeService{attributeRepository: attributeRepository, objectRepository: objectRepository}
 }
 
-func (as *AttributeService) GetAttributeForObject(objectID uuid.UUID, objectTypeId *int) (*models.ObjectInstanceAttribute, error) {
-	return as.attributeRepository.GetAttributeForObject(objectID, objectTypeId)
+func (as *AttributeService) GetAttributeForObject(objectID uuid.UUID, objectTypeId *int, profileID int) (*models.ObjectInstanceAttribute, error) {
+	return as.attributeRepository.GetAttributeForObject(objectID, objectTypeId, profileID)
 }
 
 // CreateAttribute creates a new attribute
-func (as *AttributeService) CreateAttribute(attribute *models.Attribute) error {
+func (as *AttributeService) CreateAttribute(attribute *models.Attribute, changes *repositories.ChangeLog) error {
 	// Validate required fields
 	if attribute.AttributeName == "" {
 		return fmt.Errorf("attribute name is required and must be unique")
@@ -40,7 +40,7 @@ func (as *AttributeService) CreateAttribute(attribute *models.Attribute) error {
 		return fmt.Errorf("attribute name '%s' already exists, name must be unique", attribute.AttributeName)
 	}
 
-	return as.attributeRepository.Create(attribute)
+	return as.attributeRepository.Create(attribute, changes)
 }
 
 // GetAttributeByID retrieves an attribute by its ID
@@ -49,36 +49,20 @@ func (as *AttributeService) GetAttributeByID(id string) (*models.Attribute, erro
 }
 
 // GetAllAttributes retrieves all attributes with pagination
-func (as *AttributeService) GetAllAttributes(page, pageSize int) (*models.PaginatedResponse, error) {
-	// Set default pagination values
-	if page <= 0 {
-		page = 1
-	}
-	if pageSize <= 0 {
-		pageSize = 10
-	}
-	if pageSize > 100 {
-		pageSize = 100
-	}
+func (as *AttributeService) GetAllAttributes(req models.PageRequest) (*models.PaginatedResponse, error) {
+	req = pageDefaults(req, 10, 100)
 
-	attributes, totalCount, err := as.attributeRepository.GetAll(page, pageSize)
+	attributes, info, err := as.attributeRepository.GetAll(req)
 	if err != nil {
 		return nil, err
 	}
 
-	totalPages := int(math.Ceil(float64(totalCount) / float64(pageSize)))
-
-	return &models.PaginatedResponse{
-		Data:       attributes,
-		Page:       page,
-		PageSize:   pageSize,
-		TotalCount: totalCount,
-		TotalPages: totalPages,
-	}, nil
+	response := paginatedResponse(req, attributes, info)
+	return &response, nil
 }
 
 // UpdateAttribute updates an existing attribute
-func (as *AttributeService) UpdateAttribute(id string, attribute *models.Attribute) (*models.Attribute, error) {
+func (as *AttributeService) UpdateAttribute(id string, attribute *models.Attribute, changes *repositories.ChangeLog) (*models.Attribute, error) {
 	// Validate that attribute exists
 	_, err := as.attributeRepository.GetByID(id)
 	if err != nil {
@@ -93,7 +77,7 @@ func (as *AttributeService) UpdateAttribute(id string, attribute *models.Attribu
 		return nil, fmt.Errorf("attribute type is required")
 	}
 
-	err = as.attributeRepository.Update(id, attribute)
+	err = as.attributeRepository.Update(id, attribute, changes)
 	if err != nil {
 		return nil, err
 	}
@@ -102,12 +86,12 @@ func (as *AttributeService) UpdateAttribute(id string, attribute *models.Attribu
 }
 
 // DeleteAttribute deletes an attribute by its ID
-func (as *AttributeService) DeleteAttribute(id string) error {
-	return as.attributeRepository.Delete(id)
+func (as *AttributeService) DeleteAttribute(id string, changes *repositories.ChangeLog) error {
+	return as.attributeRepository.Delete(id, changes)
 }
 
 // AssignAttributeToObjectType assigns an attribute to an object type
-func (as *AttributeService) AssignAttributeToObjectType(req *models.AssignAttributeToObjectTypeRequest) error {
+func (as *AttributeService) AssignAttributeToObjectType(req *models.AssignAttributeToObjectTypeRequest, changes *repositories.ChangeLog) error {
 	// Validate required fields
 	if req.AttributeGroupName == "" {
 		return fmt.Errorf("attribute group name is required")
@@ -119,7 +103,7 @@ func (as *AttributeService) AssignAttributeToObjectType(req *models.AssignAttrib
 		return fmt.Errorf("either object type ID or relation type ID must be provided")
 	}
 
-	return as.attributeRepository.AssignAttributeToObjectType(req)
+	return as.attributeRepository.AssignAttributeToObjectType(req, changes)
 }
 
 func (as *AttributeService) GetAttributeAssignments(objectTypeId int, relationTypeId uuid.UUID) ([]models.AttributeAssignment, error) {
@@ -139,7 +123,7 @@ func (as *AttributeService) GetAttributeAssignments(objectTypeId int, relationTy
 }
 
 // UnassignAttributeFromObjectType removes an attribute assignment from an object type
-func (as *AttributeService) UnassignAttributeFromObjectType(req *models.UnassignAttributeFromObjectTypeRequest) error {
+func (as *AttributeService) UnassignAttributeFromObjectType(req *models.UnassignAttributeFromObjectTypeRequest, changes *repositories.ChangeLog) error {
 	// Validate required fields
 	if req.AttributeId.String() == "00000000-0000-0000-0000-000000000000" {
 		return fmt.Errorf("attribute ID is required")
@@ -151,11 +135,11 @@ func (as *AttributeService) UnassignAttributeFromObjectType(req *models.Unassign
 		return fmt.Errorf("either object type ID or relation type ID must be provided")
 	}
 
-	return as.attributeRepository.UnassignAttributeFromObjectType(req)
+	return as.attributeRepository.UnassignAttributeFromObjectType(req, changes)
 }
 
 // UpdateAttributeValue updates the value of multiple attributes
-func (as *AttributeService) UpdateAttributeValue(attrs []models.AssignedAttribute) error {
+func (as *AttributeService) UpdateAttributeValue(attrs []models.AssignedAttribute, userID int, changes *repositories.ChangeLog) error {
 	if len(attrs) == 0 {
 		return fmt.Errorf("no attributes provided to update")
 	}
@@ -172,5 +156,7 @@ func (as *AttributeService) UpdateAttributeValue(attrs []models.AssignedAttribut
 		}
 	}
 
-	return as.attributeRepository.UpdateAttributeValue(attrs)
+	// The repository checks under a row lock that only the checkout holder
+	// edits a checked-out object's values, and only in its current version
+	return as.attributeRepository.UpdateAttributeValue(attrs, userID, changes)
 }
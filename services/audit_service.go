@@ -0,0 +1,69 @@
+package services
+
+import (
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/repositories"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrAuditNotAllowed is returned when a profile may not read the audit trail
+	ErrAuditNotAllowed = errors.New("profile may not read the audit trail")
+	// ErrInvalidAuditQuery is returned for unusable audit trail filters
+	ErrInvalidAuditQuery = errors.New("invalid audit query")
+)
+
+// auditEntityTypes are the entity types written to the audit trail
+var auditEntityTypes = map[string]bool{
+	models.AuditEntityObject:         true,
+	models.AuditEntityObjectType:     true,
+	models.AuditEntityAttribute:      true,
+	models.AuditEntityAttributeValue: true,
+	models.AuditEntityProfile:        true,
+	models.AuditEntityEATag:          true,
+
+	models.AuditEntityDimension:           true,
+	models.AuditEntityArchiMateMapping:    true,
+	models.AuditEntityApprovers:           true,
+	models.AuditEntityAttributeAssignment: true,
+	models.AuditEntityFolderObjectType:    true,
+}
+
+// AuditService handles business logic for the audit trail
+type AuditService struct {
+	repo    *repositories.AuditRepository
+	readers map[int]bool
+}
+
+// NewAuditService creates a new AuditService. Only the reader profiles may
+// read the audit trail.
+func NewAuditService(repo *repositories.AuditRepository, readerProfiles []int) *AuditService {
+	readers := make(map[int]bool, len(readerProfiles))
+	for _, profileID := range readerProfiles {
+		readers[profileID] = true
+	}
+	return &AuditService{repo: repo, readers: readers}
+}
+
+// List retrieves a page of audit events, newest first
+func (s *AuditService) List(q models.AuditQuery, profileID int) (*models.PaginatedResponse, error) {
+	if !s.readers[profileID] {
+		return nil, ErrAuditNotAllowed
+	}
+	if q.EntityType != "" && !auditEntityTypes[q.EntityType] {
+		return nil, fmt.Errorf("%w: unknown entityType %q", ErrInvalidAuditQuery, q.EntityType)
+	}
+	if q.From != nil && q.To != nil && !q.From.Before(*q.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidAuditQuery)
+	}
+	q.PageRequest = pageDefaults(q.PageRequest, 50, 500)
+
+	events, info, err := s.repo.List(q)
+	if err != nil {
+		return nil, err
+	}
+
+	response := paginatedResponse(q.PageRequest, events, info)
+	return &response, nil
+}
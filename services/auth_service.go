@@ -0,0 +1,79 @@
+package services
+
+import (
+	"enterprise-architect-api/config"
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/repositories"
+	"enterprise-architect-api/utils"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// AuthService handles login and bearer token validation
+type AuthService struct {
+	repo *repositories.UserRepository
+	cfg  config.AuthConfig
+}
+
+// NewAuthService creates a new AuthService
+func NewAuthService(repo *repositories.UserRepository, cfg config.AuthConfig) *AuthService {
+	return &AuthService{repo: repo, cfg: cfg}
+}
+
+// Login verifies the user's credentials and issues a signed token
+func (s *AuthService) Login(req models.LoginRequest) (*models.LoginResponse, error) {
+	if req.UserName == "" || req.Password == "" {
+		return nil, fmt.Errorf("user name and password are required")
+	}
+
+	user, err := s.repo.GetByUserName(req.UserName)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user name or password")
+	}
+	if !user.IsActive {
+		return nil, fmt.Errorf("user account is disabled")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return nil, fmt.Errorf("invalid user name or password")
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.cfg.TokenTTL)
+	token, err := utils.GenerateJWT(utils.TokenClaims{
+		UserID:    user.UserID,
+		ProfileID: user.ProfileID,
+		UserName:  user.UserName,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	}, s.cfg.JWTSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.LoginResponse{
+		Token:     token,
+		TokenType: "Bearer",
+		ExpiresAt: expiresAt,
+		User: models.AuthUser{
+			UserID:    user.UserID,
+			ProfileID: user.ProfileID,
+			UserName:  user.UserName,
+		},
+	}, nil
+}
+
+// ValidateToken parses a bearer token and returns the caller it identifies
+func (s *AuthService) ValidateToken(token string) (*models.AuthUser, error) {
+	claims, err := utils.ParseJWT(token, s.cfg.JWTSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuthUser{
+		UserID:    claims.UserID,
+		ProfileID: claims.ProfileID,
+		UserName:  claims.UserName,
+	}, nil
+}
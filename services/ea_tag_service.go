@@ -3,8 +3,8 @@ package services
 import (
 	"enterprise-architect-api/models"
 	"enterprise-architect-api/repositories"
+	"enterprise-architect-api/utils"
 	"fmt"
-	"math"
 )
 
 // EATagService handles business logic for EA tags
@@ -22,7 +22,7 @@ func NewEATagService(repo *repositories.ReportConfigRepository) *EATagService {
 }
 
 // CreateEATag creates a new EA tag
-func (s *EATagService) CreateEATag(req models.CreateEATagRequest) (*models.EATag, error) {
+func (s *EATagService) CreateEATag(req models.CreateEATagRequest, changes *repositories.ChangeLog) (*models.EATag, error) {
 	// Validate required fields
 	if req.NameAr == "" {
 		return nil, fmt.Errorf("name_ar is required")
@@ -31,7 +31,7 @@ func (s *EATagService) CreateEATag(req models.CreateEATagRequest) (*models.EATag
 		return nil, fmt.Errorf("name_en is required")
 	}
 
-	return s.repo.CreateEATag(req)
+	return s.repo.CreateEATag(req, changes)
 }
 
 // GetEATagByID retrieves an EA tag by its ID
@@ -40,51 +40,35 @@ func (s *EATagService) GetEATagByID(id int) (*models.EATag, error) {
 }
 
 // GetAllEATags retrieves all EA tags with pagination
-func (s *EATagService) GetAllEATags(page, pageSize int) (*models.PaginatedResponse, error) {
-	// Set default pagination values
-	if page <= 0 {
-		page = 1
-	}
-	if pageSize <= 0 {
-		pageSize = 10
-	}
-	if pageSize > 100 {
-		pageSize = 100
-	}
+func (s *EATagService) GetAllEATags(req models.PageRequest) (*models.PaginatedResponse, error) {
+	req = pageDefaults(req, 10, 100)
 
-	tags, totalCount, err := s.repo.GetAllEATags(page, pageSize)
+	tags, info, err := s.repo.GetAllEATags(req)
 	if err != nil {
 		return nil, err
 	}
 
-	totalPages := int(math.Ceil(float64(totalCount) / float64(pageSize)))
-
-	return &models.PaginatedResponse{
-		Data:       tags,
-		Page:       page,
-		PageSize:   pageSize,
-		TotalCount: totalCount,
-		TotalPages: totalPages,
-	}, nil
+	response := paginatedResponse(req, tags, info)
+	return &response, nil
 }
 
 // UpdateEATag updates an existing EA tag
-func (s *EATagService) UpdateEATag(id int, req models.UpdateEATagRequest) (*models.EATag, error) {
+func (s *EATagService) UpdateEATag(id int, req models.UpdateEATagRequest, changes *repositories.ChangeLog) (*models.EATag, error) {
 	// Validate that at least one field is being updated
 	if req.NameAr == nil && req.NameEn == nil {
 		return nil, fmt.Errorf("at least one field must be provided for update")
 	}
 
-	return s.repo.UpdateEATag(id, req)
+	return s.repo.UpdateEATag(id, req, changes)
 }
 
 // DeleteEATag deletes an EA tag by its ID
-func (s *EATagService) DeleteEATag(id int) error {
-	return s.repo.DeleteEATag(id)
+func (s *EATagService) DeleteEATag(id int, changes *repositories.ChangeLog) error {
+	return s.repo.DeleteEATag(id, changes)
 }
 
 // AssignObjectTypeToDimention assigns an object type to a dimension
-func (s *EATagService) AssignObjectTypeToDimention(req models.AssignObjectTypeToDimentionRequest) (*models.EATagDimention, error) {
+func (s *EATagService) AssignObjectTypeToDimention(req models.AssignObjectTypeToDimentionRequest, changes *repositories.ChangeLog) (*models.EATagDimention, error) {
 	// Validate required fields
 	if req.ObjectTypeID <= 0 {
 		return nil, fmt.Errorf("object_type_id is required and must be greater than 0")
@@ -93,5 +77,29 @@ func (s *EATagService) AssignObjectTypeToDimention(req models.AssignObjectTypeTo
 		return nil, fmt.Errorf("ea_tag_id is required and must be greater than 0")
 	}
 
-	return s.repo.AssignObjectTypeToDimention(req)
+	return s.repo.AssignObjectTypeToDimention(req, changes)
+}
+
+// GetArchiMateMappings retrieves the ArchiMate element type of every mapped object type
+func (s *EATagService) GetArchiMateMappings() ([]models.ArchiMateMapping, error) {
+	return s.repo.GetArchiMateMappings()
+}
+
+// SetArchiMateMapping maps an object type to an ArchiMate 3.1 element type
+func (s *EATagService) SetArchiMateMapping(objectTypeID int, req models.SetArchiMateMappingRequest, changes *repositories.ChangeLog) (*models.ArchiMateMapping, error) {
+	// Validate required fields
+	if objectTypeID <= 0 {
+		return nil, fmt.Errorf("object_type_id is required and must be greater than 0")
+	}
+	elementType, ok := utils.ArchiMateElementType(req.ElementType)
+	if !ok {
+		return nil, fmt.Errorf("element_type %q is not an ArchiMate 3.1 element type", req.ElementType)
+	}
+
+	return s.repo.SetArchiMateMapping(objectTypeID, elementType, changes)
+}
+
+// DeleteArchiMateMapping removes the ArchiMate mapping of an object type
+func (s *EATagService) DeleteArchiMateMapping(objectTypeID int, changes *repositories.ChangeLog) error {
+	return s.repo.DeleteArchiMateMapping(objectTypeID, changes)
 }
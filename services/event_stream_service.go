@@ -0,0 +1,218 @@
+package services
+
+import (
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/repositories"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// eventSubscriberBuffer is how many events a connection may fall behind by
+// before it is dropped and has to resume with Last-Event-ID
+const eventSubscriberBuffer = 64
+
+// eventPermissionTTL is how long a connection remembers whether it may read
+// an object
+const eventPermissionTTL = 30 * time.Second
+
+// EventStreamService fans repository tree changes out to event stream
+// connections. Recent events are kept in a ring buffer so that a client that
+// reconnects with Last-Event-ID receives what it missed. The buffer is held
+// in memory, so each API instance has its own stream and event IDs are only
+// valid against the instance that issued them.
+type EventStreamService struct {
+	permissions *PermissionService
+	epoch       string
+
+	mu          sync.Mutex
+	seq         int64
+	ring        []models.StreamEvent
+	next        int
+	subscribers map[*EventSubscription]struct{}
+}
+
+// EventSubscription is one event stream connection
+type EventSubscription struct {
+	ProfileID int
+	LibraryID *uuid.UUID
+	events    chan models.StreamEvent
+	readable  map[uuid.UUID]eventPermission
+}
+
+type eventPermission struct {
+	allowed bool
+	checked time.Time
+}
+
+// NewEventStreamService creates a new EventStreamService keeping the last
+// bufferSize events for resuming clients
+func NewEventStreamService(permissions *PermissionService, bufferSize int) *EventStreamService {
+	return &EventStreamService{
+		permissions: permissions,
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		ring:        make([]models.StreamEvent, 0, bufferSize),
+		subscribers: make(map[*EventSubscription]struct{}),
+	}
+}
+
+// Events returns the channel live events are delivered on. It is closed when
+// the subscription falls too far behind and is dropped.
+func (sub *EventSubscription) Events() <-chan models.StreamEvent {
+	return sub.events
+}
+
+// Publish assigns the event its ID, stores it for resuming clients and sends
+// it to every connection watching its library. A connection that is not
+// keeping up is closed rather than allowed to block the publisher. The
+// event's IDs are sent in standard form, whichever form the caller has them in.
+func (s *EventStreamService) Publish(event models.StreamEvent) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+	event.ObjectId = streamID(event.ObjectId)
+	event.ParentId = streamIDPtr(event.ParentId)
+	event.PreviousParentId = streamIDPtr(event.PreviousParentId)
+	event.LibraryId = streamIDPtr(event.LibraryId)
+	if event.AuthorizeOn == uuid.Nil {
+		event.AuthorizeOn = event.ObjectId
+	}
+	event.AuthorizeOn = streamID(event.AuthorizeOn)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	event.Id = s.eventID(s.seq)
+	if len(s.ring) < cap(s.ring) {
+		s.ring = append(s.ring, event)
+	} else if cap(s.ring) > 0 {
+		s.ring[s.next] = event
+		s.next = (s.next + 1) % cap(s.ring)
+	}
+
+	for sub := range s.subscribers {
+		if !sub.watches(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			delete(s.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// Subscribe registers a connection and returns the buffered events after
+// lastEventID that it missed. reset is true when lastEventID cannot be
+// resumed from, either because it was issued before this instance started or
+// because the events after it have left the buffer; the client must then
+// re-fetch its tree. latestID is the ID of the most recent event.
+func (s *EventStreamService) Subscribe(profileID int, libraryID *uuid.UUID, lastEventID string) (sub *EventSubscription, missed []models.StreamEvent, reset bool, latestID string) {
+	sub = &EventSubscription{
+		ProfileID: profileID,
+		LibraryID: streamIDPtr(libraryID),
+		events:    make(chan models.StreamEvent, eventSubscriberBuffer),
+		readable:  make(map[uuid.UUID]eventPermission),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.subscribers[sub] = struct{}{}
+	latestID = s.eventID(s.seq)
+	if lastEventID == "" {
+		return sub, nil, false, latestID
+	}
+
+	seq, ok := s.parseEventID(lastEventID)
+	if !ok || seq > s.seq {
+		return sub, nil, true, latestID
+	}
+	// The oldest buffered event must directly follow the last one the client
+	// saw, or something in between has been overwritten
+	oldest := s.seq - int64(len(s.ring)) + 1
+	if seq+1 < oldest {
+		return sub, nil, true, latestID
+	}
+
+	for i := 0; i < len(s.ring); i++ {
+		event := s.ring[(s.next+i)%len(s.ring)]
+		eventSeq, _ := s.parseEventID(event.Id)
+		if eventSeq > seq && sub.watches(event) {
+			missed = append(missed, event)
+		}
+	}
+	return sub, missed, false, latestID
+}
+
+// Unsubscribe removes a connection
+func (s *EventStreamService) Unsubscribe(sub *EventSubscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subscribers[sub]; ok {
+		delete(s.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// CanRead reports whether the connection's profile may read the object the
+// event is authorized on. Answers are cached briefly per connection so that a
+// burst of changes to one folder does not query permissions for every event.
+func (s *EventStreamService) CanRead(sub *EventSubscription, event models.StreamEvent) bool {
+	if cached, ok := sub.readable[event.AuthorizeOn]; ok && time.Since(cached.checked) < eventPermissionTTL {
+		return cached.allowed
+	}
+
+	allowed := s.permissions.Authorize(event.AuthorizeOn, sub.ProfileID, models.PermissionRead) == nil
+	sub.readable[event.AuthorizeOn] = eventPermission{allowed: allowed, checked: time.Now()}
+	return allowed
+}
+
+// watches reports whether the event is in the library the connection is
+// watching
+func (sub *EventSubscription) watches(event models.StreamEvent) bool {
+	if sub.LibraryID == nil {
+		return true
+	}
+	return event.LibraryId != nil && *event.LibraryId == *sub.LibraryID
+}
+
+// streamID returns an object ID in standard form. Objects read from the
+// database carry their IDs in SQL Server's byte order, while IDs parsed from
+// URLs and query results are already standard, so every event ID is
+// normalized before it is compared or sent.
+func streamID(id uuid.UUID) uuid.UUID {
+	id, _ = repositories.TransformUUID(id)
+	return id
+}
+
+func streamIDPtr(id *uuid.UUID) *uuid.UUID {
+	if id == nil {
+		return nil
+	}
+	normalized := streamID(*id)
+	return &normalized
+}
+
+func (s *EventStreamService) eventID(seq int64) string {
+	return fmt.Sprintf("%s-%d", s.epoch, seq)
+}
+
+func (s *EventStreamService) parseEventID(id string) (int64, bool) {
+	epoch, seq, found := strings.Cut(id, "-")
+	if !found || epoch != s.epoch {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(seq, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
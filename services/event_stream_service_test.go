@@ -0,0 +1,101 @@
+package services
+
+import (
+	"enterprise-architect-api/models"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// sqlServerOrder returns id as it is scanned from a uniqueidentifier column,
+// with its first three groups byte-swapped
+func sqlServerOrder(id uuid.UUID) uuid.UUID {
+	b := id
+	b[0], b[1], b[2], b[3] = id[3], id[2], id[1], id[0]
+	b[4], b[5] = id[5], id[4]
+	b[6], b[7] = id[7], id[6]
+	return b
+}
+
+func TestEventSubscriptionLibraryFilter(t *testing.T) {
+	library := uuid.MustParse("6f1c2a3b-4d5e-4f60-8a7b-9c0d1e2f3a4b")
+	other := uuid.MustParse("0a9b8c7d-6e5f-4a3b-9c2d-1e0f9a8b7c6d")
+	raw := sqlServerOrder(library)
+	if raw.Version() == 4 {
+		t.Fatalf("test library ID %s looks standard in SQL Server byte order", library)
+	}
+
+	tests := []struct {
+		name    string
+		filter  *uuid.UUID
+		library *uuid.UUID
+		want    bool
+	}{
+		{"no filter", nil, &library, true},
+		{"no filter, no library", nil, nil, true},
+		{"standard filter, standard event", &library, &library, true},
+		{"standard filter, raw event", &library, &raw, true},
+		{"raw filter, standard event", &raw, &library, true},
+		{"raw filter, raw event", &raw, &raw, true},
+		{"other library", &library, &other, false},
+		{"event without library", &library, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewEventStreamService(nil, 8)
+			sub, _, _, _ := s.Subscribe(1, tt.filter, "")
+			defer s.Unsubscribe(sub)
+
+			s.Publish(models.StreamEvent{Type: models.StreamEventUpdate, ObjectId: uuid.New(), LibraryId: tt.library})
+
+			select {
+			case event := <-sub.Events():
+				if !tt.want {
+					t.Fatalf("received event for library %v, want none", event.LibraryId)
+				}
+				if tt.library != nil && (event.LibraryId == nil || *event.LibraryId != library) {
+					t.Errorf("event libraryId = %v, want %s", event.LibraryId, library)
+				}
+			default:
+				if tt.want {
+					t.Fatal("no event received")
+				}
+			}
+		})
+	}
+}
+
+func TestEventStreamPublishNormalizesIDs(t *testing.T) {
+	object := uuid.MustParse("3c1d2e4f-5a6b-4c7d-8e9f-0a1b2c3d4e5f")
+	parent := uuid.MustParse("7d2e3f40-6b7c-4d8e-9f0a-1b2c3d4e5f60")
+	library := uuid.MustParse("6f1c2a3b-4d5e-4f60-8a7b-9c0d1e2f3a4b")
+	rawParent := sqlServerOrder(parent)
+	rawLibrary := sqlServerOrder(library)
+
+	s := NewEventStreamService(nil, 8)
+	s.Publish(models.StreamEvent{
+		Type:      models.StreamEventCreate,
+		ObjectId:  sqlServerOrder(object),
+		ParentId:  &rawParent,
+		LibraryId: &rawLibrary,
+	})
+
+	// A client resuming from before the event receives it from the buffer
+	sub, missed, reset, _ := s.Subscribe(1, &library, s.eventID(0))
+	defer s.Unsubscribe(sub)
+	if reset || len(missed) != 1 {
+		t.Fatalf("Subscribe() missed = %d events, reset = %v, want 1 event", len(missed), reset)
+	}
+
+	event := missed[0]
+	if event.ObjectId != object {
+		t.Errorf("objectId = %s, want %s", event.ObjectId, object)
+	}
+	if event.ParentId == nil || *event.ParentId != parent {
+		t.Errorf("parentId = %v, want %s", event.ParentId, parent)
+	}
+	if event.AuthorizeOn != object {
+		t.Errorf("AuthorizeOn = %s, want %s", event.AuthorizeOn, object)
+	}
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/repositories"
+	"enterprise-architect-api/utils"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidExportRequest is returned for unusable export parameters
+var ErrInvalidExportRequest = errors.New("invalid export request")
+
+// System columns of an object export. The names match the import column
+// targets so that an export can be imported again.
+const (
+	exportColumnObjectID     = importColumnObjectID
+	exportColumnObjectName   = importColumnObjectName
+	exportColumnDescription  = importColumnDescription
+	exportColumnDateCreated  = "Date Created"
+	exportColumnDateModified = "Date Modified"
+)
+
+// ExportService writes objects and their attribute values to spreadsheets
+type ExportService struct {
+	objectRepo    *repositories.ObjectRepository
+	attributeRepo *repositories.AttributeRepository
+}
+
+// NewExportService creates a new ExportService
+func NewExportService(objectRepo *repositories.ObjectRepository, attributeRepo *repositories.AttributeRepository) *ExportService {
+	return &ExportService{objectRepo: objectRepo, attributeRepo: attributeRepo}
+}
+
+// ExportColumns validates an export and returns its columns: the system
+// columns followed by the attributes assigned to the object type that the
+// profile can read. Attributes are headed by name, or by ID when two share a
+// name, which is how the import matches columns.
+func (s *ExportService) ExportColumns(objectTypeID int, format string, profileID int) ([]models.ExportColumn, error) {
+	if objectTypeID <= 0 {
+		return nil, fmt.Errorf("%w: objectTypeId is required", ErrInvalidExportRequest)
+	}
+	if format != models.ExportFormatCSV && format != models.ExportFormatXLSX {
+		return nil, fmt.Errorf("%w: unsupported format %q, expected csv or xlsx", ErrInvalidExportRequest, format)
+	}
+
+	assignments, err := s.attributeRepo.GetAttributeAssignments(objectTypeID, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+	readable, err := s.attributeRepo.GetReadableAttributeIDs(profileID)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := []models.ExportColumn{
+		{Header: exportColumnObjectID},
+		{Header: exportColumnObjectName},
+		{Header: exportColumnDescription},
+	}
+
+	names := make(map[string]int, len(assignments))
+	seen := make(map[uuid.UUID]bool, len(assignments))
+	var attributes []models.AttributeAssignment
+	for _, a := range assignments {
+		// An attribute is listed once per attribute group it is assigned in
+		if seen[a.AttributeId] || !readable[a.AttributeId] {
+			continue
+		}
+		seen[a.AttributeId] = true
+		names[strings.ToLower(a.AttributeName)]++
+		attributes = append(attributes, a)
+	}
+	for _, a := range attributes {
+		header := a.AttributeName
+		if names[strings.ToLower(header)] > 1 || isExportSystemColumn(header) {
+			header = a.AttributeId.String()
+		}
+		columns = append(columns, models.ExportColumn{Header: header, AttributeId: a.AttributeId, AttributeType: a.AttributeType})
+	}
+
+	return append(columns,
+		models.ExportColumn{Header: exportColumnDateCreated},
+		models.ExportColumn{Header: exportColumnDateModified},
+	), nil
+}
+
+// isExportSystemColumn reports whether header would be read as a system column
+func isExportSystemColumn(header string) bool {
+	for _, name := range []string{exportColumnObjectID, exportColumnObjectName, exportColumnDescription, exportColumnDateCreated, exportColumnDateModified} {
+		if strings.EqualFold(header, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExportObjects streams the objects of a type in a library to out, one row
+// per object under a header row of the columns returned by ExportColumns
+func (s *ExportService) ExportObjects(out io.Writer, format string, columns []models.ExportColumn, objectTypeID int, libraryID uuid.UUID, profileID int) error {
+	writer, err := utils.NewSpreadsheetWriter(out, format)
+	if err != nil {
+		return err
+	}
+
+	header := make([]interface{}, len(columns))
+	for i, col := range columns {
+		header[i] = col.Header
+	}
+	if err := writer.WriteRow(header); err != nil {
+		return err
+	}
+
+	err = s.objectRepo.ExportObjects(objectTypeID, libraryID, profileID, func(obj *models.ExportedObject) error {
+		cells := make([]interface{}, len(columns))
+		for i, col := range columns {
+			cells[i] = s.exportCell(col, obj)
+		}
+		return writer.WriteRow(cells)
+	})
+	if err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+// exportCell returns the value of a column for an object, typed so that the
+// spreadsheet stores numbers, booleans and dates natively
+func (s *ExportService) exportCell(col models.ExportColumn, obj *models.ExportedObject) interface{} {
+	if col.AttributeId == uuid.Nil {
+		switch col.Header {
+		case exportColumnObjectID:
+			return obj.ObjectID.String()
+		case exportColumnObjectName:
+			return obj.ObjectName
+		case exportColumnDescription:
+			if obj.Description != nil {
+				return *obj.Description
+			}
+		case exportColumnDateCreated:
+			if obj.DateCreated != nil {
+				return *obj.DateCreated
+			}
+		case exportColumnDateModified:
+			if obj.DateModified != nil {
+				return *obj.DateModified
+			}
+		}
+		return nil
+	}
+
+	value, ok := obj.Values[col.AttributeId]
+	if !ok {
+		return nil
+	}
+	switch s.objectRepo.GetTypeId(col.AttributeType) {
+	case 1:
+		if value.IntegerValue != nil {
+			return *value.IntegerValue
+		}
+	case 2:
+		if value.DateValue != nil {
+			return *value.DateValue
+		}
+	case 3:
+		if value.FloatValue != nil {
+			return *value.FloatValue
+		}
+	case 5:
+		if value.BooleanValue != nil {
+			return *value.BooleanValue
+		}
+	case 6:
+		// Kept as stored; the import recognises RTF and stores it unchanged
+		if value.RichTextValue != nil {
+			return *value.RichTextValue
+		}
+	default:
+		if value.TextValue != nil {
+			return *value.TextValue
+		}
+	}
+	return nil
+}
@@ -4,18 +4,20 @@ import (
 	"enterprise-architect-api/models"
 	"enterprise-architect-api/repositories"
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 )
 
 // FolderService handles business logic for folders
 type FolderService struct {
-	repo *repositories.FolderRepository
+	repo    *repositories.FolderRepository
+	objects *repositories.ObjectRepository
 }
 
 // NewFolderService creates a new FolderService
-func NewFolderService(repo *repositories.FolderRepository) *FolderService {
-	return &FolderService{repo: repo}
+func NewFolderService(repo *repositories.FolderRepository, objects *repositories.ObjectRepository) *FolderService {
+	return &FolderService{repo: repo, objects: objects}
 }
 
 // GetObjectTypeFolders retrieves folders and system repositories by library ID
@@ -40,3 +42,79 @@ func (s *FolderService) GetFoldersByLibrary(folderID uuid.UUID, profileID int) (
 	return contents, nil
 }
 
+// GetFolder retrieves a folder object
+func (s *FolderService) GetFolder(id uuid.UUID) (*models.Object, error) {
+	return s.objects.GetByID(id)
+}
+
+// CreateFolder creates a folder in a folder or at the top of a library
+func (s *FolderService) CreateFolder(req models.CreateFolderRequest, userID int, changes *repositories.ChangeLog) (*models.Object, error) {
+	req.ObjectName = strings.TrimSpace(req.ObjectName)
+	if req.ObjectName == "" {
+		return nil, fmt.Errorf("%w: folder name is required", repositories.ErrInvalidFolder)
+	}
+	if req.ObjectTypeId == 0 {
+		return nil, fmt.Errorf("%w: object type ID is required", repositories.ErrInvalidFolder)
+	}
+	if req.SortOrder != nil && *req.SortOrder < 1 {
+		return nil, fmt.Errorf("%w: sort order must be at least 1", repositories.ErrInvalidFolder)
+	}
+
+	id, err := s.repo.Create(req, userID, changes)
+	if err != nil {
+		return nil, err
+	}
+	return s.objects.GetByID(id)
+}
+
+// UpdateFolder renames a folder or changes its description or AutoSort. Only
+// the checkout holder may edit a checked-out folder.
+func (s *FolderService) UpdateFolder(id uuid.UUID, req models.UpdateFolderRequest, userID int, changes *repositories.ChangeLog) (*models.Object, error) {
+	if req.ObjectName == nil && req.ObjectDescription == nil && req.AutoSort == nil {
+		return nil, fmt.Errorf("%w: at least one field must be provided for update", repositories.ErrInvalidFolder)
+	}
+	if req.ObjectName != nil {
+		name := strings.TrimSpace(*req.ObjectName)
+		if name == "" {
+			return nil, fmt.Errorf("%w: folder name cannot be empty", repositories.ErrInvalidFolder)
+		}
+		req.ObjectName = &name
+	}
+
+	if err := s.repo.Update(id, req, userID, changes); err != nil {
+		return nil, err
+	}
+	return s.objects.GetByID(id)
+}
+
+// ReorderFolder sets the order of a folder's or library's contents and
+// returns them in their new order
+func (s *FolderService) ReorderFolder(id uuid.UUID, req models.ReorderFolderRequest, profileID int, changes *repositories.ChangeLog) ([]models.FolderContent, error) {
+	if len(req.ObjectIds) == 0 {
+		return nil, fmt.Errorf("%w: objectIds is required", repositories.ErrInvalidFolder)
+	}
+	seen := make(map[uuid.UUID]bool, len(req.ObjectIds))
+	for _, objectID := range req.ObjectIds {
+		if seen[objectID] {
+			return nil, fmt.Errorf("%w: object %s is listed more than once", repositories.ErrInvalidFolder, objectID)
+		}
+		seen[objectID] = true
+	}
+
+	if err := s.repo.Reorder(id, req.ObjectIds, changes); err != nil {
+		return nil, err
+	}
+	return s.GetFoldersByLibrary(id, profileID)
+}
+
+// DeleteFolder moves a folder and everything in it to the recycle bin
+func (s *FolderService) DeleteFolder(id uuid.UUID, userID int, changes *repositories.ChangeLog) (*models.RecycleBinEntry, error) {
+	isFolder, err := s.repo.IsFolder(id)
+	if err != nil {
+		return nil, err
+	}
+	if !isFolder {
+		return nil, repositories.ErrFolderNotFound
+	}
+	return s.objects.Delete(id, userID, changes)
+}
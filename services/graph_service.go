@@ -0,0 +1,180 @@
+package services
+
+import (
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/repositories"
+	"enterprise-architect-api/utils"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidGraphRequest is returned for unusable graph export requests
+var ErrInvalidGraphRequest = errors.New("invalid graph export request")
+
+// GraphService exports libraries and impact analyses as GraphML or Graphviz
+// DOT graphs
+type GraphService struct {
+	objectRepo       *repositories.ObjectRepository
+	contentRepo      *repositories.ObjectContentRepository
+	relationshipRepo *repositories.RelationshipRepository
+	relationships    *RelationshipService
+}
+
+// NewGraphService creates a new GraphService
+func NewGraphService(objectRepo *repositories.ObjectRepository, contentRepo *repositories.ObjectContentRepository, relationshipRepo *repositories.RelationshipRepository, relationships *RelationshipService) *GraphService {
+	return &GraphService{
+		objectRepo:       objectRepo,
+		contentRepo:      contentRepo,
+		relationshipRepo: relationshipRepo,
+		relationships:    relationships,
+	}
+}
+
+// CheckFormat validates a graph format, defaulting to GraphML
+func (s *GraphService) CheckFormat(format string) (string, error) {
+	format = strings.ToLower(format)
+	switch format {
+	case "":
+		return models.GraphFormatGraphML, nil
+	case models.GraphFormatGraphML, models.GraphFormatDOT:
+		return format, nil
+	}
+	return "", fmt.Errorf("%w: format must be %s or %s", ErrInvalidGraphRequest, models.GraphFormatGraphML, models.GraphFormatDOT)
+}
+
+// CheckLibraryExport validates a library export before anything is written
+// and returns the library name and the edge kinds to export
+func (s *GraphService) CheckLibraryExport(libraryID uuid.UUID, edges string) (string, string, error) {
+	switch edges {
+	case "":
+		edges = models.GraphEdgesAll
+	case models.GraphEdgesContainment, models.GraphEdgesRelationships, models.GraphEdgesAll:
+	default:
+		return "", "", fmt.Errorf("%w: edges must be %s, %s or %s", ErrInvalidGraphRequest,
+			models.GraphEdgesContainment, models.GraphEdgesRelationships, models.GraphEdgesAll)
+	}
+	library, err := s.objectRepo.GetByID(libraryID)
+	if err != nil {
+		return "", "", err
+	}
+	return library.ObjectName, edges, nil
+}
+
+// ExportLibrary streams a library and the objects in it the profile can read
+// as nodes, with containment from ObjectContents and relationships between
+// them as edges
+func (s *GraphService) ExportLibrary(out io.Writer, format string, libraryID uuid.UUID, libraryName, edges string, profileID int) error {
+	writer, err := utils.NewGraphWriter(out, format, libraryName)
+	if err != nil {
+		return err
+	}
+
+	written := make(map[uuid.UUID]bool)
+	err = s.objectRepo.ExportGraphNodes(libraryID, profileID, func(node models.GraphNode) error {
+		written[node.ObjectId] = true
+		return writer.WriteNode(graphNode(node))
+	})
+	if err != nil {
+		return err
+	}
+
+	if edges != models.GraphEdgesRelationships {
+		err = s.contentRepo.ExportContainment(libraryID, profileID, func(edge models.ContainmentEdge) error {
+			if !written[edge.ContainerObjectId] || !written[edge.ObjectId] {
+				return nil
+			}
+			return writer.WriteEdge(utils.GraphEdge{
+				ID:     "contains-" + edge.ContainerObjectId.String() + "-" + edge.ObjectId.String(),
+				Source: edge.ContainerObjectId.String(),
+				Target: edge.ObjectId.String(),
+				Label:  "contains",
+				Kind:   models.GraphEdgesContainment,
+				Type:   strconv.Itoa(edge.ContainmentType),
+			})
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if edges != models.GraphEdgesContainment {
+		relationships, err := s.relationshipRepo.GetLibraryRelationships(libraryID, profileID)
+		if err != nil {
+			return err
+		}
+		for _, rel := range relationships {
+			if !written[rel.SourceObjectId] || !written[rel.TargetObjectId] {
+				continue
+			}
+			if err := writer.WriteEdge(relationshipEdge(models.ImpactEdge{
+				RelationshipId:   rel.RelationshipId,
+				RelationTypeId:   rel.RelationTypeId,
+				RelationTypeName: rel.RelationTypeName,
+				SourceObjectId:   rel.SourceObjectId,
+				TargetObjectId:   rel.TargetObjectId,
+			})); err != nil {
+				return err
+			}
+		}
+	}
+
+	return writer.Close()
+}
+
+// AnalyzeImpact runs the impact analysis of an object to export
+func (s *GraphService) AnalyzeImpact(objectID uuid.UUID, direction string, depth int, relationTypeIDs []uuid.UUID, profileID int) (*models.ImpactGraph, error) {
+	return s.relationships.GetImpact(objectID, direction, depth, relationTypeIDs, profileID)
+}
+
+// ExportImpact writes the result of an impact analysis as a graph
+func (s *GraphService) ExportImpact(out io.Writer, format string, graph *models.ImpactGraph) error {
+	name := "impact-" + graph.RootObjectId.String()
+	if len(graph.Nodes) > 0 {
+		name = "Impact of " + graph.Nodes[0].ObjectName
+	}
+	writer, err := utils.NewGraphWriter(out, format, name)
+	if err != nil {
+		return err
+	}
+	for _, node := range graph.Nodes {
+		if err := writer.WriteNode(graphNode(node.GraphNode)); err != nil {
+			return err
+		}
+	}
+	for _, edge := range graph.Edges {
+		if err := writer.WriteEdge(relationshipEdge(edge)); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// graphNode labels a node with the object name and type, colored with the
+// object type color
+func graphNode(node models.GraphNode) utils.GraphNode {
+	n := utils.GraphNode{ID: node.ObjectId.String(), Label: node.ObjectName}
+	if node.ObjectTypeName != nil {
+		n.Type = *node.ObjectTypeName
+	}
+	if node.Color != nil {
+		n.Color = utils.GraphColor(*node.Color)
+	}
+	return n
+}
+
+// relationshipEdge labels an edge with its relation type
+func relationshipEdge(edge models.ImpactEdge) utils.GraphEdge {
+	return utils.GraphEdge{
+		ID:     edge.RelationshipId.String(),
+		Source: edge.SourceObjectId.String(),
+		Target: edge.TargetObjectId.String(),
+		Label:  edge.RelationTypeName,
+		Kind:   models.GraphEdgesRelationships,
+		Type:   edge.RelationTypeId.String(),
+	}
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"enterprise-architect-api/config"
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/repositories"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ImportJobService queues imports and processes them in the background. Job
+// state lives in the database, so jobs interrupted by a restart are resumed
+// from their last committed chunk once their lease expires.
+type ImportJobService struct {
+	repo    *repositories.ImportJobRepository
+	imports *ImportService
+	cfg     config.ImportConfig
+	wake    chan struct{}
+}
+
+// NewImportJobService creates a new ImportJobService
+func NewImportJobService(repo *repositories.ImportJobRepository, imports *ImportService, cfg config.ImportConfig) *ImportJobService {
+	return &ImportJobService{repo: repo, imports: imports, cfg: cfg, wake: make(chan struct{}, 1)}
+}
+
+// Create queues a JSON import request
+func (s *ImportJobService) Create(req models.ObjectImportRequest, userID, profileID int) (*models.ImportJob, error) {
+	if err := s.imports.ValidateImportRequest(req); err != nil {
+		return nil, err
+	}
+	return s.enqueue(models.ImportJobPayload{Request: req}, nil, nil, userID, profileID)
+}
+
+// CreateFromSpreadsheet parses and maps an uploaded spreadsheet and queues the
+// resulting import
+func (s *ImportJobService) CreateFromSpreadsheet(req models.SpreadsheetImportRequest, fileName string, data []byte, userID, profileID int) (*models.ImportJob, error) {
+	importReq, lines, columns, err := s.imports.PrepareSpreadsheet(req, fileName, data)
+	if err != nil {
+		return nil, err
+	}
+	return s.enqueue(models.ImportJobPayload{Request: *importReq, Lines: lines}, &fileName, columns, userID, profileID)
+}
+
+func (s *ImportJobService) enqueue(payload models.ImportJobPayload, fileName *string, columns []models.ImportColumnMatch, userID, profileID int) (*models.ImportJob, error) {
+	if payload.Request.DryRun {
+		return nil, fmt.Errorf("%w: dry runs are not supported for import jobs, use the synchronous import endpoints", ErrInvalidImportRequest)
+	}
+	if len(payload.Request.Data) == 0 {
+		return nil, fmt.Errorf("%w: there are no rows to import", ErrInvalidImportRequest)
+	}
+
+	jobID, err := s.repo.Create(payload, fileName, userID, profileID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Let an idle worker pick the job up without waiting for the next poll
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	job, err := s.repo.GetByID(jobID)
+	if err != nil {
+		return nil, err
+	}
+	job.Columns = columns
+	return job, nil
+}
+
+// GetJob returns a job started by the user
+func (s *ImportJobService) GetJob(jobID uuid.UUID, userID int) (*models.ImportJob, error) {
+	job, err := s.repo.GetByID(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.CreatedBy != userID {
+		return nil, repositories.ErrImportJobNotFound
+	}
+	return job, nil
+}
+
+// Cancel stops a queued or running job started by the user. Rows imported
+// before the cancellation are kept.
+func (s *ImportJobService) Cancel(jobID uuid.UUID, userID int) (*models.ImportJob, error) {
+	if _, err := s.GetJob(jobID, userID); err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.RequestCancel(jobID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(jobID)
+}
+
+// Start launches the worker pool; workers stop when ctx is cancelled
+func (s *ImportJobService) Start(ctx context.Context) {
+	host, _ := os.Hostname()
+	for i := 0; i < s.cfg.Workers; i++ {
+		go s.worker(ctx, fmt.Sprintf("%s:%d:%d", host, os.Getpid(), i))
+	}
+}
+
+func (s *ImportJobService) worker(ctx context.Context, workerID string) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			job, err := s.repo.Claim(workerID, s.cfg.Lease)
+			if err != nil {
+				log.Printf("import worker %s: %v", workerID, err)
+				if job != nil {
+					s.finish(job.JobID, workerID, models.ImportJobFailed, err)
+				}
+				break
+			}
+			if job == nil {
+				break
+			}
+			s.run(ctx, workerID, job)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// run processes a claimed job chunk by chunk from its resume point
+func (s *ImportJobService) run(ctx context.Context, workerID string, job *repositories.ClaimedImportJob) {
+	req := job.Payload.Request
+	req.DryRun = false
+	data := req.Data
+
+	for start := job.NextRow; start < len(data); start += s.cfg.ChunkSize {
+		if ctx.Err() != nil {
+			// Left running; the lease expires and the job is resumed later
+			return
+		}
+
+		end := start + s.cfg.ChunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		chunk := req
+		chunk.Data = data[start:end]
+
+		rowNumbers := make([]int, end-start)
+		for i := range rowNumbers {
+			if job.Payload.Lines != nil {
+				rowNumbers[i] = job.Payload.Lines[start+i]
+			} else {
+				rowNumbers[i] = start + i + 1
+			}
+		}
+
+		cancelled, err := s.repo.ProcessChunk(job.JobID, workerID, chunk, rowNumbers, end, job.UserID, job.ProfileID, s.cfg.Lease)
+		if errors.Is(err, repositories.ErrImportJobLeaseLost) {
+			log.Printf("import worker %s: lost lease on job %s", workerID, job.JobID)
+			return
+		}
+		if err != nil {
+			log.Printf("import worker %s: job %s failed: %v", workerID, job.JobID, err)
+			s.finish(job.JobID, workerID, models.ImportJobFailed, err)
+			return
+		}
+		if cancelled {
+			return
+		}
+	}
+
+	s.finish(job.JobID, workerID, models.ImportJobCompleted, nil)
+}
+
+func (s *ImportJobService) finish(jobID uuid.UUID, workerID, status string, cause error) {
+	var message *string
+	if cause != nil {
+		text := cause.Error()
+		message = &text
+	}
+	if err := s.repo.Finish(jobID, workerID, status, message); err != nil {
+		log.Printf("import worker %s: error finishing job %s: %v", workerID, jobID, err)
+	}
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/repositories"
+	"enterprise-architect-api/utils"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Import validation errors
+var (
+	// ErrInvalidImportFile is returned when an uploaded spreadsheet or its
+	// column mapping cannot be used for an import
+	ErrInvalidImportFile = errors.New("invalid import file")
+	// ErrInvalidImportRequest is returned for unusable import options
+	ErrInvalidImportRequest = errors.New("invalid import request")
+)
+
+// Column targets for the system fields of an imported object
+const (
+	importColumnObjectName  = "Object Name"
+	importColumnObjectID    = "Object ID"
+	importColumnDescription = "Description"
+)
+
+// ImportService builds object imports from uploaded spreadsheets
+type ImportService struct {
+	objectRepo    *repositories.ObjectRepository
+	attributeRepo *repositories.AttributeRepository
+}
+
+// NewImportService creates a new ImportService
+func NewImportService(objectRepo *repositories.ObjectRepository, attributeRepo *repositories.AttributeRepository) *ImportService {
+	return &ImportService{objectRepo: objectRepo, attributeRepo: attributeRepo}
+}
+
+// ImportSpreadsheet parses a CSV or XLSX file, maps its columns to the object
+// type's attributes and imports the rows
+func (s *ImportService) ImportSpreadsheet(req models.SpreadsheetImportRequest, fileName string, data []byte, userID, profileID int, changes *repositories.ChangeLog) (*models.SpreadsheetImportResponse, error) {
+	importReq, lines, columns, err := s.PrepareSpreadsheet(req, fileName, data)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.objectRepo.ImportObjects(*importReq, userID, profileID, changes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Report spreadsheet line numbers rather than positions in the data
+	for i := range result.Rows {
+		result.Rows[i].Row = lines[result.Rows[i].Row-1]
+	}
+
+	return &models.SpreadsheetImportResponse{
+		ObjectImportResponse: *result,
+		Columns:              columns,
+	}, nil
+}
+
+// PrepareSpreadsheet parses a CSV or XLSX file into an import request. It also
+// returns the spreadsheet line number of each data row and how every column
+// was mapped.
+func (s *ImportService) PrepareSpreadsheet(req models.SpreadsheetImportRequest, fileName string, data []byte) (*models.ObjectImportRequest, []int, []models.ImportColumnMatch, error) {
+	if req.ObjectTypeId <= 0 {
+		return nil, nil, nil, fmt.Errorf("%w: objectTypeId is required", ErrInvalidImportFile)
+	}
+	if req.FolderId == uuid.Nil || req.LibraryId == uuid.Nil {
+		return nil, nil, nil, fmt.Errorf("%w: libraryId and folderId are required", ErrInvalidImportFile)
+	}
+	importReq := &models.ObjectImportRequest{
+		LibraryId:        req.LibraryId,
+		FolderId:         req.FolderId,
+		ObjectTypeId:     req.ObjectTypeId,
+		DryRun:           req.DryRun,
+		Locale:           req.Locale,
+		MatchBy:          req.MatchBy,
+		MatchAttributeId: req.MatchAttributeId,
+		Strategy:         req.Strategy,
+		// Date cells of a workbook hold serial day numbers
+		ExcelDates: strings.EqualFold(filepath.Ext(fileName), ".xlsx"),
+	}
+	if err := s.ValidateImportRequest(*importReq); err != nil {
+		return nil, nil, nil, err
+	}
+
+	rows, err := utils.ReadSpreadsheet(fileName, data)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+	}
+	if len(rows) < 2 {
+		return nil, nil, nil, fmt.Errorf("%w: the file must contain a header row and at least one data row", ErrInvalidImportFile)
+	}
+
+	assignments, err := s.attributeRepo.GetAttributeAssignments(req.ObjectTypeId, uuid.Nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	columns, err := matchImportColumns(rows[0], req.Mapping, assignments)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := checkImportKeyColumn(columns, *importReq); err != nil {
+		return nil, nil, nil, err
+	}
+
+	importData, lines := buildImportRows(rows[1:], columns)
+	importReq.Data = importData
+	return importReq, lines, columns, nil
+}
+
+// ValidateImportRequest checks the locale, matching key and strategy of an
+// import before any row is read
+func (s *ImportService) ValidateImportRequest(req models.ObjectImportRequest) error {
+	if _, err := utils.LookupValueLocale(req.Locale); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidImportRequest, err)
+	}
+	definitions, err := s.attributeRepo.GetAssignedAttributeDefinitions(req.ObjectTypeId)
+	if err != nil {
+		return err
+	}
+	return repositories.ValidateImportKey(req, definitions)
+}
+
+// matchImportColumns resolves every header to an import target. Headers with
+// an entry in mapping use it; the rest are matched case-insensitively against
+// "Object Name", "Object ID", "Description" and the assigned attribute names.
+func matchImportColumns(header []string, mapping map[string]string, assignments []models.AttributeAssignment) ([]models.ImportColumnMatch, error) {
+	byName := make(map[string]models.AttributeAssignment, len(assignments))
+	byID := make(map[uuid.UUID]models.AttributeAssignment, len(assignments))
+	for _, a := range assignments {
+		byName[strings.ToLower(a.AttributeName)] = a
+		byID[a.AttributeId] = a
+	}
+
+	seen := make(map[string]bool, len(header))
+	for _, h := range header {
+		seen[strings.TrimSpace(h)] = true
+	}
+	for column := range mapping {
+		if !seen[strings.TrimSpace(column)] {
+			return nil, fmt.Errorf("%w: mapped column %q is not in the file", ErrInvalidImportFile, column)
+		}
+	}
+
+	columns := make([]models.ImportColumnMatch, len(header))
+	targets := make(map[string]string, len(header))
+	for i, h := range header {
+		column := strings.TrimSpace(h)
+		match := models.ImportColumnMatch{Column: column, MatchedBy: "auto"}
+
+		target, explicit := mapping[column]
+		if explicit {
+			match.MatchedBy = "mapping"
+		} else {
+			target = column
+		}
+		target = strings.TrimSpace(target)
+
+		switch {
+		case target == "":
+			match.MatchedBy = "ignored"
+		case strings.EqualFold(target, importColumnObjectName):
+			match.Target = importColumnObjectName
+		case strings.EqualFold(target, importColumnObjectID):
+			match.Target = importColumnObjectID
+		case strings.EqualFold(target, importColumnDescription):
+			match.Target = importColumnDescription
+		default:
+			a, ok := byName[strings.ToLower(target)]
+			if !ok {
+				if id, err := uuid.Parse(target); err == nil {
+					a, ok = byID[id]
+				}
+			}
+			if !ok {
+				if explicit {
+					return nil, fmt.Errorf("%w: column %q is mapped to %q, which is not an attribute of this object type", ErrInvalidImportFile, column, target)
+				}
+				match.MatchedBy = "ignored"
+				break
+			}
+			id, attrType := a.AttributeId.String(), a.AttributeType
+			match.Target = a.AttributeName
+			match.AttributeId = &id
+			match.AttributeType = &attrType
+		}
+
+		if match.Target != "" {
+			if other, dup := targets[match.Target]; dup {
+				return nil, fmt.Errorf("%w: columns %q and %q both map to %q", ErrInvalidImportFile, other, column, match.Target)
+			}
+			targets[match.Target] = column
+		}
+		columns[i] = match
+	}
+	return columns, nil
+}
+
+// checkImportKeyColumn makes sure a column holds the key rows are matched by:
+// "Object Name", "Object ID" or the matching attribute
+func checkImportKeyColumn(columns []models.ImportColumnMatch, req models.ObjectImportRequest) error {
+	target := importColumnObjectName
+	switch req.MatchBy {
+	case models.ImportMatchByObjectID:
+		target = importColumnObjectID
+	case models.ImportMatchByExternalID, models.ImportMatchByAutoID:
+		attrID, _ := uuid.Parse(strings.TrimSpace(*req.MatchAttributeId))
+		for _, col := range columns {
+			if col.AttributeId != nil && *col.AttributeId == attrID.String() {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: no column is mapped to the matching attribute %s", ErrInvalidImportFile, attrID)
+	}
+
+	for _, col := range columns {
+		if col.Target == target {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: no column is mapped to %q", ErrInvalidImportFile, target)
+}
+
+// buildImportRows converts spreadsheet rows into import rows keyed by target,
+// along with the spreadsheet line number of each. Blank lines are skipped and
+// blank attribute and description cells are left out so that they do not
+// overwrite existing values.
+func buildImportRows(rows [][]string, columns []models.ImportColumnMatch) ([]map[string]models.ObjectImportRow, []int) {
+	data := make([]map[string]models.ObjectImportRow, 0, len(rows))
+	lines := make([]int, 0, len(rows))
+	for n, cells := range rows {
+		row := make(map[string]models.ObjectImportRow)
+		blank := true
+		for i, col := range columns {
+			if col.Target == "" || i >= len(cells) {
+				continue
+			}
+			value := strings.TrimSpace(cells[i])
+			if value != "" {
+				blank = false
+			} else if col.AttributeId != nil || col.Target == importColumnDescription {
+				continue
+			}
+
+			target := col.Target
+			row[target] = models.ObjectImportRow{
+				AttributeId:    col.AttributeId,
+				AttributeName:  &target,
+				AttributeType:  col.AttributeType,
+				AttributeValue: &value,
+			}
+		}
+		if !blank {
+			data = append(data, row)
+			// +2 for the header line and 1-based numbering
+			lines = append(lines, n+2)
+		}
+	}
+	return data, lines
+}
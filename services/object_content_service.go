@@ -4,7 +4,6 @@ import (
 	"enterprise-architect-api/models"
 	"enterprise-architect-api/repositories"
 	"fmt"
-	"math"
 
 	"github.com/google/uuid"
 )
@@ -43,32 +42,16 @@ func (s *ObjectContentService) GetObjectContentByID(id int) (*models.ObjectConte
 }
 
 // GetAllObjectContents retrieves all object contents with pagination
-func (s *ObjectContentService) GetAllObjectContents(page, pageSize int) (*models.PaginatedResponse, error) {
-	// Set default pagination values
-	if page <= 0 {
-		page = 1
-	}
-	if pageSize <= 0 {
-		pageSize = 10
-	}
-	if pageSize > 100 {
-		pageSize = 100
-	}
+func (s *ObjectContentService) GetAllObjectContents(req models.PageRequest) (*models.PaginatedResponse, error) {
+	req = pageDefaults(req, 10, 100)
 
-	objectContents, totalCount, err := s.repo.GetAll(page, pageSize)
+	objectContents, info, err := s.repo.GetAll(req)
 	if err != nil {
 		return nil, err
 	}
 
-	totalPages := int(math.Ceil(float64(totalCount) / float64(pageSize)))
-
-	return &models.PaginatedResponse{
-		Data:       objectContents,
-		Page:       page,
-		PageSize:   pageSize,
-		TotalCount: totalCount,
-		TotalPages: totalPages,
-	}, nil
+	response := paginatedResponse(req, objectContents, info)
+	return &response, nil
 }
 
 // UpdateObjectContent updates an existing object content
@@ -3,28 +3,50 @@ package services
 import (
 	"enterprise-architect-api/models"
 	"enterprise-architect-api/repositories"
+	"enterprise-architect-api/utils"
+	"errors"
 	"fmt"
 	"math"
+	"strings"
 
 	"github.com/google/uuid"
 )
 
+// ErrLibraryNotAllowed is returned when a profile may not create libraries
+var ErrLibraryNotAllowed = errors.New("profile may not create libraries")
+
 // ObjectService handles business logic for objects
 type ObjectService struct {
-	repo *repositories.ObjectRepository
+	repo          *repositories.ObjectRepository
+	libraryAdmins map[int]bool
 }
 
-func (s *ObjectService) GetObjectsByObjectTypeIDAndLibraryID(objectTypeID int, libraryID uuid.UUID, page int, pageSize int) ([]models.Object, int, error) {
-	return s.repo.GetByObjectTypeIDAndLibraryID(objectTypeID, libraryID, page, pageSize)
+// GetObjectsByObjectTypeIDAndLibraryID retrieves the readable objects of a type
+// in a library that match the query
+func (s *ObjectService) GetObjectsByObjectTypeIDAndLibraryID(objectTypeID int, libraryID uuid.UUID, q models.ObjectListQuery, profileID int) (*models.ObjectListResponse, error) {
+	q = objectListDefaults(q)
+	objects, info, facets, err := s.repo.GetByObjectTypeIDAndLibraryID(objectTypeID, libraryID, q, profileID)
+	if err != nil {
+		return nil, err
+	}
+	return objectListResponse(q, objects, info, facets), nil
 }
 
-// NewObjectService creates a new ObjectService
-func NewObjectService(repo *repositories.ObjectRepository) *ObjectService {
-	return &ObjectService{repo: repo}
+// NewObjectService creates a new ObjectService. Only the library admin
+// profiles may create libraries.
+func NewObjectService(repo *repositories.ObjectRepository, libraryAdminProfiles []int) *ObjectService {
+	libraryAdmins := make(map[int]bool, len(libraryAdminProfiles))
+	for _, profileID := range libraryAdminProfiles {
+		libraryAdmins[profileID] = true
+	}
+	return &ObjectService{repo: repo, libraryAdmins: libraryAdmins}
 }
 
 // CreateObject creates a new object
-func (s *ObjectService) CreateObject(req models.CreateObjectRequest) (*models.Object, error) {
+func (s *ObjectService) CreateObject(req models.CreateObjectRequest, profileID int, changes *repositories.ChangeLog) (*models.Object, error) {
+	if req.IsLibrary && !s.libraryAdmins[profileID] {
+		return nil, ErrLibraryNotAllowed
+	}
 	// Validate required fields
 	if req.ObjectName == "" {
 		return nil, fmt.Errorf("object name is required")
@@ -39,7 +61,7 @@ func (s *ObjectService) CreateObject(req models.CreateObjectRequest) (*models.Ob
 		return nil, fmt.Errorf("created by is required")
 	}
 
-	return s.repo.Create(req)
+	return s.repo.Create(req, changes)
 }
 
 // GetObjectByID retrieves an object by its ID
@@ -47,37 +69,32 @@ func (s *ObjectService) GetObjectByID(id uuid.UUID) (*models.Object, error) {
 	return s.repo.GetByID(id)
 }
 
-// GetAllObjects retrieves all objects with pagination
-func (s *ObjectService) GetAllObjects(page, pageSize int) (*models.PaginatedResponse, error) {
-	// Set default pagination values
-	if page <= 0 {
-		page = 1
-	}
-	if pageSize <= 0 {
-		pageSize = 10
-	}
-	if pageSize > 100 {
-		pageSize = 100
-	}
-
-	objects, totalCount, err := s.repo.GetAll(page, pageSize)
+// GetAllObjects retrieves the objects readable by the profile that match the
+// query, with pagination and facets
+func (s *ObjectService) GetAllObjects(q models.ObjectListQuery, profileID int) (*models.ObjectListResponse, error) {
+	q = objectListDefaults(q)
+	objects, info, facets, err := s.repo.GetAll(q, profileID)
 	if err != nil {
 		return nil, err
 	}
+	return objectListResponse(q, objects, info, facets), nil
+}
 
-	totalPages := int(math.Ceil(float64(totalCount) / float64(pageSize)))
+// objectListDefaults applies the default and maximum page size
+func objectListDefaults(q models.ObjectListQuery) models.ObjectListQuery {
+	q.PageRequest = pageDefaults(q.PageRequest, 10, 100)
+	return q
+}
 
-	return &models.PaginatedResponse{
-		Data:       objects,
-		Page:       page,
-		PageSize:   pageSize,
-		TotalCount: totalCount,
-		TotalPages: totalPages,
-	}, nil
+func objectListResponse(q models.ObjectListQuery, objects []models.Object, info models.PageInfo, facets []models.Facet) *models.ObjectListResponse {
+	return &models.ObjectListResponse{
+		PaginatedResponse: paginatedResponse(q.PageRequest, objects, info),
+		Facets:            facets,
+	}
 }
 
 // UpdateObject updates an existing object
-func (s *ObjectService) UpdateObject(id uuid.UUID, req models.UpdateObjectRequest) (*models.Object, error) {
+func (s *ObjectService) UpdateObject(id uuid.UUID, req models.UpdateObjectRequest, changes *repositories.ChangeLog) (*models.Object, error) {
 	// Validate that at least one field is being updated
 	if req.ObjectName == nil && req.ObjectDescription == nil && req.ObjectTypeID == nil &&
 		req.ExactObjectTypeID == nil && req.RichTextDescription == nil && req.IsLibrary == nil &&
@@ -89,16 +106,33 @@ func (s *ObjectService) UpdateObject(id uuid.UUID, req models.UpdateObjectReques
 		return nil, fmt.Errorf("modified by is required")
 	}
 
-	return s.repo.Update(id, req)
+	// The repository checks under a row lock that only the checkout holder
+	// edits a checked-out object
+	return s.repo.Update(id, req, changes)
 }
 
-// DeleteObject deletes an object by its ID
-func (s *ObjectService) DeleteObject(id uuid.UUID) error {
-	return s.repo.Delete(id)
+// DeleteObject moves an object and its folder tree to the recycle bin
+func (s *ObjectService) DeleteObject(id uuid.UUID, userID int, changes *repositories.ChangeLog) (*models.RecycleBinEntry, error) {
+	return s.repo.Delete(id, userID, changes)
 }
 
-// GetLibraries retrieves all objects where IsLibrary is true
-func (s *ObjectService) GetLibraries(page, pageSize int) (*models.PaginatedResponse, error) {
+// MoveObject moves an object, with everything in its folder tree, into
+// another folder or library
+func (s *ObjectService) MoveObject(id uuid.UUID, req models.MoveObjectRequest, userID, profileID int, changes *repositories.ChangeLog) (*models.ObjectPlacementResult, error) {
+	return s.repo.Move(id, req, userID, profileID, changes)
+}
+
+// CopyObject copies an object into a folder or library. A deep copy also
+// copies its attribute values and the readable objects in its folder tree.
+func (s *ObjectService) CopyObject(id uuid.UUID, req models.CopyObjectRequest, userID, profileID int, changes *repositories.ChangeLog) (*models.ObjectPlacementResult, error) {
+	if req.ObjectName != nil && strings.TrimSpace(*req.ObjectName) == "" {
+		return nil, fmt.Errorf("%w: object name cannot be empty", repositories.ErrInvalidPlacement)
+	}
+	return s.repo.Copy(id, req, userID, profileID, changes)
+}
+
+// GetLibraries retrieves all readable objects where IsLibrary is true
+func (s *ObjectService) GetLibraries(page, pageSize, profileID int) (*models.PaginatedResponse, error) {
 	// Set default pagination values
 	if page <= 0 {
 		page = 1
@@ -110,7 +144,7 @@ func (s *ObjectService) GetLibraries(page, pageSize int) (*models.PaginatedRespo
 		pageSize = 100
 	}
 
-	objects, totalCount, err := s.repo.GetLibraries(page, pageSize)
+	objects, totalCount, err := s.repo.GetLibraries(page, pageSize, profileID)
 	if err != nil {
 		return nil, err
 	}
@@ -126,38 +160,23 @@ func (s *ObjectService) GetLibraries(page, pageSize int) (*models.PaginatedRespo
 	}, nil
 }
 
-// GetObjectsByTypeID retrieves all objects by ObjectTypeID
-func (s *ObjectService) GetObjectsByTypeID(objectTypeID, page, pageSize int) (*models.PaginatedResponse, error) {
-	// Set default pagination values
-	if page <= 0 {
-		page = 1
-	}
-	if pageSize <= 0 {
-		pageSize = 10
-	}
-	if pageSize > 100 {
-		pageSize = 100
-	}
-
-	objects, totalCount, err := s.repo.GetByObjectTypeID(objectTypeID, page, pageSize)
+// GetObjectsByTypeID retrieves the readable objects with an ObjectTypeID that
+// match the query
+func (s *ObjectService) GetObjectsByTypeID(objectTypeID int, q models.ObjectListQuery, profileID int) (*models.ObjectListResponse, error) {
+	q = objectListDefaults(q)
+	objects, info, facets, err := s.repo.GetByObjectTypeID(objectTypeID, q, profileID)
 	if err != nil {
 		return nil, err
 	}
-
-	totalPages := int(math.Ceil(float64(totalCount) / float64(pageSize)))
-
-	return &models.PaginatedResponse{
-		Data:       objects,
-		Page:       page,
-		PageSize:   pageSize,
-		TotalCount: totalCount,
-		TotalPages: totalPages,
-	}, nil
+	return objectListResponse(q, objects, info, facets), nil
 }
 
 func (s *ObjectService) GetHierarchyFolder(ObjectID uuid.UUID, profileID int, isFolder bool) ([]models.ObjectTree, error) {
 	return s.repo.GetHierarchyFolderV2(ObjectID, profileID, isFolder)
 }
-func (s *ObjectService) ImportObjects(req models.ObjectImportRequest) (*models.ObjectImportResponse, error) {
-	return s.repo.ImportObjects(req)
+func (s *ObjectService) ImportObjects(req models.ObjectImportRequest, userID, profileID int, changes *repositories.ChangeLog) (*models.ObjectImportResponse, error) {
+	if _, err := utils.LookupValueLocale(req.Locale); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImportRequest, err)
+	}
+	return s.repo.ImportObjects(req, userID, profileID, changes)
 }
@@ -20,13 +20,13 @@ func NewObjectTypeService(repo *repositories.ObjectTypeRepository) *ObjectTypeSe
 }
 
 // CreateObjectType creates a new object type
-func (s *ObjectTypeService) CreateObjectType(req models.CreateObjectTypeRequest) (*models.ObjectType, error) {
+func (s *ObjectTypeService) CreateObjectType(req models.CreateObjectTypeRequest, changes *repositories.ChangeLog) (*models.ObjectType, error) {
 	// Validate required fields
 	if req.CreatedBy == 0 {
 		return nil, fmt.Errorf("created by is required")
 	}
 
-	return s.repo.Create(req)
+	return s.repo.Create(req, changes)
 }
 
 // GetObjectTypeByID retrieves an object type by its ID
@@ -93,7 +93,7 @@ func (s *ObjectTypeService) SearchObjectTypesByName(name string, page, pageSize
 }
 
 // UpdateObjectType updates an existing object type
-func (s *ObjectTypeService) UpdateObjectType(id int, req models.UpdateObjectTypeRequest) (*models.ObjectType, error) {
+func (s *ObjectTypeService) UpdateObjectType(id int, req models.UpdateObjectTypeRequest, changes *repositories.ChangeLog) (*models.ObjectType, error) {
 	// Validate that at least one field is being updated
 	if req.ObjectTypeName == nil && req.Description == nil && req.FileExtension == nil &&
 		req.IsTemplateType == nil && req.ActiveType == nil {
@@ -104,7 +104,7 @@ func (s *ObjectTypeService) UpdateObjectType(id int, req models.UpdateObjectType
 		return nil, fmt.Errorf("modified by is required")
 	}
 
-	return s.repo.Update(id, req)
+	return s.repo.Update(id, req, changes)
 }
 
 // GetFolderRepositoryTree retrieves the hierarchy of object types
@@ -115,22 +115,22 @@ func (s *ObjectTypeService) GetFolderRepositoryTree() ([]models.ObjectTypeHierar
 // GetBaseLibrary retrieves the base library of object types
 
 // AddFolderToTree adds a new folder to the folder hierarchy tree
-func (s *ObjectTypeService) AddFolderToTree(req models.AddFolderToTreeRequest) (*uuid.UUID, error) {
+func (s *ObjectTypeService) AddFolderToTree(req models.AddFolderToTreeRequest, userID int, changes *repositories.ChangeLog) (*uuid.UUID, error) {
 	// Validate: if FolderObjectTypeId is 0, ObjectTypeName must be provided
 	if req.FolderObjectTypeId == 0 && req.ObjectTypeName == "" {
 		return nil, fmt.Errorf("object type name is required when creating a new object type")
 	}
 
-	return s.repo.AddFolderToTree(req)
+	return s.repo.AddFolderToTree(req, userID, changes)
 }
 
 // DeleteObjectType deletes an object type by its ID
-func (s *ObjectTypeService) DeleteObjectType(id int) error {
-	return s.repo.Delete(id)
+func (s *ObjectTypeService) DeleteObjectType(id int, changes *repositories.ChangeLog) error {
+	return s.repo.Delete(id, changes)
 }
 
 // AssignObjectTypeToFolder assigns an object type to a folder type setting
-func (s *ObjectTypeService) AssignObjectTypeToFolder(req models.FolderObjectTypes) error {
+func (s *ObjectTypeService) AssignObjectTypeToFolder(req models.FolderObjectTypes, changes *repositories.ChangeLog) error {
 	// Validate required fields
 	if req.FolderObjectTypeId == 0 {
 		return fmt.Errorf("folder object type ID is required")
@@ -139,7 +139,7 @@ func (s *ObjectTypeService) AssignObjectTypeToFolder(req models.FolderObjectType
 		return fmt.Errorf("object type ID is required")
 	}
 
-	return s.repo.AssignObjectTypeToFolder(req)
+	return s.repo.AssignObjectTypeToFolder(req, changes)
 }
 
 // GetAvailableTypesForFolder retrieves available object types for a specific folder
@@ -160,7 +160,7 @@ func (s *ObjectTypeService) GetAvailableTypesForLibsAndFolder(folderObjectTypeId
 }
 
 // DeleteObjectTypeFromFolder removes an object type assignment from a folder
-func (s *ObjectTypeService) DeleteObjectTypeFromFolder(folderObjectTypeId, objectTypeId int) error {
+func (s *ObjectTypeService) DeleteObjectTypeFromFolder(folderObjectTypeId, objectTypeId int, changes *repositories.ChangeLog) error {
 	if folderObjectTypeId == 0 {
 		return fmt.Errorf("folder object type ID is required")
 	}
@@ -168,7 +168,7 @@ func (s *ObjectTypeService) DeleteObjectTypeFromFolder(folderObjectTypeId, objec
 		return fmt.Errorf("object type ID is required")
 	}
 
-	return s.repo.DeleteObjectTypeFromFolder(folderObjectTypeId, objectTypeId)
+	return s.repo.DeleteObjectTypeFromFolder(folderObjectTypeId, objectTypeId, changes)
 }
 func (s *ObjectTypeService) GetBaseLibrary() ([]models.ObjectTypeHierarchy, error) {
 	return s.repo.GetBaseLibrary()
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"enterprise-architect-api/models"
+	"math"
+)
+
+// pageDefaults fills in the page number and size of a request, capping the
+// size at maxPageSize
+func pageDefaults(req models.PageRequest, defaultPageSize, maxPageSize int) models.PageRequest {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = defaultPageSize
+	}
+	if req.PageSize > maxPageSize {
+		req.PageSize = maxPageSize
+	}
+	return req
+}
+
+// paginatedResponse builds the response for a page read by a repository.
+// TotalPages is -1, like TotalCount, when the count was skipped.
+func paginatedResponse(req models.PageRequest, data interface{}, info models.PageInfo) models.PaginatedResponse {
+	totalPages := -1
+	if info.TotalCount >= 0 {
+		totalPages = int(math.Ceil(float64(info.TotalCount) / float64(req.PageSize)))
+	}
+	return models.PaginatedResponse{
+		Data:       data,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalCount: info.TotalCount,
+		TotalPages: totalPages,
+		NextCursor: info.NextCursor,
+		PrevCursor: info.PrevCursor,
+	}
+}
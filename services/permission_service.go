@@ -0,0 +1,67 @@
+package services
+
+import (
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/repositories"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// PermissionDeniedError is returned when a profile lacks a permission on an object
+type PermissionDeniedError struct {
+	ObjectID  uuid.UUID
+	ProfileID int
+	Action    string
+}
+
+func (e *PermissionDeniedError) Error() string {
+	return fmt.Sprintf("profile %d does not have %s permission on object %s", e.ProfileID, e.Action, e.ObjectID)
+}
+
+// PermissionService checks ObjectPermissions before object operations
+type PermissionService struct {
+	repo *repositories.PermissionRepository
+}
+
+// NewPermissionService creates a new PermissionService
+func NewPermissionService(repo *repositories.PermissionRepository) *PermissionService {
+	return &PermissionService{repo: repo}
+}
+
+// GetEffectivePermission retrieves the profile's effective permissions on an object
+func (s *PermissionService) GetEffectivePermission(objectID uuid.UUID, profileID int) (*models.ObjectPermission, error) {
+	return s.repo.GetEffectivePermission(objectID, profileID)
+}
+
+// Authorize returns a PermissionDeniedError unless the profile may perform action on the object
+func (s *PermissionService) Authorize(objectID uuid.UUID, profileID int, action string) error {
+	perm, err := s.repo.GetEffectivePermission(objectID, profileID)
+	if err != nil {
+		return err
+	}
+
+	if !allows(perm, action) {
+		return &PermissionDeniedError{ObjectID: objectID, ProfileID: profileID, Action: action}
+	}
+
+	return nil
+}
+
+// allows reports whether perm grants the given action
+func allows(perm *models.ObjectPermission, action string) bool {
+	switch action {
+	case models.PermissionRead:
+		return perm.HasRead
+	case models.PermissionModify:
+		return perm.HasModify
+	case models.PermissionDelete:
+		return perm.HasDelete
+	case models.PermissionModifyContents:
+		return perm.HasModifyContents
+	case models.PermissionModifyRelationships:
+		return perm.HasModifyRelationships
+	default:
+		return false
+	}
+}
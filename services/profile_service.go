@@ -18,7 +18,7 @@ func NewProfileService(repo *repositories.ProfileRepository) *ProfileService {
 }
 
 // CreateProfile creates a new profile
-func (s *ProfileService) CreateProfile(req models.CreateProfileRequest) (*models.Profile, error) {
+func (s *ProfileService) CreateProfile(req models.CreateProfileRequest, changes *repositories.ChangeLog) (*models.Profile, error) {
 	// Validate required fields
 	if req.ProfileName == "" {
 		return nil, fmt.Errorf("profile name is required")
@@ -27,7 +27,7 @@ func (s *ProfileService) CreateProfile(req models.CreateProfileRequest) (*models
 		return nil, fmt.Errorf("created by is required")
 	}
 
-	return s.repo.Create(req)
+	return s.repo.Create(req, changes)
 }
 
 // GetProfileByID retrieves a profile by its ID
@@ -65,7 +65,7 @@ func (s *ProfileService) GetAllProfiles(page, pageSize int) (*models.PaginatedRe
 }
 
 // UpdateProfile updates an existing profile
-func (s *ProfileService) UpdateProfile(id int, req models.UpdateProfileRequest) (*models.Profile, error) {
+func (s *ProfileService) UpdateProfile(id int, req models.UpdateProfileRequest, changes *repositories.ChangeLog) (*models.Profile, error) {
 	// Validate that at least one field is being updated
 	if req.ProfileName == nil && req.ProfileDescription == nil && req.PortalStartPageId == nil {
 		return nil, fmt.Errorf("at least one field must be provided for update")
@@ -75,11 +75,11 @@ func (s *ProfileService) UpdateProfile(id int, req models.UpdateProfileRequest)
 		return nil, fmt.Errorf("modified by is required")
 	}
 
-	return s.repo.Update(id, req)
+	return s.repo.Update(id, req, changes)
 }
 
 // DeleteProfile deletes a profile by its ID
-func (s *ProfileService) DeleteProfile(id int) error {
-	return s.repo.Delete(id)
+func (s *ProfileService) DeleteProfile(id int, changes *repositories.ChangeLog) error {
+	return s.repo.Delete(id, changes)
 }
 
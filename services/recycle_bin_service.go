@@ -0,0 +1,82 @@
+package services
+
+import (
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/repositories"
+
+	"github.com/google/uuid"
+)
+
+// RecycleBinService handles business logic for listing, restoring and
+// purging deleted objects. Each operation is authorized against the root
+// object of the delete transaction.
+type RecycleBinService struct {
+	repo *repositories.RecycleBinRepository
+}
+
+// NewRecycleBinService creates a new RecycleBinService
+func NewRecycleBinService(repo *repositories.RecycleBinRepository) *RecycleBinService {
+	return &RecycleBinService{repo: repo}
+}
+
+// List retrieves a page of the delete transactions the profile can read
+func (s *RecycleBinService) List(req models.PageRequest, profileID int) (*models.PaginatedResponse, error) {
+	req = pageDefaults(req, 20, 100)
+
+	entries, info, err := s.repo.List(req, profileID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := paginatedResponse(req, entries, info)
+	return &response, nil
+}
+
+// Get retrieves a delete transaction with the objects it deleted
+func (s *RecycleBinService) Get(transactionID uuid.UUID, profileID int) (*models.RecycleBinEntryDetail, error) {
+	entry, err := s.authorize(transactionID, profileID, models.PermissionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := s.repo.GetObjects(transactionID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.RecycleBinEntryDetail{RecycleBinEntry: *entry, Objects: objects}, nil
+}
+
+// Restore undeletes the objects of a delete transaction, returning the
+// transaction and how many objects were restored
+func (s *RecycleBinService) Restore(transactionID uuid.UUID, userID, profileID int, changes *repositories.ChangeLog) (*models.RecycleBinEntry, int, error) {
+	entry, err := s.authorize(transactionID, profileID, models.PermissionDelete)
+	if err != nil {
+		return nil, 0, err
+	}
+	count, err := s.repo.Restore(transactionID, userID, changes)
+	return entry, count, err
+}
+
+// Purge permanently deletes the objects of a delete transaction, returning
+// the transaction and how many objects were purged
+func (s *RecycleBinService) Purge(transactionID uuid.UUID, profileID int, changes *repositories.ChangeLog) (*models.RecycleBinEntry, int, error) {
+	entry, err := s.authorize(transactionID, profileID, models.PermissionDelete)
+	if err != nil {
+		return nil, 0, err
+	}
+	count, err := s.repo.Purge(transactionID, changes)
+	return entry, count, err
+}
+
+// authorize retrieves a delete transaction and returns a PermissionDeniedError
+// unless the profile may perform action on its root object
+func (s *RecycleBinService) authorize(transactionID uuid.UUID, profileID int, action string) (*models.RecycleBinEntry, error) {
+	entry, perm, err := s.repo.Get(transactionID, profileID)
+	if err != nil {
+		return nil, err
+	}
+	if !allows(perm, action) {
+		return nil, &PermissionDeniedError{ObjectID: entry.RootObjectId, ProfileID: profileID, Action: action}
+	}
+	return entry, nil
+}
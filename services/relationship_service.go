@@ -0,0 +1,289 @@
+package services
+
+import (
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/repositories"
+	"enterprise-architect-api/utils"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidRelationship is returned for unusable relation type or relationship requests
+var ErrInvalidRelationship = errors.New("invalid relationship request")
+
+// Impact analysis depth limits
+const (
+	defaultImpactDepth = 3
+	maxImpactDepth     = 10
+)
+
+// RelationshipService handles relation types and the relationships between objects
+type RelationshipService struct {
+	repo          *repositories.RelationshipRepository
+	attributeRepo *repositories.AttributeRepository
+	objectRepo    *repositories.ObjectRepository
+}
+
+// NewRelationshipService creates a new RelationshipService
+func NewRelationshipService(repo *repositories.RelationshipRepository, attributeRepo *repositories.AttributeRepository, objectRepo *repositories.ObjectRepository) *RelationshipService {
+	return &RelationshipService{repo: repo, attributeRepo: attributeRepo, objectRepo: objectRepo}
+}
+
+// GetRelationTypes retrieves all relation types
+func (s *RelationshipService) GetRelationTypes() ([]models.RelationType, error) {
+	return s.repo.GetRelationTypes()
+}
+
+// GetRelationType retrieves a relation type
+func (s *RelationshipService) GetRelationType(id uuid.UUID) (*models.RelationType, error) {
+	return s.repo.GetRelationType(id)
+}
+
+// CreateRelationType creates a relation type
+func (s *RelationshipService) CreateRelationType(req models.RelationTypeRequest, userID int) (*models.RelationType, error) {
+	if err := validateRelationType(&req); err != nil {
+		return nil, err
+	}
+	return s.repo.CreateRelationType(req, userID)
+}
+
+// UpdateRelationType replaces a relation type's settings and rules
+func (s *RelationshipService) UpdateRelationType(id uuid.UUID, req models.RelationTypeRequest, userID int) (*models.RelationType, error) {
+	if err := validateRelationType(&req); err != nil {
+		return nil, err
+	}
+	return s.repo.UpdateRelationType(id, req, userID)
+}
+
+// DeleteRelationType deletes a relation type that no relationship uses
+func (s *RelationshipService) DeleteRelationType(id uuid.UUID) error {
+	return s.repo.DeleteRelationType(id)
+}
+
+// GetRelationTypeAttributes retrieves the attributes assigned to a relation
+// type. Attributes are assigned with the attribute assignment endpoints,
+// passing relationTypeId instead of objectTypeId.
+func (s *RelationshipService) GetRelationTypeAttributes(id uuid.UUID) ([]models.AttributeAssignment, error) {
+	if _, err := s.repo.GetRelationType(id); err != nil {
+		return nil, err
+	}
+	assignments, err := s.attributeRepo.GetAttributeAssignments(0, id)
+	if err != nil {
+		return nil, err
+	}
+	if assignments == nil {
+		assignments = []models.AttributeAssignment{}
+	}
+	return assignments, nil
+}
+
+// validateRelationType checks a relation type request, trimming its name and
+// normalizing its ArchiMate type and rules
+func validateRelationType(req *models.RelationTypeRequest) error {
+	req.RelationTypeName = strings.TrimSpace(req.RelationTypeName)
+	if req.RelationTypeName == "" {
+		return fmt.Errorf("%w: relationTypeName is required", ErrInvalidRelationship)
+	}
+	if req.ArchiMateType != nil {
+		if strings.TrimSpace(*req.ArchiMateType) == "" {
+			req.ArchiMateType = nil
+		} else {
+			archiMateType, ok := utils.ArchiMateRelationshipType(*req.ArchiMateType)
+			if !ok {
+				return fmt.Errorf("%w: archiMateType %q is not an ArchiMate 3.1 relationship type", ErrInvalidRelationship, *req.ArchiMateType)
+			}
+			req.ArchiMateType = &archiMateType
+		}
+	}
+	if len(req.Rules) == 0 {
+		return fmt.Errorf("%w: at least one rule with a source and target object type is required", ErrInvalidRelationship)
+	}
+
+	seen := make(map[models.RelationTypeRule]bool, len(req.Rules))
+	rules := make([]models.RelationTypeRule, 0, len(req.Rules))
+	for _, rule := range req.Rules {
+		if rule.SourceObjectTypeId <= 0 || rule.TargetObjectTypeId <= 0 {
+			return fmt.Errorf("%w: rules need a sourceObjectTypeId and targetObjectTypeId greater than 0", ErrInvalidRelationship)
+		}
+		if !seen[rule] {
+			seen[rule] = true
+			rules = append(rules, rule)
+		}
+	}
+	req.Rules = rules
+	return nil
+}
+
+// GetRelationship retrieves a relationship with the attribute values the profile can read
+func (s *RelationshipService) GetRelationship(id uuid.UUID, profileID int) (*models.Relationship, error) {
+	relationship, err := s.repo.GetRelationship(id)
+	if err != nil {
+		return nil, err
+	}
+	relationships := []models.Relationship{*relationship}
+	if err := s.filterReadableValues(relationships, profileID); err != nil {
+		return nil, err
+	}
+	return &relationships[0], nil
+}
+
+// GetObjectRelationships retrieves the relationships of an object in a direction
+func (s *RelationshipService) GetObjectRelationships(objectID uuid.UUID, direction string, relationTypeID *uuid.UUID, profileID int) ([]models.Relationship, error) {
+	if direction == "" {
+		direction = models.RelationshipDirectionBoth
+	}
+	switch direction {
+	case models.RelationshipDirectionOutgoing, models.RelationshipDirectionIncoming, models.RelationshipDirectionBoth:
+	default:
+		return nil, fmt.Errorf("%w: direction must be outgoing, incoming or both", ErrInvalidRelationship)
+	}
+
+	relationships, err := s.repo.GetObjectRelationships(objectID, direction, relationTypeID, profileID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.filterReadableValues(relationships, profileID); err != nil {
+		return nil, err
+	}
+	return relationships, nil
+}
+
+// filterReadableValues drops attribute values the profile cannot read
+func (s *RelationshipService) filterReadableValues(relationships []models.Relationship, profileID int) error {
+	readable, err := s.attributeRepo.GetReadableAttributeIDs(profileID)
+	if err != nil {
+		return err
+	}
+	for i := range relationships {
+		values := relationships[i].Attributes[:0]
+		for _, value := range relationships[i].Attributes {
+			if readable[value.AttributeID] {
+				values = append(values, value)
+			}
+		}
+		relationships[i].Attributes = values
+	}
+	return nil
+}
+
+// CreateRelationship creates a relationship between two objects
+func (s *RelationshipService) CreateRelationship(req models.CreateRelationshipRequest, userID, profileID int, changes *repositories.ChangeLog) (*models.Relationship, error) {
+	if req.RelationTypeId == uuid.Nil || req.SourceObjectId == uuid.Nil || req.TargetObjectId == uuid.Nil {
+		return nil, fmt.Errorf("%w: relationTypeId, sourceObjectId and targetObjectId are required", ErrInvalidRelationship)
+	}
+	if req.SourceObjectId == req.TargetObjectId {
+		return nil, fmt.Errorf("%w: an object cannot be related to itself", ErrInvalidRelationship)
+	}
+	if _, err := s.repo.GetRelationType(req.RelationTypeId); err != nil {
+		return nil, err
+	}
+	attrs, err := s.resolveValues(req.RelationTypeId, req.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	req.Attributes = attrs
+
+	id, err := s.repo.CreateRelationship(req, userID, changes)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetRelationship(id, profileID)
+}
+
+// UpdateRelationship updates the description and attribute values of a relationship
+func (s *RelationshipService) UpdateRelationship(id uuid.UUID, req models.UpdateRelationshipRequest, userID, profileID int, changes *repositories.ChangeLog) (*models.Relationship, error) {
+	relationship, err := s.repo.GetRelationship(id)
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := s.resolveValues(relationship.RelationTypeId, req.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	req.Attributes = attrs
+
+	if err := s.repo.UpdateRelationship(id, req, userID, changes); err != nil {
+		return nil, err
+	}
+	return s.GetRelationship(id, profileID)
+}
+
+// DeleteRelationship deletes a relationship
+func (s *RelationshipService) DeleteRelationship(id uuid.UUID, changes *repositories.ChangeLog) error {
+	return s.repo.DeleteRelationship(id, changes)
+}
+
+// resolveValues checks attribute values against the attributes assigned to
+// a relation type and fills in their names and types
+func (s *RelationshipService) resolveValues(relationTypeID uuid.UUID, attrs []models.AssignedAttribute) ([]models.AssignedAttribute, error) {
+	if len(attrs) == 0 {
+		return nil, nil
+	}
+	assignments, err := s.attributeRepo.GetAttributeAssignments(0, relationTypeID)
+	if err != nil {
+		return nil, err
+	}
+	assigned := make(map[uuid.UUID]models.AttributeAssignment, len(assignments))
+	for _, a := range assignments {
+		assigned[a.AttributeId] = a
+	}
+
+	resolved := make([]models.AssignedAttribute, 0, len(attrs))
+	for _, attr := range attrs {
+		a, ok := assigned[attr.AttributeID]
+		if !ok {
+			return nil, fmt.Errorf("%w: attribute %s is not assigned to this relation type", ErrInvalidRelationship, attr.AttributeID)
+		}
+		if typeID := assignedValueType(attr); typeID != 0 && typeID != s.objectRepo.GetTypeId(a.AttributeType) {
+			return nil, fmt.Errorf("%w: attribute %q takes a %s value", ErrInvalidRelationship, a.AttributeName, a.AttributeType)
+		}
+		attr.AttributeName = a.AttributeName
+		attr.AttributeType = a.AttributeType
+		resolved = append(resolved, attr)
+	}
+	return resolved, nil
+}
+
+// assignedValueType returns the DataType code of the value field set in
+// attr, or 0 when no value is set
+func assignedValueType(attr models.AssignedAttribute) int64 {
+	switch {
+	case attr.IntegerValue != nil:
+		return 1
+	case attr.DateValue != nil:
+		return 2
+	case attr.FloatValue != nil:
+		return 3
+	case attr.TextValue != nil:
+		return 4
+	case attr.BooleanValue != nil:
+		return 5
+	case attr.RichTextValue != nil:
+		return 6
+	}
+	return 0
+}
+
+// GetImpact walks the relationships of an object to find the objects it
+// affects (down), the objects that affect it (up), or both. A depth of 0
+// uses the default.
+func (s *RelationshipService) GetImpact(objectID uuid.UUID, direction string, depth int, relationTypeIDs []uuid.UUID, profileID int) (*models.ImpactGraph, error) {
+	if direction == "" {
+		direction = models.ImpactDirectionDown
+	}
+	switch direction {
+	case models.ImpactDirectionUp, models.ImpactDirectionDown, models.ImpactDirectionBoth:
+	default:
+		return nil, fmt.Errorf("%w: direction must be up, down or both", ErrInvalidRelationship)
+	}
+	if depth == 0 {
+		depth = defaultImpactDepth
+	}
+	if depth < 1 || depth > maxImpactDepth {
+		return nil, fmt.Errorf("%w: depth must be between 1 and %d", ErrInvalidRelationship, maxImpactDepth)
+	}
+	return s.repo.GetImpact(objectID, direction, depth, relationTypeIDs, profileID)
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/repositories"
+	"enterprise-architect-api/utils"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidSearch is returned for unusable search requests
+var ErrInvalidSearch = errors.New("invalid search")
+
+const (
+	// searchMaxTerms is the number of query terms searched for
+	searchMaxTerms = 10
+	// searchCandidateLimit is the number of objects ranked per search
+	searchCandidateLimit = 500
+	// searchSnippetRadius is the number of characters shown around a match
+	searchSnippetRadius = 60
+)
+
+// Score of a term found in each field. A name that equals the query scores
+// searchExactNameScore on top.
+const (
+	searchExactNameScore   = 100
+	searchNameScore        = 30
+	searchNamePrefixScore  = 20
+	searchDescriptionScore = 10
+	searchRichTextScore    = 8
+	searchAttributeScore   = 5
+)
+
+// SearchService handles business logic for object search
+type SearchService struct {
+	repo *repositories.SearchRepository
+}
+
+// NewSearchService creates a new SearchService
+func NewSearchService(repo *repositories.SearchRepository) *SearchService {
+	return &SearchService{repo: repo}
+}
+
+// Search finds the objects the profile can read that contain every term of
+// the query, ranked by where the terms were found, with highlighted snippets
+// of the matching fields
+func (s *SearchService) Search(query string, filter models.SearchFilter, page, pageSize, profileID int) (*models.SearchResponse, error) {
+	terms := utils.SearchTerms(query, searchMaxTerms)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("%w: q is required", ErrInvalidSearch)
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	candidates, err := s.repo.FindSearchCandidates(terms, filter, profileID, searchCandidateLimit+1)
+	if err != nil {
+		return nil, err
+	}
+	truncated := len(candidates) > searchCandidateLimit
+	if truncated {
+		candidates = candidates[:searchCandidateLimit]
+	}
+
+	normalizedQuery := strings.Join(terms, " ")
+	results := make([]models.SearchResult, 0)
+	for _, c := range candidates {
+		if result, ok := rankCandidate(c, terms, normalizedQuery); ok {
+			results = append(results, result)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ObjectName < results[j].ObjectName
+	})
+
+	response := &models.SearchResponse{
+		Query:      query,
+		Data:       []models.SearchResult{},
+		Page:       page,
+		PageSize:   pageSize,
+		TotalCount: len(results),
+		TotalPages: int(math.Ceil(float64(len(results)) / float64(pageSize))),
+		Truncated:  truncated,
+	}
+	if start := (page - 1) * pageSize; start < len(results) {
+		end := start + pageSize
+		if end > len(results) {
+			end = len(results)
+		}
+		response.Data = results[start:end]
+	}
+	return response, nil
+}
+
+// rankCandidate scores a candidate and collects its matches. It reports false
+// when the candidate does not contain every term.
+func rankCandidate(c models.SearchCandidate, terms []string, normalizedQuery string) (models.SearchResult, bool) {
+	result := models.SearchResult{
+		ObjectId:       c.ObjectId,
+		ObjectName:     c.ObjectName,
+		ObjectTypeId:   c.ObjectTypeId,
+		ObjectTypeName: c.ObjectTypeName,
+		LibraryId:      c.LibraryId,
+		Matches:        []models.SearchMatch{},
+	}
+	found := make([]bool, len(terms))
+
+	match := func(field, text string, weight int) *models.SearchMatch {
+		spans, foundTerms := utils.MatchTerms(text, terms)
+		if len(spans) == 0 {
+			return nil
+		}
+		for i, ok := range foundTerms {
+			if ok {
+				found[i] = true
+				result.Score += weight
+			}
+		}
+		snippet, highlights := utils.Snippet(text, spans, searchSnippetRadius)
+		m := models.SearchMatch{Field: field, Snippet: snippet, Highlights: make([]models.SearchHighlight, len(highlights))}
+		for i, h := range highlights {
+			m.Highlights[i] = models.SearchHighlight{Start: h.Start, End: h.End}
+		}
+		result.Matches = append(result.Matches, m)
+		return &result.Matches[len(result.Matches)-1]
+	}
+
+	normalizedName := utils.NormalizeSearchText(c.ObjectName)
+	nameWeight := searchNameScore
+	if strings.HasPrefix(normalizedName, terms[0]) {
+		nameWeight += searchNamePrefixScore
+	}
+	if match(models.SearchFieldName, c.ObjectName, nameWeight) != nil && strings.Join(strings.Fields(normalizedName), " ") == normalizedQuery {
+		result.Score += searchExactNameScore
+	}
+
+	description := ""
+	if c.ObjectDescription != nil {
+		description = *c.ObjectDescription
+		match(models.SearchFieldDescription, description, searchDescriptionScore)
+	}
+	if c.RichTextDescription != nil {
+		// The plain description usually repeats the rich text description
+		richText := utils.StripRTF(*c.RichTextDescription)
+		if utils.NormalizeSearchText(richText) != utils.NormalizeSearchText(description) {
+			match(models.SearchFieldRichText, richText, searchRichTextScore)
+		}
+	}
+
+	for _, value := range c.Attributes {
+		text := value.Value
+		if value.RichText {
+			text = utils.StripRTF(text)
+		}
+		if m := match(models.SearchFieldAttribute, text, searchAttributeScore); m != nil {
+			attributeID, attributeName := value.AttributeId, value.AttributeName
+			m.AttributeId, m.AttributeName = &attributeID, &attributeName
+		}
+	}
+
+	for _, ok := range found {
+		if !ok {
+			return result, false
+		}
+	}
+	return result, true
+}
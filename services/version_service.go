@@ -0,0 +1,186 @@
+package services
+
+import (
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/repositories"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Checkout workflow errors
+var (
+	ErrAlreadyCheckedOut       = repositories.ErrCheckedOut
+	ErrNotCheckedOut           = repositories.ErrNotCheckedOut
+	ErrCheckedOutByAnotherUser = repositories.ErrCheckedOutByOther
+	ErrPendingApproval         = repositories.ErrPendingApproval
+)
+
+// VersionService handles the check-out / check-in workflow for objects
+type VersionService struct {
+	repo       *repositories.VersionRepository
+	objectRepo *repositories.ObjectRepository
+}
+
+// NewVersionService creates a new VersionService
+func NewVersionService(repo *repositories.VersionRepository, objectRepo *repositories.ObjectRepository) *VersionService {
+	return &VersionService{repo: repo, objectRepo: objectRepo}
+}
+
+// CheckOut creates a new working version of the object held by the user
+func (s *VersionService) CheckOut(objectID uuid.UUID, userID int, changes *repositories.ChangeLog) (*models.Object, error) {
+	// The repository checks the object's state under a row lock
+	if err := s.repo.CheckOut(objectID, userID, changes); err != nil {
+		return nil, err
+	}
+
+	return s.objectRepo.GetByID(objectID)
+}
+
+// CheckIn promotes the user's working version to the checked-in version. For
+// governed object types the version is submitted for approval instead.
+func (s *VersionService) CheckIn(objectID uuid.UUID, userID, profileID int, req models.CheckInRequest, changes *repositories.ChangeLog) (*models.Object, error) {
+	if req.Reason == "" {
+		return nil, fmt.Errorf("check-in reason is required")
+	}
+
+	if err := s.repo.CheckIn(objectID, userID, profileID, req.Reason, changes); err != nil {
+		return nil, err
+	}
+
+	return s.objectRepo.GetByID(objectID)
+}
+
+// UndoCheckOut discards the user's working version
+func (s *VersionService) UndoCheckOut(objectID uuid.UUID, userID int, changes *repositories.ChangeLog) (*models.Object, error) {
+	if err := s.repo.UndoCheckOut(objectID, userID, changes); err != nil {
+		return nil, err
+	}
+
+	return s.objectRepo.GetByID(objectID)
+}
+
+// RestoreVersion creates a new checked-in version copied from a historical one
+// and makes it current. The object must not be checked out. For governed object
+// types the restored version is submitted for approval.
+func (s *VersionService) RestoreVersion(objectID, versionID uuid.UUID, userID, profileID int, changes *repositories.ChangeLog) (*models.Object, error) {
+	// The repository checks the object's state under a row lock
+	if _, err := s.repo.Restore(objectID, versionID, userID, profileID, changes); err != nil {
+		return nil, err
+	}
+
+	return s.objectRepo.GetByID(objectID)
+}
+
+// ListVersions retrieves the version history of an object
+func (s *VersionService) ListVersions(objectID uuid.UUID) ([]models.ObjectVersion, error) {
+	return s.repo.ListVersions(objectID)
+}
+
+// DiffVersions compares two versions of an object, returning only what changed
+func (s *VersionService) DiffVersions(objectID, fromID, toID uuid.UUID, profileID int) (*models.VersionDiff, error) {
+	from, err := s.repo.GetVersion(objectID, fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.repo.GetVersion(objectID, toID)
+	if err != nil {
+		return nil, err
+	}
+
+	fromAttrs, err := s.repo.GetVersionAttributeValues(objectID, fromID, profileID)
+	if err != nil {
+		return nil, err
+	}
+	toAttrs, err := s.repo.GetVersionAttributeValues(objectID, toID, profileID)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &models.VersionDiff{
+		ObjectID:   objectID,
+		From:       *from,
+		To:         *to,
+		Fields:     []models.VersionFieldChange{},
+		Attributes: []models.VersionAttributeChange{},
+	}
+
+	fields := []struct {
+		name     string
+		from, to *string
+	}{
+		{"objectName", &from.ObjectName, &to.ObjectName},
+		{"objectDescription", from.ObjectDescription, to.ObjectDescription},
+		{"richTextDescription", from.RichTextDescription, to.RichTextDescription},
+	}
+	for _, f := range fields {
+		if !equalStringPtr(f.from, f.to) {
+			diff.Fields = append(diff.Fields, models.VersionFieldChange{Field: f.name, From: f.from, To: f.to})
+		}
+	}
+
+	toByID := make(map[uuid.UUID]models.AssignedAttribute, len(toAttrs))
+	for _, attr := range toAttrs {
+		toByID[attr.AttributeID] = attr
+	}
+	for _, before := range fromAttrs {
+		after, ok := toByID[before.AttributeID]
+		if !ok {
+			diff.Attributes = append(diff.Attributes, attributeChange(before, "removed", attributeValue(before), nil))
+			continue
+		}
+		delete(toByID, before.AttributeID)
+		if oldValue, newValue := attributeValue(before), attributeValue(after); oldValue != newValue {
+			diff.Attributes = append(diff.Attributes, attributeChange(after, "modified", oldValue, newValue))
+		}
+	}
+	for _, after := range toAttrs {
+		if _, ok := toByID[after.AttributeID]; ok {
+			diff.Attributes = append(diff.Attributes, attributeChange(after, "added", nil, attributeValue(after)))
+		}
+	}
+
+	return diff, nil
+}
+
+// attributeChange builds a VersionAttributeChange for the given attribute
+func attributeChange(attr models.AssignedAttribute, changeType string, from, to interface{}) models.VersionAttributeChange {
+	return models.VersionAttributeChange{
+		AttributeID:   attr.AttributeID,
+		AttributeName: attr.AttributeName,
+		AttributeType: attr.AttributeType,
+		ChangeType:    changeType,
+		From:          from,
+		To:            to,
+	}
+}
+
+// attributeValue returns the typed value held by an attribute value row, with
+// dates formatted as RFC 3339 so values compare with ==
+func attributeValue(attr models.AssignedAttribute) interface{} {
+	switch {
+	case attr.TextValue != nil:
+		return *attr.TextValue
+	case attr.RichTextValue != nil:
+		return *attr.RichTextValue
+	case attr.BooleanValue != nil:
+		return *attr.BooleanValue
+	case attr.IntegerValue != nil:
+		return *attr.IntegerValue
+	case attr.FloatValue != nil:
+		return *attr.FloatValue
+	case attr.DateValue != nil:
+		return attr.DateValue.UTC().Format(time.RFC3339)
+	default:
+		return nil
+	}
+}
+
+// equalStringPtr reports whether two optional strings hold the same value
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,378 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"enterprise-architect-api/config"
+	"enterprise-architect-api/models"
+	"enterprise-architect-api/repositories"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrWebhookNotAllowed is returned when a profile may not manage webhooks
+	ErrWebhookNotAllowed = errors.New("profile may not manage webhooks")
+	// ErrInvalidWebhook is returned for unusable webhook subscriptions and
+	// delivery filters
+	ErrInvalidWebhook = errors.New("invalid webhook")
+)
+
+// Webhook request headers
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookEventIDHeader   = "X-Webhook-Event-Id"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// webhookEntityTypes are the entity types delivered to webhooks
+var webhookEntityTypes = map[string]bool{
+	models.WebhookEntityObject:         true,
+	models.WebhookEntityAttributeValue: true,
+	models.WebhookEntityRelationship:   true,
+}
+
+// minWebhookSecret is the shortest secret accepted from a client
+const minWebhookSecret = 16
+
+// webhookBatchSize is the number of deliveries a worker claims at a time
+const webhookBatchSize = 10
+
+// WebhookService manages webhook subscriptions and delivers the events that
+// repositories queue in the outbox in the background. Events are queued in
+// the transaction of their change and deliveries live in the database, so an
+// event is delivered if and only if its change was saved, even across a
+// restart.
+type WebhookService struct {
+	repo   *repositories.WebhookRepository
+	cfg    config.WebhookConfig
+	admins map[int]bool
+	client *http.Client
+	wake   chan struct{}
+}
+
+// NewWebhookService creates a new WebhookService. Only the configured admin
+// profiles may manage subscriptions.
+func NewWebhookService(repo *repositories.WebhookRepository, cfg config.WebhookConfig) *WebhookService {
+	admins := make(map[int]bool, len(cfg.AdminProfiles))
+	for _, profileID := range cfg.AdminProfiles {
+		admins[profileID] = true
+	}
+	return &WebhookService{
+		repo:   repo,
+		cfg:    cfg,
+		admins: admins,
+		client: &http.Client{Timeout: cfg.Timeout},
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// CreateSubscription creates a subscription. The response carries the
+// secret, which is not returned again.
+func (s *WebhookService) CreateSubscription(req models.WebhookSubscriptionRequest, userID, profileID int) (*models.WebhookSubscription, error) {
+	if !s.admins[profileID] {
+		return nil, ErrWebhookNotAllowed
+	}
+	if req.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("error generating webhook secret: %w", err)
+		}
+		req.Secret = hex.EncodeToString(secret)
+	}
+	sub := &models.WebhookSubscription{IsActive: true, CreatedBy: userID}
+	if err := applyWebhookRequest(sub, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateSubscription(sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// GetSubscription retrieves a subscription without its secret
+func (s *WebhookService) GetSubscription(id uuid.UUID, profileID int) (*models.WebhookSubscription, error) {
+	if !s.admins[profileID] {
+		return nil, ErrWebhookNotAllowed
+	}
+	sub, err := s.repo.GetSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+	sub.Secret = ""
+	return sub, nil
+}
+
+// ListSubscriptions retrieves every subscription without its secret
+func (s *WebhookService) ListSubscriptions(profileID int) ([]models.WebhookSubscription, error) {
+	if !s.admins[profileID] {
+		return nil, ErrWebhookNotAllowed
+	}
+	subs, err := s.repo.ListSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+	for i := range subs {
+		subs[i].Secret = ""
+	}
+	return subs, nil
+}
+
+// UpdateSubscription replaces the URL and filters of a subscription, and its
+// secret and state when given
+func (s *WebhookService) UpdateSubscription(id uuid.UUID, req models.WebhookSubscriptionRequest, profileID int) (*models.WebhookSubscription, error) {
+	if !s.admins[profileID] {
+		return nil, ErrWebhookNotAllowed
+	}
+	sub, err := s.repo.GetSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Secret == "" {
+		req.Secret = sub.Secret
+	}
+	if err := applyWebhookRequest(sub, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateSubscription(sub); err != nil {
+		return nil, err
+	}
+	if sub.IsActive {
+		s.notify()
+	}
+	sub.Secret = ""
+	return sub, nil
+}
+
+// DeleteSubscription deletes a subscription with its deliveries
+func (s *WebhookService) DeleteSubscription(id uuid.UUID, profileID int) error {
+	if !s.admins[profileID] {
+		return ErrWebhookNotAllowed
+	}
+	return s.repo.DeleteSubscription(id)
+}
+
+// ListDeliveries retrieves a page of deliveries, newest first
+func (s *WebhookService) ListDeliveries(q models.WebhookDeliveryQuery, profileID int) (*models.PaginatedResponse, error) {
+	if !s.admins[profileID] {
+		return nil, ErrWebhookNotAllowed
+	}
+	switch q.Status {
+	case "", models.WebhookDeliveryPending, models.WebhookDeliveryDelivered, models.WebhookDeliveryDead:
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidWebhook, q.Status)
+	}
+	if q.SubscriptionId != nil {
+		if _, err := s.repo.GetSubscription(*q.SubscriptionId); err != nil {
+			return nil, err
+		}
+	}
+	q.PageRequest = pageDefaults(q.PageRequest, 20, 100)
+
+	deliveries, info, err := s.repo.ListDeliveries(q)
+	if err != nil {
+		return nil, err
+	}
+
+	response := paginatedResponse(q.PageRequest, deliveries, info)
+	return &response, nil
+}
+
+// Redeliver queues a delivered or dead-lettered delivery to be sent again
+func (s *WebhookService) Redeliver(id uuid.UUID, profileID int) (*models.WebhookDelivery, error) {
+	if !s.admins[profileID] {
+		return nil, ErrWebhookNotAllowed
+	}
+	if err := s.repo.Redeliver(id); err != nil {
+		return nil, err
+	}
+	s.notify()
+	return s.repo.GetDelivery(id)
+}
+
+// notify lets an idle worker pick up new deliveries without waiting for the
+// next poll
+func (s *WebhookService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start launches the delivery workers; workers stop when ctx is cancelled
+func (s *WebhookService) Start(ctx context.Context) {
+	host, _ := os.Hostname()
+	for i := 0; i < s.cfg.Workers; i++ {
+		go s.worker(ctx, fmt.Sprintf("%s:%d:webhook:%d", host, os.Getpid(), i))
+	}
+}
+
+func (s *WebhookService) worker(ctx context.Context, workerID string) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	// A lease outlives every attempt in a batch, so that an interrupted
+	// worker's deliveries are retried by another once it expires
+	lease := s.cfg.Timeout*webhookBatchSize + time.Minute
+
+	for {
+		for ctx.Err() == nil {
+			deliveries, err := s.repo.ClaimDeliveries(workerID, lease, webhookBatchSize)
+			if err != nil {
+				log.Printf("webhook worker %s: %v", workerID, err)
+				break
+			}
+			if len(deliveries) == 0 {
+				break
+			}
+			for _, delivery := range deliveries {
+				s.attempt(ctx, workerID, delivery)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// attempt sends a claimed delivery once and records the outcome: delivered on
+// a 2xx response, otherwise retried with exponential backoff until the
+// attempts run out and it is dead-lettered
+func (s *WebhookService) attempt(ctx context.Context, workerID string, delivery repositories.ClaimedWebhookDelivery) {
+	statusCode, err := s.send(ctx, delivery)
+	if ctx.Err() != nil {
+		// Shutting down; the lease expires and the delivery is sent later
+		return
+	}
+
+	status, retryIn := models.WebhookDeliveryDelivered, time.Duration(0)
+	var message *string
+	var code *int
+	if statusCode != 0 {
+		code = &statusCode
+	}
+	if err != nil {
+		text := err.Error()
+		message = &text
+		status, retryIn = models.WebhookDeliveryPending, webhookBackoff(s.cfg, delivery.Attempts+1)
+		if delivery.Attempts+1 >= s.cfg.MaxAttempts {
+			status, retryIn = models.WebhookDeliveryDead, 0
+		}
+	}
+
+	held, err := s.repo.RecordAttempt(delivery.DeliveryId, workerID, status, code, message, retryIn)
+	if err != nil {
+		log.Printf("webhook worker %s: delivery %s: %v", workerID, delivery.DeliveryId, err)
+		return
+	}
+	if !held {
+		log.Printf("webhook worker %s: lost lease on delivery %s", workerID, delivery.DeliveryId)
+	}
+}
+
+// send posts a delivery's payload to its subscription URL, returning the
+// response status, if any, and an error unless the status is 2xx
+func (s *WebhookService) send(ctx context.Context, delivery repositories.ClaimedWebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("error creating request: %w", err)
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "enterprise-architect-api-webhooks")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookEventIDHeader, delivery.EventId.String())
+	req.Header.Set(WebhookDeliveryHeader, delivery.DeliveryId.String())
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhookPayload returns the signature header of a payload sent at
+// timestamp (Unix seconds): "sha256=" and the hex HMAC-SHA256, keyed with the
+// subscription secret, of the timestamp, a dot and the payload
+func SignWebhookPayload(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff is the delay after the given number of failed attempts: the
+// base backoff doubled for every attempt after the first, up to the maximum
+func webhookBackoff(cfg config.WebhookConfig, attempts int) time.Duration {
+	delay := cfg.Backoff
+	for i := 1; i < attempts && delay < cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > cfg.MaxBackoff {
+		delay = cfg.MaxBackoff
+	}
+	return delay
+}
+
+// applyWebhookRequest validates a subscription request and copies it onto
+// sub
+func applyWebhookRequest(sub *models.WebhookSubscription, req models.WebhookSubscriptionRequest) error {
+	u, err := url.Parse(req.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+	if len(req.Url) > 2000 {
+		return fmt.Errorf("%w: url is longer than 2000 characters", ErrInvalidWebhook)
+	}
+	if len(req.Secret) < minWebhookSecret || len(req.Secret) > 200 {
+		return fmt.Errorf("%w: secret must be %d to 200 characters", ErrInvalidWebhook, minWebhookSecret)
+	}
+	seen := make(map[string]bool)
+	entityTypes := []string{}
+	for _, entityType := range req.EntityTypes {
+		if !webhookEntityTypes[entityType] {
+			return fmt.Errorf("%w: unknown entity type %q", ErrInvalidWebhook, entityType)
+		}
+		if !seen[entityType] {
+			seen[entityType] = true
+			entityTypes = append(entityTypes, entityType)
+		}
+	}
+
+	sub.Url = req.Url
+	sub.Secret = req.Secret
+	sub.EntityTypes = entityTypes
+	sub.ObjectTypeId = req.ObjectTypeId
+	sub.LibraryId = req.LibraryId
+	if req.IsActive != nil {
+		sub.IsActive = *req.IsActive
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"enterprise-architect-api/config"
+	"enterprise-architect-api/repositories"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	payload := []byte(`{"eventType":"object.update"}`)
+	mac := hmac.New(sha256.New, []byte("0123456789abcdef"))
+	mac.Write([]byte("1700000000." + string(payload)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := SignWebhookPayload("0123456789abcdef", 1700000000, payload); got != want {
+		t.Errorf("SignWebhookPayload() = %q, want %q", got, want)
+	}
+	if got := SignWebhookPayload("0123456789abcdef", 1700000001, payload); got == want {
+		t.Error("SignWebhookPayload() with another timestamp gave the same signature")
+	}
+	if got := SignWebhookPayload("fedcba9876543210", 1700000000, payload); got == want {
+		t.Error("SignWebhookPayload() with another secret gave the same signature")
+	}
+}
+
+func TestWebhookSend(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{"ok", http.StatusOK, false},
+		{"no content", http.StatusNoContent, false},
+		{"redirect", http.StatusFound, true},
+		{"client error", http.StatusGone, true},
+		{"server error", http.StatusInternalServerError, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delivery := repositories.ClaimedWebhookDelivery{
+				DeliveryId: uuid.New(),
+				EventId:    uuid.New(),
+				EventType:  "object.update",
+				Payload:    []byte(`{"entityId":"1"}`),
+				Secret:     "0123456789abcdef",
+			}
+
+			var got *http.Request
+			var body []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r
+				body, _ = io.ReadAll(r.Body)
+				w.Header().Set("Location", "/elsewhere")
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+			delivery.Url = server.URL
+
+			s := NewWebhookService(nil, config.WebhookConfig{Timeout: 5 * time.Second})
+			s.client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+			before := time.Now().Unix()
+			status, err := s.send(context.Background(), delivery)
+			after := time.Now().Unix()
+
+			if status != tt.status {
+				t.Errorf("send() status = %d, want %d", status, tt.status)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("send() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got == nil {
+				t.Fatal("receiver got no request")
+			}
+
+			timestamp, err := strconv.ParseInt(got.Header.Get(WebhookTimestampHeader), 10, 64)
+			if err != nil || timestamp < before || timestamp > after {
+				t.Errorf("%s = %q, want a Unix time between %d and %d",
+					WebhookTimestampHeader, got.Header.Get(WebhookTimestampHeader), before, after)
+			}
+			if want := SignWebhookPayload(delivery.Secret, timestamp, body); got.Header.Get(WebhookSignatureHeader) != want {
+				t.Errorf("%s = %q, want %q", WebhookSignatureHeader, got.Header.Get(WebhookSignatureHeader), want)
+			}
+			if string(body) != string(delivery.Payload) {
+				t.Errorf("body = %s, want %s", body, delivery.Payload)
+			}
+			if got.Header.Get(WebhookEventHeader) != delivery.EventType ||
+				got.Header.Get(WebhookEventIDHeader) != delivery.EventId.String() ||
+				got.Header.Get(WebhookDeliveryHeader) != delivery.DeliveryId.String() {
+				t.Errorf("headers = %v, want the event type, event ID and delivery ID", got.Header)
+			}
+		})
+	}
+}
+
+func TestWebhookSendUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	s := NewWebhookService(nil, config.WebhookConfig{Timeout: 5 * time.Second})
+	status, err := s.send(context.Background(), repositories.ClaimedWebhookDelivery{Url: url, Secret: "0123456789abcdef"})
+	if status != 0 || err == nil {
+		t.Errorf("send() = %d, %v, want 0 and an error", status, err)
+	}
+}
+
+func TestWebhookAttemptOnShutdown(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	// Without a repository, recording the attempt would panic; a delivery
+	// interrupted by shutdown is left to its lease instead
+	s := NewWebhookService(nil, config.WebhookConfig{Timeout: 5 * time.Second, MaxAttempts: 3})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.attempt(ctx, "worker", repositories.ClaimedWebhookDelivery{DeliveryId: uuid.New(), Url: server.URL})
+
+	if requests != 0 {
+		t.Errorf("receiver got %d requests, want none", requests)
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	cfg := config.WebhookConfig{Backoff: 30 * time.Second, MaxBackoff: 10 * time.Minute}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{5, 8 * time.Minute},
+		{6, 10 * time.Minute},
+		{7, 10 * time.Minute},
+		{100, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.attempts), func(t *testing.T) {
+			if got := webhookBackoff(cfg, tt.attempts); got != tt.want {
+				t.Errorf("webhookBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+			}
+		})
+	}
+
+	capped := config.WebhookConfig{Backoff: time.Hour, MaxBackoff: 10 * time.Minute}
+	if got := webhookBackoff(capped, 1); got != 10*time.Minute {
+		t.Errorf("webhookBackoff() with backoff above the maximum = %v, want %v", got, 10*time.Minute)
+	}
+}
@@ -0,0 +1,269 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ArchiMateNamespace is the namespace of the ArchiMate 3.x Open Exchange Format
+const ArchiMateNamespace = "http://www.opengroup.org/xsd/archimate/3.0/"
+
+const archiMateSchemaLocation = "http://www.opengroup.org/xsd/archimate/3.0/ http://www.opengroup.org/xsd/archimate/3.1/archimate3_Diagram.xsd"
+
+// archiMateElementTypes lists the element types of ArchiMate 3.1
+var archiMateElementTypes = []string{
+	// Strategy
+	"Resource", "Capability", "ValueStream", "CourseOfAction",
+	// Business
+	"BusinessActor", "BusinessRole", "BusinessCollaboration", "BusinessInterface", "BusinessProcess",
+	"BusinessFunction", "BusinessInteraction", "BusinessEvent", "BusinessService", "BusinessObject",
+	"Contract", "Representation", "Product",
+	// Application
+	"ApplicationComponent", "ApplicationCollaboration", "ApplicationInterface", "ApplicationFunction",
+	"ApplicationInteraction", "ApplicationProcess", "ApplicationEvent", "ApplicationService", "DataObject",
+	// Technology and physical
+	"Node", "Device", "SystemSoftware", "TechnologyCollaboration", "TechnologyInterface", "Path",
+	"CommunicationNetwork", "TechnologyFunction", "TechnologyProcess", "TechnologyInteraction",
+	"TechnologyEvent", "TechnologyService", "Artifact", "Equipment", "Facility", "DistributionNetwork", "Material",
+	// Motivation
+	"Stakeholder", "Driver", "Assessment", "Goal", "Outcome", "Principle", "Requirement", "Constraint",
+	"Meaning", "Value",
+	// Implementation and migration
+	"WorkPackage", "Deliverable", "ImplementationEvent", "Plateau", "Gap",
+	// Other
+	"Grouping", "Location",
+}
+
+// archiMateRelationshipTypes lists the relationship types of ArchiMate 3.1
+var archiMateRelationshipTypes = []string{
+	"Composition", "Aggregation", "Assignment", "Realization", "Serving", "Access",
+	"Influence", "Triggering", "Flow", "Specialization", "Association",
+}
+
+// ArchiMateElementType returns the canonical spelling of an ArchiMate 3.1
+// element type, matched case-insensitively
+func ArchiMateElementType(name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	for _, t := range archiMateElementTypes {
+		if strings.EqualFold(t, name) {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+// ArchiMateRelationshipType returns the canonical spelling of an ArchiMate
+// 3.1 relationship type, matched case-insensitively
+func ArchiMateRelationshipType(name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	for _, t := range archiMateRelationshipTypes {
+		if strings.EqualFold(t, name) {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+// ArchiMateLangString is a text in one language, such as a name or a
+// property value
+type ArchiMateLangString struct {
+	Lang  string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	Value string `xml:",chardata"`
+}
+
+// ArchiMateProperty is a property value of an element or relationship
+type ArchiMateProperty struct {
+	DefinitionRef string                `xml:"propertyDefinitionRef,attr"`
+	Values        []ArchiMateLangString `xml:"value"`
+}
+
+// ArchiMateElement is an element of an exchange file. Type holds the
+// xsi:type, such as "ApplicationComponent".
+type ArchiMateElement struct {
+	Identifier    string                `xml:"identifier,attr"`
+	Type          string                `xml:"type,attr"`
+	Name          []ArchiMateLangString `xml:"name"`
+	Documentation []ArchiMateLangString `xml:"documentation"`
+	Properties    []ArchiMateProperty   `xml:"properties>property"`
+}
+
+// ArchiMateRelationship is a relationship of an exchange file
+type ArchiMateRelationship struct {
+	Identifier    string                `xml:"identifier,attr"`
+	Type          string                `xml:"type,attr"`
+	Source        string                `xml:"source,attr"`
+	Target        string                `xml:"target,attr"`
+	Name          []ArchiMateLangString `xml:"name"`
+	Documentation []ArchiMateLangString `xml:"documentation"`
+	Properties    []ArchiMateProperty   `xml:"properties>property"`
+}
+
+// ArchiMatePropertyDefinition declares a property. Type is one of string,
+// boolean, currency, date, time or number.
+type ArchiMatePropertyDefinition struct {
+	Identifier string                `xml:"identifier,attr"`
+	Type       string                `xml:"type,attr"`
+	Name       []ArchiMateLangString `xml:"name"`
+}
+
+// ArchiMateModel is the content of an exchange file that is used for import
+type ArchiMateModel struct {
+	Identifier          string                        `xml:"identifier,attr"`
+	Name                []ArchiMateLangString         `xml:"name"`
+	Elements            []ArchiMateElement            `xml:"elements>element"`
+	Relationships       []ArchiMateRelationship       `xml:"relationships>relationship"`
+	PropertyDefinitions []ArchiMatePropertyDefinition `xml:"propertyDefinitions>propertyDefinition"`
+}
+
+// ReadArchiMate parses an ArchiMate Open Exchange Format file. Views and
+// organizations are ignored.
+func ReadArchiMate(data []byte) (*ArchiMateModel, error) {
+	var model ArchiMateModel
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	if err := decoder.Decode(&model); err != nil {
+		return nil, fmt.Errorf("error reading ArchiMate exchange file: %w", err)
+	}
+	return &model, nil
+}
+
+// ArchiMateText picks the text of a multilingual value: the first English
+// or untagged entry, otherwise the first entry
+func ArchiMateText(values []ArchiMateLangString) string {
+	for _, v := range values {
+		if v.Lang == "" || strings.EqualFold(v.Lang, "en") || strings.HasPrefix(strings.ToLower(v.Lang), "en-") {
+			return strings.TrimSpace(v.Value)
+		}
+	}
+	if len(values) > 0 {
+		return strings.TrimSpace(values[0].Value)
+	}
+	return ""
+}
+
+// ArchiMateWriter streams an exchange file. Elements must be written before
+// relationships; property definitions are written by Close, as the format
+// lists them after both.
+type ArchiMateWriter struct {
+	buf     *bufio.Writer
+	section string
+}
+
+// NewArchiMateWriter starts an exchange file for a model
+func NewArchiMateWriter(w io.Writer, identifier, name string) (*ArchiMateWriter, error) {
+	a := &ArchiMateWriter{buf: bufio.NewWriterSize(w, 64<<10)}
+	a.buf.WriteString(xml.Header)
+	fmt.Fprintf(a.buf, `<model xmlns="%s" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="%s" identifier="%s">`,
+		ArchiMateNamespace, archiMateSchemaLocation, escapeXMLAttr(identifier))
+	a.writeText("name", name)
+	if _, err := a.buf.WriteString("\n"); err != nil {
+		return nil, fmt.Errorf("error writing ArchiMate exchange file: %w", err)
+	}
+	return a, nil
+}
+
+// WriteElement writes an element
+func (a *ArchiMateWriter) WriteElement(e ArchiMateElement) error {
+	a.enter("elements")
+	fmt.Fprintf(a.buf, `<element identifier="%s" xsi:type="%s">`, escapeXMLAttr(e.Identifier), escapeXMLAttr(e.Type))
+	a.writeConcept(e.Name, e.Documentation, e.Properties)
+	return a.flushLine(`</element>`)
+}
+
+// WriteRelationship writes a relationship
+func (a *ArchiMateWriter) WriteRelationship(r ArchiMateRelationship) error {
+	a.enter("relationships")
+	fmt.Fprintf(a.buf, `<relationship identifier="%s" source="%s" target="%s" xsi:type="%s">`,
+		escapeXMLAttr(r.Identifier), escapeXMLAttr(r.Source), escapeXMLAttr(r.Target), escapeXMLAttr(r.Type))
+	a.writeConcept(r.Name, r.Documentation, r.Properties)
+	return a.flushLine(`</relationship>`)
+}
+
+// Close writes the property definitions and ends the file. It does not close
+// the underlying writer.
+func (a *ArchiMateWriter) Close(definitions []ArchiMatePropertyDefinition) error {
+	a.enter("")
+	if len(definitions) > 0 {
+		a.buf.WriteString("<propertyDefinitions>")
+		for _, d := range definitions {
+			fmt.Fprintf(a.buf, `<propertyDefinition identifier="%s" type="%s">`, escapeXMLAttr(d.Identifier), escapeXMLAttr(d.Type))
+			for _, n := range d.Name {
+				a.writeLangString("name", n)
+			}
+			a.buf.WriteString("</propertyDefinition>")
+		}
+		a.buf.WriteString("</propertyDefinitions>\n")
+	}
+	a.buf.WriteString("</model>\n")
+	if err := a.buf.Flush(); err != nil {
+		return fmt.Errorf("error writing ArchiMate exchange file: %w", err)
+	}
+	return nil
+}
+
+// enter closes the current section and opens the next one if it differs
+func (a *ArchiMateWriter) enter(section string) {
+	if a.section == section {
+		return
+	}
+	if a.section != "" {
+		fmt.Fprintf(a.buf, "</%s>\n", a.section)
+	}
+	if section != "" {
+		fmt.Fprintf(a.buf, "<%s>\n", section)
+	}
+	a.section = section
+}
+
+func (a *ArchiMateWriter) writeConcept(name, documentation []ArchiMateLangString, properties []ArchiMateProperty) {
+	for _, n := range name {
+		a.writeLangString("name", n)
+	}
+	for _, d := range documentation {
+		a.writeLangString("documentation", d)
+	}
+	if len(properties) == 0 {
+		return
+	}
+	a.buf.WriteString("<properties>")
+	for _, p := range properties {
+		fmt.Fprintf(a.buf, `<property propertyDefinitionRef="%s">`, escapeXMLAttr(p.DefinitionRef))
+		for _, v := range p.Values {
+			a.writeLangString("value", v)
+		}
+		a.buf.WriteString("</property>")
+	}
+	a.buf.WriteString("</properties>")
+}
+
+func (a *ArchiMateWriter) writeText(tag, text string) {
+	a.writeLangString(tag, ArchiMateLangString{Lang: "en", Value: text})
+}
+
+func (a *ArchiMateWriter) writeLangString(tag string, s ArchiMateLangString) {
+	if s.Lang != "" {
+		fmt.Fprintf(a.buf, `<%s xml:lang="%s">`, tag, escapeXMLAttr(s.Lang))
+	} else {
+		fmt.Fprintf(a.buf, "<%s>", tag)
+	}
+	xml.EscapeText(a.buf, []byte(s.Value))
+	fmt.Fprintf(a.buf, "</%s>", tag)
+}
+
+// flushLine ends a concept; bufio keeps the first write error and returns it
+// from every later call
+func (a *ArchiMateWriter) flushLine(closing string) error {
+	if _, err := a.buf.WriteString(closing + "\n"); err != nil {
+		return fmt.Errorf("error writing ArchiMate exchange file: %w", err)
+	}
+	return nil
+}
+
+func escapeXMLAttr(s string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Cursor is a position in a keyset-paginated list: the sort key values of a
+// row, ending with its ID. Sort identifies the list and order the cursor
+// belongs to. A backward cursor pages towards the start of the list.
+type Cursor struct {
+	Sort     string
+	Values   []interface{}
+	Backward bool
+}
+
+// cursorValue is a typed sort key value, so that values decode to the types
+// they were read as: n(ull), s(tring), i(nt), f(loat), b(ool), t(ime) or
+// (he)x bytes
+type cursorValue struct {
+	T string          `json:"t"`
+	V json.RawMessage `json:"v,omitempty"`
+}
+
+type cursorJSON struct {
+	S string        `json:"s"`
+	V []cursorValue `json:"v"`
+	B bool          `json:"b,omitempty"`
+}
+
+// EncodeCursor encodes a cursor as an opaque URL-safe string. Values must be
+// nil or the string, integer, float, bool, time or []byte values read from
+// the database.
+func EncodeCursor(c Cursor) (string, error) {
+	out := cursorJSON{S: c.Sort, B: c.Backward, V: make([]cursorValue, len(c.Values))}
+	for i, value := range c.Values {
+		var kind string
+		switch v := value.(type) {
+		case nil:
+			out.V[i] = cursorValue{T: "n"}
+			continue
+		case string:
+			kind = "s"
+		case int64, int32, int16, int, uint8:
+			kind = "i"
+		case float64, float32:
+			kind = "f"
+		case bool:
+			kind = "b"
+		case time.Time:
+			kind, value = "t", v.Format(time.RFC3339Nano)
+		case []byte:
+			kind = "x"
+		default:
+			return "", fmt.Errorf("unsupported cursor value %T", value)
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("error encoding cursor: %w", err)
+		}
+		out.V[i] = cursorValue{T: kind, V: raw}
+	}
+	raw, err := json.Marshal(out)
+	if err != nil {
+		return "", fmt.Errorf("error encoding cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// DecodeCursor decodes a cursor made by EncodeCursor
+func DecodeCursor(s string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	var in cursorJSON
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+
+	c := &Cursor{Sort: in.S, Backward: in.B, Values: make([]interface{}, len(in.V))}
+	for i, value := range in.V {
+		var dest interface{}
+		switch value.T {
+		case "n":
+			continue
+		case "s":
+			dest = new(string)
+		case "i":
+			dest = new(int64)
+		case "f":
+			dest = new(float64)
+		case "b":
+			dest = new(bool)
+		case "t":
+			dest = new(time.Time)
+		case "x":
+			dest = new([]byte)
+		default:
+			return nil, fmt.Errorf("malformed cursor")
+		}
+		if err := json.Unmarshal(value.V, dest); err != nil {
+			return nil, fmt.Errorf("malformed cursor")
+		}
+		switch d := dest.(type) {
+		case *string:
+			c.Values[i] = *d
+		case *int64:
+			c.Values[i] = *d
+		case *float64:
+			c.Values[i] = *d
+		case *bool:
+			c.Values[i] = *d
+		case *time.Time:
+			c.Values[i] = *d
+		case *[]byte:
+			c.Values[i] = *d
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	modified := time.Date(2025, 3, 1, 10, 15, 30, 123456789, time.FixedZone("", 2*60*60))
+
+	tests := []struct {
+		name   string
+		cursor Cursor
+		want   []interface{}
+	}{
+		{"no values", Cursor{Sort: "objects:name"}, []interface{}{}},
+		{"string and ID", Cursor{Sort: "objects:name", Values: []interface{}{"Customer", []byte{0x01, 0xfe, 0x00}}},
+			[]interface{}{"Customer", []byte{0x01, 0xfe, 0x00}}},
+		{"null", Cursor{Sort: "objects:-dateModified", Values: []interface{}{nil, "id"}}, []interface{}{nil, "id"}},
+		{"integers", Cursor{Sort: "s", Values: []interface{}{int64(-9007199254740993), int32(7), int16(-3), int(42), uint8(255)}},
+			[]interface{}{int64(-9007199254740993), int64(7), int64(-3), int64(42), int64(255)}},
+		{"floats", Cursor{Sort: "s", Values: []interface{}{12.5, float32(0.25)}}, []interface{}{12.5, 0.25}},
+		{"bools", Cursor{Sort: "s", Values: []interface{}{true, false}}, []interface{}{true, false}},
+		{"time", Cursor{Sort: "s", Values: []interface{}{modified}}, []interface{}{modified}},
+		{"unicode", Cursor{Sort: "objects:name", Values: []interface{}{"مرحبا \"quoted\""}}, []interface{}{"مرحبا \"quoted\""}},
+		{"backward", Cursor{Sort: "objects:name", Values: []interface{}{"a"}, Backward: true}, []interface{}{"a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := EncodeCursor(tt.cursor)
+			if err != nil {
+				t.Fatalf("EncodeCursor() error = %v", err)
+			}
+			if strings.ContainsAny(encoded, "+/=") {
+				t.Errorf("EncodeCursor() = %q, want URL-safe", encoded)
+			}
+
+			got, err := DecodeCursor(encoded)
+			if err != nil {
+				t.Fatalf("DecodeCursor() error = %v", err)
+			}
+			if got.Sort != tt.cursor.Sort || got.Backward != tt.cursor.Backward {
+				t.Errorf("DecodeCursor() = sort %q backward %v, want sort %q backward %v",
+					got.Sort, got.Backward, tt.cursor.Sort, tt.cursor.Backward)
+			}
+			if len(got.Values) != len(tt.want) {
+				t.Fatalf("DecodeCursor() values = %#v, want %#v", got.Values, tt.want)
+			}
+			for i, want := range tt.want {
+				if !cursorValueEqual(got.Values[i], want) {
+					t.Errorf("DecodeCursor() value %d = %#v, want %#v", i, got.Values[i], want)
+				}
+			}
+		})
+	}
+}
+
+func cursorValueEqual(got, want interface{}) bool {
+	switch w := want.(type) {
+	case time.Time:
+		g, ok := got.(time.Time)
+		return ok && g.Equal(w)
+	case []byte:
+		g, ok := got.([]byte)
+		return ok && bytes.Equal(g, w)
+	}
+	return got == want
+}
+
+func TestEncodeCursorUnsupportedValue(t *testing.T) {
+	if _, err := EncodeCursor(Cursor{Sort: "s", Values: []interface{}{struct{}{}}}); err == nil {
+		t.Fatal("EncodeCursor() error = nil, want unsupported value error")
+	}
+}
+
+func TestDecodeCursorRejectsTampering(t *testing.T) {
+	valid, err := EncodeCursor(Cursor{Sort: "objects:name", Values: []interface{}{"Customer", int64(7)}})
+	if err != nil {
+		t.Fatalf("EncodeCursor() error = %v", err)
+	}
+	encode := func(json string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(json))
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "not a cursor!"},
+		{"padded base64", valid + "=="},
+		{"standard base64 alphabet", strings.NewReplacer("-", "+", "_", "/").Replace(encode(`{"s":"~~~","v":[{"t":"s","v":"???"}]}`))},
+		{"truncated", valid[:len(valid)-3]},
+		{"not JSON", encode("objects:name|Customer")},
+		{"values not a list", encode(`{"s":"objects:name","v":"Customer"}`)},
+		{"unknown value type", encode(`{"s":"objects:name","v":[{"t":"q","v":"Customer"}]}`)},
+		{"missing value", encode(`{"s":"objects:name","v":[{"t":"s"}]}`)},
+		{"string as integer", encode(`{"s":"objects:name","v":[{"t":"i","v":"7"}]}`)},
+		{"fraction as integer", encode(`{"s":"objects:name","v":[{"t":"i","v":7.5}]}`)},
+		{"number as string", encode(`{"s":"objects:name","v":[{"t":"s","v":7}]}`)},
+		{"invalid time", encode(`{"s":"objects:name","v":[{"t":"t","v":"yesterday"}]}`)},
+		{"invalid bytes", encode(`{"s":"objects:name","v":[{"t":"x","v":"not base64!"}]}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := DecodeCursor(tt.cursor)
+			if err == nil {
+				t.Fatalf("DecodeCursor(%q) = %+v, want error", tt.cursor, c)
+			}
+			if err.Error() != "malformed cursor" {
+				t.Errorf("DecodeCursor(%q) error = %q, want %q", tt.cursor, err, "malformed cursor")
+			}
+		})
+	}
+}
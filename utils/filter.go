@@ -0,0 +1,444 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Comparison operators of a filter expression
+const (
+	FilterEq         = "="
+	FilterNe         = "!="
+	FilterLt         = "<"
+	FilterLe         = "<="
+	FilterGt         = ">"
+	FilterGe         = ">="
+	FilterIn         = "in"
+	FilterContains   = "contains"
+	FilterStartsWith = "startswith"
+	FilterIsNull     = "isnull"
+	FilterIsNotNull  = "isnotnull"
+)
+
+// Kinds of filter literal
+const (
+	FilterString = iota
+	FilterNumber
+	FilterDate
+	FilterBool
+)
+
+// FilterExpr is a node of a parsed filter expression: a *FilterLogical,
+// *FilterNot or *FilterComparison
+type FilterExpr interface {
+	filterExpr()
+}
+
+// FilterLogical joins two or more expressions with "and" or "or"
+type FilterLogical struct {
+	Op    string
+	Terms []FilterExpr
+}
+
+// FilterNot negates an expression
+type FilterNot struct {
+	Expr FilterExpr
+}
+
+// FilterField is a column name such as dateModified, or the name of an
+// attribute written attr["Name"]
+type FilterField struct {
+	Name      string
+	Attribute bool
+}
+
+func (f FilterField) String() string {
+	if f.Attribute {
+		return "attr[" + strconv.Quote(f.Name) + "]"
+	}
+	return f.Name
+}
+
+// FilterValue is a literal of a filter expression. A date written without a
+// time has DateOnly set.
+type FilterValue struct {
+	Kind     int
+	Text     string
+	Number   float64
+	Date     time.Time
+	DateOnly bool
+	Bool     bool
+}
+
+// FilterComparison compares a field with its values: one value for the
+// relational operators, contains and startswith, one or more for in and none
+// for the null checks
+type FilterComparison struct {
+	Field  FilterField
+	Op     string
+	Values []FilterValue
+}
+
+// FilterSortKey is a field to sort by
+type FilterSortKey struct {
+	Field FilterField
+	Desc  bool
+}
+
+func (*FilterLogical) filterExpr()    {}
+func (*FilterNot) filterExpr()        {}
+func (*FilterComparison) filterExpr() {}
+
+// filterDateLayouts are the accepted date literals
+var filterDateLayouts = []string{"2006-01-02T15:04:05Z07:00", "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"}
+
+type filterToken struct {
+	kind string // ident, string, literal, op or punct
+	text string
+	pos  int
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+	input  string
+}
+
+// ParseFilter parses a filter expression such as
+//
+//	attr["Status"] = "Active" and dateModified > 2025-01-01
+//
+// Comparisons are joined with and, or and not and grouped with parentheses;
+// keywords are case-insensitive.
+func ParseFilter(input string) (FilterExpr, error) {
+	p, err := newFilterParser(input)
+	if err != nil {
+		return nil, err
+	}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, p.errorf("unexpected %q", p.peek().text)
+	}
+	return expr, nil
+}
+
+// ParseFilterSort parses a comma-separated list of fields to sort by; a
+// leading "-" sorts a field in descending order
+func ParseFilterSort(input string) ([]FilterSortKey, error) {
+	var keys []FilterSortKey
+	err := parseFilterFieldList(input, true, func(field FilterField, desc bool) {
+		keys = append(keys, FilterSortKey{Field: field, Desc: desc})
+	})
+	return keys, err
+}
+
+// ParseFilterFields parses a comma-separated list of fields
+func ParseFilterFields(input string) ([]FilterField, error) {
+	var fields []FilterField
+	err := parseFilterFieldList(input, false, func(field FilterField, _ bool) {
+		fields = append(fields, field)
+	})
+	return fields, err
+}
+
+func parseFilterFieldList(input string, allowDesc bool, fn func(FilterField, bool)) error {
+	p, err := newFilterParser(input)
+	if err != nil {
+		return err
+	}
+	if p.done() {
+		return nil
+	}
+	for {
+		desc := false
+		if allowDesc && p.peek().kind == "op" && p.peek().text == "-" {
+			desc = true
+			p.pos++
+		}
+		field, err := p.parseField()
+		if err != nil {
+			return err
+		}
+		fn(field, desc)
+		if p.done() {
+			return nil
+		}
+		if err := p.expect(","); err != nil {
+			return err
+		}
+	}
+}
+
+func newFilterParser(input string) (*filterParser, error) {
+	tokens, err := lexFilter(input)
+	if err != nil {
+		return nil, err
+	}
+	return &filterParser{tokens: tokens, input: input}, nil
+}
+
+func lexFilter(input string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			start := i
+			var b strings.Builder
+			for i++; ; i++ {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("unterminated string at position %d", start+1)
+				}
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					b.WriteRune(runes[i])
+					continue
+				}
+				if runes[i] == r {
+					i++
+					break
+				}
+				b.WriteRune(runes[i])
+			}
+			tokens = append(tokens, filterToken{"string", b.String(), start})
+		case unicode.IsDigit(r) || r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1]) && !filterOperand(tokens):
+			start := i
+			for i++; i < len(runes) && (unicode.IsDigit(runes[i]) || strings.ContainsRune(".:-+TZ", runes[i])); i++ {
+			}
+			tokens = append(tokens, filterToken{"literal", string(runes[start:i]), start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, filterToken{"ident", string(runes[start:i]), start})
+		case strings.ContainsRune("<>!=", r):
+			start := i
+			i++
+			if i < len(runes) && runes[i] == '=' {
+				i++
+			}
+			op := string(runes[start:i])
+			if op == "!" {
+				return nil, fmt.Errorf("unexpected \"!\" at position %d", start+1)
+			}
+			if op == "==" {
+				op = FilterEq
+			}
+			tokens = append(tokens, filterToken{"op", op, start})
+		case r == '-':
+			tokens = append(tokens, filterToken{"op", "-", i})
+			i++
+		case strings.ContainsRune("()[],", r):
+			tokens = append(tokens, filterToken{"punct", string(r), i})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected %q at position %d", r, i+1)
+		}
+	}
+	return tokens, nil
+}
+
+// filterOperand reports whether the last token ends an operand, so that a
+// following "-" cannot start a negative number
+func filterOperand(tokens []filterToken) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	last := tokens[len(tokens)-1]
+	return last.kind == "literal" || last.kind == "string" || last.kind == "punct" && (last.text == ")" || last.text == "]")
+}
+
+func (p *filterParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *filterParser) peek() filterToken {
+	if p.done() {
+		return filterToken{kind: "end", text: "end of filter", pos: len([]rune(p.input))}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s at position %d", fmt.Sprintf(format, args...), p.peek().pos+1)
+}
+
+func (p *filterParser) keyword(word string) bool {
+	t := p.peek()
+	if t.kind == "ident" && strings.EqualFold(t.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) expect(punct string) error {
+	if t := p.peek(); t.kind != "punct" || t.text != punct {
+		return p.errorf("expected %q", punct)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *filterParser) parseOr() (FilterExpr, error) {
+	return p.parseLogical("or", p.parseAnd)
+}
+
+func (p *filterParser) parseAnd() (FilterExpr, error) {
+	return p.parseLogical("and", p.parseUnary)
+}
+
+func (p *filterParser) parseLogical(op string, operand func() (FilterExpr, error)) (FilterExpr, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	terms := []FilterExpr{first}
+	for p.keyword(op) {
+		next, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, next)
+	}
+	if len(terms) == 1 {
+		return first, nil
+	}
+	return &FilterLogical{Op: op, Terms: terms}, nil
+}
+
+func (p *filterParser) parseUnary() (FilterExpr, error) {
+	if p.keyword("not") {
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &FilterNot{Expr: expr}, nil
+	}
+	if t := p.peek(); t.kind == "punct" && t.text == "(" {
+		p.pos++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseField() (FilterField, error) {
+	t := p.peek()
+	if t.kind != "ident" {
+		return FilterField{}, p.errorf("expected a field")
+	}
+	p.pos++
+	if !strings.EqualFold(t.text, "attr") {
+		return FilterField{Name: t.text}, nil
+	}
+	if err := p.expect("["); err != nil {
+		return FilterField{}, err
+	}
+	name := p.peek()
+	if name.kind != "string" {
+		return FilterField{}, p.errorf("expected a quoted attribute name")
+	}
+	p.pos++
+	if err := p.expect("]"); err != nil {
+		return FilterField{}, err
+	}
+	return FilterField{Name: name.text, Attribute: true}, nil
+}
+
+func (p *filterParser) parseComparison() (FilterExpr, error) {
+	field, err := p.parseField()
+	if err != nil {
+		return nil, err
+	}
+	c := &FilterComparison{Field: field}
+
+	switch t := p.peek(); {
+	case t.kind == "op" && t.text != "-":
+		p.pos++
+		c.Op = t.text
+	case p.keyword("is"):
+		c.Op = FilterIsNull
+		if p.keyword("not") {
+			c.Op = FilterIsNotNull
+		}
+		if !p.keyword("null") {
+			return nil, p.errorf("expected null")
+		}
+		return c, nil
+	case p.keyword("in"):
+		c.Op = FilterIn
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		for {
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			c.Values = append(c.Values, value)
+			if t := p.peek(); t.kind == "punct" && t.text == ")" {
+				p.pos++
+				return c, nil
+			}
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+	case p.keyword(FilterContains):
+		c.Op = FilterContains
+	case p.keyword(FilterStartsWith):
+		c.Op = FilterStartsWith
+	default:
+		return nil, p.errorf("expected an operator after %s", field)
+	}
+
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	c.Values = []FilterValue{value}
+	return c, nil
+}
+
+func (p *filterParser) parseValue() (FilterValue, error) {
+	t := p.peek()
+	switch {
+	case t.kind == "string":
+		p.pos++
+		return FilterValue{Kind: FilterString, Text: t.text}, nil
+	case t.kind == "literal":
+		p.pos++
+		for _, layout := range filterDateLayouts {
+			if date, err := time.Parse(layout, t.text); err == nil {
+				return FilterValue{Kind: FilterDate, Text: t.text, Date: date, DateOnly: len(t.text) == len("2006-01-02")}, nil
+			}
+		}
+		number, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			p.pos--
+			return FilterValue{}, p.errorf("invalid number or date %q", t.text)
+		}
+		return FilterValue{Kind: FilterNumber, Text: t.text, Number: number}, nil
+	case t.kind == "ident" && (strings.EqualFold(t.text, "true") || strings.EqualFold(t.text, "false")):
+		p.pos++
+		return FilterValue{Kind: FilterBool, Text: t.text, Bool: strings.EqualFold(t.text, "true")}, nil
+	}
+	return FilterValue{}, p.errorf("expected a value")
+}
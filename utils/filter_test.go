@@ -0,0 +1,202 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// formatFilter renders a parsed filter with explicit grouping and literal
+// kinds, so that tests can compare parse trees as strings
+func formatFilter(expr FilterExpr) string {
+	switch e := expr.(type) {
+	case *FilterLogical:
+		terms := make([]string, len(e.Terms))
+		for i, term := range e.Terms {
+			terms[i] = formatFilter(term)
+		}
+		return "(" + e.Op + " " + strings.Join(terms, " ") + ")"
+	case *FilterNot:
+		return "(not " + formatFilter(e.Expr) + ")"
+	case *FilterComparison:
+		values := make([]string, len(e.Values))
+		for i, value := range e.Values {
+			values[i] = formatFilterValue(value)
+		}
+		s := e.Field.String() + " " + e.Op
+		switch {
+		case e.Op == FilterIn:
+			s += " (" + strings.Join(values, ", ") + ")"
+		case len(values) > 0:
+			s += " " + values[0]
+		}
+		return s
+	}
+	return "?"
+}
+
+func formatFilterValue(v FilterValue) string {
+	switch v.Kind {
+	case FilterString:
+		return strconv.Quote(v.Text)
+	case FilterNumber:
+		return "num:" + strconv.FormatFloat(v.Number, 'g', -1, 64)
+	case FilterDate:
+		s := "date:" + v.Date.Format(time.RFC3339)
+		if v.DateOnly {
+			s += "/day"
+		}
+		return s
+	case FilterBool:
+		return "bool:" + strconv.FormatBool(v.Bool)
+	}
+	return "?"
+}
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"comparison", `objectName = "Customer"`, `objectName = "Customer"`},
+		{"double equals", `objectName == "Customer"`, `objectName = "Customer"`},
+		{"relational operators", `a != 1 and b < 2 and c <= 3 and d > 4 and e >= 5`,
+			`(and a != num:1 b < num:2 c <= num:3 d > num:4 e >= num:5)`},
+		{"and binds tighter than or", `a = 1 or b = 2 and c = 3`, `(or a = num:1 (and b = num:2 c = num:3))`},
+		{"parentheses", `(a = 1 or b = 2) and c = 3`, `(and (or a = num:1 b = num:2) c = num:3)`},
+		{"not binds tighter than and", `not a = 1 and b = 2`, `(and (not a = num:1) b = num:2)`},
+		{"not of a group", `not (a = 1 or b = 2)`, `(not (or a = num:1 b = num:2))`},
+		{"chained or", `a = 1 or b = 2 or c = 3`, `(or a = num:1 b = num:2 c = num:3)`},
+		{"keywords are case-insensitive", `a = 1 AND NOT b = 2 Or c Is Not Null`,
+			`(or (and a = num:1 (not b = num:2)) c isnotnull)`},
+		{"attribute", `attr["Status"] = "Active"`, `attr["Status"] = "Active"`},
+		{"single quotes", `attr['Owner'] = 'Finance'`, `attr["Owner"] = "Finance"`},
+		{"escaped quotes", `attr["Say \"hi\""] = 'it\'s'`, `attr["Say \"hi\""] = "it's"`},
+		{"escaped backslash", `a = "C:\\Temp"`, `a = "C:\\Temp"`},
+		{"other quote inside string", `a = "it's" or b = 'say "hi"'`, `(or a = "it's" b = "say \"hi\"")`},
+		{"unicode string", `objectName = "مرحبا"`, `objectName = "مرحبا"`},
+		{"in", `status in ("Active", 'Retired', 3)`, `status in ("Active", "Retired", num:3)`},
+		{"in with one value", `status IN ("Active")`, `status in ("Active")`},
+		{"is null", `attr["Owner"] is null`, `attr["Owner"] isnull`},
+		{"is not null", `attr["Owner"] is not null`, `attr["Owner"] isnotnull`},
+		{"contains", `objectName contains "Cust"`, `objectName contains "Cust"`},
+		{"startswith", `objectName STARTSWITH "Cu"`, `objectName startswith "Cu"`},
+		{"boolean", `attr["Active"] = TRUE and attr["Legacy"] = false`,
+			`(and attr["Active"] = bool:true attr["Legacy"] = bool:false)`},
+		{"decimal", `attr["Cost"] >= 12.5`, `attr["Cost"] >= num:12.5`},
+		{"negative number", `attr["Delta"] > -5`, `attr["Delta"] > num:-5`},
+		{"negative number without spaces", `attr["Delta"]>-5`, `attr["Delta"] > num:-5`},
+		{"date", `dateModified > 2025-01-01`, `dateModified > date:2025-01-01T00:00:00Z/day`},
+		{"date and time", `dateModified <= 2025-01-01T10:30:00Z`, `dateModified <= date:2025-01-01T10:30:00Z`},
+		{"date and time with offset", `dateCreated < 2025-01-01T10:30:00+02:00`, `dateCreated < date:2025-01-01T10:30:00+02:00`},
+		{"date and time without seconds", `dateCreated < 2025-01-01T10:30`, `dateCreated < date:2025-01-01T10:30:00Z`},
+		{"dates in", `dateCreated in (2025-01-01, 2025-02-01)`,
+			`dateCreated in (date:2025-01-01T00:00:00Z/day, date:2025-02-01T00:00:00Z/day)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := ParseFilter(tt.input)
+			if err != nil {
+				t.Fatalf("ParseFilter(%q) error = %v", tt.input, err)
+			}
+			if got := formatFilter(expr); got != tt.want {
+				t.Errorf("ParseFilter(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"empty", ``, "expected a field at position 1"},
+		{"missing value", `a =`, "expected a value at position 4"},
+		{"missing operator", `a 1`, "expected an operator after a at position 3"},
+		{"unknown operator", `a like "x"`, "expected an operator after a"},
+		{"bang", `a ! 1`, `unexpected "!" at position 3`},
+		{"unexpected character", `a # 1`, `unexpected '#' at position 3`},
+		{"unterminated string", `a = "x`, "unterminated string at position 5"},
+		{"unterminated escape", `a = "x\"`, "unterminated string at position 5"},
+		{"missing and", `a = 1 b = 2`, `unexpected "b" at position 7`},
+		{"unclosed group", `(a = 1`, `expected ")" at position 7`},
+		{"extra close", `a = 1)`, `unexpected ")" at position 6`},
+		{"dangling and", `a = 1 and`, "expected a field at position 10"},
+		{"dangling not", `not`, "expected a field at position 4"},
+		{"unclosed in", `a in (1, 2`, `expected "," at position 11`},
+		{"in without parentheses", `a in 1`, `expected "(" at position 6`},
+		{"empty in", `a in ()`, "expected a value at position 7"},
+		{"is without null", `a is 1`, "expected null at position 6"},
+		{"is not without null", `a is not`, "expected null at position 9"},
+		{"invalid date", `a = 2025-13-45`, `invalid number or date "2025-13-45" at position 5`},
+		{"invalid number", `a = 1.2.3`, `invalid number or date "1.2.3" at position 5`},
+		{"unquoted attribute name", `attr[Status] = 1`, "expected a quoted attribute name at position 6"},
+		{"unclosed attribute", `attr["Status" = 1`, `expected "]" at position 15`},
+		{"field as value", `a = b`, "expected a value at position 5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := ParseFilter(tt.input)
+			if err == nil {
+				t.Fatalf("ParseFilter(%q) = %s, want error %q", tt.input, formatFilter(expr), tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseFilter(%q) error = %q, want %q", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseFilterSort(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []FilterSortKey
+		wantErr string
+	}{
+		{"empty", ``, nil, ""},
+		{"one field", `objectName`, []FilterSortKey{{Field: FilterField{Name: "objectName"}}}, ""},
+		{"descending and attribute", `-dateModified, attr["Score"],objectName`, []FilterSortKey{
+			{Field: FilterField{Name: "dateModified"}, Desc: true},
+			{Field: FilterField{Name: "Score", Attribute: true}},
+			{Field: FilterField{Name: "objectName"}},
+		}, ""},
+		{"descending attribute", `-attr['Cost']`, []FilterSortKey{{Field: FilterField{Name: "Cost", Attribute: true}, Desc: true}}, ""},
+		{"trailing comma", `objectName,`, nil, "expected a field at position 12"},
+		{"empty key", `objectName,,dateCreated`, nil, "expected a field at position 12"},
+		{"missing comma", `objectName dateCreated`, nil, `expected "," at position 12`},
+		{"bare minus", `-`, nil, "expected a field at position 2"},
+		{"double minus", `--objectName`, nil, "expected a field at position 2"},
+		{"direction keyword", `objectName desc`, nil, `expected "," at position 12`},
+		{"literal", `1`, nil, "expected a field at position 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := ParseFilterSort(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseFilterSort(%q) error = %v, want %q", tt.input, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseFilterSort(%q) error = %v", tt.input, err)
+			}
+			if len(keys) != len(tt.want) {
+				t.Fatalf("ParseFilterSort(%q) = %v, want %v", tt.input, keys, tt.want)
+			}
+			for i := range keys {
+				if keys[i] != tt.want[i] {
+					t.Errorf("ParseFilterSort(%q)[%d] = %+v, want %+v", tt.input, i, keys[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
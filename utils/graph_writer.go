@@ -0,0 +1,242 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// GraphNode is a node of an exported graph. Color is a "#rrggbb" fill color,
+// or empty for the tool's default.
+type GraphNode struct {
+	ID    string
+	Label string
+	Type  string
+	Color string
+}
+
+// GraphEdge is a directed edge of an exported graph. Kind tells containment
+// from relationship edges and Type identifies the containment or relation
+// type.
+type GraphEdge struct {
+	ID     string
+	Source string
+	Target string
+	Label  string
+	Kind   string
+	Type   string
+}
+
+// GraphWriter writes a graph one node or edge at a time, so large graphs can
+// be streamed. All nodes must be written before the first edge.
+type GraphWriter interface {
+	WriteNode(n GraphNode) error
+	WriteEdge(e GraphEdge) error
+	// Close finishes the file. It does not close the underlying writer.
+	Close() error
+}
+
+// NewGraphWriter creates a writer for the "graphml" or "dot" format
+func NewGraphWriter(w io.Writer, format, name string) (GraphWriter, error) {
+	switch strings.ToLower(format) {
+	case "graphml":
+		return NewGraphMLWriter(w, name)
+	case "dot":
+		return NewDOTWriter(w, name)
+	default:
+		return nil, fmt.Errorf("unsupported graph format %q, expected graphml or dot", format)
+	}
+}
+
+// GraphContentType returns the MIME type of a graph format
+func GraphContentType(format string) string {
+	if strings.EqualFold(format, "dot") {
+		return "text/vnd.graphviz; charset=utf-8"
+	}
+	return "application/xml; charset=utf-8"
+}
+
+// GraphColor converts a stored color to "#rrggbb". The low 24 bits are read
+// as RGB, so an alpha channel in the high byte is ignored.
+func GraphColor(color int) string {
+	return fmt.Sprintf("#%06x", color&0xffffff)
+}
+
+type graphMLWriter struct {
+	w     *bufio.Writer
+	edges bool
+}
+
+// NewGraphMLWriter creates a GraphML writer. Besides plain label, type and
+// color data, nodes carry yFiles shape graphics so that yEd shows labels and
+// fill colors, and r, g and b values that Gephi reads as node colors.
+func NewGraphMLWriter(w io.Writer, name string) (GraphWriter, error) {
+	g := &graphMLWriter{w: bufio.NewWriter(w)}
+	g.w.WriteString(xml.Header)
+	g.w.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xmlns:y="http://www.yworks.com/xml/graphml" xsi:schemaLocation="http://graphml.graphdrawing.org/xmlns http://www.yworks.com/xml/schema/graphml/1.1/ygraphml.xsd">` + "\n")
+	g.w.WriteString(`<key id="label" for="node" attr.name="label" attr.type="string"/>` + "\n")
+	g.w.WriteString(`<key id="type" for="node" attr.name="type" attr.type="string"/>` + "\n")
+	g.w.WriteString(`<key id="color" for="node" attr.name="color" attr.type="string"/>` + "\n")
+	g.w.WriteString(`<key id="r" for="node" attr.name="r" attr.type="int"/>` + "\n")
+	g.w.WriteString(`<key id="g" for="node" attr.name="g" attr.type="int"/>` + "\n")
+	g.w.WriteString(`<key id="b" for="node" attr.name="b" attr.type="int"/>` + "\n")
+	g.w.WriteString(`<key id="graphics" for="node" yfiles.type="nodegraphics"/>` + "\n")
+	g.w.WriteString(`<key id="elabel" for="edge" attr.name="label" attr.type="string"/>` + "\n")
+	g.w.WriteString(`<key id="kind" for="edge" attr.name="kind" attr.type="string"/>` + "\n")
+	g.w.WriteString(`<key id="etype" for="edge" attr.name="type" attr.type="string"/>` + "\n")
+	g.w.WriteString(`<key id="egraphics" for="edge" yfiles.type="edgegraphics"/>` + "\n")
+	fmt.Fprintf(g.w, `<graph id="%s" edgedefault="directed">`, escapeXMLAttr(name))
+	if err := g.end(""); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func (g *graphMLWriter) WriteNode(n GraphNode) error {
+	if g.edges {
+		return fmt.Errorf("graph node %s written after edges", n.ID)
+	}
+	label := n.Label
+	if n.Type != "" {
+		label += "\n" + n.Type
+	}
+	fmt.Fprintf(g.w, `<node id="%s">`, escapeXMLAttr(n.ID))
+	fmt.Fprintf(g.w, `<data key="label">%s</data>`, escapeXMLAttr(n.Label))
+	if n.Type != "" {
+		fmt.Fprintf(g.w, `<data key="type">%s</data>`, escapeXMLAttr(n.Type))
+	}
+	fill := ""
+	if n.Color != "" {
+		var r, gr, b int
+		fmt.Sscanf(n.Color, "#%02x%02x%02x", &r, &gr, &b)
+		fmt.Fprintf(g.w, `<data key="color">%s</data><data key="r">%d</data><data key="g">%d</data><data key="b">%d</data>`, n.Color, r, gr, b)
+		fill = fmt.Sprintf(`<y:Fill color="%s" transparent="false"/>`, n.Color)
+	}
+	fmt.Fprintf(g.w, `<data key="graphics"><y:ShapeNode><y:Geometry width="160" height="40"/>%s<y:NodeLabel>%s</y:NodeLabel><y:Shape type="roundrectangle"/></y:ShapeNode></data>`,
+		fill, escapeXMLAttr(label))
+	return g.end("</node>")
+}
+
+func (g *graphMLWriter) WriteEdge(e GraphEdge) error {
+	g.edges = true
+	fmt.Fprintf(g.w, `<edge id="%s" source="%s" target="%s">`, escapeXMLAttr(e.ID), escapeXMLAttr(e.Source), escapeXMLAttr(e.Target))
+	if e.Label != "" {
+		fmt.Fprintf(g.w, `<data key="elabel">%s</data>`, escapeXMLAttr(e.Label))
+	}
+	if e.Kind != "" {
+		fmt.Fprintf(g.w, `<data key="kind">%s</data>`, escapeXMLAttr(e.Kind))
+	}
+	if e.Type != "" {
+		fmt.Fprintf(g.w, `<data key="etype">%s</data>`, escapeXMLAttr(e.Type))
+	}
+	g.w.WriteString(`<data key="egraphics"><y:PolyLineEdge><y:Arrows source="none" target="standard"/>`)
+	if e.Label != "" {
+		fmt.Fprintf(g.w, `<y:EdgeLabel>%s</y:EdgeLabel>`, escapeXMLAttr(e.Label))
+	}
+	return g.end("</y:PolyLineEdge></data></edge>")
+}
+
+func (g *graphMLWriter) Close() error {
+	g.w.WriteString("</graph>\n</graphml>\n")
+	if err := g.w.Flush(); err != nil {
+		return fmt.Errorf("error writing graphml: %w", err)
+	}
+	return nil
+}
+
+// end finishes a line; bufio keeps the first write error and returns it from
+// every later call
+func (g *graphMLWriter) end(closing string) error {
+	if _, err := g.w.WriteString(closing + "\n"); err != nil {
+		return fmt.Errorf("error writing graphml: %w", err)
+	}
+	return nil
+}
+
+type dotWriter struct {
+	w     *bufio.Writer
+	edges bool
+}
+
+// NewDOTWriter creates a Graphviz DOT writer for a directed graph. Nodes are
+// labelled with their name and type on two lines and filled with their color.
+func NewDOTWriter(w io.Writer, name string) (GraphWriter, error) {
+	d := &dotWriter{w: bufio.NewWriter(w)}
+	fmt.Fprintf(d.w, "digraph %s {\n", dotQuote(name))
+	if err := d.end("\tnode [shape=box, style=\"rounded,filled\", fillcolor=\"#ffffff\"];"); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *dotWriter) WriteNode(n GraphNode) error {
+	if d.edges {
+		return fmt.Errorf("graph node %s written after edges", n.ID)
+	}
+	label := n.Label
+	if n.Type != "" {
+		label += "\n" + n.Type
+	}
+	fmt.Fprintf(d.w, "\t%s [label=%s", dotQuote(n.ID), dotQuote(label))
+	if n.Type != "" {
+		fmt.Fprintf(d.w, ", type=%s", dotQuote(n.Type))
+	}
+	if n.Color != "" {
+		fmt.Fprintf(d.w, ", fillcolor=%s", dotQuote(n.Color))
+	}
+	return d.end("];")
+}
+
+func (d *dotWriter) WriteEdge(e GraphEdge) error {
+	d.edges = true
+	fmt.Fprintf(d.w, "\t%s -> %s [id=%s", dotQuote(e.Source), dotQuote(e.Target), dotQuote(e.ID))
+	if e.Label != "" {
+		fmt.Fprintf(d.w, ", label=%s", dotQuote(e.Label))
+	}
+	if e.Kind != "" {
+		fmt.Fprintf(d.w, ", kind=%s", dotQuote(e.Kind))
+	}
+	if e.Type != "" {
+		fmt.Fprintf(d.w, ", type=%s", dotQuote(e.Type))
+	}
+	return d.end("];")
+}
+
+func (d *dotWriter) Close() error {
+	d.w.WriteString("}\n")
+	if err := d.w.Flush(); err != nil {
+		return fmt.Errorf("error writing dot: %w", err)
+	}
+	return nil
+}
+
+func (d *dotWriter) end(closing string) error {
+	if _, err := d.w.WriteString(closing + "\n"); err != nil {
+		return fmt.Errorf("error writing dot: %w", err)
+	}
+	return nil
+}
+
+// dotQuote writes s as a DOT quoted string. Line breaks become \n, which
+// Graphviz renders as centered line breaks in labels.
+func dotQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
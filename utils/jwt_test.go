@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func TestParseJWT(t *testing.T) {
+	now := time.Now().Unix()
+	valid := TokenClaims{UserID: 7, ProfileID: 3, UserName: "alice", IssuedAt: now, ExpiresAt: now + 3600}
+
+	sign := func(claims TokenClaims, secret string) string {
+		token, err := GenerateJWT(claims, secret)
+		if err != nil {
+			t.Fatalf("GenerateJWT: %v", err)
+		}
+		return token
+	}
+	withHeader := func(token, header string) string {
+		parts := strings.Split(token, ".")
+		parts[0] = base64.RawURLEncoding.EncodeToString([]byte(header))
+		return strings.Join(parts, ".")
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{"valid", sign(valid, testSecret), ""},
+		{"malformed", "abc.def", "malformed token"},
+		{"wrong secret", sign(valid, "another-secret-another-secret-xx"), "invalid token signature"},
+		{"tampered payload", withHeader(sign(valid, testSecret), `{"alg":"HS256","typ":"JWT","x":1}`), "invalid token signature"},
+		{"unsupported algorithm", withHeader(sign(valid, testSecret), `{"alg":"none"}`), "unsupported token algorithm"},
+		{"expired", sign(TokenClaims{UserID: 7, IssuedAt: now - 7200, ExpiresAt: now - 3600}, testSecret), "token has expired"},
+		{"no expiry", sign(TokenClaims{UserID: 7, IssuedAt: now}, testSecret), "token has no expiry"},
+		{"no subject", sign(TokenClaims{IssuedAt: now, ExpiresAt: now + 3600}, testSecret), "token has no subject"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := ParseJWT(tt.token, testSecret)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseJWT() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseJWT() error = %v", err)
+			}
+			if *claims != valid {
+				t.Errorf("ParseJWT() = %+v, want %+v", *claims, valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,388 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SearchFold is a character that search normalisation replaces with To, or
+// removes when To is empty
+type SearchFold struct {
+	From rune
+	To   string
+}
+
+// SearchFolds lists the Arabic folds of NormalizeSearchText: alef variants
+// become a bare alef, alef maksura becomes yeh, teh marbuta becomes heh, and
+// tatweel and diacritics are removed. Repositories apply the same folds in
+// SQL so that prefiltering matches what NormalizeSearchText matches.
+var SearchFolds = []SearchFold{
+	{'\u0623', "\u0627"}, // alef with hamza above
+	{'\u0625', "\u0627"}, // alef with hamza below
+	{'\u0622', "\u0627"}, // alef with madda above
+	{'\u0671', "\u0627"}, // alef wasla
+	{'\u0649', "\u064A"}, // alef maksura
+	{'\u0629', "\u0647"}, // teh marbuta
+	{'\u0640', ""},       // tatweel
+	{'\u064B', ""},       // fathatan
+	{'\u064C', ""},       // dammatan
+	{'\u064D', ""},       // kasratan
+	{'\u064E', ""},       // fatha
+	{'\u064F', ""},       // damma
+	{'\u0650', ""},       // kasra
+	{'\u0651', ""},       // shadda
+	{'\u0652', ""},       // sukun
+	{'\u0670', ""},       // superscript alef
+}
+
+var searchFoldMap = func() map[rune]string {
+	m := make(map[rune]string, len(SearchFolds))
+	for _, f := range SearchFolds {
+		m[f.From] = f.To
+	}
+	return m
+}()
+
+// TextSpan is a range of runes [Start, End) in a text
+type TextSpan struct {
+	Start int
+	End   int
+}
+
+// normalizeRunes normalises s and returns, for every normalised rune, the
+// index of the rune of s it came from
+func normalizeRunes(s string) ([]rune, []int) {
+	norm := make([]rune, 0, len(s))
+	offsets := make([]int, 0, len(s))
+	i := 0
+	for _, r := range s {
+		if to, ok := searchFoldMap[r]; ok {
+			for _, t := range to {
+				norm = append(norm, t)
+				offsets = append(offsets, i)
+			}
+		} else {
+			norm = append(norm, unicode.ToLower(r))
+			offsets = append(offsets, i)
+		}
+		i++
+	}
+	return norm, offsets
+}
+
+// NormalizeSearchText lower-cases s and applies SearchFolds
+func NormalizeSearchText(s string) string {
+	norm, _ := normalizeRunes(s)
+	return string(norm)
+}
+
+// SearchTerms splits a query into distinct normalised terms, keeping at most
+// max of them
+func SearchTerms(q string, max int) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, term := range strings.Fields(NormalizeSearchText(q)) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+		terms = append(terms, term)
+		if len(terms) == max {
+			break
+		}
+	}
+	return terms
+}
+
+// MatchTerms finds the normalised terms in text. It returns the matched spans
+// in runes of the original text, in order, and which terms were found.
+func MatchTerms(text string, terms []string) ([]TextSpan, []bool) {
+	norm, offsets := normalizeRunes(text)
+	original := []rune(text)
+	found := make([]bool, len(terms))
+	covered := make([]bool, len(norm))
+	for t, term := range terms {
+		tr := []rune(term)
+		if len(tr) == 0 {
+			continue
+		}
+		for i := 0; i+len(tr) <= len(norm); i++ {
+			if !runesEqual(norm[i:i+len(tr)], tr) {
+				continue
+			}
+			found[t] = true
+			for j := i; j < i+len(tr); j++ {
+				covered[j] = true
+			}
+			i += len(tr) - 1
+		}
+	}
+
+	var spans []TextSpan
+	for i := 0; i < len(norm); i++ {
+		if !covered[i] {
+			continue
+		}
+		j := i
+		for j+1 < len(norm) && covered[j+1] {
+			j++
+		}
+		span := TextSpan{Start: offsets[i], End: offsets[j] + 1}
+		// Diacritics after the last letter belong to the match
+		for span.End < len(original) {
+			if to, ok := searchFoldMap[original[span.End]]; !ok || to != "" {
+				break
+			}
+			span.End++
+		}
+		spans = append(spans, span)
+		i = j
+	}
+	return spans, found
+}
+
+func runesEqual(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Snippet cuts the part of text around the first span, about radius runes
+// on either side, with whitespace collapsed and "…" where text was cut. It
+// returns the spans that fall in the snippet relative to the snippet.
+func Snippet(text string, spans []TextSpan, radius int) (string, []TextSpan) {
+	runes := []rune(text)
+	anchor := TextSpan{}
+	if len(spans) > 0 {
+		anchor = spans[0]
+	}
+	start, end := anchor.Start-radius, anchor.End+radius
+	if len(spans) == 0 {
+		start, end = 0, 2*radius
+	}
+	if start < 0 {
+		start = 0
+	}
+	if end > len(runes) {
+		end = len(runes)
+	}
+	// Do not cut words in half, unless they are very long
+	for start > 0 && !unicode.IsSpace(runes[start-1]) && start > anchor.Start-2*radius {
+		start--
+	}
+	for end < len(runes) && !unicode.IsSpace(runes[end]) && end < anchor.End+2*radius {
+		end++
+	}
+
+	var b strings.Builder
+	mapped := make(map[int]int, end-start+1)
+	n := 0
+	if start > 0 {
+		b.WriteRune('…')
+		n++
+	}
+	space := false
+	for i := start; i < end; i++ {
+		mapped[i] = n
+		if unicode.IsSpace(runes[i]) {
+			if !space && n > 0 {
+				b.WriteRune(' ')
+				n++
+			}
+			space = true
+			continue
+		}
+		space = false
+		b.WriteRune(runes[i])
+		n++
+	}
+	mapped[end] = n
+	if end < len(runes) {
+		b.WriteRune('…')
+	}
+
+	var highlights []TextSpan
+	for _, span := range spans {
+		if span.Start < start || span.End > end {
+			continue
+		}
+		highlights = append(highlights, TextSpan{Start: mapped[span.Start], End: mapped[span.End]})
+	}
+	return strings.TrimRight(b.String(), " "), highlights
+}
+
+// RTFEscape encodes s the way rich text values are stored, as one \uN?
+// control word per character
+func RTFEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		b.WriteString(`\u`)
+		b.WriteString(strconv.Itoa(int(r)))
+		b.WriteByte('?')
+	}
+	return b.String()
+}
+
+// IsRTF reports whether s is already stored rich text: an RTF document, or
+// the bare \uN? control words RTFEscape produces. Such values are imported
+// unchanged so that exported rich text is not encoded a second time.
+func IsRTF(s string) bool {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, `{\rtf`) {
+		return true
+	}
+	if s == "" {
+		return false
+	}
+	for s != "" {
+		if !strings.HasPrefix(s, `\u`) {
+			return false
+		}
+		s = strings.TrimPrefix(s[2:], "-")
+		digits := 0
+		for digits < len(s) && s[digits] >= '0' && s[digits] <= '9' {
+			digits++
+		}
+		if digits == 0 || digits >= len(s) || s[digits] != '?' {
+			return false
+		}
+		s = s[digits+1:]
+	}
+	return true
+}
+
+// rtfSkipDestinations are RTF groups that hold no document text
+var rtfSkipDestinations = map[string]bool{
+	"fonttbl": true, "colortbl": true, "stylesheet": true, "info": true,
+	"pict": true, "header": true, "footer": true, "listtable": true,
+	"listoverridetable": true, "rsidtbl": true, "generator": true,
+	"themedata": true, "datastore": true, "latentstyles": true,
+}
+
+// StripRTF returns the plain text of an RTF document or fragment, such as
+// the bare \uN? sequences the API stores for rich text values. Text without
+// control words is returned unchanged.
+func StripRTF(s string) string {
+	if !strings.ContainsAny(s, `\{}`) {
+		return s
+	}
+
+	type group struct {
+		skip bool
+		uc   int
+	}
+	runes := []rune(s)
+	state := group{uc: 1}
+	var stack []group
+	var b strings.Builder
+	emit := func(r rune) {
+		if !state.skip {
+			b.WriteRune(r)
+		}
+	}
+
+	// skipFallback skips the n characters that follow a \u control word for
+	// readers without Unicode support
+	skipFallback := func(i, n int) int {
+		for ; n > 0 && i < len(runes); n-- {
+			if runes[i] == '\\' && i+1 < len(runes) && runes[i+1] == '\'' {
+				i += 4
+			} else if runes[i] == '{' || runes[i] == '}' {
+				break
+			} else {
+				i++
+			}
+		}
+		return i
+	}
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch r {
+		case '{':
+			stack = append(stack, state)
+			i++
+		case '}':
+			if len(stack) > 0 {
+				state = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+			i++
+		case '\r', '\n':
+			i++
+		case '\\':
+			i++
+			if i >= len(runes) {
+				break
+			}
+			c := runes[i]
+			switch {
+			case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+				start := i
+				for i < len(runes) && (runes[i] >= 'a' && runes[i] <= 'z' || runes[i] >= 'A' && runes[i] <= 'Z') {
+					i++
+				}
+				word := string(runes[start:i])
+				paramStart := i
+				if i < len(runes) && runes[i] == '-' {
+					i++
+				}
+				for i < len(runes) && runes[i] >= '0' && runes[i] <= '9' {
+					i++
+				}
+				param, hasParam := 0, i > paramStart
+				if hasParam {
+					param, _ = strconv.Atoi(string(runes[paramStart:i]))
+				}
+				if i < len(runes) && runes[i] == ' ' {
+					i++
+				}
+				switch {
+				case word == "u" && hasParam:
+					if param < 0 {
+						param += 65536
+					}
+					emit(rune(param))
+					i = skipFallback(i, state.uc)
+				case word == "uc" && hasParam:
+					state.uc = param
+				case word == "par" || word == "line" || word == "sect" || word == "row":
+					emit('\n')
+				case word == "tab" || word == "cell":
+					emit(' ')
+				case rtfSkipDestinations[word]:
+					state.skip = true
+				}
+			case c == '\'':
+				if i+2 < len(runes) {
+					if v, err := strconv.ParseUint(string(runes[i+1:i+3]), 16, 8); err == nil {
+						emit(rune(v))
+					}
+				}
+				i += 3
+			case c == '*':
+				state.skip = true
+				i++
+			case c == '\\' || c == '{' || c == '}':
+				emit(c)
+				i++
+			case c == '~':
+				emit(' ')
+				i++
+			case c == '\r' || c == '\n':
+				emit('\n')
+				i++
+			default:
+				i++
+			}
+		default:
+			emit(r)
+			i++
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,61 @@
+package utils
+
+import "testing"
+
+func TestIsRTF(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  bool
+	}{
+		{"document", `{\rtf1\ansi Hello}`, true},
+		{"document with whitespace", "  {\\rtf1 Hello}\n", true},
+		{"escaped text", RTFEscape("Hello"), true},
+		{"escaped arabic", RTFEscape("مرحبا"), true},
+		{"negative code point", `\u-3913?`, true},
+		{"plain text", "Hello", false},
+		{"empty", "", false},
+		{"text after escapes", `\u72?ello`, false},
+		{"missing fallback", `\u72`, false},
+		{"other control word", `\par Hello`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRTF(tt.value); got != tt.want {
+				t.Errorf("IsRTF(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRichTextRoundTrip checks that a rich text value imported as plain text,
+// exported as stored and imported again is stored once and reads back as the
+// original text
+func TestRichTextRoundTrip(t *testing.T) {
+	tests := []string{"Hello", "Line one\nLine two", "مرحبا بالعالم", "a{b}c\\d", "{\\rtf1\\ansi Hello}"}
+
+	importValue := func(value string) string {
+		if IsRTF(value) {
+			return value
+		}
+		return RTFEscape(value)
+	}
+
+	for _, text := range tests {
+		t.Run(text, func(t *testing.T) {
+			stored := importValue(text)
+			reimported := importValue(stored)
+			if reimported != stored {
+				t.Fatalf("re-import stored %q, want %q", reimported, stored)
+			}
+			want := text
+			if IsRTF(text) {
+				want = StripRTF(text)
+			}
+			if got := StripRTF(reimported); got != want {
+				t.Errorf("StripRTF() = %q, want %q", got, want)
+			}
+		})
+	}
+}
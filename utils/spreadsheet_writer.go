@@ -0,0 +1,236 @@
+package utils
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SpreadsheetWriter writes rows of a spreadsheet one at a time, so large
+// exports can be streamed. Cells may be nil, string, int, int64, float64,
+// bool or time.Time; anything else is written as text.
+type SpreadsheetWriter interface {
+	WriteRow(cells []interface{}) error
+	// Close finishes the file. It does not close the underlying writer.
+	Close() error
+}
+
+// NewSpreadsheetWriter creates a writer for the "csv" or "xlsx" format
+func NewSpreadsheetWriter(w io.Writer, format string) (SpreadsheetWriter, error) {
+	switch strings.ToLower(format) {
+	case "csv":
+		return NewCSVWriter(w)
+	case "xlsx":
+		return NewXLSXWriter(w, "Sheet1")
+	default:
+		return nil, fmt.Errorf("unsupported export format %q, expected csv or xlsx", format)
+	}
+}
+
+// ContentType returns the MIME type of a spreadsheet format
+func ContentType(format string) string {
+	if strings.EqualFold(format, "xlsx") {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+// NewCSVWriter creates a CSV writer. A UTF-8 byte order mark is written first
+// so that Excel detects the encoding. Numbers use a decimal point and dates
+// are written in ISO 8601, which ReadSpreadsheet and the value parsers read
+// back in any locale.
+func NewCSVWriter(w io.Writer) (SpreadsheetWriter, error) {
+	if _, err := io.WriteString(w, "\xef\xbb\xbf"); err != nil {
+		return nil, fmt.Errorf("error writing csv: %w", err)
+	}
+	return &csvWriter{w: csv.NewWriter(w)}, nil
+}
+
+func (c *csvWriter) WriteRow(cells []interface{}) error {
+	record := make([]string, len(cells))
+	for i, cell := range cells {
+		record[i] = FormatCell(cell)
+	}
+	if err := c.w.Write(record); err != nil {
+		return fmt.Errorf("error writing csv: %w", err)
+	}
+	return nil
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	if err := c.w.Error(); err != nil {
+		return fmt.Errorf("error writing csv: %w", err)
+	}
+	return nil
+}
+
+// FormatCell renders a cell value as text, as written to CSV
+func FormatCell(cell interface{}) string {
+	switch v := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		if isDateOnly(v) {
+			return v.Format("2006-01-02")
+		}
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Cell styles of the generated workbook, as indexes into cellXfs
+const (
+	xlsxStyleDefault  = 0
+	xlsxStyleDate     = 1
+	xlsxStyleDateTime = 2
+)
+
+var xlsxStaticParts = []struct{ name, body string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`},
+	{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><numFmts count="2"><numFmt numFmtId="164" formatCode="yyyy-mm-dd"/><numFmt numFmtId="165" formatCode="yyyy-mm-dd hh:mm:ss"/></numFmts><fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts><fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills><borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders><cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs><cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs></styleSheet>`},
+}
+
+type xlsxWriter struct {
+	zw  *zip.Writer
+	buf *bufio.Writer
+	row int
+}
+
+// NewXLSXWriter creates a single-sheet XLSX writer. Strings are written
+// inline rather than to a shared string table so that rows can be streamed;
+// dates are stored as Excel serial numbers with a date format.
+func NewXLSXWriter(w io.Writer, sheetName string) (SpreadsheetWriter, error) {
+	zw := zip.NewWriter(w)
+
+	var name strings.Builder
+	xml.EscapeText(&name, []byte(sheetName))
+	parts := append(xlsxStaticParts[:len(xlsxStaticParts):len(xlsxStaticParts)], struct{ name, body string }{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="` + name.String() + `" sheetId="1" r:id="rId1"/></sheets></workbook>`})
+
+	for _, part := range parts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, fmt.Errorf("error writing xlsx: %w", err)
+		}
+		if _, err := io.WriteString(f, part.body); err != nil {
+			return nil, fmt.Errorf("error writing xlsx: %w", err)
+		}
+	}
+
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, fmt.Errorf("error writing xlsx: %w", err)
+	}
+	x := &xlsxWriter{zw: zw, buf: bufio.NewWriterSize(sheet, 64<<10)}
+	x.buf.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return x, nil
+}
+
+func (x *xlsxWriter) WriteRow(cells []interface{}) error {
+	x.row++
+	fmt.Fprintf(x.buf, `<row r="%d">`, x.row)
+	for i, cell := range cells {
+		ref := columnName(i) + strconv.Itoa(x.row)
+		switch v := cell.(type) {
+		case nil:
+			continue
+		case int:
+			fmt.Fprintf(x.buf, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case int64:
+			fmt.Fprintf(x.buf, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case float64:
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				continue
+			}
+			fmt.Fprintf(x.buf, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(v, 'g', -1, 64))
+		case bool:
+			b := 0
+			if v {
+				b = 1
+			}
+			fmt.Fprintf(x.buf, `<c r="%s" t="b"><v>%d</v></c>`, ref, b)
+		case time.Time:
+			style := xlsxStyleDate
+			if !isDateOnly(v) {
+				style = xlsxStyleDateTime
+			}
+			fmt.Fprintf(x.buf, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, strconv.FormatFloat(excelSerial(v), 'f', -1, 64))
+		default:
+			text := FormatCell(v)
+			if text == "" {
+				continue
+			}
+			fmt.Fprintf(x.buf, `<c r="%s" t="inlineStr" s="%d"><is><t xml:space="preserve">`, ref, xlsxStyleDefault)
+			xml.EscapeText(x.buf, []byte(text))
+			x.buf.WriteString(`</t></is></c>`)
+		}
+	}
+	// bufio keeps the first write error and returns it from every later call
+	if _, err := x.buf.WriteString(`</row>`); err != nil {
+		return fmt.Errorf("error writing xlsx: %w", err)
+	}
+	return nil
+}
+
+func (x *xlsxWriter) Close() error {
+	x.buf.WriteString(`</sheetData></worksheet>`)
+	if err := x.buf.Flush(); err != nil {
+		return fmt.Errorf("error writing xlsx: %w", err)
+	}
+	if err := x.zw.Close(); err != nil {
+		return fmt.Errorf("error writing xlsx: %w", err)
+	}
+	return nil
+}
+
+// isDateOnly reports whether t has no time of day
+func isDateOnly(t time.Time) bool {
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0
+}
+
+// excelSerial converts a time to an Excel serial day number, ignoring its
+// time zone as Excel has none
+func excelSerial(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	return wall.Sub(excelEpoch).Hours() / 24
+}
+
+// columnName converts a zero-based column index to its letters, the inverse
+// of columnIndex
+func columnName(col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name
+}
@@ -0,0 +1,259 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ValueLocale describes how numbers and dates are written in imported data
+type ValueLocale struct {
+	Tag              string
+	DecimalSeparator rune // 0 detects the separator from each value
+	DayFirst         bool
+	Detect           bool // true when no locale was given
+	ExcelDates       bool // bare numbers are Excel serial day numbers, as in XLSX cells
+}
+
+// decimal comma, day-first languages
+var commaLocales = map[string]bool{
+	"de": true, "fr": true, "es": true, "it": true, "nl": true, "pt": true, "ru": true,
+	"tr": true, "pl": true, "sv": true, "da": true, "nb": true, "fi": true, "cs": true,
+}
+
+// LookupValueLocale resolves a language tag such as "en-US", "de" or "ar-SA".
+// An empty tag returns a locale that detects the decimal separator from each
+// value and reads ambiguous dates day-first.
+func LookupValueLocale(tag string) (ValueLocale, error) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return ValueLocale{DayFirst: true, Detect: true}, nil
+	}
+
+	normalized := strings.ToLower(strings.ReplaceAll(tag, "_", "-"))
+	lang := normalized
+	if i := strings.IndexByte(normalized, '-'); i >= 0 {
+		lang = normalized[:i]
+	}
+
+	switch {
+	case normalized == "en-us" || normalized == "en-ph" || normalized == "en":
+		return ValueLocale{Tag: tag, DecimalSeparator: '.', DayFirst: false}, nil
+	case lang == "en" || lang == "ar" || lang == "fa" || lang == "he" || lang == "ur":
+		return ValueLocale{Tag: tag, DecimalSeparator: '.', DayFirst: true}, nil
+	case commaLocales[lang]:
+		return ValueLocale{Tag: tag, DecimalSeparator: ',', DayFirst: true}, nil
+	}
+	return ValueLocale{}, fmt.Errorf("unsupported locale %q", tag)
+}
+
+// NormalizeDigits converts Arabic-Indic and Extended Arabic-Indic digits and
+// separators to their ASCII equivalents and maps non-breaking spaces used for
+// digit grouping to plain spaces
+func NormalizeDigits(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for _, ch := range strings.TrimSpace(s) {
+		switch {
+		case ch >= '٠' && ch <= '٩':
+			sb.WriteRune('0' + ch - '٠')
+		case ch >= '۰' && ch <= '۹':
+			sb.WriteRune('0' + ch - '۰')
+		case ch == '٫': // Arabic decimal separator
+			sb.WriteRune('.')
+		case ch == '٬': // Arabic thousands separator
+			sb.WriteRune('\'')
+		case ch == '−': // minus sign
+			sb.WriteRune('-')
+		case ch == '\u00a0', ch == '\u202f', ch == '\u2009': // non-breaking and thin spaces
+			sb.WriteRune(' ')
+		default:
+			sb.WriteRune(ch)
+		}
+	}
+	return sb.String()
+}
+
+// ParseNumber parses a number written in the given locale. Group separators
+// (spaces, apostrophes and the non-decimal punctuation mark) are ignored.
+func ParseNumber(s string, loc ValueLocale) (float64, error) {
+	value := NormalizeDigits(s)
+	// Arabic separators were mapped to ASCII and are unambiguous
+	arabicDecimal := strings.ContainsRune(s, '٫')
+
+	value = strings.NewReplacer(" ", "", "'", "").Replace(value)
+	if value == "" {
+		return 0, fmt.Errorf("empty number")
+	}
+
+	decimal := loc.DecimalSeparator
+	if arabicDecimal {
+		decimal = '.'
+	} else if decimal == 0 {
+		var ok bool
+		if decimal, ok = detectDecimalSeparator(value); !ok {
+			return 0, fmt.Errorf("%q is ambiguous: set a locale to tell whether \",\" separates decimals or thousands", s)
+		}
+	}
+	group := ","
+	if decimal == ',' {
+		group = "."
+	}
+
+	value = strings.ReplaceAll(value, group, "")
+	if decimal == ',' {
+		value = strings.ReplaceAll(value, ",", ".")
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return 0, fmt.Errorf("%q is not a valid number", s)
+	}
+	return f, nil
+}
+
+// detectDecimalSeparator guesses the decimal separator of a number: the last
+// of '.' and ',' when both occur, otherwise a mark that occurs exactly once;
+// a repeated mark is taken as grouping. A single comma followed by exactly
+// three digits, as in "12,500", reads as either and is reported as ambiguous,
+// unless the whole part is zero.
+func detectDecimalSeparator(value string) (rune, bool) {
+	dot, comma := strings.LastIndexByte(value, '.'), strings.LastIndexByte(value, ',')
+	switch {
+	case dot >= 0 && comma >= 0:
+		if comma > dot {
+			return ',', true
+		}
+		return '.', true
+	case comma >= 0 && strings.Count(value, ",") == 1:
+		whole, fraction := strings.TrimLeft(value[:comma], "+-"), value[comma+1:]
+		if len(fraction) == 3 && strings.Trim(fraction, "0123456789") == "" && whole != "0" {
+			return 0, false
+		}
+		return ',', true
+	case dot >= 0 && strings.Count(value, ".") > 1:
+		return ',', true
+	}
+	return '.', true
+}
+
+// ParseInteger parses a whole number written in the given locale
+func ParseInteger(s string, loc ValueLocale) (int, error) {
+	f, err := ParseNumber(s, loc)
+	if err != nil {
+		return 0, err
+	}
+	if f != math.Trunc(f) {
+		return 0, fmt.Errorf("%q is not a whole number", s)
+	}
+	if f > math.MaxInt32 || f < math.MinInt32 {
+		return 0, fmt.Errorf("%q is out of range", s)
+	}
+	return int(f), nil
+}
+
+var boolValues = map[string]bool{
+	"true": true, "false": false, "yes": true, "no": false, "y": true, "n": false,
+	"1": true, "0": false, "on": true, "off": false, "x": true,
+	"ja": true, "nein": false, "oui": true, "non": false, "si": true, "sí": true,
+	"نعم": true, "لا": false, "صح": true, "خطأ": false,
+}
+
+// ParseBool parses common spellings of true and false
+func ParseBool(s string) (bool, error) {
+	if b, ok := boolValues[strings.ToLower(NormalizeDigits(s))]; ok {
+		return b, nil
+	}
+	return false, fmt.Errorf("%q is not a valid boolean", s)
+}
+
+// Date layouts tried by ParseDate, without a time of day
+var (
+	isoDateLayouts = []string{
+		"2006-01-02", "2006/01/02", "2006.01.02", "20060102",
+		"2 Jan 2006", "2 January 2006", "2-Jan-2006", "2-Jan-06", "2-January-2006",
+		"Jan 2, 2006", "January 2, 2006", "Jan 2 2006", "January 2 2006",
+	}
+	dayFirstLayouts   = []string{"2/1/2006", "2.1.2006", "2-1-2006", "2/1/06", "2.1.06"}
+	monthFirstLayouts = []string{"1/2/2006", "1.2.2006", "1-2-2006", "1/2/06"}
+	timeSuffixes      = []string{"", " 15:04", " 15:04:05", "T15:04:05", "T15:04:05Z07:00", " 3:04 PM", " 3:04:05 PM"}
+)
+
+// excelEpoch is day 0 of Excel's 1900 date system, adjusted for its
+// fictitious 29 February 1900
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// ParseDate parses a date in ISO 8601, a numeric day/month/year form ordered
+// by the locale or an English month-name form. An optional time of day may
+// follow the date. Bare numbers are read as Excel serial day numbers only when
+// the locale has ExcelDates set; elsewhere "2024" is a year, not a date.
+func ParseDate(s string, loc ValueLocale) (time.Time, error) {
+	value := NormalizeDigits(s)
+	if value == "" {
+		return time.Time{}, fmt.Errorf("empty date")
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	// Excel stores dates as days since 1899-12-30; XLSX cells arrive that way
+	if serial, err := strconv.ParseFloat(value, 64); err == nil && !strings.ContainsAny(value, "eE") && loc.ExcelDates {
+		if serial >= 1 && serial < 2958466 {
+			days := math.Floor(serial)
+			seconds := math.Round((serial - days) * 86400)
+			return excelEpoch.AddDate(0, 0, int(days)).Add(time.Duration(seconds) * time.Second), nil
+		}
+	}
+
+	numeric := monthFirstLayouts
+	if loc.DayFirst {
+		numeric = dayFirstLayouts
+	}
+	layouts := append(append([]string{}, isoDateLayouts...), numeric...)
+	if loc.Detect {
+		layouts = append(layouts, monthFirstLayouts...)
+	}
+
+	for _, layout := range layouts {
+		for _, suffix := range timeSuffixes {
+			if t, err := time.Parse(layout+suffix, value); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a recognised date", s)
+}
+
+// SplitListValues splits an attribute's ListValues into its entries. Entries
+// are separated by line breaks, or by semicolons or pipes when the list is on
+// a single line.
+func SplitListValues(list string) []string {
+	list = strings.TrimSpace(list)
+	if list == "" {
+		return nil
+	}
+
+	var parts []string
+	switch {
+	case strings.ContainsAny(list, "\r\n"):
+		parts = strings.FieldsFunc(list, func(r rune) bool { return r == '\r' || r == '\n' })
+	case strings.ContainsRune(list, ';'):
+		parts = strings.Split(list, ";")
+	case strings.ContainsRune(list, '|'):
+		parts = strings.Split(list, "|")
+	default:
+		parts = []string{list}
+	}
+
+	values := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimFunc(p, unicode.IsSpace); p != "" {
+			values = append(values, p)
+		}
+	}
+	return values
+}
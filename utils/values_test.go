@@ -0,0 +1,239 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustLocale(t *testing.T, tag string) ValueLocale {
+	t.Helper()
+	loc, err := LookupValueLocale(tag)
+	if err != nil {
+		t.Fatalf("LookupValueLocale(%q) error = %v", tag, err)
+	}
+	return loc
+}
+
+func TestLookupValueLocale(t *testing.T) {
+	tests := []struct {
+		tag      string
+		decimal  rune
+		dayFirst bool
+		detect   bool
+		wantErr  bool
+	}{
+		{"", 0, true, true, false},
+		{"en", '.', false, false, false},
+		{"en-US", '.', false, false, false},
+		{"en_GB", '.', true, false, false},
+		{"ar-SA", '.', true, false, false},
+		{"de-DE", ',', true, false, false},
+		{"FR", ',', true, false, false},
+		{"xx", 0, false, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			loc, err := LookupValueLocale(tt.tag)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("LookupValueLocale(%q) = %+v, want error", tt.tag, loc)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LookupValueLocale(%q) error = %v", tt.tag, err)
+			}
+			if loc.DecimalSeparator != tt.decimal || loc.DayFirst != tt.dayFirst || loc.Detect != tt.detect {
+				t.Errorf("LookupValueLocale(%q) = %+v, want decimal %q, dayFirst %v, detect %v",
+					tt.tag, loc, tt.decimal, tt.dayFirst, tt.detect)
+			}
+		})
+	}
+}
+
+func TestNormalizeDigits(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"ascii", "  12.5 ", "12.5"},
+		{"arabic-indic", "٠١٢٣٤٥٦٧٨٩", "0123456789"},
+		{"extended arabic-indic", "۰۱۲۳۴۵۶۷۸۹", "0123456789"},
+		{"arabic decimal separator", "٣٫١٤", "3.14"},
+		{"arabic thousands separator", "١٬٢٣٤", "1'234"},
+		{"minus sign", "−5", "-5"},
+		{"non-breaking space", "1 234", "1 234"},
+		{"narrow non-breaking space", "1 234", "1 234"},
+		{"text", "Customer", "Customer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeDigits(tt.value); got != tt.want {
+				t.Errorf("NormalizeDigits(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		locale  string
+		want    float64
+		wantErr string
+	}{
+		{"decimal point", "12.5", "", 12.5, ""},
+		{"decimal comma", "12,5", "", 12.5, ""},
+		{"grouped with decimal point", "1,234.5", "", 1234.5, ""},
+		{"grouped with decimal comma", "1.234,5", "", 1234.5, ""},
+		{"repeated comma groups", "1,234,567", "", 1234567, ""},
+		{"repeated dot groups", "1.234.567", "", 1234567, ""},
+		{"single comma before three digits", "12,500", "", 0, "ambiguous"},
+		{"negative single comma before three digits", "-12,500", "", 0, "ambiguous"},
+		{"zero before three decimals", "0,500", "", 0.5, ""},
+		{"comma before two digits", "12,50", "", 12.5, ""},
+		{"comma before four digits", "1,2500", "", 1.25, ""},
+		{"en-US thousands", "12,500", "en-US", 12500, ""},
+		{"de decimal comma", "12,500", "de", 12.5, ""},
+		{"de thousands", "12.500", "de", 12500, ""},
+		{"fr spaces", "1 234,5", "fr", 1234.5, ""},
+		{"non-breaking space group", "1 234,5", "de", 1234.5, ""},
+		{"apostrophe group", "1'234.5", "", 1234.5, ""},
+		{"arabic digits", "١٢٣", "", 123, ""},
+		{"arabic decimal separator", "٣٫١٤", "", 3.14, ""},
+		{"arabic thousands separator", "١٢٬٥٠٠", "", 12500, ""},
+		{"arabic separators with de locale", "١٬٢٣٤٫٥", "de", 1234.5, ""},
+		{"extended arabic-indic digits", "۱۲٫۵", "", 12.5, ""},
+		{"minus sign", "−5", "", -5, ""},
+		{"empty", "  ", "", 0, "empty number"},
+		{"text", "abc", "", 0, "not a valid number"},
+		{"overflow", "1e400", "", 0, "not a valid number"},
+		{"infinity", "Inf", "", 0, "not a valid number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseNumber(tt.value, mustLocale(t, tt.locale))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseNumber(%q, %q) = %v, %v, want error %q", tt.value, tt.locale, got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseNumber(%q, %q) error = %v", tt.value, tt.locale, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseNumber(%q, %q) = %v, want %v", tt.value, tt.locale, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInteger(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		locale  string
+		want    int
+		wantErr string
+	}{
+		{"plain", "42", "", 42, ""},
+		{"negative", "-42", "", -42, ""},
+		{"grouped", "1,234,567", "", 1234567, ""},
+		{"en thousands", "12,500", "en", 12500, ""},
+		{"ambiguous thousands", "12,500", "", 0, "ambiguous"},
+		{"de thousands", "12.500", "de", 12500, ""},
+		{"arabic digits", "٤٢", "ar", 42, ""},
+		{"whole decimal", "42.0", "", 42, ""},
+		{"fraction", "12.5", "", 0, "not a whole number"},
+		{"out of range", "3000000000", "", 0, "out of range"},
+		{"text", "forty-two", "", 0, "not a valid number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseInteger(tt.value, mustLocale(t, tt.locale))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseInteger(%q, %q) = %v, %v, want error %q", tt.value, tt.locale, got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseInteger(%q, %q) error = %v", tt.value, tt.locale, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseInteger(%q, %q) = %v, want %v", tt.value, tt.locale, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	date := func(y int, m time.Month, d, hh, mm int) time.Time {
+		return time.Date(y, m, d, hh, mm, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name       string
+		value      string
+		locale     string
+		excelDates bool
+		want       time.Time
+		wantErr    bool
+	}{
+		{"iso", "2024-01-31", "", false, date(2024, 1, 31, 0, 0), false},
+		{"rfc 3339", "2024-01-31T10:00:00Z", "", false, date(2024, 1, 31, 10, 0), false},
+		{"iso with time", "2024-01-31 10:30", "", false, date(2024, 1, 31, 10, 30), false},
+		{"basic iso", "20240131", "", false, date(2024, 1, 31, 0, 0), false},
+		{"day first without locale", "02/01/2024", "", false, date(2024, 1, 2, 0, 0), false},
+		{"only month first valid without locale", "01/31/2024", "", false, date(2024, 1, 31, 0, 0), false},
+		{"en-US month first", "02/01/2024", "en-US", false, date(2024, 2, 1, 0, 0), false},
+		{"en-GB day first", "02/01/2024", "en-GB", false, date(2024, 1, 2, 0, 0), false},
+		{"de dots", "31.01.2024", "de", false, date(2024, 1, 31, 0, 0), false},
+		{"de rejects month first", "01/31/2024", "de", false, time.Time{}, true},
+		{"dashes", "31-01-2024", "", false, date(2024, 1, 31, 0, 0), false},
+		{"month name", "31 Jan 2024", "", false, date(2024, 1, 31, 0, 0), false},
+		{"english long form", "January 31, 2024", "", false, date(2024, 1, 31, 0, 0), false},
+		{"time of day", "31/01/2024 14:30", "", false, date(2024, 1, 31, 14, 30), false},
+		{"twelve-hour time", "01/31/2024 2:30 PM", "en-US", false, date(2024, 1, 31, 14, 30), false},
+		{"arabic digits", "٣١/٠١/٢٠٢٤", "ar", false, date(2024, 1, 31, 0, 0), false},
+		{"extended arabic-indic digits", "۲۰۲۴-۰۱-۳۱", "", false, date(2024, 1, 31, 0, 0), false},
+		{"excel serial", "45322", "", true, date(2024, 1, 31, 0, 0), false},
+		{"excel serial with time", "45322.5", "", true, date(2024, 1, 31, 12, 0), false},
+		{"excel serial with locale", "45322", "de", true, date(2024, 1, 31, 0, 0), false},
+		{"serial outside a workbook", "45322", "", false, time.Time{}, true},
+		{"year outside a workbook", "2024", "", false, time.Time{}, true},
+		{"year in a workbook is a serial", "2024", "", true, date(1905, 7, 16, 0, 0), false},
+		{"serial out of range", "2958466", "", true, time.Time{}, true},
+		{"invalid day", "32/01/2024", "", false, time.Time{}, true},
+		{"text", "yesterday", "", false, time.Time{}, true},
+		{"empty", "", "", false, time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := mustLocale(t, tt.locale)
+			loc.ExcelDates = tt.excelDates
+			got, err := ParseDate(tt.value, loc)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseDate(%q, %q) = %v, want error", tt.value, tt.locale, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDate(%q, %q) error = %v", tt.value, tt.locale, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseDate(%q, %q) = %v, want %v", tt.value, tt.locale, got, tt.want)
+			}
+		})
+	}
+}